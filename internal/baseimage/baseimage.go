@@ -0,0 +1,88 @@
+// Package baseimage finds the image another image was built from.
+package baseimage
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/tags"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Candidate is an image that may be the base of another.
+type Candidate struct {
+	// Name is the reference shown for the candidate, e.g. alpine:3.20.
+	Name    string
+	Digest  v1.Hash
+	DiffIDs []v1.Hash
+}
+
+// Match returns the candidates whose layers form the longest prefix of
+// diffIDs, sorted by name. Several candidates match when tags share an
+// image, e.g. alpine:3.20 and alpine:3.20.3. It returns nil when no
+// candidate is a prefix.
+func Match(diffIDs []v1.Hash, candidates []Candidate) []Candidate {
+	var matches []Candidate
+	best := 0
+	for _, c := range candidates {
+		if len(c.DiffIDs) == 0 || !hasPrefix(diffIDs, c.DiffIDs) {
+			continue
+		}
+		switch {
+		case len(c.DiffIDs) > best:
+			best = len(c.DiffIDs)
+			matches = []Candidate{c}
+		case len(c.DiffIDs) == best:
+			matches = append(matches, c)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Name < matches[j].Name })
+	return matches
+}
+
+// Newer returns the highest stable tag newer than current with the same
+// variant and precision, so that 3.20 is followed by 3.21 and 3.20.1 by
+// 3.20.2 or 3.21.0. It reports false when current is not a version or no
+// newer tag exists.
+func Newer(current string, tagList []string) (string, bool) {
+	cur, ok := tags.ParseVersion(current)
+	if !ok {
+		return "", false
+	}
+
+	var newest *tags.Version
+	for _, tag := range tagList {
+		v, ok := tags.ParseVersion(tag)
+		if !ok || v.Prerelease() || v.Variant != cur.Variant || precision(v) != precision(cur) {
+			continue
+		}
+		if !v.Semver.GreaterThan(cur.Semver) {
+			continue
+		}
+		if newest == nil || v.Semver.GreaterThan(newest.Semver) {
+			newest = v
+		}
+	}
+	if newest == nil {
+		return "", false
+	}
+	return newest.Tag, true
+}
+
+// precision counts the components of the numeric part of a version tag.
+func precision(v *tags.Version) int {
+	numeric, _, _ := strings.Cut(strings.TrimPrefix(v.Semver.Original(), "v"), "-")
+	return strings.Count(numeric, ".") + 1
+}
+
+func hasPrefix(diffIDs, prefix []v1.Hash) bool {
+	if len(diffIDs) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if diffIDs[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
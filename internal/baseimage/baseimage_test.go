@@ -0,0 +1,61 @@
+package baseimage_test
+
+import (
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/baseimage"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func hashes(hexes ...string) []v1.Hash {
+	var hs []v1.Hash
+	for _, h := range hexes {
+		hs = append(hs, v1.Hash{Algorithm: "sha256", Hex: h})
+	}
+	return hs
+}
+
+func TestMatch(t *testing.T) {
+	candidates := []baseimage.Candidate{
+		{Name: "debian:bookworm", DiffIDs: hashes("debian")},
+		{Name: "python:3.12", DiffIDs: hashes("debian", "python")},
+		{Name: "python:3.12.4", DiffIDs: hashes("debian", "python")},
+		{Name: "python:3.13", DiffIDs: hashes("debian", "python313")},
+		{Name: "empty"},
+	}
+
+	matches := baseimage.Match(hashes("debian", "python", "app"), candidates)
+	var names []string
+	for _, m := range matches {
+		names = append(names, m.Name)
+	}
+	assert.Equal(t, []string{"python:3.12", "python:3.12.4"}, names)
+
+	assert.Nil(t, baseimage.Match(hashes("alpine", "app"), candidates))
+	assert.Nil(t, baseimage.Match(hashes("debian"), candidates[1:2]), "longer than the image")
+}
+
+func TestNewer(t *testing.T) {
+	tagList := []string{"3.19", "3.20", "3.20.1", "3.20.3", "3.21", "3.21.0", "3.22-rc1", "3.21-slim", "latest"}
+
+	tests := []struct {
+		current string
+		want    string
+		ok      bool
+	}{
+		{current: "3.20", want: "3.21", ok: true},
+		{current: "3.20.1", want: "3.21.0", ok: true},
+		{current: "3.19-slim", want: "3.21-slim", ok: true},
+		{current: "3.21"},
+		{current: "latest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.current, func(t *testing.T) {
+			got, ok := baseimage.Newer(tt.current, tagList)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,545 @@
+package browse
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// PreviewLimit is how much of a file the preview reads.
+const PreviewLimit = 64 * 1024
+
+const mergedView = -1
+
+type pane int
+
+const (
+	layersPane pane = iota
+	treePane
+)
+
+// treeState is a tree and the position of the cursor in it.
+type treeState struct {
+	tree   *Tree
+	cursor int
+	offset int
+}
+
+// App is the full-screen browser. Layers are listed on the left; the right
+// pane shows the changes made by the selected layer, or the merged
+// filesystem, as a collapsible tree with a preview for files.
+type App struct {
+	screen tcell.Screen
+	image  *Image
+
+	focus       pane
+	layer       int
+	layerOffset int
+	merged      bool
+	trees       map[int]*treeState
+
+	preview       *Preview
+	previewOffset int
+
+	searching bool
+	message   string
+}
+
+// NewApp returns a browser for image drawing on screen, which must be
+// initialized.
+func NewApp(screen tcell.Screen, image *Image) *App {
+	return &App{
+		screen: screen,
+		image:  image,
+		trees:  make(map[int]*treeState),
+	}
+}
+
+// Run handles events until the user quits.
+func (a *App) Run() error {
+	for {
+		a.draw()
+		switch ev := a.screen.PollEvent().(type) {
+		case nil:
+			return nil
+		case *tcell.EventResize:
+			a.screen.Sync()
+		case *tcell.EventKey:
+			if a.handleKey(ev) {
+				return nil
+			}
+		}
+	}
+}
+
+// current returns the tree shown in the right pane, loading the merged
+// filesystem on first use.
+func (a *App) current() *treeState {
+	key := a.layer
+	if a.merged {
+		key = mergedView
+	}
+	if t, ok := a.trees[key]; ok {
+		return t
+	}
+
+	var root *Node
+	if a.merged {
+		// Reading every layer takes a moment on large images.
+		w, h := a.screen.Size()
+		fill(a.screen, 0, h-1, w, styleDefault)
+		drawText(a.screen, 0, h-1, w, styleDefault, "Reading merged filesystem...")
+		a.screen.Show()
+		var err error
+		root, err = a.image.Merged()
+		if err != nil {
+			a.message = err.Error()
+			a.merged = false
+			return a.current()
+		}
+	} else {
+		root = a.image.Layers[a.layer].Changes
+	}
+	t := &treeState{tree: NewTree(root)}
+	a.trees[key] = t
+	return t
+}
+
+// handleKey applies a key press and reports whether to quit.
+func (a *App) handleKey(ev *tcell.EventKey) bool {
+	if a.searching {
+		a.handleSearchKey(ev)
+		return false
+	}
+	a.message = ""
+
+	switch ev.Key() {
+	case tcell.KeyCtrlC:
+		return true
+	case tcell.KeyTab, tcell.KeyBacktab:
+		a.preview = nil
+		if a.focus == layersPane {
+			a.focus = treePane
+		} else {
+			a.focus = layersPane
+		}
+		return false
+	case tcell.KeyEscape:
+		if a.preview != nil {
+			a.preview = nil
+		} else {
+			a.current().tree.Search("")
+		}
+		return false
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'q':
+			return true
+		case 'm':
+			a.merged = !a.merged
+			a.preview = nil
+			return false
+		case '/':
+			a.preview = nil
+			a.focus = treePane
+			a.searching = true
+			a.current().tree.Search("")
+			return false
+		}
+	}
+
+	switch {
+	case a.preview != nil:
+		a.handlePreviewKey(ev)
+	case a.focus == layersPane:
+		a.handleLayersKey(ev)
+	default:
+		a.handleTreeKey(ev)
+	}
+	return false
+}
+
+func (a *App) handleSearchKey(ev *tcell.EventKey) {
+	t := a.current()
+	query := t.tree.Query()
+	switch ev.Key() {
+	case tcell.KeyEnter:
+		a.searching = false
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		a.searching = false
+		query = ""
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if query != "" {
+			_, size := utf8.DecodeLastRuneInString(query)
+			query = query[:len(query)-size]
+		}
+	case tcell.KeyRune:
+		query += string(ev.Rune())
+	}
+	t.tree.Search(query)
+	t.cursor, t.offset = 0, 0
+}
+
+// move returns cursor moved by a navigation key within n rows, and
+// whether the key was one.
+func move(ev *tcell.EventKey, cursor, n, page int) (int, bool) {
+	switch ev.Key() {
+	case tcell.KeyUp:
+		cursor--
+	case tcell.KeyDown:
+		cursor++
+	case tcell.KeyPgUp:
+		cursor -= page
+	case tcell.KeyPgDn:
+		cursor += page
+	case tcell.KeyHome:
+		cursor = 0
+	case tcell.KeyEnd:
+		cursor = n - 1
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'k':
+			cursor--
+		case 'j':
+			cursor++
+		case 'g':
+			cursor = 0
+		case 'G':
+			cursor = n - 1
+		default:
+			return cursor, false
+		}
+	default:
+		return cursor, false
+	}
+	return max(0, min(cursor, n-1)), true
+}
+
+func (a *App) handleLayersKey(ev *tcell.EventKey) {
+	if layer, ok := move(ev, a.layer, len(a.image.Layers), a.bodyHeight()); ok {
+		if layer != a.layer {
+			a.layer = layer
+			a.merged = false
+		}
+		return
+	}
+	if ev.Key() == tcell.KeyEnter || ev.Key() == tcell.KeyRight || isRune(ev, 'l') {
+		a.merged = false
+		a.focus = treePane
+	}
+}
+
+func (a *App) handleTreeKey(ev *tcell.EventKey) {
+	t := a.current()
+	rows := t.tree.Rows()
+	if cursor, ok := move(ev, t.cursor, len(rows), a.bodyHeight()); ok {
+		t.cursor = cursor
+		return
+	}
+	if len(rows) == 0 {
+		return
+	}
+	row := rows[t.cursor]
+
+	switch {
+	case ev.Key() == tcell.KeyEnter || isRune(ev, ' '):
+		if row.Node.Dir {
+			t.tree.Toggle(row.Node.Path)
+			return
+		}
+		preview, err := a.image.Preview(row.Node, PreviewLimit)
+		if err != nil {
+			a.message = err.Error()
+			return
+		}
+		a.preview, a.previewOffset = preview, 0
+	case ev.Key() == tcell.KeyRight || isRune(ev, 'l'):
+		if row.Node.Dir {
+			t.tree.SetExpanded(row.Node.Path, true)
+		}
+	case ev.Key() == tcell.KeyLeft || isRune(ev, 'h'):
+		if row.Node.Dir && t.tree.Expanded(row.Node.Path) && t.tree.Query() == "" {
+			t.tree.SetExpanded(row.Node.Path, false)
+			return
+		}
+		// Move to the parent directory.
+		for i := t.cursor - 1; i >= 0; i-- {
+			if rows[i].Depth < row.Depth {
+				t.cursor = i
+				return
+			}
+		}
+		if row.Depth == 0 {
+			a.focus = layersPane
+		}
+	}
+}
+
+func (a *App) handlePreviewKey(ev *tcell.EventKey) {
+	lines := len(a.previewLines())
+	if offset, ok := move(ev, a.previewOffset, lines, a.bodyHeight()); ok {
+		a.previewOffset = offset
+		return
+	}
+	if ev.Key() == tcell.KeyLeft || isRune(ev, 'h') {
+		a.preview = nil
+	}
+}
+
+// bodyHeight is the number of rows available to the panes' contents,
+// below the title and pane headers and above the status line.
+func (a *App) bodyHeight() int {
+	_, h := a.screen.Size()
+	return max(1, h-3)
+}
+
+var (
+	styleDefault  = tcell.StyleDefault
+	styleTitle    = tcell.StyleDefault.Reverse(true)
+	styleHeader   = tcell.StyleDefault.Bold(true)
+	styleSelected = tcell.StyleDefault.Reverse(true)
+	styleInactive = tcell.StyleDefault.Underline(true)
+	styleDim      = tcell.StyleDefault.Dim(true)
+	styleAdded    = tcell.StyleDefault.Foreground(tcell.ColorGreen)
+	styleModified = tcell.StyleDefault.Foreground(tcell.ColorYellow)
+	styleDeleted  = tcell.StyleDefault.Foreground(tcell.ColorRed)
+)
+
+func (a *App) draw() {
+	s := a.screen
+	s.Clear()
+	w, h := s.Size()
+	if w < 20 || h < 5 {
+		drawText(s, 0, 0, w, styleDefault, "Terminal too small")
+		s.Show()
+		return
+	}
+
+	mode := fmt.Sprintf("layer %d/%d", a.layer+1, len(a.image.Layers))
+	if a.merged {
+		mode = "merged"
+	}
+	fill(s, 0, 0, w, styleTitle)
+	drawText(s, 1, 0, w, styleTitle, "cek browse "+a.image.Ref)
+	drawText(s, w-len(mode)-1, 0, w, styleTitle, mode)
+
+	left := min(max(w/3, 24), 60)
+	for y := 1; y < h-1; y++ {
+		s.SetContent(left, y, tcell.RuneVLine, nil, styleDim)
+	}
+	a.drawLayers(0, 1, left, h-2)
+	if a.preview != nil {
+		a.drawPreview(left+2, 1, w, h-2)
+	} else {
+		a.drawTree(left+2, 1, w, h-2)
+	}
+	a.drawStatus(0, h-1, w)
+
+	s.Show()
+}
+
+func (a *App) drawLayers(x, y, maxX, height int) {
+	drawText(a.screen, x+1, y, maxX, styleHeader, "Layers")
+	rows := height - 1
+	a.layerOffset = scroll(a.layer, a.layerOffset, rows)
+
+	for i := a.layerOffset; i < len(a.image.Layers) && i-a.layerOffset < rows; i++ {
+		l := a.image.Layers[i]
+		line := fmt.Sprintf("%3d %9s  %s", l.Index, oci.FormatBytes(l.Size), createdBy(l))
+		style := styleDefault
+		switch {
+		case i == a.layer && a.focus == layersPane:
+			style = styleSelected
+		case i == a.layer && !a.merged:
+			style = styleInactive
+		}
+		if style != styleDefault {
+			fill(a.screen, x, y+1+i-a.layerOffset, maxX, style)
+		}
+		drawText(a.screen, x, y+1+i-a.layerOffset, maxX, style, line)
+	}
+}
+
+// createdBy shortens the instruction that created a layer for display.
+func createdBy(l Layer) string {
+	s := strings.TrimSpace(l.CreatedBy)
+	s = strings.TrimPrefix(s, "/bin/sh -c ")
+	s = strings.TrimPrefix(s, "#(nop) ")
+	if s == "" {
+		return shortDigest(l.Digest.String())
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func shortDigest(digest string) string {
+	const prefix = len("sha256:") + 12
+	if len(digest) <= prefix {
+		return digest
+	}
+	return digest[:prefix]
+}
+
+func (a *App) drawTree(x, y, maxX, height int) {
+	t := a.current()
+	header := fmt.Sprintf("Changes in layer %d", a.layer+1)
+	if a.merged {
+		header = "Merged filesystem"
+	}
+	header += " (" + oci.FormatBytes(t.tree.Root.Size) + ")"
+	if q := t.tree.Query(); q != "" {
+		header += "  search: " + q
+	}
+	drawText(a.screen, x, y, maxX, styleHeader, header)
+
+	rows := t.tree.Rows()
+	if len(rows) == 0 {
+		drawText(a.screen, x, y+1, maxX, styleDim, "No files")
+		return
+	}
+	t.cursor = min(t.cursor, len(rows)-1)
+	visible := height - 1
+	t.offset = scroll(t.cursor, t.offset, visible)
+
+	for i := t.offset; i < len(rows) && i-t.offset < visible; i++ {
+		row := rows[i]
+		n := row.Node
+		ry := y + 1 + i - t.offset
+
+		style := styleDefault
+		switch n.Change {
+		case Added:
+			style = styleAdded
+		case Modified:
+			style = styleModified
+		case Deleted:
+			style = styleDeleted.StrikeThrough(true)
+		}
+		if i == t.cursor {
+			cursor := styleInactive
+			if a.focus == treePane {
+				cursor = styleSelected
+			}
+			fill(a.screen, x, ry, maxX, cursor)
+			style = cursor
+		}
+
+		marker := "  "
+		if n.Dir {
+			marker = "▸ "
+			if t.tree.Expanded(n.Path) {
+				marker = "▾ "
+			}
+		}
+		name := n.Name
+		if n.Dir {
+			name += "/"
+		}
+		if n.Link != "" {
+			name += " → " + n.Link
+		}
+		size := ""
+		if n.Change != Deleted {
+			size = oci.FormatBytes(n.Size)
+		}
+
+		line := strings.Repeat("  ", row.Depth) + marker + changeMark(n.Change) + name
+		sizeX := maxX - len(size) - 1
+		drawText(a.screen, x, ry, sizeX-1, style, line)
+		drawText(a.screen, sizeX, ry, maxX, style, size)
+	}
+}
+
+func changeMark(c Change) string {
+	switch c {
+	case Added:
+		return "+ "
+	case Modified:
+		return "~ "
+	case Deleted:
+		return "- "
+	default:
+		return ""
+	}
+}
+
+// previewLines splits the preview into lines for display.
+func (a *App) previewLines() []string {
+	p := a.preview
+	if p.Binary {
+		return []string{fmt.Sprintf("Binary file, %s", oci.FormatBytes(p.Size))}
+	}
+	lines := strings.Split(strings.ReplaceAll(p.Text, "\t", "    "), "\n")
+	if p.Truncated {
+		lines = append(lines, fmt.Sprintf("... preview truncated at %s of %s", oci.FormatBytes(PreviewLimit), oci.FormatBytes(p.Size)))
+	}
+	return lines
+}
+
+func (a *App) drawPreview(x, y, maxX, height int) {
+	drawText(a.screen, x, y, maxX, styleHeader, fmt.Sprintf("%s (%s)", a.preview.Path, oci.FormatBytes(a.preview.Size)))
+	lines := a.previewLines()
+	a.previewOffset = min(a.previewOffset, max(0, len(lines)-1))
+	for i := a.previewOffset; i < len(lines) && i-a.previewOffset < height-1; i++ {
+		drawText(a.screen, x, y+1+i-a.previewOffset, maxX, styleDefault, lines[i])
+	}
+}
+
+func (a *App) drawStatus(x, y, maxX int) {
+	switch {
+	case a.searching:
+		query := "/" + a.current().tree.Query()
+		end := drawText(a.screen, x, y, maxX, styleDefault, query)
+		a.screen.ShowCursor(end, y)
+		return
+	case a.message != "":
+		drawText(a.screen, x, y, maxX, styleDefault, a.message)
+	case a.preview != nil:
+		drawText(a.screen, x, y, maxX, styleDim, "↑↓ scroll  esc close  tab layers  q quit")
+	default:
+		drawText(a.screen, x, y, maxX, styleDim, "↑↓ move  enter open  ←→ collapse/expand  tab switch pane  m merged  / search  q quit")
+	}
+	a.screen.HideCursor()
+}
+
+// scroll returns the offset keeping cursor within rows visible rows.
+func scroll(cursor, offset, rows int) int {
+	if cursor < offset {
+		return cursor
+	}
+	if cursor >= offset+rows {
+		return cursor - rows + 1
+	}
+	return offset
+}
+
+func fill(s tcell.Screen, x, y, maxX int, style tcell.Style) {
+	for ; x < maxX; x++ {
+		s.SetContent(x, y, ' ', nil, style)
+	}
+}
+
+// drawText draws s from x up to maxX, replacing control characters, and
+// returns the column after the last character drawn.
+func drawText(s tcell.Screen, x, y, maxX int, style tcell.Style, text string) int {
+	for _, r := range text {
+		if r < ' ' || r == 0x7f {
+			r = '?'
+		}
+		rw := runewidth.RuneWidth(r)
+		if x+rw > maxX {
+			break
+		}
+		s.SetContent(x, y, r, nil, style)
+		x += rw
+	}
+	return x
+}
+
+// isRune reports whether ev is the key for r.
+func isRune(ev *tcell.EventKey, r rune) bool {
+	return ev.Key() == tcell.KeyRune && ev.Rune() == r
+}
@@ -0,0 +1,244 @@
+package browse_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/browse"
+	"github.com/gdamore/tcell/v2"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type entry struct {
+	name    string
+	content string
+	dir     bool
+}
+
+func newLayer(t *testing.T, entries ...entry) v1.Layer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		if e.dir {
+			hdr.Typeflag, hdr.Mode, hdr.Size = tar.TypeDir, 0o755, 0
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(e.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	data := buf.Bytes()
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+	require.NoError(t, err)
+	return layer
+}
+
+func newImage(t *testing.T) *browse.Image {
+	t.Helper()
+
+	img, err := mutate.Append(empty.Image,
+		mutate.Addendum{
+			Layer: newLayer(t,
+				entry{name: "etc/", dir: true},
+				entry{name: "etc/os-release", content: "ID=alpine\n"},
+				entry{name: "etc/motd", content: "welcome"},
+				entry{name: "var/", dir: true},
+				entry{name: "var/cache/", dir: true},
+				entry{name: "var/cache/apk/", dir: true},
+				entry{name: "var/cache/apk/index", content: "index"},
+				entry{name: "bin/busybox", content: "\x7fELF\x00\x01"},
+			),
+			History: v1.History{CreatedBy: "/bin/sh -c #(nop) ADD file:abc in / "},
+		},
+		mutate.Addendum{
+			Layer: newLayer(t,
+				entry{name: "etc/", dir: true},
+				entry{name: "etc/os-release", content: "ID=alpine\nVERSION_ID=3.20\n"},
+				entry{name: "etc/.wh.motd"},
+				entry{name: "var/cache/apk/.wh..wh..opq"},
+				entry{name: "app/config.yaml", content: "port: 8080\n"},
+			),
+			History: v1.History{CreatedBy: "COPY config.yaml /app/ # buildkit"},
+		},
+	)
+	require.NoError(t, err)
+
+	image, err := browse.Load("app:1", img)
+	require.NoError(t, err)
+	return image
+}
+
+func TestLoad_Changes(t *testing.T) {
+	image := newImage(t)
+	require.Len(t, image.Layers, 2)
+
+	base := image.Layers[0]
+	assert.Equal(t, "/bin/sh -c #(nop) ADD file:abc in / ", base.CreatedBy)
+	assert.Equal(t, browse.Added, base.Changes.Find("/etc/os-release").Change)
+	assert.Equal(t, int64(len("ID=alpine\n")+len("welcome")), base.Changes.Find("/etc").Size)
+
+	top := image.Layers[1].Changes
+	assert.Equal(t, browse.Unchanged, top.Find("/etc").Change, "repeated directory")
+	assert.Equal(t, browse.Modified, top.Find("/etc/os-release").Change)
+	assert.Equal(t, browse.Deleted, top.Find("/etc/motd").Change)
+	assert.Equal(t, browse.Modified, top.Find("/var/cache/apk").Change, "opaque directory")
+	assert.Equal(t, browse.Added, top.Find("/app/config.yaml").Change)
+	assert.Nil(t, top.Find("/bin/busybox"))
+}
+
+func TestMerged(t *testing.T) {
+	image := newImage(t)
+
+	merged, err := image.Merged()
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, merged.Find("/etc/os-release").Layer)
+	assert.Equal(t, 1, merged.Find("/bin/busybox").Layer)
+	assert.Nil(t, merged.Find("/etc/motd"))
+	assert.Nil(t, merged.Find("/var/cache/apk/index"))
+
+	var names []string
+	for _, child := range merged.Children {
+		names = append(names, child.Name)
+	}
+	assert.Equal(t, []string{"app", "bin", "etc", "var"}, names)
+}
+
+func TestPreview(t *testing.T) {
+	image := newImage(t)
+	merged, err := image.Merged()
+	require.NoError(t, err)
+
+	preview, err := image.Preview(merged.Find("/etc/os-release"), browse.PreviewLimit)
+	require.NoError(t, err)
+	assert.Equal(t, "ID=alpine\nVERSION_ID=3.20\n", preview.Text)
+	assert.False(t, preview.Truncated)
+
+	preview, err = image.Preview(merged.Find("/etc/os-release"), 4)
+	require.NoError(t, err)
+	assert.Equal(t, "ID=a", preview.Text)
+	assert.True(t, preview.Truncated)
+
+	preview, err = image.Preview(merged.Find("/bin/busybox"), browse.PreviewLimit)
+	require.NoError(t, err)
+	assert.True(t, preview.Binary)
+	assert.Empty(t, preview.Text)
+
+	_, err = image.Preview(merged.Find("/etc"), browse.PreviewLimit)
+	assert.Error(t, err)
+}
+
+func TestTree(t *testing.T) {
+	image := newImage(t)
+	merged, err := image.Merged()
+	require.NoError(t, err)
+
+	paths := func(tree *browse.Tree) []string {
+		var p []string
+		for _, row := range tree.Rows() {
+			p = append(p, row.Node.Path)
+		}
+		return p
+	}
+
+	tree := browse.NewTree(merged)
+	assert.Equal(t, []string{"/app", "/bin", "/etc", "/var"}, paths(tree))
+
+	tree.Toggle("/etc")
+	assert.Equal(t, []string{"/app", "/bin", "/etc", "/etc/os-release", "/var"}, paths(tree))
+
+	tree.Search("CONFIG")
+	assert.Equal(t, []string{"/app", "/app/config.yaml"}, paths(tree))
+
+	tree.Search("")
+	tree.SetExpanded("/etc", false)
+	assert.Equal(t, []string{"/app", "/bin", "/etc", "/var"}, paths(tree))
+}
+
+// screenText returns the lines shown on a simulation screen.
+func screenText(s tcell.SimulationScreen) []string {
+	cells, w, h := s.GetContents()
+	lines := make([]string, h)
+	for y := 0; y < h; y++ {
+		var b strings.Builder
+		for x := 0; x < w; x++ {
+			c := cells[y*w+x]
+			if len(c.Runes) == 0 {
+				b.WriteRune(' ')
+				continue
+			}
+			b.WriteRune(c.Runes[0])
+		}
+		lines[y] = strings.TrimRight(b.String(), " ")
+	}
+	return lines
+}
+
+func runApp(t *testing.T, keys ...*tcell.EventKey) string {
+	t.Helper()
+
+	screen := tcell.NewSimulationScreen("")
+	require.NoError(t, screen.Init())
+	screen.SetSize(100, 20)
+	defer screen.Fini()
+
+	for _, k := range keys {
+		require.NoError(t, screen.PostEvent(k))
+	}
+	require.NoError(t, screen.PostEvent(tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone)))
+
+	require.NoError(t, browse.NewApp(screen, newImage(t)).Run())
+	return strings.Join(screenText(screen), "\n")
+}
+
+func key(k tcell.Key) *tcell.EventKey {
+	return tcell.NewEventKey(k, 0, tcell.ModNone)
+}
+
+func char(r rune) *tcell.EventKey {
+	return tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+}
+
+func TestApp(t *testing.T) {
+	t.Run("layer changes", func(t *testing.T) {
+		out := runApp(t, key(tcell.KeyDown))
+
+		assert.Contains(t, out, "cek browse app:1")
+		assert.Contains(t, out, "layer 2/2")
+		assert.Contains(t, out, "ADD file:abc in /")
+		assert.Contains(t, out, "COPY config.yaml")
+		assert.Contains(t, out, "Changes in layer 2 (37 B)")
+		assert.Contains(t, out, "▸ app/")
+	})
+
+	t.Run("merged preview", func(t *testing.T) {
+		// Switch to the merged view, open /etc and preview os-release.
+		out := runApp(t, char('m'), key(tcell.KeyTab),
+			char('j'), char('j'), key(tcell.KeyEnter), char('j'), key(tcell.KeyEnter))
+
+		assert.Contains(t, out, "/etc/os-release")
+		assert.Contains(t, out, "VERSION_ID=3.20")
+	})
+
+	t.Run("search", func(t *testing.T) {
+		out := runApp(t, char('m'), char('/'), char('r'), char('e'), char('l'), key(tcell.KeyEnter))
+
+		assert.Contains(t, out, "search: rel")
+		assert.Contains(t, out, "os-release")
+		assert.NotContains(t, out, "busybox")
+	})
+}
@@ -0,0 +1,224 @@
+package browse
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/pkg/imagefs"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Layer is a layer of the browsed image and the changes it makes.
+type Layer struct {
+	Index  int
+	Digest v1.Hash
+	Size   int64
+	// CreatedBy is the instruction that created the layer, from the image
+	// history.
+	CreatedBy string
+	Changes   *Node
+}
+
+// Image is a browsed image.
+type Image struct {
+	Ref    string
+	Layers []Layer
+
+	layers []v1.Layer
+	// read holds the tar headers of layers.
+	read   []*imagefs.Layer
+	merged *Node
+}
+
+// Load reads the layers of img and the changes each makes to the layers
+// below it. The merged filesystem is read on first use.
+func Load(ref string, img v1.Image) (*Image, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layers: %w", err)
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config file: %w", err)
+	}
+
+	// History has entries for instructions that created no layer too.
+	var createdBy []string
+	for _, h := range config.History {
+		if !h.EmptyLayer {
+			createdBy = append(createdBy, h.CreatedBy)
+		}
+	}
+
+	image := &Image{Ref: ref, layers: layers, read: make([]*imagefs.Layer, len(layers))}
+	for i, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get layer digest: %w", err)
+		}
+		size, err := layer.Size()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get layer size: %w", err)
+		}
+		read, err := imagefs.ReadLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read layer %d: %w", i+1, err)
+		}
+		image.read[i] = read
+
+		// The filesystem of the layers below tells additions from
+		// modifications.
+		below := imagefs.Stack(image.read[:i]...)
+		root := newRoot()
+		read.Changes(func(p string, hdr *tar.Header) {
+			change := Added
+			if info, ok := lstat(below, p); ok {
+				change = Modified
+				// Directories repeated to hold new entries are not changes.
+				if info.IsDir() && hdr.Typeflag == tar.TypeDir {
+					change = Unchanged
+				}
+			}
+			root.add(p, hdr, i+1, change)
+		}, func(p string, opaque bool) {
+			if opaque {
+				// The directory stays, with its lower contents hidden.
+				root.add(p, nil, i+1, Modified)
+				return
+			}
+			info, ok := lstat(below, p)
+			root.add(p, nil, i+1, Deleted).Dir = ok && info.IsDir()
+		})
+		root.finish()
+
+		l := Layer{Index: i + 1, Digest: digest, Size: size, Changes: root}
+		if i < len(createdBy) {
+			l.CreatedBy = createdBy[i]
+		}
+		image.Layers = append(image.Layers, l)
+	}
+
+	return image, nil
+}
+
+// lstat returns the entry of fsys at the clean absolute path p. Paths
+// reached through a symbolic link are not the entry at p, and are not
+// returned.
+func lstat(fsys *imagefs.FS, p string) (fs.FileInfo, bool) {
+	name := "."
+	if p != "/" {
+		name = strings.TrimPrefix(p, "/")
+	}
+	info, err := fsys.Lstat(name)
+	if err != nil {
+		return nil, false
+	}
+	if hdr, ok := info.Sys().(*tar.Header); !ok || oci.CleanPath(hdr.Name) != p {
+		return nil, false
+	}
+	return info, true
+}
+
+// Merged returns the merged filesystem a container would see.
+func (img *Image) Merged() (*Node, error) {
+	if img.merged != nil {
+		return img.merged, nil
+	}
+
+	root := newRoot()
+	err := fs.WalkDir(imagefs.Stack(img.read...), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		// Directories only implied by their contents are added with them.
+		layer := imagefs.LayerIndex(info)
+		if layer < 0 {
+			return nil
+		}
+		root.add("/"+p, info.Sys().(*tar.Header), layer+1, Unchanged)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read merged filesystem: %w", err)
+	}
+	root.finish()
+
+	img.merged = root
+	return root, nil
+}
+
+// Preview is the start of a file's contents.
+type Preview struct {
+	Path string
+	Size int64
+	// Text is empty for binary files.
+	Text      string
+	Binary    bool
+	Truncated bool
+}
+
+// Preview reads up to limit bytes of the file at n from the layer it
+// comes from.
+func (img *Image) Preview(n *Node, limit int64) (*Preview, error) {
+	if n.Dir || n.Change == Deleted || n.Layer < 1 || n.Layer > len(img.layers) {
+		return nil, fmt.Errorf("%s is not a file", n.Path)
+	}
+
+	var preview *Preview
+	err := oci.WalkLayer(img.layers[n.Layer-1], n.Layer, func(f *oci.FileEntry) error {
+		if f.Path != n.Path {
+			return nil
+		}
+		preview = &Preview{Path: n.Path, Size: f.Header.Size}
+		switch {
+		case f.Header.Typeflag == tar.TypeSymlink:
+			preview.Text = "symbolic link to " + f.Header.Linkname
+		case f.Header.Typeflag == tar.TypeLink:
+			preview.Text = "hard link to " + f.Header.Linkname
+		case f.IsRegular():
+			data, err := io.ReadAll(io.LimitReader(f.Reader, limit))
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", f.Path, err)
+			}
+			preview.Truncated = f.Header.Size > int64(len(data))
+			preview.Binary = isBinary(data, preview.Truncated)
+			if !preview.Binary {
+				preview.Text = string(data)
+			}
+		default:
+			preview.Text = fmt.Sprintf("%s, no contents", f.Header.FileInfo().Mode().Type())
+		}
+		return fs.SkipAll
+	})
+	if err != nil {
+		return nil, err
+	}
+	if preview == nil {
+		return nil, fmt.Errorf("%s not found in layer %d", n.Path, n.Layer)
+	}
+	return preview, nil
+}
+
+// isBinary reports whether data does not look like text. A truncated
+// multi-byte character at the end of a truncated file is ignored.
+func isBinary(data []byte, truncated bool) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+	if truncated {
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	return !utf8.Valid(data)
+}
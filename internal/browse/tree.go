@@ -0,0 +1,195 @@
+// Package browse implements the interactive image browser behind
+// cek browse.
+package browse
+
+import (
+	"archive/tar"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Change is how a layer changes a path.
+type Change string
+
+const (
+	Unchanged Change = ""
+	Added     Change = "added"
+	Modified  Change = "modified"
+	Deleted   Change = "deleted"
+)
+
+// Node is a file or directory in a tree.
+type Node struct {
+	Name string
+	Path string
+	Dir  bool
+	// Size is the file size, or the total size of the files below a
+	// directory.
+	Size int64
+	Mode fs.FileMode
+	// Link is the target of symbolic and hard links.
+	Link string
+	// Layer is the 1-indexed layer the entry comes from, 0 for
+	// directories only implied by their contents.
+	Layer    int
+	Change   Change
+	Children []*Node
+
+	byName map[string]*Node
+}
+
+func newRoot() *Node {
+	return &Node{Name: "/", Path: "/", Dir: true, Mode: fs.ModeDir | 0o755}
+}
+
+// add inserts the entry for p, creating missing parent directories, and
+// returns its node.
+func (n *Node) add(p string, hdr *tar.Header, layer int, change Change) *Node {
+	node := n.ensure(p)
+	if hdr != nil {
+		node.Dir = hdr.Typeflag == tar.TypeDir
+		node.Mode = hdr.FileInfo().Mode()
+		node.Link = hdr.Linkname
+		if !node.Dir {
+			node.Size = hdr.Size
+		}
+	}
+	node.Layer = layer
+	node.Change = change
+	return node
+}
+
+// ensure returns the node for p, creating it and its parents as
+// directories when missing.
+func (n *Node) ensure(p string) *Node {
+	if p == "/" {
+		return n
+	}
+	parent := n.ensure(path.Dir(p))
+	name := path.Base(p)
+	if child, ok := parent.byName[name]; ok {
+		return child
+	}
+	if parent.byName == nil {
+		parent.byName = make(map[string]*Node)
+	}
+	child := &Node{Name: name, Path: p, Dir: true, Mode: fs.ModeDir | 0o755}
+	parent.byName[name] = child
+	parent.Children = append(parent.Children, child)
+	return child
+}
+
+// Find returns the node for the absolute path p below n, or nil.
+func (n *Node) Find(p string) *Node {
+	rel := strings.Trim(strings.TrimPrefix(p, n.Path), "/")
+	if rel == "" {
+		return n
+	}
+	node := n
+	for _, name := range strings.Split(rel, "/") {
+		node = node.byName[name]
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// finish sorts children, directories first, and totals directory sizes.
+func (n *Node) finish() int64 {
+	if !n.Dir {
+		return n.Size
+	}
+	sort.Slice(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		if a.Dir != b.Dir {
+			return a.Dir
+		}
+		return a.Name < b.Name
+	})
+	n.Size = 0
+	for _, child := range n.Children {
+		n.Size += child.finish()
+	}
+	return n.Size
+}
+
+// Row is a node shown in a tree at some depth.
+type Row struct {
+	Node  *Node
+	Depth int
+}
+
+// Tree is the browsing state of a tree: which directories are expanded
+// and the current search.
+type Tree struct {
+	Root     *Node
+	expanded map[string]bool
+	query    string
+}
+
+// NewTree returns a tree with the top-level directory expanded.
+func NewTree(root *Node) *Tree {
+	return &Tree{Root: root, expanded: map[string]bool{root.Path: true}}
+}
+
+// Expanded reports whether the directory at p is expanded.
+func (t *Tree) Expanded(p string) bool {
+	return t.expanded[p] || t.query != ""
+}
+
+// Toggle expands or collapses the directory at p.
+func (t *Tree) Toggle(p string) {
+	t.expanded[p] = !t.expanded[p]
+}
+
+// SetExpanded expands or collapses the directory at p.
+func (t *Tree) SetExpanded(p string, expanded bool) {
+	t.expanded[p] = expanded
+}
+
+// Search limits the rows to paths containing query, ignoring case, and
+// their parent directories, which are shown expanded. An empty query
+// clears the search.
+func (t *Tree) Search(query string) {
+	t.query = strings.ToLower(query)
+}
+
+// Query returns the current search.
+func (t *Tree) Query() string {
+	return t.query
+}
+
+// Rows returns the visible nodes below the root in display order.
+func (t *Tree) Rows() []Row {
+	var rows []Row
+	var visit func(n *Node, depth int)
+	visit = func(n *Node, depth int) {
+		for _, child := range n.Children {
+			if t.query != "" && !t.matches(child) {
+				continue
+			}
+			rows = append(rows, Row{Node: child, Depth: depth})
+			if child.Dir && t.Expanded(child.Path) {
+				visit(child, depth+1)
+			}
+		}
+	}
+	visit(t.Root, 0)
+	return rows
+}
+
+// matches reports whether n or anything below it matches the search.
+func (t *Tree) matches(n *Node) bool {
+	if strings.Contains(strings.ToLower(n.Path), t.query) {
+		return true
+	}
+	for _, child := range n.Children {
+		if t.matches(child) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/bschaatsbergen/cek/internal/referrers"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+type AttestationsOptions struct {
+	Types          []string
+	ShowPredicates bool
+	Platform       string
+}
+
+func NewAttestationsCommand(cli *CLI) *cobra.Command {
+	opts := AttestationsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "attestations <image>",
+		Short: "Decode in-toto attestations attached to an image",
+		Long: highlight("cek attestations my-app:latest") + "\n\n" +
+			"Find the in-toto attestations attached to an image, such as SLSA\n" +
+			"provenance and SBOMs, and decode their DSSE envelopes and statements.\n\n" +
+			"Attestations are read from the cosign sha256-<digest>.att tag, from the\n" +
+			"OCI 1.1 referrers API (including sigstore bundles) and from BuildKit\n" +
+			"attestation manifests stored in the image index.\n\n" +
+			"Signatures on the envelopes are counted but not verified.\n\n" +
+			"Filter by predicate type with --type, using either the full predicate\n" +
+			"type URI or one of the short names slsaprovenance, spdx, spdxjson,\n" +
+			"cyclonedx, vuln, openvex and link.\n\n" +
+			"Examples:\n" +
+			"  cek attestations my-app:latest\n" +
+			"  cek attestations --type slsaprovenance --predicate my-app:latest\n" +
+			"  cek attestations --type https://spdx.dev/Document --json my-app:latest\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			imageRef := args[0]
+			return RunAttestations(cmd.Context(), cli, imageRef, &opts)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.Types, "type", nil, "Only show attestations of these predicate types (comma-separated)")
+	cmd.Flags().BoolVar(&opts.ShowPredicates, "predicate", false, "Print the decoded predicates")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+
+	return cmd
+}
+
+func RunAttestations(ctx context.Context, cli *CLI, imageRef string, opts *AttestationsOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Fetching attestations", "image", imageRef)
+
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	remoteOpts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+
+	desc, subject, err := resolveSubject(ref, opts.Platform, remoteOpts)
+	if err != nil {
+		return err
+	}
+
+	skipped := func(a referrers.Artifact) {
+		logger.Debug("Skipping artifact that is not an attestation", "digest", a.Digest, "artifactType", a.ArtifactType, "mediaType", a.MediaType)
+	}
+	attestations, err := referrers.Fetch(ref.Context(), desc.Digest, skipped, remoteOpts...)
+	if err != nil {
+		return err
+	}
+	if subject != desc.Digest {
+		found, err := referrers.Fetch(ref.Context(), subject, skipped, remoteOpts...)
+		if err != nil {
+			return err
+		}
+		attestations = append(attestations, found...)
+	}
+
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return fmt.Errorf("failed to get image index: %w", err)
+		}
+		filter := &subject
+		if subject == desc.Digest {
+			filter = nil
+		}
+		found, err := referrers.FromIndex(idx, filter)
+		if err != nil {
+			return err
+		}
+		attestations = append(attestations, found...)
+	}
+
+	logger.Debug("Fetched attestations", "count", len(attestations))
+
+	data := &view.AttestationsData{
+		ImageRef:       imageRef,
+		Digest:         desc.Digest.String(),
+		ShowPredicates: opts.ShowPredicates,
+	}
+	for _, a := range attestations {
+		if !matchPredicateTypes(opts.Types, a.Statement.PredicateType) {
+			continue
+		}
+		data.Attestations = append(data.Attestations, attestationInfo(&a))
+	}
+	sort.SliceStable(data.Attestations, func(i, j int) bool {
+		return data.Attestations[i].PredicateType < data.Attestations[j].PredicateType
+	})
+
+	return cli.Attestations().Render(data)
+}
+
+func matchPredicateTypes(filters []string, predicateType string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, f := range filters {
+		if referrers.MatchPredicateType(f, predicateType) {
+			return true
+		}
+	}
+	return false
+}
+
+func attestationInfo(a *referrers.Attestation) view.AttestationInfo {
+	info := view.AttestationInfo{
+		Source:        string(a.Source),
+		Manifest:      a.Manifest.String(),
+		Subject:       a.Subject.String(),
+		StatementType: a.Statement.Type,
+		PredicateType: a.Statement.PredicateType,
+		Predicate:     a.Statement.Predicate,
+	}
+
+	for _, s := range a.Statement.Subject {
+		// Subjects are usually identified by a single sha256 digest.
+		algorithms := make([]string, 0, len(s.Digest))
+		for alg := range s.Digest {
+			algorithms = append(algorithms, alg)
+		}
+		sort.Strings(algorithms)
+		subject := s.Name
+		if len(algorithms) > 0 {
+			subject = fmt.Sprintf("%s@%s:%s", s.Name, algorithms[0], s.Digest[algorithms[0]])
+		}
+		info.StatementSubjects = append(info.StatementSubjects, subject)
+	}
+
+	if a.Envelope != nil {
+		info.PayloadType = a.Envelope.PayloadType
+		info.Signatures = len(a.Envelope.Signatures)
+		for _, sig := range a.Envelope.Signatures {
+			if sig.KeyID != "" {
+				info.KeyIDs = append(info.KeyIDs, sig.KeyID)
+			}
+		}
+	}
+
+	return info
+}
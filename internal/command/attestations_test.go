@@ -0,0 +1,70 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/referrers"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func inTotoStatement(predicateType, predicate string) string {
+	return fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"app","digest":{"sha256":"abc"}}],"predicateType":%q,"predicate":%s}`,
+		predicateType, predicate)
+}
+
+func TestNewAttestationsCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewAttestationsCommand(cli)
+
+	assert.Equal(t, "attestations", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.NotNil(t, cmd.Flags().Lookup("type"))
+	assert.Equal(t, "false", cmd.Flags().Lookup("predicate").DefValue)
+}
+
+func TestRunAttestations(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{"app/main": "v1"}))
+
+	provenance := inTotoStatement("https://slsa.dev/provenance/v1", `{"buildDefinition":{"buildType":"https://example.com/ci"}}`)
+	envelope := fmt.Sprintf(`{"payloadType":"application/vnd.in-toto+json","payload":%q,"signatures":[{"keyid":"","sig":"c2ln"}]}`,
+		base64.StdEncoding.EncodeToString([]byte(provenance)))
+	attachTestArtifact(t, ref, string(referrers.DSSEMediaType), referrers.DSSEMediaType, envelope)
+	attachTestArtifact(t, ref, string(referrers.InTotoMediaType), referrers.InTotoMediaType, inTotoStatement("https://spdx.dev/Document", `{"spdxVersion":"SPDX-2.3"}`))
+
+	t.Run("all", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewAttestationsCommand(cli)
+		cmd.SetArgs([]string{ref})
+		require.NoError(t, cmd.Execute())
+
+		output := buf.String()
+		assert.Contains(t, output, "https://slsa.dev/provenance/v1")
+		assert.Contains(t, output, "https://spdx.dev/Document")
+		assert.Contains(t, output, "yes (1)")
+	})
+
+	t.Run("filtered with predicate", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewAttestationsCommand(cli)
+		cmd.SetArgs([]string{ref, "--type", "slsaprovenance", "--predicate"})
+		require.NoError(t, cmd.Execute())
+
+		output := buf.String()
+		assert.Contains(t, output, "https://slsa.dev/provenance/v1")
+		assert.NotContains(t, output, "https://spdx.dev/Document")
+		assert.Contains(t, output, "app@sha256:abc")
+		assert.Contains(t, output, `"buildType": "https://example.com/ci"`)
+	})
+}
@@ -0,0 +1,284 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bschaatsbergen/cek/internal/baseimage"
+	"github.com/bschaatsbergen/cek/internal/diff"
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/tags"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+type BaseOptions struct {
+	Candidates  []string
+	Repo        string
+	Match       string
+	Platform    string
+	Pull        string
+	Concurrency int
+}
+
+func NewBaseCommand(cli *CLI) *cobra.Command {
+	opts := BaseOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "base <image>",
+		Short: "Detect the base image an image was built from",
+		Long: highlight("cek base myapp:1.4.3 --repo alpine") + "\n\n" +
+			"Determine the base image of an image by matching the layers of candidate\n" +
+			"images, compared by uncompressed digest (diffID), against the first\n" +
+			"layers of the image. The candidate with the most layers in common wins.\n" +
+			"Candidates are:\n" +
+			"  - The images given with --candidate\n" +
+			"  - Every tag of the repository given with --repo, optionally filtered\n" +
+			"    by the --match regular expression\n" +
+			"  - The base recorded in the org.opencontainers.image.base.name and\n" +
+			"    org.opencontainers.image.base.digest annotations, when present\n\n" +
+			"For the detected base, cek reports how many layers on top are\n" +
+			"app-specific, whether the base tag has moved since the image was built,\n" +
+			"and whether a newer tag of the same variant exists. An image whose base\n" +
+			"tag has moved needs a rebuild to pick up fixes in the base.\n\n" +
+			"Examples:\n" +
+			"  cek base myapp:1.4.3\n" +
+			"  cek base myapp:1.4.3 --repo alpine --match '3\\.[0-9]+'\n" +
+			"  cek base myapp:1.4.3 -c debian:bookworm-slim -c debian:bookworm\n" +
+			"  cek base myapp:1.4.3 --repo registry.internal/base/python --json\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunBase(cmd.Context(), cli, args[0], &opts)
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&opts.Candidates, "candidate", "c", nil, "Candidate base images")
+	cmd.Flags().StringVar(&opts.Repo, "repo", "", "Consider every tag of this repository a candidate")
+	cmd.Flags().StringVar(&opts.Match, "match", "", "Only consider --repo tags matching this regular expression")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Maximum candidates fetched at once")
+
+	return cmd
+}
+
+func RunBase(ctx context.Context, cli *CLI, imageRef string, opts *BaseOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Detecting base image", "image", imageRef)
+
+	remoteOpts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+
+	img, _, err := oci.FetchImage(ctx, imageRef, &oci.FetchOptions{
+		Platform:   opts.Platform,
+		PullPolicy: oci.PullPolicy(opts.Pull),
+		Keychain:   authn.DefaultKeychain,
+	})
+	if err != nil {
+		return err
+	}
+	target, err := baseCandidate(imageRef, img)
+	if err != nil {
+		return err
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return fmt.Errorf("failed to get config file: %w", err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("failed to get manifest: %w", err)
+	}
+
+	data := &view.BaseData{
+		ImageRef:         imageRef,
+		Digest:           target.Digest.String(),
+		Layers:           len(target.DiffIDs),
+		AnnotationName:   manifest.Annotations[diff.AnnotationBaseName],
+		AnnotationDigest: manifest.Annotations[diff.AnnotationBaseDigest],
+	}
+
+	// Candidates are compared on the platform of the image.
+	platform := config.Platform()
+	if platform == nil || platform.OS == "" {
+		platform = &v1.Platform{OS: "linux", Architecture: "amd64"}
+	}
+
+	// The annotated base, when present, is the first candidate.
+	annotated := data.AnnotationName != "" && data.AnnotationDigest != ""
+	var refs, names []string
+	add := func(ref, display string) {
+		refs = append(refs, ref)
+		names = append(names, display)
+	}
+	if annotated {
+		ref, err := name.ParseReference(data.AnnotationName)
+		if err != nil {
+			logger.Warn("Ignoring invalid base image annotation", "base", data.AnnotationName, "error", err)
+			annotated = false
+		} else {
+			add(ref.Context().Digest(data.AnnotationDigest).String(), data.AnnotationName)
+		}
+	}
+	for _, c := range opts.Candidates {
+		add(c, c)
+	}
+
+	var repoTags []string
+	if opts.Repo != "" {
+		repo, err := name.NewRepository(opts.Repo)
+		if err != nil {
+			return fmt.Errorf("failed to parse repository: %w", err)
+		}
+		repoTags, err = remote.List(repo, remoteOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to list tags: %w", err)
+		}
+		filter, err := tags.NewFilter(opts.Match, "")
+		if err != nil {
+			return err
+		}
+		for _, tag := range filter.Apply(repoTags) {
+			ref := repo.Tag(tag).String()
+			add(ref, ref)
+		}
+	}
+	if len(refs) == 0 {
+		return errors.New("no candidates: use --candidate or --repo, or build the image with base image annotations")
+	}
+	data.Candidates = len(refs)
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+	fetchOpts := &oci.FetchOptions{
+		Platform:   platform.String(),
+		PullPolicy: oci.PullPolicy(opts.Pull),
+		Keychain:   authn.DefaultKeychain,
+	}
+	candidates := make([]*baseimage.Candidate, len(refs))
+	errs := make([]error, len(refs))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, ref := range refs {
+		g.Go(func() error {
+			img, _, err := oci.FetchImage(gctx, ref, fetchOpts)
+			if err == nil {
+				candidates[i], err = baseCandidate(names[i], img)
+			}
+			if err != nil {
+				// Tags such as signatures and other platforms are not bases.
+				logger.Debug("Skipping candidate", "candidate", ref, "error", err)
+				errs[i] = err
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	var fetched []baseimage.Candidate
+	for _, c := range candidates {
+		if c != nil {
+			fetched = append(fetched, *c)
+		}
+	}
+	// Some candidates failing is expected, all of them failing is not: a
+	// missing credential would otherwise pass for "no base found".
+	if len(fetched) == 0 {
+		return fmt.Errorf("failed to fetch any of the %d candidates: %s: %w", len(refs), refs[0], errs[0])
+	}
+
+	matches := baseimage.Match(target.DiffIDs, fetched)
+	if len(matches) == 0 {
+		return cli.Base().Render(data)
+	}
+
+	base := &view.BaseMatch{
+		Digest:    matches[0].Digest.String(),
+		Layers:    len(matches[0].DiffIDs),
+		AppLayers: len(target.DiffIDs) - len(matches[0].DiffIDs),
+	}
+	for _, m := range matches {
+		base.Names = append(base.Names, m.Name)
+		if annotated && candidates[0] != nil && m.Name == candidates[0].Name && m.Digest == candidates[0].Digest {
+			base.Annotated = true
+		}
+	}
+	data.Base = base
+
+	// A base found through the annotations is the image the tag pointed
+	// at during the build; the tag may have moved since.
+	if base.Annotated {
+		if ref, err := name.ParseReference(data.AnnotationName); err == nil {
+			if desc, err := remote.Head(ref, remoteOpts...); err != nil {
+				logger.Warn("Failed to resolve base tag", "base", data.AnnotationName, "error", err)
+			} else if desc.Digest.String() != data.AnnotationDigest {
+				base.Current = desc.Digest.String()
+			}
+		}
+	}
+
+	if err := newerBase(base, opts.Repo, repoTags, remoteOpts); err != nil {
+		logger.Warn("Failed to look for a newer base tag", "error", err)
+	}
+
+	return cli.Base().Render(data)
+}
+
+// baseCandidate reads the digest and layer diffIDs of an image.
+func baseCandidate(displayName string, img v1.Image) (*baseimage.Candidate, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image digest: %w", err)
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config file: %w", err)
+	}
+	return &baseimage.Candidate{Name: displayName, Digest: digest, DiffIDs: config.RootFS.DiffIDs}, nil
+}
+
+// newerBase looks for a newer tag of any matched base name that is a
+// version. The tags of repo are already known; other repositories are
+// listed once.
+func newerBase(base *view.BaseMatch, repo string, repoTags []string, remoteOpts []remote.Option) error {
+	lists := make(map[string][]string)
+	if r, err := name.NewRepository(repo); err == nil {
+		lists[r.String()] = repoTags
+	}
+
+	for _, n := range base.Names {
+		tag, err := name.NewTag(n)
+		if err != nil {
+			continue
+		}
+		if _, ok := tags.ParseVersion(tag.TagStr()); !ok {
+			continue
+		}
+
+		tagList, ok := lists[tag.Context().String()]
+		if !ok {
+			tagList, err = remote.List(tag.Context(), remoteOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to list tags of %s: %w", tag.Context(), err)
+			}
+			lists[tag.Context().String()] = tagList
+		}
+		if newer, ok := baseimage.Newer(tag.TagStr(), tagList); ok {
+			base.Newer = tag.Context().Tag(newer).String()
+			return nil
+		}
+	}
+	return nil
+}
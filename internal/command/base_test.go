@@ -0,0 +1,146 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type baseOutput struct {
+	Candidates int `json:"candidates"`
+	Base       *struct {
+		Names         []string `json:"names"`
+		Layers        int      `json:"layers"`
+		AppLayers     int      `json:"app_layers"`
+		Annotated     bool     `json:"annotated"`
+		Rebuild       bool     `json:"rebuild_needed"`
+		CurrentDigest string   `json:"current_digest"`
+		Newer         string   `json:"newer_tag"`
+	} `json:"base"`
+}
+
+func runBase(t *testing.T, args ...string) baseOutput {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+	cmd := command.NewBaseCommand(cli)
+	cmd.SetArgs(append(args, "--pull", "always"))
+	require.NoError(t, cmd.Execute())
+
+	var out baseOutput
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	return out
+}
+
+// buildOn appends a layer with files to base, as a Dockerfile would.
+func buildOn(t *testing.T, base v1.Image, files map[string]string) v1.Image {
+	t.Helper()
+
+	layers, err := newTestImage(t, files).Layers()
+	require.NoError(t, err)
+	img, err := mutate.AppendLayers(base, layers...)
+	require.NoError(t, err)
+	return img
+}
+
+func TestBase(t *testing.T) {
+	host := newTestRegistry(t)
+	oldBase := newTestImage(t, map[string]string{"etc/os-release": "ID=alpine\n"}, map[string]string{"lib/libc.so": "1"})
+	newBase := newTestImage(t, map[string]string{"etc/os-release": "ID=alpine\n"}, map[string]string{"lib/libc.so": "2"})
+	pushTestImage(t, host+"/base:3.20", oldBase)
+	pushTestImage(t, host+"/base:3.20.1", oldBase)
+	pushTestImage(t, host+"/base:3.21", newTestImage(t, map[string]string{"etc/alpine-release": "3.21"}))
+	pushTestImage(t, host+"/other:1", newTestImage(t, map[string]string{"etc/debian_version": "12"}))
+
+	oldDigest, err := oldBase.Digest()
+	require.NoError(t, err)
+	app := buildOn(t, buildOn(t, oldBase, map[string]string{"app": "a"}), map[string]string{"config": "c"})
+	app = mutate.Annotations(app, map[string]string{
+		"org.opencontainers.image.base.name":   host + "/base:3.20",
+		"org.opencontainers.image.base.digest": oldDigest.String(),
+	}).(v1.Image)
+	appRef := pushTestImage(t, host+"/app:1", app)
+
+	t.Run("repo", func(t *testing.T) {
+		out := runBase(t, appRef, "--repo", host+"/base", "-c", host+"/other:1")
+
+		assert.Equal(t, 5, out.Candidates, "annotation, candidate and three tags")
+		require.NotNil(t, out.Base)
+		assert.Contains(t, out.Base.Names, host+"/base:3.20.1")
+		assert.Equal(t, 2, out.Base.Layers)
+		assert.Equal(t, 2, out.Base.AppLayers)
+		assert.True(t, out.Base.Annotated)
+		assert.False(t, out.Base.Rebuild)
+		assert.Equal(t, host+"/base:3.21", out.Base.Newer)
+	})
+
+	t.Run("moved tag", func(t *testing.T) {
+		pushTestImage(t, host+"/base:3.20", newBase)
+		newDigest, err := newBase.Digest()
+		require.NoError(t, err)
+
+		out := runBase(t, appRef)
+
+		assert.Equal(t, 1, out.Candidates)
+		require.NotNil(t, out.Base)
+		assert.Equal(t, []string{host + "/base:3.20"}, out.Base.Names)
+		assert.True(t, out.Base.Rebuild)
+		assert.Equal(t, newDigest.String(), out.Base.CurrentDigest)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewBaseCommand(cli)
+		cmd.SetArgs([]string{host + "/other:1", "-c", host + "/base:3.21", "--pull", "always"})
+		require.NoError(t, cmd.Execute())
+
+		assert.Contains(t, buf.String(), "No base found among 1 candidates")
+	})
+}
+
+func TestBase_NoCandidates(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1", newTestImage(t, map[string]string{"app": "a"}))
+
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewBaseCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	assert.ErrorContains(t, cmd.Execute(), "no candidates")
+}
+
+func TestBase_PrivateRegistry(t *testing.T) {
+	host := newAuthTestRegistry(t)
+	base := newTestImage(t, map[string]string{"etc/os-release": "ID=alpine\n"})
+	pushTestImage(t, host+"/base:3.20", base)
+	appRef := pushTestImage(t, host+"/app:1", buildOn(t, base, map[string]string{"app": "a"}))
+
+	out := runBase(t, appRef, "--repo", host+"/base")
+
+	require.NotNil(t, out.Base)
+	assert.Equal(t, []string{host + "/base:3.20"}, out.Base.Names)
+}
+
+func TestBase_CandidatesUnavailable(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1", newTestImage(t, map[string]string{"app": "a"}))
+
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewBaseCommand(cli)
+	cmd.SetArgs([]string{ref, "-c", host + "/missing:1", "--pull", "always"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	assert.ErrorContains(t, cmd.Execute(), "failed to fetch any of the 1 candidates")
+}
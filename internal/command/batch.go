@@ -0,0 +1,207 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+// batchLong documents --from-file for commands supporting batch mode.
+const batchLong = "Use --from-file to run over many images, one reference per line, or - to\n" +
+	"read them from stdin. Blank lines and lines starting with # are ignored.\n" +
+	"Images are processed concurrently and layers shared between images are\n" +
+	"downloaded once. Output is NDJSON: one JSON record per image, tagged with\n" +
+	"its reference, and warnings such as unreadable package databases are\n" +
+	"listed in it. Failures are recorded and do not stop the run, and the\n" +
+	"command exits with an error if any image failed.\n\n"
+
+// batchFunc runs a command for a single image, rendering to cli.
+type batchFunc func(ctx context.Context, cli *CLI, imageRef string, layers *oci.LayerCache) error
+
+// batchRecord is the NDJSON record written for each image.
+type batchRecord struct {
+	Image  string          `json:"image"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	// Warnings are the warnings and errors the image logged, such as
+	// package databases that could not be read, so that a partial result
+	// is not taken for a complete one.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// batchViewer renders an image of a batch, logging through a batchLogger.
+type batchViewer struct {
+	view.Viewer
+	logger *batchLogger
+}
+
+func (v *batchViewer) Logger() view.Logger {
+	return v.logger
+}
+
+// batchLogger forwards the logs of an image to the batch logger, tagged
+// with the image, and records its warnings and errors.
+type batchLogger struct {
+	logger view.Logger
+	image  string
+
+	mu       sync.Mutex
+	warnings []string
+}
+
+func (l *batchLogger) Debug(msg string, args ...any) {
+	l.logger.Debug(msg, append([]any{"image", l.image}, args...)...)
+}
+
+func (l *batchLogger) Info(msg string, args ...any) {
+	l.logger.Info(msg, append([]any{"image", l.image}, args...)...)
+}
+
+func (l *batchLogger) Warn(msg string, args ...any) {
+	l.record(msg, args)
+	l.logger.Warn(msg, append([]any{"image", l.image}, args...)...)
+}
+
+func (l *batchLogger) Error(msg string, args ...any) {
+	l.record(msg, args)
+	l.logger.Error(msg, append([]any{"image", l.image}, args...)...)
+}
+
+// record formats a log entry as its message followed by key=value pairs.
+func (l *batchLogger) record(msg string, args []any) {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		_, _ = fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warnings = append(l.warnings, b.String())
+}
+
+// batchArgs validates positional arguments with batch in batch mode, and
+// with args otherwise.
+func batchArgs(fromFile *string, batch, args cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, a []string) error {
+		if *fromFile != "" {
+			return batch(cmd, a)
+		}
+		return args(cmd, a)
+	}
+}
+
+// runBatch runs fn for every image listed in fromFile, or stdin for "-",
+// with at most concurrency images in flight. Each image renders its JSON
+// view into a record written to cli as soon as it completes, along with the
+// warnings it logged.
+func runBatch(ctx context.Context, cli *CLI, fromFile string, stdin io.Reader, concurrency int, fn batchFunc) error {
+	logger := cli.Logger()
+
+	images, err := readImageList(fromFile, stdin)
+	if err != nil {
+		return err
+	}
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+
+	dir, err := os.MkdirTemp("", "cek-layers-")
+	if err != nil {
+		return fmt.Errorf("failed to create layer cache: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	layers := oci.NewLayerCache(dir)
+
+	logger.Debug("Running batch", "images", len(images), "concurrency", concurrency)
+
+	var mu sync.Mutex
+	failed := 0
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, image := range images {
+		g.Go(func() error {
+			var buf bytes.Buffer
+			imageCLI := NewCLI(view.ViewJSON, &buf, view.LogLevelSilent)
+			imageLogger := &batchLogger{logger: logger, image: image}
+			imageCLI.Viewer = &batchViewer{Viewer: imageCLI.Viewer, logger: imageLogger}
+			runErr := fn(gctx, imageCLI, image, layers)
+
+			record := batchRecord{Image: image, Warnings: imageLogger.warnings}
+			var compact bytes.Buffer
+			if buf.Len() > 0 && json.Compact(&compact, buf.Bytes()) == nil {
+				record.Result = compact.Bytes()
+			}
+			if runErr != nil {
+				record.Error = runErr.Error()
+				logger.Debug("Image failed", "image", image, "error", runErr)
+			}
+			line, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("failed to encode JSON: %w", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if runErr != nil {
+				failed++
+			}
+			_, err = fmt.Fprintf(cli.Writer, "%s\n", line)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d images failed", failed, len(images))
+	}
+	return nil
+}
+
+// readImageList reads image references, one per line, from path or from
+// stdin for "-".
+func readImageList(path string, stdin io.Reader) ([]string, error) {
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image list: %w", err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		r = f
+	}
+
+	var images []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		images = append(images, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read image list: %w", err)
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no images listed in %s", path)
+	}
+	return images, nil
+}
@@ -0,0 +1,158 @@
+package command_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type batchRecord struct {
+	Image    string          `json:"image"`
+	Result   json.RawMessage `json:"result"`
+	Error    string          `json:"error"`
+	Warnings []string        `json:"warnings"`
+}
+
+// readBatch parses NDJSON output into records keyed by image.
+func readBatch(t *testing.T, out string) map[string]batchRecord {
+	t.Helper()
+
+	records := make(map[string]batchRecord)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		var r batchRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r), "each line is a JSON record")
+		records[r.Image] = r
+	}
+	return records
+}
+
+func TestBatch_FromFile(t *testing.T) {
+	host := newTestRegistry(t)
+	app := pushTestImage(t, host+"/app:1", newTestImage(t, map[string]string{"etc/app.conf": "a"}))
+	web := pushTestImage(t, host+"/web:1", newTestImage(t, map[string]string{"etc/web.conf": "w"}))
+	missing := host + "/missing:1"
+
+	list := filepath.Join(t.TempDir(), "images.txt")
+	require.NoError(t, os.WriteFile(list, []byte("# nightly audit\n"+app+"\n\n"+web+"\n"+missing+"\n"), 0o644))
+
+	tests := []struct {
+		name  string
+		cmd   func(*command.CLI) *cobra.Command
+		args  []string
+		check func(t *testing.T, result json.RawMessage)
+	}{
+		{
+			name: "inspect",
+			cmd:  command.NewInspectCommand,
+			check: func(t *testing.T, result json.RawMessage) {
+				var out struct {
+					Digest string `json:"digest"`
+				}
+				require.NoError(t, json.Unmarshal(result, &out))
+				assert.NotEmpty(t, out.Digest)
+			},
+		},
+		{
+			name: "ls",
+			cmd:  command.NewLsCommand,
+			check: func(t *testing.T, result json.RawMessage) {
+				assert.Contains(t, string(result), "etc/")
+			},
+		},
+		{
+			name: "lint",
+			cmd:  command.NewLintCommand,
+			check: func(t *testing.T, result json.RawMessage) {
+				assert.Contains(t, string(result), "root-user")
+			},
+		},
+		{
+			name: "packages",
+			cmd:  command.NewPackagesCommand,
+			check: func(t *testing.T, result json.RawMessage) {
+				assert.True(t, json.Valid(result))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+			cmd := tt.cmd(cli)
+			cmd.SetArgs(append(tt.args, "--from-file", list, "--pull", "always", "--concurrency", "2"))
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			err := cmd.Execute()
+			assert.ErrorContains(t, err, "1 of 3 images failed")
+
+			records := readBatch(t, buf.String())
+			require.Len(t, records, 3)
+			for _, image := range []string{app, web} {
+				require.Empty(t, records[image].Error, image)
+				tt.check(t, records[image].Result)
+			}
+			assert.NotEmpty(t, records[missing].Error)
+		})
+	}
+}
+
+func TestBatch_Stdin(t *testing.T) {
+	host := newTestRegistry(t)
+	app := pushTestImage(t, host+"/app:1", newTestImage(t, map[string]string{"etc/app.conf": "a", "bin/app": "x"}))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewLsCommand(cli)
+	cmd.SetIn(strings.NewReader(app + "\n"))
+	cmd.SetArgs([]string{"--from-file", "-", "--pull", "always", "/etc"})
+	require.NoError(t, cmd.Execute())
+
+	records := readBatch(t, buf.String())
+	require.Contains(t, records, app)
+	assert.Contains(t, string(records[app].Result), "app.conf")
+	assert.NotContains(t, string(records[app].Result), "bin/app")
+}
+
+func TestBatch_Warnings(t *testing.T) {
+	host := newTestRegistry(t)
+	app := pushTestImage(t, host+"/app:1", newTestImage(t, map[string]string{
+		"app/node_modules/lodash/package.json": `{"name":"lodash","version":"4.17.20"}`,
+		"app/node_modules/broken/package.json": `{"name":`,
+	}))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewPackagesCommand(cli)
+	cmd.SetIn(strings.NewReader(app + "\n"))
+	cmd.SetArgs([]string{"--from-file", "-", "--pull", "always"})
+	require.NoError(t, cmd.Execute())
+
+	records := readBatch(t, buf.String())
+	require.Contains(t, records, app)
+	assert.Contains(t, string(records[app].Result), "lodash")
+	require.Len(t, records[app].Warnings, 1)
+	assert.Contains(t, records[app].Warnings[0], "/app/node_modules/broken/package.json")
+}
+
+func TestBatch_SARIF(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewLintCommand(cli)
+	cmd.SetArgs([]string{"--from-file", "images.txt", "--sarif"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	assert.ErrorContains(t, cmd.Execute(), "--sarif cannot be used with --from-file")
+}
@@ -0,0 +1,123 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bschaatsbergen/cek/internal/browse"
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/gdamore/tcell/v2"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/spf13/cobra"
+)
+
+type BrowseOptions struct {
+	Platform string
+	Pull     string
+}
+
+func NewBrowseCommand(cli *CLI) *cobra.Command {
+	opts := BrowseOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "browse <image>",
+		Short: "Browse the layers and files of an image interactively",
+		Long: highlight("cek browse nginx:latest") + "\n\n" +
+			"Open a full-screen browser for the layers and files of an image.\n\n" +
+			"The layers are listed on the left with their size and the instruction\n" +
+			"that created them. The right pane shows the files the selected layer\n" +
+			"adds (+), modifies (~) and deletes (-) as a collapsible tree with sizes;\n" +
+			"press m to switch to the merged filesystem a container would see.\n" +
+			"Press enter on a file to preview it.\n\n" +
+			"Keys:\n" +
+			"  ↑↓ j k        move\n" +
+			"  enter space   expand or collapse a directory, preview a file\n" +
+			"  ←→ h l        collapse or expand, go to the parent directory\n" +
+			"  tab           switch between the layers and the files\n" +
+			"  m             toggle the merged filesystem\n" +
+			"  /             search paths, enter to keep the results, esc to clear\n" +
+			"  esc           close the preview or clear the search\n" +
+			"  q             quit\n\n" +
+			"Besides registry references, the image can be read from an OCI layout,\n" +
+			"a docker save archive or the container daemon using the transports of\n" +
+			"cek copy.\n\n" +
+			"Examples:\n" +
+			"  cek browse nginx:latest\n" +
+			"  cek browse --platform linux/arm64 golang:1.24\n" +
+			"  cek browse oci:./build/layout:1.0\n" +
+			"  cek browse docker-archive:app.tar\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunBrowse(cmd.Context(), cli, args[0], &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+
+	return cmd
+}
+
+func RunBrowse(ctx context.Context, cli *CLI, imageRef string, opts *BrowseOptions) error {
+	logger := cli.Logger()
+
+	if _, ok := cli.Viewer.(*view.JSONView); ok {
+		return errors.New("browse is interactive and does not support --json")
+	}
+
+	// Layers are read several times, for their changes, the merged
+	// filesystem and previews, so registry layers are cached on disk.
+	dir, err := os.MkdirTemp("", "cek-layers-")
+	if err != nil {
+		return fmt.Errorf("failed to create layer cache: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	img, err := readImage(ctx, imageRef, opts.Platform, opts.Pull, oci.NewLayerCache(dir))
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Reading layers", "image", imageRef)
+	image, err := browse.Load(imageRef, img)
+	if err != nil {
+		return err
+	}
+	if len(image.Layers) == 0 {
+		return fmt.Errorf("image %s has no layers", imageRef)
+	}
+
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return fmt.Errorf("failed to open terminal: %w", err)
+	}
+	if err := screen.Init(); err != nil {
+		return fmt.Errorf("failed to open terminal: %w", err)
+	}
+	defer screen.Fini()
+
+	return browse.NewApp(screen, image).Run()
+}
+
+// readImage reads an image from a registry, with its layers cached in
+// layers, or from any other transport of cek copy.
+func readImage(ctx context.Context, imageRef, platform, pull string, layers *oci.LayerCache) (v1.Image, error) {
+	loc, err := oci.ParseLocation(imageRef)
+	if err != nil {
+		return nil, err
+	}
+	if loc.Transport != oci.TransportRegistry {
+		return oci.ReadImage(ctx, loc, platform)
+	}
+	img, _, err := oci.FetchImage(ctx, loc.Ref.String(), &oci.FetchOptions{
+		Platform:   platform,
+		PullPolicy: oci.PullPolicy(pull),
+		Layers:     layers,
+	})
+	return img, err
+}
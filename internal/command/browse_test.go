@@ -0,0 +1,23 @@
+package command_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrowseCommand_RejectsJSON(t *testing.T) {
+	cli := command.NewCLI(view.ViewJSON, new(bytes.Buffer), view.LogLevelSilent)
+	cmd := command.NewBrowseCommand(cli)
+	cmd.SetArgs([]string{"nginx:latest"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support --json")
+}
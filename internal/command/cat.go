@@ -1,11 +1,12 @@
 package command
 
 import (
-	"archive/tar"
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"path/filepath"
+	"io/fs"
+	"os"
+	"path"
 	"strings"
 
 	"github.com/bschaatsbergen/cek/internal/oci"
@@ -17,6 +18,7 @@ type CatOptions struct {
 	Layer    int
 	Platform string
 	Pull     string
+	layers   *oci.LayerCache
 }
 
 func NewCatCommand(cli *CLI) *cobra.Command {
@@ -31,7 +33,8 @@ func NewCatCommand(cli *CLI) *cobra.Command {
 			"Show file contents from an OCI image.\n\n" +
 			"By default, shows the file as it appears in the final overlay\n" +
 			"(top layer), which is what you'd see in a running container.\n" +
-			"Use --layer to read from a specific layer.\n\n" +
+			"Use --layer to read from a specific layer. Symbolic links are\n" +
+			"followed within the image.\n\n" +
 			"Examples:\n" +
 			"  cek cat alpine:latest /etc/alpine-release\n" +
 			"  cek cat --layer 2 nginx:alpine /etc/nginx/nginx.conf\n" +
@@ -62,9 +65,25 @@ func RunCat(ctx context.Context, cli *CLI, imageRef, filePath string, opts *CatO
 		filePath = "/" + filePath
 	}
 
+	// The tar headers of the layers are read before the file, so the layer
+	// holding it is read twice; registry layers are cached on disk so it is
+	// downloaded once.
+	cache := opts.layers
+	if cache == nil {
+		dir, err := os.MkdirTemp("", "cek-layers-")
+		if err != nil {
+			return fmt.Errorf("failed to create layer cache: %w", err)
+		}
+		defer func() {
+			_ = os.RemoveAll(dir)
+		}()
+		cache = oci.NewLayerCache(dir)
+	}
+
 	fetchOpts := &oci.FetchOptions{
 		Platform:   opts.Platform,
 		PullPolicy: oci.PullPolicy(opts.Pull),
+		Layers:     cache,
 	}
 	img, _, err := oci.FetchImage(ctx, imageRef, fetchOpts)
 	if err != nil {
@@ -78,81 +97,49 @@ func RunCat(ctx context.Context, cli *CLI, imageRef, filePath string, opts *CatO
 
 	logger.Debug("Found layers", "count", len(layers))
 
-	var layersToSearch []int
-	if opts.Layer > 0 {
-		if opts.Layer > len(layers) {
-			return fmt.Errorf("layer %d does not exist (image has %d layers)", opts.Layer, len(layers))
-		}
-		layersToSearch = []int{opts.Layer - 1}
-	} else {
-		// Search top-down to find the final file state after all overlays.
-		for i := len(layers) - 1; i >= 0; i-- {
-			layersToSearch = append(layersToSearch, i)
-		}
-	}
-
-	for _, layerIdx := range layersToSearch {
-		layer := layers[layerIdx]
-		content, found, err := extractFileFromLayer(layer, filePath)
-		if err != nil {
-			return fmt.Errorf("failed to read layer %d: %w", layerIdx+1, err)
-		}
-
-		if found {
-			return cli.Cat().Render(&view.CatData{
-				Content: content,
-			})
-		}
-	}
-
-	return fmt.Errorf("file not found: %s", filePath)
-}
-
-// extractFileFromLayer returns file contents if found in the layer's tar archive.
-// Returns (content, found, error) where found indicates whether the file exists.
-func extractFileFromLayer(layer interface {
-	Uncompressed() (io.ReadCloser, error)
-}, targetPath string) (content string, found bool, err error) {
-	rc, err := layer.Uncompressed()
+	fsys, err := imageFS(layers, opts.Layer)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to get uncompressed layer: %w", err)
+		return err
 	}
 	defer func() {
-		_ = rc.Close()
+		_ = fsys.Close()
 	}()
 
-	tr := tar.NewReader(rc)
-	normalizedTarget := "/" + strings.TrimPrefix(targetPath, "/")
-
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return "", false, fmt.Errorf("failed to read tar header: %w", err)
-		}
-
-		tarPath := "/" + strings.TrimPrefix(header.Name, "/")
-
-		if tarPath == normalizedTarget {
-			// Whiteout files indicate the file was deleted in this layer.
-			if strings.HasPrefix(filepath.Base(header.Name), ".wh.") {
-				return "", false, nil
-			}
+	content, found, err := readFileFromFS(fsys, filePath)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("file not found: %s", filePath)
+	}
 
-			if header.Typeflag != tar.TypeReg {
-				return "", false, fmt.Errorf("%s is not a regular file (type: %c)", normalizedTarget, header.Typeflag)
-			}
+	return cli.Cat().Render(&view.CatData{
+		Content: content,
+	})
+}
 
-			content, err := io.ReadAll(tr)
-			if err != nil {
-				return "", false, fmt.Errorf("failed to read file contents: %w", err)
-			}
+// readFileFromFS returns the contents of the file at the absolute path
+// targetPath, following symbolic links.
+func readFileFromFS(fsys fs.FS, targetPath string) (content string, found bool, err error) {
+	name := strings.Trim(path.Clean("/"+targetPath), "/")
+	if name == "" {
+		name = "."
+	}
 
-			return string(content), true, nil
-		}
+	info, err := fs.Stat(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if !info.Mode().IsRegular() {
+		return "", false, fmt.Errorf("%s is not a regular file (type: %s)", "/"+name, info.Mode().Type())
 	}
 
-	return "", false, nil
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read file contents: %w", err)
+	}
+	return string(data), true, nil
 }
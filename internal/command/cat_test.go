@@ -1,83 +1,99 @@
 package command
 
 import (
-	"context"
+	"archive/tar"
+	"bytes"
+	"io"
 	"testing"
 
-	"github.com/bschaatsbergen/cek/internal/oci"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestExtractFileFromLayer_FileFound(t *testing.T) {
-	ctx := context.Background()
-
-	img, _, err := oci.FetchImage(ctx, "nginx:latest", &oci.FetchOptions{
-		PullPolicy: oci.PullIfNotPresent,
+// catTestLayer builds a layer holding the given files, in the order given.
+// A name starting with .wh. is written as a whiteout.
+func catTestLayer(t *testing.T, files ...[2]string) v1.Layer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     f[0],
+			Mode:     0o644,
+			Size:     int64(len(f[1])),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(f[1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	data := buf.Bytes()
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
 	})
 	require.NoError(t, err)
-
-	layers, err := img.Layers()
-	require.NoError(t, err)
-	require.NotEmpty(t, layers)
-
-	// Test reading from first layer
-	content, found, err := extractFileFromLayer(layers[0], "/etc/debian_version")
-	assert.NoError(t, err)
-	assert.True(t, found)
-	assert.NotEmpty(t, content)
+	return layer
 }
 
-func TestExtractFileFromLayer_FileNotFound(t *testing.T) {
-	ctx := context.Background()
-
-	img, _, err := oci.FetchImage(ctx, "nginx:latest", &oci.FetchOptions{
-		PullPolicy: oci.PullIfNotPresent,
-	})
-	require.NoError(t, err)
-
-	layers, err := img.Layers()
-	require.NoError(t, err)
-	require.NotEmpty(t, layers)
-
-	content, found, err := extractFileFromLayer(layers[0], "/nonexistent/file.txt")
-	assert.NoError(t, err)
-	assert.False(t, found)
-	assert.Empty(t, content)
+func TestReadFileFromFS(t *testing.T) {
+	layers := []v1.Layer{
+		catTestLayer(t,
+			[2]string{"etc/debian_version", "12.5\n"},
+			[2]string{"etc/motd", "welcome\n"},
+		),
+		catTestLayer(t,
+			[2]string{"etc/debian_version", "12.6\n"},
+			[2]string{"etc/.wh.motd", ""},
+		),
+	}
+
+	tests := []struct {
+		name      string
+		layer     int
+		path      string
+		content   string
+		wantFound bool
+	}{
+		{"merged", -1, "/etc/debian_version", "12.6\n", true},
+		{"merged without slash", -1, "etc/debian_version", "12.6\n", true},
+		{"merged deleted", -1, "/etc/motd", "", false},
+		{"layer", 1, "/etc/debian_version", "12.5\n", true},
+		{"layer without slash", 1, "etc/motd", "welcome\n", true},
+		{"missing", 1, "/nonexistent/file.txt", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys, err := imageFS(layers, tt.layer)
+			require.NoError(t, err)
+			defer func() {
+				_ = fsys.Close()
+			}()
+
+			content, found, err := readFileFromFS(fsys, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.content, content)
+		})
+	}
 }
 
-func TestExtractFileFromLayer_PathWithSlash(t *testing.T) {
-	ctx := context.Background()
-
-	img, _, err := oci.FetchImage(ctx, "nginx:latest", &oci.FetchOptions{
-		PullPolicy: oci.PullIfNotPresent,
-	})
+func TestReadFileFromFS_NotRegular(t *testing.T) {
+	fsys, err := imageFS([]v1.Layer{catTestLayer(t, [2]string{"etc/motd", "welcome\n"})}, -1)
 	require.NoError(t, err)
+	defer func() {
+		_ = fsys.Close()
+	}()
 
-	layers, err := img.Layers()
-	require.NoError(t, err)
-	require.NotEmpty(t, layers)
-
-	content, found, err := extractFileFromLayer(layers[0], "/etc/debian_version")
-	assert.NoError(t, err)
-	assert.True(t, found)
-	assert.NotEmpty(t, content)
+	_, _, err = readFileFromFS(fsys, "/etc")
+	assert.ErrorContains(t, err, "/etc is not a regular file")
 }
 
-func TestExtractFileFromLayer_PathWithoutSlash(t *testing.T) {
-	ctx := context.Background()
-
-	img, _, err := oci.FetchImage(ctx, "nginx:latest", &oci.FetchOptions{
-		PullPolicy: oci.PullIfNotPresent,
-	})
-	require.NoError(t, err)
-
-	layers, err := img.Layers()
-	require.NoError(t, err)
-	require.NotEmpty(t, layers)
-
-	content, found, err := extractFileFromLayer(layers[0], "etc/debian_version")
-	assert.NoError(t, err)
-	assert.True(t, found)
-	assert.NotEmpty(t, content)
+func TestImageFS_InvalidLayer(t *testing.T) {
+	_, err := imageFS([]v1.Layer{catTestLayer(t)}, 2)
+	assert.ErrorContains(t, err, "layer 2 does not exist (image has 1 layers)")
 }
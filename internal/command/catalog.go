@@ -0,0 +1,181 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+type CatalogOptions struct {
+	Prefix      string
+	Limit       int
+	PageSize    int
+	CountTags   bool
+	Concurrency int
+}
+
+func NewCatalogCommand(cli *CLI) *cobra.Command {
+	opts := CatalogOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "catalog <registry>",
+		Short: "List the repositories in a registry",
+		Long: highlight("cek catalog registry.internal") + "\n\n" +
+			"List the repositories in a registry using the catalog API, following\n" +
+			"pagination until every repository is listed. Use --prefix to only show\n" +
+			"repositories under a path, and --count-tags to count the tags of each one.\n\n" +
+			"Not every registry offers the catalog API. Docker Hub, for example, does\n" +
+			"not, while most self-hosted registries do.\n\n" +
+			"Registry credentials are read from the Docker config file and credential\n" +
+			"helpers.\n\n" +
+			"Examples:\n" +
+			"  cek catalog registry.internal\n" +
+			"  cek catalog --prefix team-a/ registry.internal\n" +
+			"  cek catalog --count-tags localhost:5000\n" +
+			"  cek catalog --json registry.internal\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunCatalog(cmd.Context(), cli, args[0], &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Prefix, "prefix", "", "Only show repositories starting with this prefix")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 0, "Limit the number of repositories returned (0 = unlimited)")
+	cmd.Flags().IntVar(&opts.PageSize, "page-size", 100, "Number of repositories requested per page")
+	cmd.Flags().BoolVar(&opts.CountTags, "count-tags", false, "Count the tags of each repository")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Maximum concurrent registry requests with --count-tags")
+
+	return cmd
+}
+
+func RunCatalog(ctx context.Context, cli *CLI, registry string, opts *CatalogOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Listing repositories", "registry", registry, "prefix", opts.Prefix)
+
+	reg, err := name.NewRegistry(registry)
+	if err != nil {
+		return fmt.Errorf("failed to parse registry: %w", err)
+	}
+
+	remoteOpts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+
+	repos, err := listCatalog(ctx, reg, opts, remoteOpts)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Listed repositories", "count", len(repos))
+
+	data := &view.CatalogData{
+		Registry:     reg.Name(),
+		Repositories: make([]view.CatalogRepository, len(repos)),
+		CountTags:    opts.CountTags,
+	}
+	for i, repo := range repos {
+		data.Repositories[i] = view.CatalogRepository{Name: repo, Tags: -1}
+	}
+
+	if opts.CountTags {
+		if err := countTags(ctx, reg, data.Repositories, opts.Concurrency, remoteOpts); err != nil {
+			return err
+		}
+	}
+
+	return cli.Catalog().Render(data)
+}
+
+// listCatalog pages through the catalog of reg, following the Link header
+// of each response, and keeps repositories that match the prefix until the
+// limit is reached. Registries may cap the page size below the one
+// requested, so a short page does not mean the catalog is complete.
+func listCatalog(ctx context.Context, reg name.Registry, opts *CatalogOptions, remoteOpts []remote.Option) ([]string, error) {
+	pageSize := opts.PageSize
+	if pageSize < 1 {
+		pageSize = 100
+	}
+
+	wrap := func(err error) error {
+		return fmt.Errorf("failed to list repositories in %s (the registry may not support the catalog API): %w", reg.Name(), err)
+	}
+
+	puller, err := remote.NewPuller(append(remoteOpts[:len(remoteOpts):len(remoteOpts)], remote.WithPageSize(pageSize))...)
+	if err != nil {
+		return nil, wrap(err)
+	}
+	catalogger, err := puller.Catalogger(ctx, reg)
+	if err != nil {
+		return nil, wrap(err)
+	}
+
+	var repos []string
+	seen := make(map[string]bool)
+	for catalogger.HasNext() {
+		page, err := catalogger.Next(ctx)
+		if err != nil {
+			return nil, wrap(err)
+		}
+
+		// A registry whose next link points back at a page already seen
+		// would loop forever, so stop once a page brings nothing new.
+		progress := false
+		for _, repo := range page.Repos {
+			if seen[repo] {
+				continue
+			}
+			seen[repo] = true
+			progress = true
+			if !strings.HasPrefix(repo, opts.Prefix) {
+				continue
+			}
+			repos = append(repos, repo)
+			if opts.Limit > 0 && len(repos) == opts.Limit {
+				sort.Strings(repos)
+				return repos, nil
+			}
+		}
+
+		if !progress {
+			break
+		}
+	}
+
+	sort.Strings(repos)
+	return repos, nil
+}
+
+// countTags fills in the tag count of each repository. Repositories whose
+// tags cannot be listed, e.g. for lack of permission, are left at -1.
+func countTags(ctx context.Context, reg name.Registry, repos []view.CatalogRepository, concurrency int, remoteOpts []remote.Option) error {
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i := range repos {
+		g.Go(func() error {
+			repo := reg.Repo(repos[i].Name)
+			list, err := remote.List(repo, append(remoteOpts[:len(remoteOpts):len(remoteOpts)], remote.WithContext(ctx))...)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return nil
+			}
+			repos[i].Tags = len(list)
+			return nil
+		})
+	}
+	return g.Wait()
+}
@@ -0,0 +1,159 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPaginatingRegistry starts an in-process registry whose catalog honours
+// the n and last parameters, which the in-process registry ignores, caps n
+// at maxPageSize and links to the next page like a real registry does. It
+// returns the host and a counter of catalog requests.
+func newPaginatingRegistry(t *testing.T, maxPageSize int) (string, *atomic.Int32) {
+	t.Helper()
+
+	var pages atomic.Int32
+	reg := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/_catalog" {
+			reg.ServeHTTP(w, r)
+			return
+		}
+		pages.Add(1)
+
+		rec := httptest.NewRecorder()
+		reg.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v2/_catalog", nil))
+		var all struct {
+			Repositories []string `json:"repositories"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &all))
+		sort.Strings(all.Repositories)
+
+		last := r.URL.Query().Get("last")
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		if n == 0 || n > maxPageSize {
+			n = maxPageSize
+		}
+		var page []string
+		more := false
+		for _, repo := range all.Repositories {
+			if repo <= last {
+				continue
+			}
+			if len(page) == n {
+				more = true
+				break
+			}
+			page = append(page, repo)
+		}
+		if more {
+			next := url.Values{"last": {page[len(page)-1]}, "n": {strconv.Itoa(n)}}
+			w.Header().Set("Link", fmt.Sprintf(`</v2/_catalog?%s>; rel="next"`, next.Encode()))
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]string{"repositories": page})
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://"), &pages
+}
+
+func TestNewCatalogCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewCatalogCommand(cli)
+
+	assert.Equal(t, "catalog", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.Equal(t, "100", cmd.Flags().Lookup("page-size").DefValue)
+	assert.Equal(t, "false", cmd.Flags().Lookup("count-tags").DefValue)
+	assert.NotNil(t, cmd.Flags().Lookup("prefix"))
+	assert.NotNil(t, cmd.Flags().Lookup("limit"))
+}
+
+func TestRunCatalog(t *testing.T) {
+	host, pages := newPaginatingRegistry(t, 2)
+	img := newTestImage(t, map[string]string{"app": "x"})
+	for _, ref := range []string{"team-a/api:1", "team-a/api:2", "team-a/web:1", "team-b/db:1", "tools:1"} {
+		pushTestImage(t, host+"/"+ref, img)
+	}
+
+	run := func(t *testing.T, vt view.ViewType, args ...string) string {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(vt, buf, view.LogLevelSilent)
+		cmd := command.NewCatalogCommand(cli)
+		cmd.SetArgs(append(args, host))
+		require.NoError(t, cmd.Execute())
+		return buf.String()
+	}
+
+	t.Run("follows pagination", func(t *testing.T) {
+		pages.Store(0)
+		out := run(t, view.ViewHuman, "--page-size", "2")
+		assert.Equal(t, "team-a/api\nteam-a/web\nteam-b/db\ntools\n", out)
+		assert.Equal(t, int32(2), pages.Load())
+	})
+
+	t.Run("registry caps page size", func(t *testing.T) {
+		pages.Store(0)
+		out := run(t, view.ViewHuman, "--page-size", "3")
+		assert.Equal(t, "team-a/api\nteam-a/web\nteam-b/db\ntools\n", out)
+		assert.Equal(t, int32(2), pages.Load())
+	})
+
+	t.Run("prefix and limit", func(t *testing.T) {
+		assert.Equal(t, "team-a/api\nteam-a/web\n", run(t, view.ViewHuman, "--prefix", "team-a/"))
+		assert.Equal(t, "team-a/api\n", run(t, view.ViewHuman, "--prefix", "team-a/", "--limit", "1"))
+	})
+
+	t.Run("tag counts", func(t *testing.T) {
+		out := run(t, view.ViewJSON, "--count-tags", "--prefix", "team-")
+
+		var data struct {
+			Repositories []struct {
+				Name string `json:"name"`
+				Tags int    `json:"tags"`
+			} `json:"repositories"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &data))
+		require.Len(t, data.Repositories, 3)
+		assert.Equal(t, "team-a/api", data.Repositories[0].Name)
+		assert.Equal(t, 2, data.Repositories[0].Tags)
+		assert.Equal(t, 1, data.Repositories[2].Tags)
+	})
+}
+
+func TestRunCatalog_IgnoredLast(t *testing.T) {
+	// The in-process registry ignores last and sends no Link header, so
+	// the first page must be the whole catalog.
+	host := newTestRegistry(t)
+	img := newTestImage(t, map[string]string{"app": "x"})
+	for _, ref := range []string{"app-a:1", "app-b:1", "app-c:1"} {
+		pushTestImage(t, host+"/"+ref, img)
+	}
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewCatalogCommand(cli)
+	cmd.SetArgs([]string{"--page-size", "3", host})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "app-a\napp-b\napp-c\n", buf.String())
+}
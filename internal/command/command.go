@@ -1,8 +1,11 @@
 package command
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/bschaatsbergen/cek/internal/view"
 
@@ -21,6 +24,11 @@ type CLI struct {
 	ContextFlag string
 }
 
+// defaultConcurrency is how many images or registry requests commands with a
+// --concurrency flag handle at once. It is kept low since registries such as
+// Docker Hub rate limit bursts of requests.
+const defaultConcurrency = 4
+
 // highlight applies a blue color to the given format and arguments.
 func highlight(format string, a ...any) string {
 	return color.RGB(50, 108, 229).Sprintf(format, a...)
@@ -84,3 +92,18 @@ func MaxArgs(number int) cobra.PositionalArgs {
 		return fmt.Errorf("expected at most %d arguments, got %d", number, len(args))
 	}
 }
+
+// confirm prints question to out and reads the answer from in. Only "y" and
+// "yes" confirm; anything else, including end of input, declines.
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	_, _ = fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
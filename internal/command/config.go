@@ -0,0 +1,98 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+type ConfigOptions struct {
+	Pretty   bool
+	Digest   bool
+	Platform string
+}
+
+func NewConfigCommand(cli *CLI) *cobra.Command {
+	opts := ConfigOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "config <image>",
+		Short: "Show the raw image config blob",
+		Long: highlight("cek config nginx:latest") + "\n\n" +
+			"Print the image config blob exactly as the registry serves it. The\n" +
+			"output is byte-for-byte identical to the blob, so piping it to\n" +
+			"sha256sum yields the config digest.\n\n" +
+			"For multi-platform images the config of the linux/amd64 image is shown\n" +
+			"unless --platform selects another one.\n\n" +
+			"This queries the remote registry, not the local daemon.\n\n" +
+			"Examples:\n" +
+			"  cek config nginx:latest\n" +
+			"  cek config --platform linux/arm64 --pretty nginx:latest\n" +
+			"  cek config --digest nginx:latest\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			imageRef := args[0]
+			return RunConfig(cmd.Context(), cli, imageRef, &opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Pretty, "pretty", false, "Pretty-print the JSON")
+	cmd.Flags().BoolVar(&opts.Digest, "digest", false, "Only show the digest, media type and size")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+
+	return cmd
+}
+
+func RunConfig(ctx context.Context, cli *CLI, imageRef string, opts *ConfigOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Fetching config", "image", imageRef, "platform", opts.Platform)
+
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	remoteOpts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+	if opts.Platform != "" {
+		platform, err := v1.ParsePlatform(opts.Platform)
+		if err != nil {
+			return fmt.Errorf("failed to parse platform: %w", err)
+		}
+		remoteOpts = append(remoteOpts, remote.WithPlatform(*platform))
+	}
+
+	img, err := remote.Image(ref, remoteOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch image: %w", err)
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("failed to get manifest: %w", err)
+	}
+	content, err := img.RawConfigFile()
+	if err != nil {
+		return fmt.Errorf("failed to get config: %w", err)
+	}
+
+	logger.Debug("Fetched config", "digest", manifest.Config.Digest.String())
+
+	return cli.Raw().Render(&view.RawData{
+		ImageRef:       imageRef,
+		Digest:         manifest.Config.Digest.String(),
+		MediaType:      string(manifest.Config.MediaType),
+		Size:           int64(len(content)),
+		Content:        content,
+		Pretty:         opts.Pretty,
+		DescriptorOnly: opts.Digest,
+	})
+}
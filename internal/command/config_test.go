@@ -0,0 +1,47 @@
+package command_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfigCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewConfigCommand(cli)
+
+	assert.Equal(t, "config", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.Equal(t, "false", cmd.Flags().Lookup("pretty").DefValue)
+	assert.Equal(t, "false", cmd.Flags().Lookup("digest").DefValue)
+}
+
+func TestRunConfig(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := host + "/app:multi"
+	arm64 := pushTestIndex(t, ref)
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewConfigCommand(cli)
+	cmd.SetArgs([]string{ref, "--platform", "linux/arm64"})
+	require.NoError(t, cmd.Execute())
+
+	want, err := arm64.ConfigName()
+	require.NoError(t, err)
+	assert.Equal(t, want.String(), sha256Digest(buf.Bytes()))
+
+	buf.Reset()
+	cmd = command.NewConfigCommand(cli)
+	cmd.SetArgs([]string{ref, "--platform", "linux/arm64", "--digest"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "Digest:     "+want.String())
+	assert.Contains(t, buf.String(), "Media Type: application/vnd.docker.container.image.v1+json")
+}
@@ -0,0 +1,94 @@
+package command
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+type CopyOptions struct {
+	AllPlatforms bool
+	Platform     string
+}
+
+func NewCopyCommand(cli *CLI) *cobra.Command {
+	opts := CopyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "copy <source> <destination>",
+		Short: "Copy an image between registries, the daemon, archives and OCI layouts",
+		Long: highlight("cek copy nginx:latest registry.internal/mirror/nginx:latest") + "\n\n" +
+			"Copy an image from one location to another. Locations use the skopeo\n" +
+			"syntax:\n\n" +
+			"  nginx:latest, docker://nginx:latest   registry\n" +
+			"  docker-daemon:nginx:latest            container daemon\n" +
+			"  docker-archive:nginx.tar[:nginx:1.0]  docker save tarball\n" +
+			"  oci:./layout[:1.0]                    OCI image layout directory\n\n" +
+			"Copies from registries and OCI layouts preserve manifests byte for byte,\n" +
+			"so the digest at the destination matches the source. Blobs that already\n" +
+			"exist at a registry destination are skipped, and blobs in another\n" +
+			"repository on the same registry are mounted instead of uploaded.\n\n" +
+			"By default a single platform is copied from multi-platform images. Use\n" +
+			"--all-platforms to copy the whole index. The daemon and docker archives\n" +
+			"hold a single platform only.\n\n" +
+			"Registry credentials are read from the Docker config file and credential\n" +
+			"helpers.\n\n" +
+			"Examples:\n" +
+			"  cek copy --all-platforms nginx:latest registry.internal/mirror/nginx:latest\n" +
+			"  cek copy --platform linux/arm64 nginx:latest docker-daemon:nginx:arm64\n" +
+			"  cek copy nginx:latest docker-archive:nginx.tar\n" +
+			"  cek copy --all-platforms nginx:latest oci:./nginx:latest\n" +
+			"  cek copy oci:./nginx:latest registry.internal/mirror/nginx:latest\n",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunCopy(cmd.Context(), cli, args[0], args[1], &opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.AllPlatforms, "all-platforms", false, "Copy every platform of a multi-platform image")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Platform to copy from a multi-platform image (e.g., linux/amd64)")
+
+	return cmd
+}
+
+func RunCopy(ctx context.Context, cli *CLI, source, destination string, opts *CopyOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Copying image", "source", source, "destination", destination, "allPlatforms", opts.AllPlatforms, "platform", opts.Platform)
+
+	src, err := oci.ParseLocation(source)
+	if err != nil {
+		return err
+	}
+	dst, err := oci.ParseLocation(destination)
+	if err != nil {
+		return err
+	}
+
+	result, err := oci.Copy(ctx, src, dst, &oci.CopyOptions{
+		AllPlatforms: opts.AllPlatforms,
+		Platform:     opts.Platform,
+		RemoteOptions: []remote.Option{
+			remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Copied image", "digest", result.Digest.String(), "blobs", result.Blobs)
+
+	return cli.Copy().Render(&view.CopyData{
+		Source:      strings.TrimPrefix(source, "docker://"),
+		Destination: strings.TrimPrefix(destination, "docker://"),
+		Digest:      result.Digest.String(),
+		MediaType:   string(result.MediaType),
+		Platforms:   result.Platforms,
+		Blobs:       result.Blobs,
+		Size:        result.Size,
+	})
+}
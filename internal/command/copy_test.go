@@ -0,0 +1,169 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadCounter counts blob uploads and cross-repository mounts made
+// against a test registry.
+type uploadCounter struct {
+	mu      sync.Mutex
+	uploads int
+	mounts  int
+	// blobs records which repositories hold which blobs.
+	blobs map[string]bool
+}
+
+func (c *uploadCounter) reset() (uploads, mounts int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	uploads, mounts = c.uploads, c.mounts
+	c.uploads, c.mounts = 0, 0
+	return uploads, mounts
+}
+
+// newCountingRegistry starts an in-process registry like newTestRegistry
+// that records blob upload and mount requests. The in-process registry
+// shares blobs between repositories, so blob existence is tracked per
+// repository here to make clients mount blobs as they would against a
+// real registry.
+func newCountingRegistry(t *testing.T) (string, *uploadCounter) {
+	t.Helper()
+
+	counter := &uploadCounter{blobs: make(map[string]bool)}
+	reg := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repo, rest, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/blobs/")
+		if !ok {
+			reg.ServeHTTP(w, r)
+			return
+		}
+
+		counter.mu.Lock()
+		switch {
+		case r.Method == http.MethodHead && !strings.HasPrefix(rest, "uploads/"):
+			if !counter.blobs[repo+"@"+rest] {
+				counter.mu.Unlock()
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		case r.Method == http.MethodPost && r.URL.Query().Get("mount") != "":
+			counter.mounts++
+			counter.blobs[repo+"@"+r.URL.Query().Get("mount")] = true
+		case r.Method == http.MethodPost:
+			counter.uploads++
+		case r.Method == http.MethodPut && r.URL.Query().Get("digest") != "":
+			counter.blobs[repo+"@"+r.URL.Query().Get("digest")] = true
+		}
+		counter.mu.Unlock()
+
+		reg.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://"), counter
+}
+
+func TestNewCopyCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewCopyCommand(cli)
+
+	assert.Equal(t, "copy", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.Equal(t, "false", cmd.Flags().Lookup("all-platforms").DefValue)
+	assert.NotNil(t, cmd.Flags().Lookup("platform"))
+}
+
+func TestRunCopy(t *testing.T) {
+	host, counter := newCountingRegistry(t)
+	src := host + "/base:multi"
+	pushTestIndex(t, src)
+
+	srcRef, err := name.ParseReference(src)
+	require.NoError(t, err)
+	head, err := remote.Head(srcRef)
+	require.NoError(t, err)
+	counter.reset()
+
+	runCopy := func(t *testing.T, args ...string) map[string]any {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+		cmd := command.NewCopyCommand(cli)
+		cmd.SetArgs(args)
+		require.NoError(t, cmd.Execute())
+
+		var out map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+		return out
+	}
+
+	t.Run("mounts blobs across repositories", func(t *testing.T) {
+		dst := host + "/mirror/base:multi"
+		out := runCopy(t, "--all-platforms", src, dst)
+		assert.Equal(t, head.Digest.String(), out["digest"])
+		assert.Equal(t, []any{"linux/amd64", "linux/arm64"}, out["platforms"])
+
+		uploads, mounts := counter.reset()
+		assert.Zero(t, uploads)
+		assert.Positive(t, mounts)
+
+		dstRef, err := name.ParseReference(dst)
+		require.NoError(t, err)
+		copied, err := remote.Head(dstRef)
+		require.NoError(t, err)
+		assert.Equal(t, head.Digest, copied.Digest)
+	})
+
+	t.Run("skips existing blobs", func(t *testing.T) {
+		runCopy(t, "--all-platforms", src, host+"/mirror/base:again")
+
+		uploads, mounts := counter.reset()
+		assert.Zero(t, uploads)
+		assert.Zero(t, mounts)
+	})
+
+	t.Run("through an OCI layout", func(t *testing.T) {
+		dir := t.TempDir()
+		runCopy(t, "--all-platforms", src, "oci:"+dir+":multi")
+		out := runCopy(t, "--all-platforms", "oci:"+dir+":multi", "docker://"+host+"/fromlayout:multi")
+		assert.Equal(t, head.Digest.String(), out["digest"])
+		assert.Equal(t, host+"/fromlayout:multi", out["destination"])
+	})
+
+	t.Run("single platform", func(t *testing.T) {
+		out := runCopy(t, "--platform", "linux/arm64", src, host+"/arm64:latest")
+		assert.NotEqual(t, head.Digest.String(), out["digest"])
+		assert.Nil(t, out["platforms"])
+		assert.Equal(t, float64(2), out["blobs"])
+	})
+}
+
+func TestRunCopy_InvalidLocation(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewCopyCommand(cli)
+	cmd.SetArgs([]string{"oci:", "nginx:latest"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, "missing layout path")
+}
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/diff"
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/view"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/spf13/cobra"
+)
+
+type DiffOptions struct {
+	Config   bool
+	Platform string
+	Pull     string
+	OS       bool
+	layers   *oci.LayerCache
+}
+
+func NewDiffCommand(cli *CLI) *cobra.Command {
+	opts := DiffOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "diff <old-image> <new-image> --config",
+		Short: "Compare the metadata of two images",
+		Long: highlight("cek diff --config myapp:1.4.2 myapp:1.4.3") + "\n\n" +
+			"Compare the config of two images, typically two tags of the same\n" +
+			"repository, and summarize what changed:\n" +
+			"  - Environment variables, entrypoint, cmd, user and working directory\n" +
+			"  - Exposed ports, volumes and labels\n" +
+			"  - Layers shared, rebuilt, added or removed, compared by digest\n" +
+			"  - Base image, from the OCI base image annotations and, with --os, the\n" +
+			"    detected operating system\n\n" +
+			"The first line is a one-line summary suitable for release notes, e.g.\n" +
+			"\"myapp:1.4.2 → myapp:1.4.3: base layer changed, 2 layers rebuilt, ENV FOO\n" +
+			"added\". Use --json for the full comparison. Only the manifests and\n" +
+			"configs are read unless --os is set.\n\n" +
+			"Examples:\n" +
+			"  cek diff --config myapp:1.4.2 myapp:1.4.3\n" +
+			"  cek diff --config --os nginx:1.26 nginx:1.27\n" +
+			"  cek diff --config --json myapp:1.4.2 myapp:1.4.3 | jq -r .summary\n",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDiff(cmd.Context(), cli, args[0], args[1], &opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Config, "config", false, "Compare image configs and layers")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+	cmd.Flags().BoolVar(&opts.OS, "os", false, "Also compare the detected operating system")
+	_ = cmd.MarkFlagRequired("config")
+
+	return cmd
+}
+
+func RunDiff(ctx context.Context, cli *CLI, oldRef, newRef string, opts *DiffOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Comparing images", "old", oldRef, "new", newRef)
+
+	inspectOpts := &InspectOptions{
+		Platform: opts.Platform,
+		Pull:     opts.Pull,
+		OS:       opts.OS,
+		layers:   opts.layers,
+	}
+	oldImage, err := diffSide(ctx, cli, oldRef, inspectOpts)
+	if err != nil {
+		return err
+	}
+	newImage, err := diffSide(ctx, cli, newRef, inspectOpts)
+	if err != nil {
+		return err
+	}
+
+	changes := diff.CompareConfigs(oldImage.config, newImage.config)
+	changes = append(changes, diff.CompareBase(oldImage.annotations, newImage.annotations)...)
+	if oldImage.view.OS != "" && newImage.view.OS != "" && oldImage.view.OS != newImage.view.OS {
+		changes = append(changes, diff.Change{Type: diff.Changed, Field: "OS", Old: oldImage.view.OS, New: newImage.view.OS})
+	}
+	layers := diff.CompareLayers(oldImage.layers, newImage.layers)
+
+	data := &view.DiffData{
+		Old:          oldImage.view,
+		New:          newImage.view,
+		Summary:      diff.Summary(layers, changes),
+		Changes:      make([]view.DiffChange, len(changes)),
+		Layers:       make([]view.DiffLayer, len(layers.Layers)),
+		SharedLayers: layers.Shared,
+	}
+	for i, c := range changes {
+		data.Changes[i] = view.DiffChange{Type: string(c.Type), Field: c.Field, Key: c.Key, Old: c.Old, New: c.New}
+	}
+	for i, l := range layers.Layers {
+		data.Layers[i] = view.DiffLayer{Index: l.Index, Status: string(l.Status)}
+		if l.Old != (v1.Hash{}) {
+			data.Layers[i].Old = l.Old.String()
+		}
+		if l.New != (v1.Hash{}) {
+			data.Layers[i].New = l.New.String()
+		}
+	}
+
+	return cli.Diff().Render(data)
+}
+
+// diffImage is what diff compares about one image.
+type diffImage struct {
+	view        view.DiffImage
+	config      *v1.ConfigFile
+	annotations map[string]string
+	layers      []v1.Hash
+}
+
+// diffSide collects what inspect reports about an image, along with its
+// config and manifest annotations.
+func diffSide(ctx context.Context, cli *CLI, imageRef string, opts *InspectOptions) (*diffImage, error) {
+	inspected, img, err := inspectImage(ctx, cli, imageRef, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config file: %w", err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+
+	side := &diffImage{
+		view: view.DiffImage{
+			ImageRef: imageRef,
+			Digest:   inspected.Digest.String(),
+			Platform: inspected.OS + "/" + inspected.Architecture,
+		},
+		config:      config,
+		annotations: manifest.Annotations,
+	}
+	if info := inspected.OSInfo; info != nil {
+		side.view.OS = strings.TrimSpace(info.Distro + " " + info.Version)
+	}
+	for _, layer := range inspected.Layers {
+		side.layers = append(side.layers, layer.Digest)
+	}
+	return side, nil
+}
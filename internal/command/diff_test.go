@@ -0,0 +1,93 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withConfig sets the runtime config of an image.
+func withConfig(t *testing.T, img v1.Image, config v1.Config) v1.Image {
+	t.Helper()
+
+	img, err := mutate.Config(img, config)
+	require.NoError(t, err)
+	return img
+}
+
+func TestDiff_Config(t *testing.T) {
+	host := newTestRegistry(t)
+	deps := map[string]string{"usr/lib/libdeps.so": "deps"}
+	oldRef := pushTestImage(t, host+"/app:1.4.2", withConfig(t,
+		newTestImage(t, map[string]string{"etc/os-release": "ID=alpine\nVERSION_ID=3.20.1\n"}, deps, map[string]string{"app": "v1"}),
+		v1.Config{Env: []string{"PATH=/usr/bin"}, User: "app", Cmd: []string{"/app"}},
+	))
+	newRef := pushTestImage(t, host+"/app:1.4.3", withConfig(t,
+		newTestImage(t, map[string]string{"etc/os-release": "ID=alpine\nVERSION_ID=3.20.2\n"}, deps, map[string]string{"app": "v2"}),
+		v1.Config{Env: []string{"PATH=/usr/bin", "FOO=bar"}, User: "app", Cmd: []string{"/app"}},
+	))
+
+	t.Run("human", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewDiffCommand(cli)
+		cmd.SetArgs([]string{"--config", oldRef, newRef})
+		require.NoError(t, cmd.Execute())
+
+		out := buf.String()
+		assert.Contains(t, out, oldRef+" → "+newRef+": base layer changed, 1 layer rebuilt, ENV FOO added\n")
+		assert.Contains(t, out, "Layers (1 shared):")
+		assert.Contains(t, out, "unchanged")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+		cmd := command.NewDiffCommand(cli)
+		cmd.SetArgs([]string{"--config", "--os", oldRef, newRef})
+		require.NoError(t, cmd.Execute())
+
+		var out struct {
+			Old struct {
+				OS string `json:"os"`
+			} `json:"old"`
+			Summary string `json:"summary"`
+			Changes []struct {
+				Type  string `json:"type"`
+				Field string `json:"field"`
+				Key   string `json:"key"`
+			} `json:"changes"`
+			Layers []struct {
+				Status string `json:"status"`
+			} `json:"layers"`
+			SharedLayers int `json:"shared_layers"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+
+		assert.Equal(t, "alpine 3.20.1", out.Old.OS)
+		assert.Equal(t, "base layer changed, 1 layer rebuilt, ENV FOO added, OS changed", out.Summary)
+		require.Len(t, out.Changes, 2)
+		assert.Equal(t, "ENV", out.Changes[0].Field)
+		assert.Equal(t, "FOO", out.Changes[0].Key)
+		assert.Equal(t, 1, out.SharedLayers)
+		require.Len(t, out.Layers, 3)
+		assert.Equal(t, "unchanged", out.Layers[1].Status)
+	})
+}
+
+func TestDiff_RequiresConfig(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewDiffCommand(cli)
+	cmd.SetArgs([]string{"app:1", "app:2"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	assert.ErrorContains(t, cmd.Execute(), `"config" not set`)
+}
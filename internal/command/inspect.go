@@ -3,22 +3,35 @@ package command
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/osinfo"
 	"github.com/bschaatsbergen/cek/internal/view"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/spf13/cobra"
 )
 
 type InspectOptions struct {
 	Platform string
 	Pull     string
+	// OS detects the operating system, which reads the layer contents.
+	OS bool
+
+	// Files switches to inspecting every image referenced in these
+	// manifests, directories or "-" for stdin.
+	Files       []string
+	Lint        bool
+	FromFile    string
+	Concurrency int
+	layers      *oci.LayerCache
 }
 
 func NewInspectCommand(cli *CLI) *cobra.Command {
 	opts := InspectOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "inspect <image>",
+		Use:   "inspect <image> | -f <path>...",
 		Short: "Inspect an OCI image and display information",
 		Long: highlight("cek inspect alpine:latest") + "\n\n" +
 			"Inspect an OCI image and display information including:\n" +
@@ -27,13 +40,45 @@ func NewInspectCommand(cli *CLI) *cobra.Command {
 			"  - Creation timestamp\n" +
 			"  - OS/Architecture\n" +
 			"  - Total size\n" +
-			"  - Layer information (digest and size)\n\n" +
+			"  - Layer information (digest and size)\n" +
+			"  - Operating system, distro version and EOL status, with --os\n\n" +
+			"Only the manifest and config are read by default. With --os, the\n" +
+			"operating system is detected from /etc/os-release and similar files in\n" +
+			"the merged filesystem, which requires reading the layer contents.\n\n" +
 			"The image reference can be:\n" +
 			"  - A tagged image: alpine:latest\n" +
 			"  - A specific digest: alpine@sha256:...\n" +
-			"  - A full registry path: gcr.io/project/image:tag\n",
-		Args: cobra.ExactArgs(1),
+			"  - A full registry path: gcr.io/project/image:tag\n\n" +
+			"Use -f to inspect every image referenced in Kubernetes manifests,\n" +
+			"Helm-rendered output or Compose files instead. Files and directories are\n" +
+			"scanned for image fields, and each unique image is inspected once,\n" +
+			"concurrently, into a single report. Use - to read manifests from stdin,\n" +
+			"and --lint to also lint every image.\n\n" +
+			batchLong +
+			"Examples:\n" +
+			"  cek inspect alpine:latest\n" +
+			"  cek inspect --os alpine:latest\n" +
+			"  cek inspect -f deploy/\n" +
+			"  helm template ./chart | cek inspect -f - --lint\n" +
+			"  cek inspect --from-file images.txt --os > audit.ndjson\n",
+		Args: batchArgs(&opts.FromFile, cobra.NoArgs, func(cmd *cobra.Command, args []string) error {
+			if len(opts.Files) > 0 {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		}),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(opts.Files) > 0 {
+				return RunInspectManifests(cmd.Context(), cli, opts.Files, cmd.InOrStdin(), &opts)
+			}
+			if opts.FromFile != "" {
+				return runBatch(cmd.Context(), cli, opts.FromFile, cmd.InOrStdin(), opts.Concurrency,
+					func(ctx context.Context, cli *CLI, imageRef string, layers *oci.LayerCache) error {
+						imageOpts := opts
+						imageOpts.layers = layers
+						return RunInspect(ctx, cli, imageRef, &imageOpts)
+					})
+			}
 			imageRef := args[0]
 			if err := RunInspect(cmd.Context(), cli, imageRef, &opts); err != nil {
 				return err
@@ -44,6 +89,12 @@ func NewInspectCommand(cli *CLI) *cobra.Command {
 
 	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
 	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+	cmd.Flags().BoolVar(&opts.OS, "os", false, "Detect the operating system, distro version and EOL status")
+	cmd.Flags().StringSliceVarP(&opts.Files, "file", "f", nil, "Inspect the images referenced in these manifests or directories (- for stdin)")
+	cmd.Flags().BoolVar(&opts.Lint, "lint", false, "Also lint each image (with -f)")
+	cmd.Flags().StringVar(&opts.FromFile, "from-file", "", "Read image references from a file, one per line (- for stdin)")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Maximum images inspected at once (with -f or --from-file)")
+	cmd.MarkFlagsMutuallyExclusive("file", "from-file")
 
 	return cmd
 }
@@ -52,13 +103,27 @@ func RunInspect(ctx context.Context, cli *CLI, imageRef string, opts *InspectOpt
 	logger := cli.Logger()
 	logger.Debug("Inspecting image", "image", imageRef)
 
+	data, _, err := inspectImage(ctx, cli, imageRef, opts)
+	if err != nil {
+		return err
+	}
+
+	return cli.Inspect().Render(data)
+}
+
+// inspectImage fetches an image and collects what inspect reports about it.
+// The image is returned for further checks such as linting.
+func inspectImage(ctx context.Context, cli *CLI, imageRef string, opts *InspectOptions) (*view.InspectData, v1.Image, error) {
+	logger := cli.Logger()
+
 	fetchOpts := &oci.FetchOptions{
 		Platform:   opts.Platform,
 		PullPolicy: oci.PullPolicy(opts.Pull),
+		Layers:     opts.layers,
 	}
 	img, ref, err := oci.FetchImage(ctx, imageRef, fetchOpts)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	logger.Debug("Parsed reference", "ref", ref.String())
@@ -68,17 +133,17 @@ func RunInspect(ctx context.Context, cli *CLI, imageRef string, opts *InspectOpt
 
 	digest, err := img.Digest()
 	if err != nil {
-		return fmt.Errorf("failed to get image digest: %w", err)
+		return nil, nil, fmt.Errorf("failed to get image digest: %w", err)
 	}
 
 	configFile, err := img.ConfigFile()
 	if err != nil {
-		return fmt.Errorf("failed to get config file: %w", err)
+		return nil, nil, fmt.Errorf("failed to get config file: %w", err)
 	}
 
 	layers, err := img.Layers()
 	if err != nil {
-		return fmt.Errorf("failed to get layers: %w", err)
+		return nil, nil, fmt.Errorf("failed to get layers: %w", err)
 	}
 
 	var totalSize int64
@@ -86,12 +151,12 @@ func RunInspect(ctx context.Context, cli *CLI, imageRef string, opts *InspectOpt
 	for i, layer := range layers {
 		layerDigest, err := layer.Digest()
 		if err != nil {
-			return fmt.Errorf("failed to get layer digest: %w", err)
+			return nil, nil, fmt.Errorf("failed to get layer digest: %w", err)
 		}
 
 		size, err := layer.Size()
 		if err != nil {
-			return fmt.Errorf("failed to get layer size: %w", err)
+			return nil, nil, fmt.Errorf("failed to get layer size: %w", err)
 		}
 		totalSize += size
 
@@ -102,7 +167,7 @@ func RunInspect(ctx context.Context, cli *CLI, imageRef string, opts *InspectOpt
 		})
 	}
 
-	return cli.Inspect().Render(&view.InspectData{
+	data := &view.InspectData{
 		ImageRef:     imageRef,
 		Registry:     ref.Context().RegistryStr(),
 		Digest:       digest,
@@ -111,5 +176,27 @@ func RunInspect(ctx context.Context, cli *CLI, imageRef string, opts *InspectOpt
 		Architecture: configFile.Architecture,
 		TotalSize:    totalSize,
 		Layers:       layerDataList,
-	})
+	}
+
+	if opts.OS {
+		logger.Debug("Detecting operating system", "image", imageRef)
+
+		info, err := osinfo.Detect(layers, time.Now())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to detect operating system: %w", err)
+		}
+		data.OSInfo = &view.OSData{
+			Distro:     info.Distro,
+			Name:       info.Name,
+			PrettyName: info.PrettyName,
+			Version:    info.Version,
+			Codename:   info.Codename,
+			Distroless: info.Distroless,
+			Static:     info.Static,
+			EOL:        info.EOL,
+			EOLStatus:  string(info.EOLStatus),
+		}
+	}
+
+	return data, img, nil
 }
@@ -0,0 +1,128 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bschaatsbergen/cek/internal/lint"
+	"github.com/bschaatsbergen/cek/internal/lock"
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"golang.org/x/sync/errgroup"
+)
+
+// RunInspectManifests inspects, and optionally lints, every unique image
+// referenced in the given manifests. Images that fail are reported in the
+// output rather than stopping the run.
+func RunInspectManifests(ctx context.Context, cli *CLI, paths []string, stdin io.Reader, opts *InspectOptions) error {
+	logger := cli.Logger()
+
+	refs, err := scanManifests(paths, stdin)
+	if err != nil {
+		return err
+	}
+
+	// Inspect each image once, remembering everywhere it is referenced.
+	var images []string
+	sources := make(map[string][]string)
+	for _, ref := range refs {
+		if _, ok := sources[ref.Raw]; !ok {
+			images = append(images, ref.Raw)
+		}
+		sources[ref.Raw] = append(sources[ref.Raw], ref.Source())
+	}
+	logger.Debug("Found images in manifests", "references", len(refs), "images", len(images))
+
+	var linter *lint.Linter
+	if opts.Lint {
+		linter, err = lint.NewLinter(nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+
+	// Share layers between images, which often have a base in common.
+	dir, err := os.MkdirTemp("", "cek-layers-")
+	if err != nil {
+		return fmt.Errorf("failed to create layer cache: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	imageOpts := *opts
+	imageOpts.layers = oci.NewLayerCache(dir)
+
+	data := &view.InspectReportData{
+		Paths:  paths,
+		Linted: opts.Lint,
+		Images: make([]view.InspectReportImage, len(images)),
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, image := range images {
+		g.Go(func() error {
+			report := view.InspectReportImage{ImageRef: image, Sources: sources[image]}
+
+			inspected, img, err := inspectImage(gctx, cli, image, &imageOpts)
+			if err != nil {
+				logger.Debug("Failed to inspect image", "image", image, "error", err)
+				report.Error = err.Error()
+				data.Images[i] = report
+				return nil
+			}
+			report.Inspect = inspected
+
+			if linter != nil {
+				findings, err := linter.LintImage(img)
+				if err != nil {
+					report.Error = err.Error()
+				} else {
+					report.Lint = lintData(image, linter, findings).Findings
+				}
+			}
+
+			data.Images[i] = report
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	return cli.InspectReport().Render(data)
+}
+
+// scanManifests finds image references in files and directories, reading
+// YAML from stdin for "-".
+func scanManifests(paths []string, stdin io.Reader) ([]lock.Reference, error) {
+	var refs []lock.Reference
+	for _, path := range paths {
+		if path != "-" {
+			found, err := lock.Scan([]string{path})
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, found...)
+			continue
+		}
+
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		found, err := lock.ScanData("<stdin>", data, lock.KindKubernetes)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, found...)
+	}
+	return refs, nil
+}
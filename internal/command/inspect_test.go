@@ -2,6 +2,10 @@ package command_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/bschaatsbergen/cek/internal/command"
@@ -212,3 +216,128 @@ func TestRunInspect_CreatedTimestamp(t *testing.T) {
 	// Should have RFC3339 formatted timestamp
 	assert.Regexp(t, `Created: \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}`, output)
 }
+
+func TestRunInspect_OperatingSystem(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{
+		"etc/os-release":     "ID=alpine\nVERSION_ID=3.20.3\nPRETTY_NAME=\"Alpine Linux v3.20\"\n",
+		"etc/alpine-release": "3.20.3\n",
+	}))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewInspectCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always", "--os"})
+	require.NoError(t, cmd.Execute())
+
+	output := buf.String()
+	assert.Contains(t, output, "Operating System:")
+	assert.Contains(t, output, "Distro: alpine")
+	assert.Contains(t, output, "Version: 3.20.3")
+	assert.Contains(t, output, "EOL: 2026-04-01")
+}
+
+func TestRunInspect_OSOptIn(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{
+		"etc/alpine-release": "3.20.3\n",
+	}))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewInspectCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always"})
+	require.NoError(t, cmd.Execute())
+
+	assert.NotContains(t, buf.String(), "Operating System:")
+}
+
+func TestRunInspect_Manifests(t *testing.T) {
+	host := newTestRegistry(t)
+	app := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{
+		"etc/os-release": "ID=alpine\nVERSION_ID=3.20.3\n",
+	}))
+	db := pushTestImage(t, host+"/db:17", newTestImage(t, map[string]string{"data": "db"}))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: `+app+`
+---
+apiVersion: batch/v1
+kind: CronJob
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: job
+              image: `+app+`
+            - name: gone
+              image: `+host+`/gone:1
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services:\n  db:\n    image: "+db+"\n"), 0o644))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewInspectCommand(cli)
+	cmd.SetArgs([]string{"-f", dir, "--pull", "always", "--lint", "--os"})
+	require.NoError(t, cmd.Execute())
+
+	output := buf.String()
+	assert.Contains(t, output, "Found 3 images")
+	assert.Contains(t, output, "alpine 3.20.3")
+	assert.Contains(t, output, "(+1)", "app is referenced twice")
+	assert.Contains(t, output, host+"/gone:1: ")
+	assert.Contains(t, output, "3 images, 1 failed")
+}
+
+func TestRunInspect_ManifestsFromStdin(t *testing.T) {
+	host := newTestRegistry(t)
+	app := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{"app": "1"}))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+	cmd := command.NewInspectCommand(cli)
+	cmd.SetIn(strings.NewReader("kind: Pod\nspec:\n  containers:\n    - image: " + app + "\n"))
+	cmd.SetArgs([]string{"-f", "-", "--pull", "always", "--lint"})
+	require.NoError(t, cmd.Execute())
+
+	var out struct {
+		Images []struct {
+			Image   string   `json:"image"`
+			Sources []string `json:"sources"`
+			Digest  string   `json:"digest"`
+			Lint    []struct {
+				RuleID string `json:"rule_id"`
+			} `json:"lint"`
+		} `json:"images"`
+		Summary struct {
+			Images   int            `json:"images"`
+			Findings map[string]int `json:"findings"`
+		} `json:"summary"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Len(t, out.Images, 1)
+	assert.Equal(t, app, out.Images[0].Image)
+	assert.Equal(t, []string{"<stdin>:4"}, out.Images[0].Sources)
+	assert.NotEmpty(t, out.Images[0].Digest)
+	assert.NotEmpty(t, out.Images[0].Lint, "test images run as root without a healthcheck")
+	assert.NotEmpty(t, out.Summary.Findings)
+}
+
+func TestInspectCommand_FileAndImage(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewInspectCommand(cli)
+	cmd.SetArgs([]string{"-f", "deploy/", "alpine:latest"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	assert.Error(t, cmd.Execute())
+}
@@ -0,0 +1,149 @@
+package command
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bschaatsbergen/cek/internal/layershare"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+func NewLayersCommand(cli *CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "layers",
+		Short: "Analyze layers across images",
+		Long: highlight("cek layers share app-a:1 app-b:1") + "\n\n" +
+			"Analyze how layers are used across images.\n",
+	}
+
+	cmd.AddCommand(NewLayersShareCommand(cli))
+
+	return cmd
+}
+
+type LayersShareOptions struct {
+	Platform    string
+	Pull        string
+	FromFile    string
+	Concurrency int
+}
+
+func NewLayersShareCommand(cli *CLI) *cobra.Command {
+	opts := LayersShareOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "share <image> <image>...",
+		Short: "Show which layers a set of images share",
+		Long: highlight("cek layers share app-a:1 app-b:1 app-c:1") + "\n\n" +
+			"Compare the layers of a set of images by digest and report:\n" +
+			"  - The size of each image, split into bytes shared with other images\n" +
+			"    and bytes unique to it\n" +
+			"  - The layers used by more than one image\n" +
+			"  - The cost of pulling the whole set onto one node, where each layer\n" +
+			"    is downloaded and stored once, against pulling each image alone\n" +
+			"  - A suggested common base: the stack of leading layers whose sharing\n" +
+			"    saves the most, and the images not built on it\n\n" +
+			"Sizes are the compressed layer sizes from the manifests, which is what\n" +
+			"a node downloads; only manifests and configs are fetched. Use\n" +
+			"--from-file to read the images from a file, one per line, or - for stdin.\n\n" +
+			"Examples:\n" +
+			"  cek layers share app-a:1 app-b:1 app-c:1\n" +
+			"  cek layers share --from-file services.txt --platform linux/arm64\n" +
+			"  cek layers share --json --from-file services.txt | jq .suggested_base\n",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.FromFile != "" {
+				return nil
+			}
+			return cobra.MinimumNArgs(2)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			images := args
+			if opts.FromFile != "" {
+				listed, err := readImageList(opts.FromFile, cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+				images = append(images, listed...)
+			}
+			return RunLayersShare(cmd.Context(), cli, images, &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+	cmd.Flags().StringVar(&opts.FromFile, "from-file", "", "Read image references from a file, one per line (- for stdin)")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Maximum images fetched at once")
+
+	return cmd
+}
+
+func RunLayersShare(ctx context.Context, cli *CLI, imageRefs []string, opts *LayersShareOptions) error {
+	logger := cli.Logger()
+
+	if len(imageRefs) < 2 {
+		return errors.New("at least two images are needed to compare layers")
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+
+	inspectOpts := &InspectOptions{
+		Platform: opts.Platform,
+		Pull:     opts.Pull,
+	}
+	images := make([]layershare.Image, len(imageRefs))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, imageRef := range imageRefs {
+		g.Go(func() error {
+			inspected, _, err := inspectImage(gctx, cli, imageRef, inspectOpts)
+			if err != nil {
+				return err
+			}
+			images[i] = layershare.Image{Ref: imageRef}
+			for _, l := range inspected.Layers {
+				images[i].Layers = append(images[i].Layers, layershare.Layer{Digest: l.Digest, Size: l.Size})
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	analysis := layershare.Analyze(images)
+	logger.Debug("Analyzed layers", "images", len(images), "shared", len(analysis.Shared))
+
+	data := &view.LayerShareData{
+		Images:    make([]view.LayerShareImage, len(analysis.Images)),
+		Shared:    make([]view.SharedLayer, len(analysis.Shared)),
+		PullSize:  analysis.PullSize,
+		TotalSize: analysis.TotalSize,
+	}
+	for i, img := range analysis.Images {
+		data.Images[i] = view.LayerShareImage{
+			Image:  img.Ref,
+			Layers: img.Layers,
+			Size:   img.Size,
+			Shared: img.Shared,
+			Unique: img.Unique,
+		}
+	}
+	for i, l := range analysis.Shared {
+		data.Shared[i] = view.SharedLayer{Digest: l.Digest.String(), Size: l.Size, Images: l.Images}
+	}
+	if b := analysis.Base; b != nil {
+		data.Base = &view.LayerShareBase{
+			From:   b.From,
+			Layers: b.Layers,
+			Size:   b.Size,
+			Images: b.Images,
+			Others: b.Others,
+		}
+	}
+
+	return cli.LayerShare().Render(data)
+}
@@ -0,0 +1,83 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayersShare(t *testing.T) {
+	host := newTestRegistry(t)
+	base := map[string]string{"etc/os-release": "ID=alpine\n", "lib/libc.so": "libc"}
+	api := pushTestImage(t, host+"/api:1", newTestImage(t, base, map[string]string{"api": "api"}))
+	worker := pushTestImage(t, host+"/worker:1", newTestImage(t, base, map[string]string{"worker": "worker"}))
+	tool := pushTestImage(t, host+"/tool:1", newTestImage(t, map[string]string{"tool": "tool"}))
+
+	t.Run("human", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewLayersCommand(cli)
+		cmd.SetArgs([]string{"share", api, worker, tool})
+		require.NoError(t, cmd.Execute())
+
+		out := buf.String()
+		assert.Contains(t, out, "Shared layers:")
+		assert.Contains(t, out, "Pulling all 3 images:")
+		assert.Contains(t, out, "Suggested base: the first layer of "+api)
+		assert.Contains(t, out, "used by 2 of 3 images")
+		assert.Contains(t, out, "not on this base: "+tool)
+	})
+
+	t.Run("json from file", func(t *testing.T) {
+		list := filepath.Join(t.TempDir(), "images.txt")
+		require.NoError(t, os.WriteFile(list, []byte(api+"\n"+worker+"\n"+tool+"\n"), 0o644))
+
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+		cmd := command.NewLayersCommand(cli)
+		cmd.SetArgs([]string{"share", "--from-file", list})
+		require.NoError(t, cmd.Execute())
+
+		var out struct {
+			Images []struct {
+				Image  string `json:"image"`
+				Shared int64  `json:"shared"`
+				Unique int64  `json:"unique"`
+			} `json:"images"`
+			Shared []struct {
+				Images []string `json:"images"`
+			} `json:"shared_layers"`
+			PullSize  int64 `json:"pull_size"`
+			TotalSize int64 `json:"total_size"`
+			Base      struct {
+				Images []string `json:"images"`
+			} `json:"suggested_base"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+
+		require.Len(t, out.Images, 3)
+		assert.Positive(t, out.Images[0].Shared)
+		assert.Zero(t, out.Images[2].Shared)
+		require.Len(t, out.Shared, 1)
+		assert.Equal(t, []string{api, worker}, out.Shared[0].Images)
+		assert.Less(t, out.PullSize, out.TotalSize)
+		assert.Equal(t, []string{api, worker}, out.Base.Images)
+	})
+}
+
+func TestLayersShare_TooFewImages(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewLayersCommand(cli)
+	cmd.SetArgs([]string{"share", "app:1"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	assert.ErrorContains(t, cmd.Execute(), "requires at least 2 arg(s)")
+}
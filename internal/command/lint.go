@@ -0,0 +1,246 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/bschaatsbergen/cek/internal/lint"
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/view"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/spf13/cobra"
+)
+
+type LintOptions struct {
+	Config     string
+	Enable     []string
+	Disable    []string
+	FailOn     string
+	SARIF      bool
+	Dockerfile string
+	Platform   string
+	Pull       string
+
+	FromFile    string
+	Concurrency int
+	layers      *oci.LayerCache
+}
+
+func NewLintCommand(cli *CLI) *cobra.Command {
+	opts := LintOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "lint <image>",
+		Short: "Check an image against best-practice rules",
+		Long: highlight("cek lint my-app:latest") + "\n\n" +
+			"Check an OCI image's config, annotations and filesystem against a set\n" +
+			"of best-practice rules:\n\n" +
+			"  root-user               the image runs as root\n" +
+			"  no-healthcheck          no HEALTHCHECK is defined\n" +
+			"  latest-base             the base image annotation uses the latest tag\n" +
+			"  secrets-in-env          credentials are stored in ENV\n" +
+			"  package-manager-cache   apt, apk, pip, npm or other caches were left behind\n" +
+			"  setuid-binaries         files have the setuid or setgid bit set\n" +
+			"  missing-annotations     required OCI annotations such as\n" +
+			"                          org.opencontainers.image.source are missing\n" +
+			"  world-writable          files or non-sticky directories are world-writable\n\n" +
+			"Rules can be enabled, disabled and tuned with a YAML config file:\n\n" +
+			"  rules:\n" +
+			"    no-healthcheck:\n" +
+			"      enabled: false\n" +
+			"    setuid-binaries:\n" +
+			"      severity: error\n" +
+			"      ignore: [\"/usr/bin/passwd\"]\n\n" +
+			"Use --sarif to produce SARIF 2.1.0 for code scanning tools. Findings\n" +
+			"are attributed to the file given by --dockerfile, at the line of the\n" +
+			"instruction they come from when that file exists.\n\n" +
+			batchLong +
+			"Examples:\n" +
+			"  cek lint my-app:latest\n" +
+			"  cek lint --config .cek-lint.yaml --fail-on warning my-app:latest\n" +
+			"  cek lint --disable no-healthcheck,root-user my-app:latest\n" +
+			"  cek lint --sarif --dockerfile build/Dockerfile my-app:latest > lint.sarif\n" +
+			"  cek lint --from-file images.txt --fail-on error > lint.ndjson\n",
+		Args: batchArgs(&opts.FromFile, cobra.NoArgs, cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.FromFile != "" {
+				if opts.SARIF {
+					return fmt.Errorf("--sarif cannot be used with --from-file")
+				}
+				return runBatch(cmd.Context(), cli, opts.FromFile, cmd.InOrStdin(), opts.Concurrency,
+					func(ctx context.Context, cli *CLI, imageRef string, layers *oci.LayerCache) error {
+						imageOpts := opts
+						imageOpts.layers = layers
+						return RunLint(ctx, cli, imageRef, &imageOpts)
+					})
+			}
+			imageRef := args[0]
+			return RunLint(cmd.Context(), cli, imageRef, &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Config, "config", "", "Path to a YAML lint config file")
+	cmd.Flags().StringSliceVar(&opts.Enable, "enable", nil, "Enable rules (comma-separated)")
+	cmd.Flags().StringSliceVar(&opts.Disable, "disable", nil, "Disable rules (comma-separated)")
+	cmd.Flags().StringVar(&opts.FailOn, "fail-on", "", "Exit with an error if findings at or above this severity exist (note, warning, error)")
+	cmd.Flags().BoolVar(&opts.SARIF, "sarif", false, "Output results in SARIF format")
+	cmd.Flags().StringVar(&opts.Dockerfile, "dockerfile", "Dockerfile", "File that SARIF results are attributed to")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+	cmd.Flags().StringVar(&opts.FromFile, "from-file", "", "Read image references from a file, one per line (- for stdin)")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Maximum images processed at once (with --from-file)")
+
+	return cmd
+}
+
+func RunLint(ctx context.Context, cli *CLI, imageRef string, opts *LintOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Linting image", "image", imageRef)
+
+	linter, err := newLinter(opts)
+	if err != nil {
+		return err
+	}
+
+	var failOn lint.Severity
+	if opts.FailOn != "" {
+		failOn, err = lint.ParseSeverity(opts.FailOn)
+		if err != nil {
+			return err
+		}
+	}
+
+	fetchOpts := &oci.FetchOptions{
+		Platform:   opts.Platform,
+		PullPolicy: oci.PullPolicy(opts.Pull),
+		Layers:     opts.layers,
+	}
+	img, _, err := oci.FetchImage(ctx, imageRef, fetchOpts)
+	if err != nil {
+		return err
+	}
+
+	findings, err := linter.LintImage(img)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Lint complete", "rules", len(linter.Rules()), "findings", len(findings))
+
+	data := lintData(imageRef, linter, findings)
+	data.Artifact = opts.Dockerfile
+
+	if opts.SARIF {
+		if err := locateFindings(cli, data, findings, img, opts.Dockerfile); err != nil {
+			return err
+		}
+		err = view.NewLintSARIFView(cli.Stream).Render(data)
+	} else {
+		err = cli.Lint().Render(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	if failOn != "" {
+		failing := 0
+		for _, f := range findings {
+			if f.Severity.AtLeast(failOn) {
+				failing++
+			}
+		}
+		if failing > 0 {
+			return fmt.Errorf("found %d issues with severity %s or higher", failing, failOn)
+		}
+	}
+
+	return nil
+}
+
+// newLinter builds a linter from the config file and the --enable and
+// --disable flags, which take precedence over the file.
+func newLinter(opts *LintOptions) (*lint.Linter, error) {
+	cfg := &lint.Config{}
+	if opts.Config != "" {
+		var err error
+		cfg, err = lint.LoadConfig(opts.Config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range opts.Enable {
+		if err := cfg.SetEnabled(id, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range opts.Disable {
+		if err := cfg.SetEnabled(id, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return lint.NewLinter(cfg)
+}
+
+// locateFindings sets the Dockerfile line and fingerprint of each finding.
+// Lines are only known when the Dockerfile exists.
+func locateFindings(cli *CLI, data *view.LintData, findings []lint.Finding, img v1.Image, dockerfile string) error {
+	config, err := img.ConfigFile()
+	if err != nil {
+		return fmt.Errorf("failed to get config file: %w", err)
+	}
+
+	var instructions []lint.Instruction
+	f, err := os.Open(dockerfile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		cli.Logger().Debug("Dockerfile not found, findings are reported at line 1", "dockerfile", dockerfile)
+	case err != nil:
+		return fmt.Errorf("failed to open Dockerfile: %w", err)
+	default:
+		defer func() {
+			_ = f.Close()
+		}()
+		instructions, err = lint.ParseDockerfile(f)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range findings {
+		in := lint.Locate(&findings[i], config.History, instructions)
+		if in != nil {
+			data.Findings[i].Line = in.Line
+		}
+		data.Findings[i].Fingerprint = lint.Fingerprint(&findings[i], in)
+	}
+	return nil
+}
+
+func lintData(imageRef string, linter *lint.Linter, findings []lint.Finding) *view.LintData {
+	data := &view.LintData{
+		ImageRef: imageRef,
+		Findings: make([]view.LintFinding, len(findings)),
+	}
+	for _, rule := range linter.Rules() {
+		data.Rules = append(data.Rules, view.LintRule{
+			ID:          rule.ID,
+			Description: rule.Description,
+			Severity:    string(rule.Severity),
+		})
+	}
+	for i, f := range findings {
+		data.Findings[i] = view.LintFinding{
+			RuleID:   f.RuleID,
+			Severity: string(f.Severity),
+			Message:  f.Message,
+			Path:     f.Path,
+			Layer:    f.Layer,
+		}
+	}
+	return data
+}
@@ -0,0 +1,161 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLintCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewLintCommand(cli)
+
+	assert.Equal(t, "lint", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.NotNil(t, cmd.Flags().Lookup("config"))
+	assert.NotNil(t, cmd.Flags().Lookup("enable"))
+	assert.NotNil(t, cmd.Flags().Lookup("disable"))
+	assert.Equal(t, "false", cmd.Flags().Lookup("sarif").DefValue)
+	assert.Equal(t, "Dockerfile", cmd.Flags().Lookup("dockerfile").DefValue)
+}
+
+func TestLintCommand_UnknownRule(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewLintCommand(cli)
+	cmd.SetArgs([]string{"alpine:latest", "--disable", "no-such-rule"})
+
+	err := cmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown rule "no-such-rule"`)
+}
+
+func TestRunLint(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t,
+		map[string]string{"var/cache/apk/APKINDEX.tar.gz": "index"},
+	))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewLintCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always", "--disable", "no-healthcheck", "--fail-on", "warning"})
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "found 1 issues with severity warning or higher")
+
+	output := buf.String()
+	assert.Contains(t, output, "root-user")
+	assert.Contains(t, output, "package-manager-cache")
+	assert.Contains(t, output, "missing-annotations")
+	assert.NotContains(t, output, "no-healthcheck")
+	assert.Contains(t, output, "3 issues (0 errors, 1 warnings, 2 notes)")
+}
+
+func TestRunLint_SARIF(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{"app/main": "bin"}))
+
+	cfgPath := filepath.Join(t.TempDir(), "lint.yaml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte("rules:\n  root-user:\n    severity: error\n"), 0o644))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewLintCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always", "--sarif", "--config", cfgPath, "--dockerfile", "build/Dockerfile"})
+	require.NoError(t, cmd.Execute())
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	assert.Equal(t, "cek", log.Runs[0].Tool.Driver.Name)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, 8)
+
+	levels := make(map[string]string)
+	for _, r := range log.Runs[0].Results {
+		levels[r.RuleID] = r.Level
+		require.Len(t, r.Locations, 1)
+		assert.Equal(t, "build/Dockerfile", r.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	assert.Equal(t, "error", levels["root-user"])
+	assert.Equal(t, "note", levels["no-healthcheck"])
+}
+
+func TestRunLint_SARIFLines(t *testing.T) {
+	host := newTestRegistry(t)
+	img, err := mutate.Config(newTestImage(t, map[string]string{"app/main": "bin"}), v1.Config{User: "root"})
+	require.NoError(t, err)
+	ref := pushTestImage(t, host+"/app:1.0", img)
+
+	dockerfile := filepath.Join(t.TempDir(), "Dockerfile")
+	require.NoError(t, os.WriteFile(dockerfile, []byte("FROM alpine:3.20\n\n# drop privileges\nUSER root\n"), 0o644))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewLintCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always", "--sarif", "--enable", "root-user,no-healthcheck", "--dockerfile", dockerfile})
+	require.NoError(t, cmd.Execute())
+
+	var log struct {
+		Runs []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Locations []struct {
+					PhysicalLocation struct {
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+				PartialFingerprints map[string]string `json:"partialFingerprints"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Len(t, log.Runs, 1)
+
+	lines := make(map[string]int)
+	for _, r := range log.Runs[0].Results {
+		lines[r.RuleID] = r.Locations[0].PhysicalLocation.Region.StartLine
+		assert.NotEmpty(t, r.PartialFingerprints["cekFindingHash/v1"], r.RuleID)
+	}
+	assert.Equal(t, 4, lines["root-user"])
+	assert.Equal(t, 1, lines["no-healthcheck"], "no instruction to point at")
+}
@@ -0,0 +1,286 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/bschaatsbergen/cek/internal/lock"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+type LockOptions struct {
+	Lockfile    string
+	Write       bool
+	Check       bool
+	Platforms   []string
+	Concurrency int
+}
+
+func NewLockCommand(cli *CLI) *cobra.Command {
+	opts := LockOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "lock [path...]",
+		Short: "Pin image references to digests",
+		Long: highlight("cek lock .") + "\n\n" +
+			"Find image references in Dockerfiles, Compose files and Kubernetes\n" +
+			"manifests, resolve each to the digest it currently points at, and record\n" +
+			"them in a lockfile (" + lock.DefaultLockfile + " by default). Directories are\n" +
+			"searched recursively, skipping hidden directories, node_modules and vendor.\n\n" +
+			"Use --write to pin the references in place instead, rewriting e.g.\n" +
+			"nginx:1.27 to nginx:1.27@sha256:... Use --platform to also record the\n" +
+			"digest of each platform of multi-platform images.\n\n" +
+			"Use --check in CI to fail when upstream digests have drifted from those\n" +
+			"in the lockfile or pinned in place, or when a reference is not locked.\n\n" +
+			"References using build args or templates, such as ${BASE_IMAGE} or\n" +
+			"{{ .Values.image }}, are skipped.\n\n" +
+			"Examples:\n" +
+			"  cek lock\n" +
+			"  cek lock --platform linux/amd64,linux/arm64 Dockerfile deploy/\n" +
+			"  cek lock --write docker-compose.yml\n" +
+			"  cek lock --check\n",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				args = []string{"."}
+			}
+			return RunLock(cmd.Context(), cli, args, &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Lockfile, "lockfile", lock.DefaultLockfile, "Path of the lockfile")
+	cmd.Flags().BoolVarP(&opts.Write, "write", "w", false, "Pin references in place instead of writing a lockfile")
+	cmd.Flags().BoolVar(&opts.Check, "check", false, "Fail if digests drifted or references are not locked")
+	cmd.Flags().StringSliceVar(&opts.Platforms, "platform", nil, "Also lock the digest of these platforms (e.g., linux/amd64,linux/arm64)")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Maximum concurrent registry requests")
+	cmd.MarkFlagsMutuallyExclusive("write", "check")
+
+	return cmd
+}
+
+func RunLock(ctx context.Context, cli *CLI, paths []string, opts *LockOptions) error {
+	logger := cli.Logger()
+
+	if opts.Write && len(opts.Platforms) > 1 {
+		return fmt.Errorf("--write pins a single digest per reference, use at most one --platform")
+	}
+
+	refs, err := lock.Scan(paths)
+	if err != nil {
+		return err
+	}
+	logger.Debug("Found image references", "count", len(refs))
+
+	// Group references by name, keeping the first occurrence order.
+	var names []string
+	byName := make(map[string][]lock.Reference)
+	for _, ref := range refs {
+		if ref.Name == "" {
+			logger.Debug("Skipping reference without tag", "ref", ref.Raw, "source", ref.Source())
+			continue
+		}
+		if _, ok := byName[ref.Name]; !ok {
+			names = append(names, ref.Name)
+		}
+		byName[ref.Name] = append(byName[ref.Name], ref)
+	}
+	sort.Strings(names)
+
+	resolved, err := lock.Resolve(ctx, names, opts.Platforms, opts.Concurrency,
+		remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case opts.Check:
+		return checkLock(cli, names, byName, resolved, opts)
+	case opts.Write:
+		return pinInPlace(cli, refs, names, byName, resolved, opts)
+	default:
+		return writeLockfile(cli, names, byName, resolved, opts)
+	}
+}
+
+func writeLockfile(cli *CLI, names []string, byName map[string][]lock.Reference, resolved map[string]*lock.Resolved, opts *LockOptions) error {
+	previous, err := lock.ReadLockfile(opts.Lockfile)
+	if err != nil {
+		return err
+	}
+
+	lf := lock.NewLockfile()
+	data := &view.LockData{Mode: view.LockModeLockfile, Lockfile: opts.Lockfile}
+	for _, n := range names {
+		r := resolved[n]
+		entry := &lock.Entry{
+			Digest:    r.Digest.String(),
+			Platforms: platformDigests(r),
+			Sources:   sourceFiles(byName[n]),
+		}
+		lf.Images[n] = entry
+
+		img := lockImage(n, r, byName[n])
+		switch old := previous.Images[n]; {
+		case old == nil:
+			img.Status = "added"
+		case old.Digest != entry.Digest:
+			img.Status = "updated"
+			img.Previous = old.Digest
+		default:
+			img.Status = "unchanged"
+		}
+		data.Images = append(data.Images, img)
+	}
+
+	var removed []string
+	for n := range previous.Images {
+		if lf.Images[n] == nil {
+			removed = append(removed, n)
+		}
+	}
+	sort.Strings(removed)
+	for _, n := range removed {
+		data.Images = append(data.Images, view.LockImage{
+			Image:  n,
+			Status: "removed",
+			Digest: previous.Images[n].Digest,
+		})
+	}
+
+	if err := lf.Write(opts.Lockfile); err != nil {
+		return err
+	}
+	return cli.Lock().Render(data)
+}
+
+func pinInPlace(cli *CLI, refs []lock.Reference, names []string, byName map[string][]lock.Reference, resolved map[string]*lock.Resolved, opts *LockOptions) error {
+	digests := make(map[string]string, len(names))
+	for _, n := range names {
+		digest := resolved[n].Digest
+		// With a single platform, pin the platform's image rather than
+		// the index.
+		if len(opts.Platforms) == 1 {
+			if d, ok := resolved[n].Platforms[opts.Platforms[0]]; ok {
+				digest = d
+			}
+		}
+		digests[n] = digest.String()
+	}
+
+	pinned, err := lock.Pin(refs, digests)
+	if err != nil {
+		return err
+	}
+	changed := make(map[string]bool)
+	for _, ref := range pinned {
+		changed[ref.Name] = true
+	}
+
+	data := &view.LockData{Mode: view.LockModeWrite, Pinned: len(pinned)}
+	for _, n := range names {
+		img := lockImage(n, resolved[n], byName[n])
+		img.Digest = digests[n]
+		img.Status = "unchanged"
+		if changed[n] {
+			img.Status = "pinned"
+		}
+		data.Images = append(data.Images, img)
+	}
+	return cli.Lock().Render(data)
+}
+
+func checkLock(cli *CLI, names []string, byName map[string][]lock.Reference, resolved map[string]*lock.Resolved, opts *LockOptions) error {
+	lf, err := lock.ReadLockfile(opts.Lockfile)
+	if err != nil {
+		return err
+	}
+
+	data := &view.LockData{Mode: view.LockModeCheck, Lockfile: opts.Lockfile}
+	failing := 0
+	for _, n := range names {
+		r := resolved[n]
+		img := lockImage(n, r, byName[n])
+		img.Status = "ok"
+
+		unpinned := false
+		for _, ref := range byName[n] {
+			if !ref.Pinned() {
+				unpinned = true
+			} else if !r.Matches(ref.Digest) {
+				img.Status = "drifted"
+				img.Previous = ref.Digest
+			}
+		}
+		if unpinned && img.Status == "ok" {
+			entry := lf.Images[n]
+			switch {
+			case entry == nil:
+				img.Status = "unlocked"
+			case entry.Digest != r.Digest.String():
+				img.Status = "drifted"
+				img.Previous = entry.Digest
+			default:
+				for p, d := range r.Platforms {
+					if locked, ok := entry.Platforms[p]; ok && locked != d.String() {
+						img.Status = "drifted"
+						img.Previous = locked
+					}
+				}
+			}
+		}
+
+		if img.Status != "ok" {
+			failing++
+		}
+		data.Images = append(data.Images, img)
+	}
+
+	if err := cli.Lock().Render(data); err != nil {
+		return err
+	}
+	if failing > 0 {
+		return fmt.Errorf("%d of %d images drifted or are not locked, run cek lock to update", failing, len(names))
+	}
+	return nil
+}
+
+func lockImage(n string, r *lock.Resolved, refs []lock.Reference) view.LockImage {
+	sources := make([]string, len(refs))
+	for i, ref := range refs {
+		sources[i] = ref.Source()
+	}
+	return view.LockImage{
+		Image:     n,
+		Digest:    r.Digest.String(),
+		Platforms: platformDigests(r),
+		Sources:   sources,
+	}
+}
+
+func platformDigests(r *lock.Resolved) map[string]string {
+	if len(r.Platforms) == 0 {
+		return nil
+	}
+	platforms := make(map[string]string, len(r.Platforms))
+	for p, d := range r.Platforms {
+		platforms[p] = d.String()
+	}
+	return platforms
+}
+
+// sourceFiles lists the files refs were found in, without line numbers so
+// the lockfile does not change when unrelated lines move.
+func sourceFiles(refs []lock.Reference) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, ref := range refs {
+		if !seen[ref.File] {
+			seen[ref.File] = true
+			files = append(files, ref.File)
+		}
+	}
+	return files
+}
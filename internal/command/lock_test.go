@@ -0,0 +1,115 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/lock"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLockCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewLockCommand(cli)
+
+	assert.Equal(t, "lock", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.Equal(t, lock.DefaultLockfile, cmd.Flags().Lookup("lockfile").DefValue)
+	for _, flag := range []string{"write", "check", "platform", "concurrency"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), flag)
+	}
+}
+
+func TestRunLock(t *testing.T) {
+	host := newTestRegistry(t)
+	base := pushTestImage(t, host+"/base:1", newTestImage(t, map[string]string{"v": "1"}))
+	web := pushTestImage(t, host+"/web:1", newTestImage(t, map[string]string{"web": "1"}))
+
+	dir := t.TempDir()
+	dockerfile := filepath.Join(dir, "Dockerfile")
+	compose := filepath.Join(dir, "compose.yaml")
+	lockfile := filepath.Join(dir, "cek.lock")
+	require.NoError(t, os.WriteFile(dockerfile, []byte("FROM "+base+" AS build\nFROM build\n"), 0o644))
+	require.NoError(t, os.WriteFile(compose, []byte("services:\n  web:\n    image: "+web+"\n"), 0o644))
+
+	run := func(args ...string) (string, error) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewLockCommand(cli)
+		cmd.SetArgs(append(args, "--lockfile", lockfile, dir))
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		err := cmd.Execute()
+		return buf.String(), err
+	}
+
+	out, err := run()
+	require.NoError(t, err)
+	assert.Contains(t, out, "2 added")
+
+	lf, err := lock.ReadLockfile(lockfile)
+	require.NoError(t, err)
+	require.Contains(t, lf.Images, base)
+	assert.Equal(t, []string{dockerfile}, lf.Images[base].Sources)
+	assert.Contains(t, lf.Images, web)
+
+	out, err = run("--check")
+	require.NoError(t, err)
+	assert.Contains(t, out, "All 2 images match")
+
+	// Upstream re-pushes the base image.
+	pushTestImage(t, base, newTestImage(t, map[string]string{"v": "2"}))
+
+	out, err = run("--check")
+	assert.ErrorContains(t, err, "1 of 2 images drifted")
+	assert.Contains(t, out, "drifted")
+
+	out, err = run("--write")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Pinned 2 references")
+
+	data, err := os.ReadFile(dockerfile)
+	require.NoError(t, err)
+	assert.Regexp(t, `^FROM `+regexp.QuoteMeta(base)+`@sha256:[a-f0-9]{64} AS build\nFROM build\n$`, string(data))
+
+	// Pinned references are checked against upstream, not the lockfile.
+	_, err = run("--check")
+	require.NoError(t, err)
+}
+
+func TestRunLock_Unlocked(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/base:1", newTestImage(t))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM "+ref+"\n"), 0o644))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+	cmd := command.NewLockCommand(cli)
+	cmd.SetArgs([]string{"--check", "--lockfile", filepath.Join(dir, "cek.lock"), dir})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	assert.ErrorContains(t, cmd.Execute(), "not locked")
+
+	var out struct {
+		Images []struct {
+			Image  string `json:"image"`
+			Status string `json:"status"`
+		} `json:"images"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Len(t, out.Images, 1)
+	assert.Equal(t, "unlocked", out.Images[0].Status)
+}
@@ -4,13 +4,13 @@ import (
 	"archive/tar"
 	"context"
 	"fmt"
-	"io"
-	"path/filepath"
+	"io/fs"
 	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/bschaatsbergen/cek/internal/oci"
 	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/bschaatsbergen/cek/pkg/imagefs"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/spf13/cobra"
 )
@@ -21,6 +21,10 @@ type LsOptions struct {
 	Platform string
 	Pull     string
 	Path     string
+
+	FromFile    string
+	Concurrency int
+	layers      *oci.LayerCache
 }
 
 func NewLsCommand(cli *CLI) *cobra.Command {
@@ -41,15 +45,28 @@ func NewLsCommand(cli *CLI) *cobra.Command {
 			"  *.conf                  Files ending with .conf (basename only)\n" +
 			"  **/fontconfig/*.conf    .conf files in any fontconfig directory\n" +
 			"  /etc/**/*.conf          .conf files under /etc\n\n" +
+			batchLong +
 			"Examples:\n" +
 			"  cek ls alpine:latest\n" +
 			"  cek ls alpine:latest /etc\n" +
 			"  cek ls nginx:latest /etc/nginx\n" +
 			"  cek ls --layer 1 alpine:latest\n" +
 			"  cek ls --filter '*.conf' nginx:alpine\n" +
-			"  cek ls --filter '**/nginx/*.conf' nginx:alpine\n",
-		Args: cobra.RangeArgs(1, 2),
+			"  cek ls --filter '**/nginx/*.conf' nginx:alpine\n" +
+			"  cek ls --from-file images.txt /etc > files.ndjson\n",
+		Args: batchArgs(&opts.FromFile, cobra.MaximumNArgs(1), cobra.RangeArgs(1, 2)),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.FromFile != "" {
+				if len(args) > 0 {
+					opts.Path = args[0]
+				}
+				return runBatch(cmd.Context(), cli, opts.FromFile, cmd.InOrStdin(), opts.Concurrency,
+					func(ctx context.Context, cli *CLI, imageRef string, layers *oci.LayerCache) error {
+						imageOpts := opts
+						imageOpts.layers = layers
+						return RunLs(ctx, cli, imageRef, &imageOpts)
+					})
+			}
 			imageRef := args[0]
 			if len(args) > 1 {
 				opts.Path = args[1]
@@ -65,6 +82,8 @@ func NewLsCommand(cli *CLI) *cobra.Command {
 	cmd.Flags().StringVar(&opts.Filter, "filter", "", "Filter file paths by pattern")
 	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
 	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+	cmd.Flags().StringVar(&opts.FromFile, "from-file", "", "Read image references from a file, one per line (- for stdin)")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Maximum images processed at once (with --from-file)")
 
 	return cmd
 }
@@ -76,6 +95,7 @@ func RunLs(ctx context.Context, cli *CLI, imageRef string, opts *LsOptions) erro
 	fetchOpts := &oci.FetchOptions{
 		Platform:   opts.Platform,
 		PullPolicy: oci.PullPolicy(opts.Pull),
+		Layers:     opts.layers,
 	}
 	img, _, err := oci.FetchImage(ctx, imageRef, fetchOpts)
 	if err != nil {
@@ -89,26 +109,17 @@ func RunLs(ctx context.Context, cli *CLI, imageRef string, opts *LsOptions) erro
 
 	logger.Debug("Found layers", "count", len(layers))
 
-	var files []view.FileInfo
-
-	if opts.Layer > 0 {
-		if opts.Layer > len(layers) {
-			return fmt.Errorf("layer %d does not exist (image has %d layers)", opts.Layer, len(layers))
-		}
-		layerIdx := opts.Layer - 1
-		layer := layers[layerIdx]
+	fsys, err := imageFS(layers, opts.Layer)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fsys.Close()
+	}()
 
-		var err error
-		files, err = extractFilesFromLayer(layer)
-		if err != nil {
-			return fmt.Errorf("failed to extract files from layer %d: %w", layerIdx+1, err)
-		}
-	} else {
-		var err error
-		files, err = extractMergedFilesystem(layers)
-		if err != nil {
-			return fmt.Errorf("failed to extract merged filesystem: %w", err)
-		}
+	files, err := listFiles(fsys)
+	if err != nil {
+		return err
 	}
 
 	if opts.Path != "" {
@@ -126,90 +137,60 @@ func RunLs(ctx context.Context, cli *CLI, imageRef string, opts *LsOptions) erro
 	})
 }
 
-func extractFilesFromLayer(layer interface {
-	Uncompressed() (io.ReadCloser, error)
-}) ([]view.FileInfo, error) {
-	rc, err := layer.Uncompressed()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get uncompressed layer: %w", err)
-	}
-	defer func() {
-		_ = rc.Close()
-	}()
-
-	var files []view.FileInfo
-	tr := tar.NewReader(rc)
-
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
+// imageFS returns the filesystem of the 1-indexed layer, or the merged
+// overlay filesystem of all layers when layer is not positive.
+func imageFS(layers []v1.Layer, layer int) (*imagefs.FS, error) {
+	if layer > 0 {
+		if layer > len(layers) {
+			return nil, fmt.Errorf("layer %d does not exist (image has %d layers)", layer, len(layers))
 		}
+		fsys, err := imagefs.NewFromLayers(layers[layer-1])
 		if err != nil {
-			return nil, fmt.Errorf("failed to read tar header: %w", err)
+			return nil, fmt.Errorf("failed to read layer %d: %w", layer, err)
 		}
-
-		if strings.HasPrefix(filepath.Base(header.Name), ".wh.") {
-			continue
-		}
-
-		modeStr := formatFileMode(header.Typeflag, header.Mode)
-
-		files = append(files, view.FileInfo{
-			Mode: modeStr,
-			Size: header.Size,
-			Path: "/" + strings.TrimPrefix(header.Name, "/"),
-		})
+		return fsys, nil
 	}
 
-	return files, nil
+	fsys, err := imagefs.NewFromLayers(layers...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read merged filesystem: %w", err)
+	}
+	return fsys, nil
 }
 
-// extractMergedFilesystem builds the final overlay filesystem state by processing
-// all layers bottom-up. Later layers override files from earlier layers.
-func extractMergedFilesystem(layers []v1.Layer) ([]view.FileInfo, error) {
-	fileMap := make(map[string]view.FileInfo)
-
-	for _, layer := range layers {
-		rc, err := layer.Uncompressed()
+// listFiles returns the entries of fsys held by a layer in path order, so
+// directories only implied by the paths of their contents and the root are
+// left out. Symbolic links are listed rather than followed.
+func listFiles(fsys *imagefs.FS) ([]view.FileInfo, error) {
+	var files []view.FileInfo
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return nil, fmt.Errorf("failed to get uncompressed layer: %w", err)
+			return err
 		}
-
-		tr := tar.NewReader(rc)
-		for {
-			header, err := tr.Next()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				_ = rc.Close()
-				return nil, fmt.Errorf("failed to read tar header: %w", err)
-			}
-
-			path := "/" + strings.TrimPrefix(header.Name, "/")
-
-			// Whiteout files remove entries from the overlay.
-			if strings.HasPrefix(filepath.Base(header.Name), ".wh.") {
-				delete(fileMap, path)
-				continue
-			}
-
-			modeStr := formatFileMode(header.Typeflag, header.Mode)
-			fileMap[path] = view.FileInfo{
-				Mode: modeStr,
-				Size: header.Size,
-				Path: path,
-			}
+		if p == "." {
+			return nil
 		}
-		_ = rc.Close()
-	}
-
-	files := make([]view.FileInfo, 0, len(fileMap))
-	for _, file := range fileMap {
-		files = append(files, file)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if imagefs.LayerIndex(info) < 0 {
+			return nil
+		}
+		hdr, ok := info.Sys().(*tar.Header)
+		if !ok {
+			return fmt.Errorf("%s has no tar header", p)
+		}
+		files = append(files, view.FileInfo{
+			Mode: formatFileMode(hdr.Typeflag, hdr.Mode),
+			Size: hdr.Size,
+			Path: "/" + p,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
 	}
-
 	return files, nil
 }
 
@@ -0,0 +1,89 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+type ManifestOptions struct {
+	Pretty   bool
+	Digest   bool
+	Platform string
+}
+
+func NewManifestCommand(cli *CLI) *cobra.Command {
+	opts := ManifestOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "manifest <image>",
+		Short: "Show the raw image manifest or index",
+		Long: highlight("cek manifest nginx:latest") + "\n\n" +
+			"Print the manifest or index exactly as the registry serves it, including\n" +
+			"media types, annotations, compression and subject fields. The output\n" +
+			"is byte-for-byte identical to the registry response, so piping it to\n" +
+			"sha256sum yields the manifest digest.\n\n" +
+			"For multi-platform images the index is shown. Use --platform to show the\n" +
+			"manifest of a single platform instead.\n\n" +
+			"This queries the remote registry, not the local daemon.\n\n" +
+			"Examples:\n" +
+			"  cek manifest nginx:latest\n" +
+			"  cek manifest --platform linux/arm64 --pretty nginx:latest\n" +
+			"  cek manifest --digest nginx:latest\n" +
+			"  cek manifest nginx:latest | sha256sum\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			imageRef := args[0]
+			return RunManifest(cmd.Context(), cli, imageRef, &opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Pretty, "pretty", false, "Pretty-print the JSON")
+	cmd.Flags().BoolVar(&opts.Digest, "digest", false, "Only show the digest, media type and size")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Select the manifest for a platform from an index (e.g., linux/amd64)")
+
+	return cmd
+}
+
+func RunManifest(ctx context.Context, cli *CLI, imageRef string, opts *ManifestOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Fetching manifest", "image", imageRef, "platform", opts.Platform)
+
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	remoteOpts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+
+	desc, subject, err := resolveSubject(ref, opts.Platform, remoteOpts)
+	if err != nil {
+		return err
+	}
+	if subject != desc.Digest {
+		desc, err = remote.Get(ref.Context().Digest(subject.String()), remoteOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to fetch platform manifest: %w", err)
+		}
+	}
+
+	logger.Debug("Fetched manifest", "digest", desc.Digest.String(), "mediaType", string(desc.MediaType))
+
+	return cli.Raw().Render(&view.RawData{
+		ImageRef:       imageRef,
+		Digest:         desc.Digest.String(),
+		MediaType:      string(desc.MediaType),
+		Size:           desc.Size,
+		Content:        desc.Manifest,
+		Pretty:         opts.Pretty,
+		DescriptorOnly: opts.Digest,
+	})
+}
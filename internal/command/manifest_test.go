@@ -0,0 +1,131 @@
+package command_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pushTestIndex pushes a linux/amd64 and linux/arm64 index to ref and
+// returns the arm64 image.
+func pushTestIndex(t *testing.T, ref string) v1.Image {
+	t.Helper()
+
+	amd64 := newTestImage(t, map[string]string{"arch": "amd64"})
+
+	arm64 := newTestImage(t, map[string]string{"arch": "arm64"})
+	cfg, err := arm64.ConfigFile()
+	require.NoError(t, err)
+	cfg = cfg.DeepCopy()
+	cfg.Architecture = "arm64"
+	arm64, err = mutate.ConfigFile(arm64, cfg)
+	require.NoError(t, err)
+
+	idx := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: amd64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+		mutate.IndexAddendum{Add: arm64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+	)
+
+	r, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	require.NoError(t, remote.WriteIndex(r, idx))
+	return arm64
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestNewManifestCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewManifestCommand(cli)
+
+	assert.Equal(t, "manifest", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.Equal(t, "false", cmd.Flags().Lookup("pretty").DefValue)
+	assert.Equal(t, "false", cmd.Flags().Lookup("digest").DefValue)
+	assert.NotNil(t, cmd.Flags().Lookup("platform"))
+}
+
+func TestRunManifest(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := host + "/app:multi"
+	arm64 := pushTestIndex(t, ref)
+
+	r, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	head, err := remote.Head(r)
+	require.NoError(t, err)
+
+	t.Run("raw index", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewManifestCommand(cli)
+		cmd.SetArgs([]string{ref})
+		require.NoError(t, cmd.Execute())
+
+		assert.Equal(t, head.Digest.String(), sha256Digest(buf.Bytes()))
+	})
+
+	t.Run("platform child", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewManifestCommand(cli)
+		cmd.SetArgs([]string{ref, "--platform", "linux/arm64"})
+		require.NoError(t, cmd.Execute())
+
+		want, err := arm64.Digest()
+		require.NoError(t, err)
+		assert.Equal(t, want.String(), sha256Digest(buf.Bytes()))
+	})
+
+	t.Run("pretty", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewManifestCommand(cli)
+		cmd.SetArgs([]string{ref, "--pretty"})
+		require.NoError(t, cmd.Execute())
+
+		assert.Contains(t, buf.String(), "\n  \"manifests\": [")
+	})
+
+	t.Run("digest only", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+		cmd := command.NewManifestCommand(cli)
+		cmd.SetArgs([]string{ref, "--digest"})
+		require.NoError(t, cmd.Execute())
+
+		var output map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &output))
+		assert.Equal(t, head.Digest.String(), output["digest"])
+		assert.Equal(t, string(head.MediaType), output["media_type"])
+		assert.NotContains(t, output, "content")
+	})
+
+	t.Run("unknown platform", func(t *testing.T) {
+		cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+		cmd := command.NewManifestCommand(cli)
+		cmd.SetArgs([]string{ref, "--platform", "linux/s390x"})
+
+		err := cmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no manifest found for platform linux/s390x")
+	})
+}
@@ -0,0 +1,145 @@
+package command
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bschaatsbergen/cek/internal/mirror"
+	"github.com/bschaatsbergen/cek/internal/tags"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+type MirrorOptions struct {
+	Tags      string
+	Semver    string
+	Prune     bool
+	DryRun    bool
+	StatePath string
+}
+
+func NewMirrorCommand(cli *CLI) *cobra.Command {
+	opts := MirrorOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "mirror <source-repository> <destination-repository>",
+		Short: "Mirror the tags of a repository to another registry",
+		Long: highlight("cek mirror golang registry.internal/mirror/golang --semver '>=1.24'") + "\n\n" +
+			"Sync tags from a source repository to a destination repository. Every\n" +
+			"platform is copied, so digests at the destination match the source.\n\n" +
+			"Syncs are incremental: tags that already point at the source digest\n" +
+			"are skipped, and tags whose manifest already exists at the destination\n" +
+			"are retagged without copying. Use --prune to delete destination tags\n" +
+			"that are gone from the source; only tags matching the filters are\n" +
+			"considered.\n\n" +
+			"Progress is recorded in a state file so an interrupted mirror resumes\n" +
+			"where it stopped. The file is removed once a mirror completes. Use\n" +
+			"--dry-run to see what would change.\n\n" +
+			"Registry credentials are read from the Docker config file and credential\n" +
+			"helpers.\n\n" +
+			"Examples:\n" +
+			"  cek mirror golang registry.internal/mirror/golang --semver '>=1.24'\n" +
+			"  cek mirror nginx registry.internal/mirror/nginx --tags '1\\.27.*-alpine'\n" +
+			"  cek mirror --prune --dry-run nginx registry.internal/mirror/nginx\n",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunMirror(cmd.Context(), cli, args[0], args[1], &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Tags, "tags", "", "Only mirror tags matching this regular expression")
+	cmd.Flags().StringVar(&opts.Semver, "semver", "", "Only mirror tags satisfying this semver constraint (e.g., '>=1.24')")
+	cmd.Flags().BoolVar(&opts.Prune, "prune", false, "Delete destination tags that are gone from the source")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Report what would change without changing anything")
+	cmd.Flags().StringVar(&opts.StatePath, "state", "", "State file used to resume an interrupted mirror (default: in the user cache directory)")
+
+	return cmd
+}
+
+func RunMirror(ctx context.Context, cli *CLI, source, destination string, opts *MirrorOptions) error {
+	logger := cli.Logger()
+
+	src, err := name.NewRepository(source)
+	if err != nil {
+		return fmt.Errorf("failed to parse source repository: %w", err)
+	}
+	dst, err := name.NewRepository(destination)
+	if err != nil {
+		return fmt.Errorf("failed to parse destination repository: %w", err)
+	}
+
+	filter, err := tags.NewFilter(opts.Tags, opts.Semver)
+	if err != nil {
+		return err
+	}
+
+	statePath := opts.StatePath
+	if statePath == "" {
+		statePath, err = defaultMirrorStatePath(src, dst)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Debug("Mirroring repository", "source", src.String(), "destination", dst.String(), "state", statePath, "dryRun", opts.DryRun)
+
+	steps, err := mirror.Sync(ctx, src, dst, &mirror.Options{
+		Filter:    filter,
+		Prune:     opts.Prune,
+		DryRun:    opts.DryRun,
+		StatePath: statePath,
+		RemoteOptions: []remote.Option{
+			remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		},
+	})
+	if err != nil && len(steps) == 0 {
+		return err
+	}
+
+	logger.Debug("Mirrored repository", "steps", len(steps))
+
+	data := &view.MirrorData{
+		Source:      src.String(),
+		Destination: dst.String(),
+		DryRun:      opts.DryRun,
+		Incomplete:  err != nil,
+		Steps:       make([]view.MirrorStep, len(steps)),
+	}
+	for i, s := range steps {
+		data.Steps[i] = view.MirrorStep{
+			Tag:    s.Tag,
+			Action: string(s.Action),
+			Digest: s.Digest.String(),
+		}
+	}
+
+	// A failed tag still reports the tags completed before it.
+	if renderErr := cli.Mirror().Render(data); renderErr != nil {
+		return renderErr
+	}
+	if err != nil {
+		if !opts.DryRun {
+			return fmt.Errorf("mirror stopped after %d tags, run it again to resume: %w", len(steps), err)
+		}
+		return err
+	}
+	return nil
+}
+
+// defaultMirrorStatePath returns a state file in the user cache directory
+// unique to the source and destination.
+func defaultMirrorStatePath(src, dst name.Repository) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory, use --state: %w", err)
+	}
+	sum := sha256.Sum256([]byte(src.String() + "\n" + dst.String()))
+	return filepath.Join(dir, "cek", "mirror", hex.EncodeToString(sum[:8])+".json"), nil
+}
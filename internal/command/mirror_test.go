@@ -0,0 +1,114 @@
+package command_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMirrorCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewMirrorCommand(cli)
+
+	assert.Equal(t, "mirror", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.Equal(t, "false", cmd.Flags().Lookup("prune").DefValue)
+	assert.Equal(t, "false", cmd.Flags().Lookup("dry-run").DefValue)
+	for _, flag := range []string{"tags", "semver", "state"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), flag)
+	}
+}
+
+func TestRunMirror(t *testing.T) {
+	host := newTestRegistry(t)
+	for _, tag := range []string{"1.23.0", "1.24.0", "1.24.1"} {
+		pushTestImage(t, host+"/golang:"+tag, newTestImage(t, map[string]string{"version": tag}))
+	}
+	state := filepath.Join(t.TempDir(), "state.json")
+
+	t.Run("dry run", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewMirrorCommand(cli)
+		cmd.SetArgs([]string{"--dry-run", "--semver", ">=1.24", "--state", state, host + "/golang", host + "/mirror/golang"})
+		require.NoError(t, cmd.Execute())
+
+		out := buf.String()
+		assert.Contains(t, out, "Dry run")
+		assert.Contains(t, out, "1.24.1")
+		assert.NotContains(t, out, "1.23.0")
+		assert.Contains(t, out, "2 copied, 0 retagged, 0 unchanged, 0 pruned")
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewMirrorCommand(cli)
+		cmd.SetArgs([]string{"--semver", ">=1.24", "--state", state, host + "/golang", host + "/mirror/golang"})
+		require.NoError(t, cmd.Execute())
+		assert.Contains(t, buf.String(), "2 copied")
+
+		buf.Reset()
+		cmd = command.NewMirrorCommand(cli)
+		cmd.SetArgs([]string{"--semver", ">=1.24", "--state", state, host + "/golang", host + "/mirror/golang"})
+		require.NoError(t, cmd.Execute())
+		assert.Contains(t, buf.String(), "0 copied, 0 retagged, 2 unchanged, 0 pruned")
+	})
+}
+
+func TestRunMirror_PartialReport(t *testing.T) {
+	handler := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Path == "/v2/mirror/golang/manifests/1.24.1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+	for _, tag := range []string{"1.24.0", "1.24.1"} {
+		pushTestImage(t, host+"/golang:"+tag, newTestImage(t, map[string]string{"version": tag}))
+	}
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewMirrorCommand(cli)
+	cmd.SetArgs([]string{"--state", filepath.Join(t.TempDir(), "state.json"), host + "/golang", host + "/mirror/golang"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, "mirror stopped after 1 tags")
+	assert.ErrorContains(t, err, "failed to mirror tag 1.24.1")
+
+	out := buf.String()
+	assert.Contains(t, out, "1.24.0")
+	assert.Contains(t, out, "1 copied, 0 retagged, 0 unchanged, 0 pruned")
+	assert.Contains(t, out, "Stopped before all tags were mirrored")
+}
+
+func TestRunMirror_InvalidConstraint(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewMirrorCommand(cli)
+	cmd.SetArgs([]string{"--semver", "nope", "src", "dst"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, "invalid semver constraint")
+}
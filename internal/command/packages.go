@@ -0,0 +1,145 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/packages"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/spf13/cobra"
+)
+
+type PackagesOptions struct {
+	Ecosystems []string
+	Platform   string
+	Pull       string
+
+	FromFile    string
+	Concurrency int
+	layers      *oci.LayerCache
+}
+
+func NewPackagesCommand(cli *CLI) *cobra.Command {
+	opts := PackagesOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "packages <image>",
+		Aliases: []string{"sbom"},
+		Short:   "List OS and language packages installed in an OCI image",
+		Long: highlight("cek packages node:22-alpine") + "\n\n" +
+			"List OS and language packages installed in an OCI image.\n\n" +
+			"Packages are detected from the merged overlay filesystem, so packages\n" +
+			"removed in a later layer are not reported. Supported ecosystems:\n" +
+			"  debian     dpkg status database (including distroless status.d)\n" +
+			"  alpine     apk installed database\n" +
+			"  rpm        rpm database (sqlite, Berkeley DB and NDB)\n" +
+			"  go         build info embedded in Go binaries\n" +
+			"  npm        node_modules/*/package.json\n" +
+			"  pypi       *.dist-info/METADATA and *.egg-info/PKG-INFO\n" +
+			"  maven      pom.properties inside jar, war and ear archives\n" +
+			"  rubygems   installed gem specifications\n" +
+			"  crates.io  Rust binaries built with cargo auditable\n\n" +
+			"The command is also available as cek sbom, e.g. to collect the package\n" +
+			"inventory of many images with cek sbom --from-file.\n\n" +
+			batchLong +
+			"Examples:\n" +
+			"  cek packages nginx:latest\n" +
+			"  cek packages --ecosystem go gcr.io/distroless/static-debian12\n" +
+			"  cek packages --ecosystem npm,pypi my-app:latest\n" +
+			"  cek packages --json python:3.12-slim\n" +
+			"  cek packages --from-file images.txt --concurrency 8 > inventory.ndjson\n" +
+			"  cek sbom --from-file images.txt > sbom.ndjson\n",
+		Args: batchArgs(&opts.FromFile, cobra.NoArgs, cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.FromFile != "" {
+				return runBatch(cmd.Context(), cli, opts.FromFile, cmd.InOrStdin(), opts.Concurrency,
+					func(ctx context.Context, cli *CLI, imageRef string, layers *oci.LayerCache) error {
+						imageOpts := opts
+						imageOpts.layers = layers
+						return RunPackages(ctx, cli, imageRef, &imageOpts)
+					})
+			}
+			imageRef := args[0]
+			return RunPackages(cmd.Context(), cli, imageRef, &opts)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.Ecosystems, "ecosystem", nil, "Only detect packages from these ecosystems (comma-separated)")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+	cmd.Flags().StringVar(&opts.FromFile, "from-file", "", "Read image references from a file, one per line (- for stdin)")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Maximum images processed at once (with --from-file)")
+
+	return cmd
+}
+
+func RunPackages(ctx context.Context, cli *CLI, imageRef string, opts *PackagesOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Detecting packages", "image", imageRef)
+
+	detectOpts, err := packageDetectOptions(logger, opts.Ecosystems)
+	if err != nil {
+		return err
+	}
+
+	fetchOpts := &oci.FetchOptions{
+		Platform:   opts.Platform,
+		PullPolicy: oci.PullPolicy(opts.Pull),
+		Layers:     opts.layers,
+	}
+	img, _, err := oci.FetchImage(ctx, imageRef, fetchOpts)
+	if err != nil {
+		return err
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return fmt.Errorf("failed to get layers: %w", err)
+	}
+
+	logger.Debug("Found layers", "count", len(layers))
+
+	pkgs, err := packages.Detect(layers, detectOpts)
+	if err != nil {
+		return fmt.Errorf("failed to detect packages: %w", err)
+	}
+
+	logger.Debug("Detected packages", "count", len(pkgs))
+
+	return cli.Packages().Render(&view.PackagesData{
+		ImageRef: imageRef,
+		Packages: packageInfos(pkgs),
+	})
+}
+
+func packageDetectOptions(logger view.Logger, names []string) (*packages.Options, error) {
+	detectOpts := &packages.Options{
+		Warn: func(path string, err error) {
+			logger.Warn("Failed to read package metadata", "path", path, "error", err)
+		},
+	}
+	for _, name := range names {
+		ecosystem, err := packages.ParseEcosystem(name)
+		if err != nil {
+			return nil, err
+		}
+		detectOpts.Ecosystems = append(detectOpts.Ecosystems, ecosystem)
+	}
+	return detectOpts, nil
+}
+
+func packageInfos(pkgs []packages.Package) []view.PackageInfo {
+	infos := make([]view.PackageInfo, len(pkgs))
+	for i, pkg := range pkgs {
+		infos[i] = view.PackageInfo{
+			Name:      pkg.Name,
+			Version:   pkg.Version,
+			Ecosystem: string(pkg.Ecosystem),
+			Source:    pkg.Source,
+			Path:      pkg.Path,
+			Layer:     pkg.Layer,
+		}
+	}
+	return infos
+}
@@ -0,0 +1,73 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPackagesCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewPackagesCommand(cli)
+
+	assert.Equal(t, "packages", cmd.Name())
+	assert.True(t, cmd.HasAlias("sbom"))
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.NotNil(t, cmd.Flags().Lookup("ecosystem"))
+	assert.Equal(t, "if-not-present", cmd.Flags().Lookup("pull").DefValue)
+}
+
+func TestPackagesCommand_RequiresImageArg(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewPackagesCommand(cli)
+	cmd.SetArgs([]string{})
+
+	err := cmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "accepts 1 arg(s)")
+}
+
+func TestPackagesCommand_UnknownEcosystem(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewPackagesCommand(cli)
+	cmd.SetArgs([]string{"alpine:latest", "--ecosystem", "cobol"})
+
+	err := cmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown ecosystem")
+}
+
+func TestRunPackages_JSON(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t,
+		map[string]string{"lib/apk/db/installed": "P:musl\nV:1.2.4-r2\n"},
+		map[string]string{"app/node_modules/lodash/package.json": `{"name":"lodash","version":"4.17.20"}`},
+	))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+	cmd := command.NewPackagesCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always", "--ecosystem", "npm"})
+	require.NoError(t, cmd.Execute())
+
+	var output struct {
+		Packages []struct {
+			Name      string `json:"name"`
+			Ecosystem string `json:"ecosystem"`
+			Layer     int    `json:"layer"`
+		} `json:"packages"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &output))
+	require.Len(t, output.Packages, 1)
+	assert.Equal(t, "lodash", output.Packages[0].Name)
+	assert.Equal(t, "npm", output.Packages[0].Ecosystem)
+	assert.Equal(t, 2, output.Packages[0].Layer)
+}
@@ -0,0 +1,238 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/tags"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+type PruneOptions struct {
+	KeepLast    int
+	OlderThan   string
+	Match       string
+	DryRun      bool
+	Yes         bool
+	Concurrency int
+
+	in     io.Reader
+	prompt io.Writer
+	// now is the reference time for --older-than, defaulting to the
+	// current time.
+	now time.Time
+}
+
+func NewPruneCommand(cli *CLI) *cobra.Command {
+	opts := PruneOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "prune <repository>",
+		Short: "Delete old tags from a registry repository",
+		Long: highlight("cek prune registry.internal/app --match 'pr-.*' --keep-last 10 --older-than 30d") + "\n\n" +
+			"Delete tags that are no longer needed, such as CI builds. Tags matching\n" +
+			"--match are candidates; the images they point at are ordered by their\n" +
+			"creation date, the newest --keep-last images are kept, and of the rest\n" +
+			"those older than --older-than are deleted. At least one of --keep-last\n" +
+			"and --older-than is required. Images without a creation date are kept.\n\n" +
+			"When every tag of an image is pruned, the manifest is deleted by digest.\n" +
+			"Otherwise only the pruned tags are deleted, so tags outside the match\n" +
+			"keep working.\n\n" +
+			"You are asked to confirm before anything is deleted. Use --yes to skip\n" +
+			"the prompt, or --dry-run to only show what would be deleted.\n\n" +
+			"Examples:\n" +
+			"  cek prune registry.internal/app --match 'pr-.*' --older-than 30d\n" +
+			"  cek prune registry.internal/app --match 'main-.*' --keep-last 10\n" +
+			"  cek prune --dry-run registry.internal/app --keep-last 5 --older-than 2w\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.in, opts.prompt = cmd.InOrStdin(), cmd.ErrOrStderr()
+			return RunPrune(cmd.Context(), cli, args[0], &opts)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.KeepLast, "keep-last", 0, "Keep the N most recently created images")
+	cmd.Flags().StringVar(&opts.OlderThan, "older-than", "", "Only delete images older than this age (e.g., 30d, 2w, 12h)")
+	cmd.Flags().StringVar(&opts.Match, "match", "", "Only consider tags matching this regular expression")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Show what would be deleted without deleting anything")
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Delete without asking for confirmation")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Maximum concurrent registry requests")
+
+	return cmd
+}
+
+func RunPrune(ctx context.Context, cli *CLI, repository string, opts *PruneOptions) error {
+	logger := cli.Logger()
+
+	if opts.KeepLast <= 0 && opts.OlderThan == "" {
+		return errors.New("prune needs --keep-last, --older-than or both")
+	}
+	var maxAge time.Duration
+	if opts.OlderThan != "" {
+		age, err := parseAge(opts.OlderThan)
+		if err != nil {
+			return err
+		}
+		maxAge = age
+	}
+	filter, err := tags.NewFilter(opts.Match, "")
+	if err != nil {
+		return err
+	}
+
+	repo, err := name.NewRepository(repository)
+	if err != nil {
+		return fmt.Errorf("failed to parse repository: %w", err)
+	}
+
+	// Resolve sets the context of each of its requests itself, so it only
+	// gets the credentials.
+	auth := remote.WithAuthFromKeychain(authn.DefaultKeychain)
+	remoteOpts := []remote.Option{remote.WithContext(ctx), auth}
+
+	tagList, err := remote.List(repo, remoteOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	logger.Debug("Resolving tags", "repository", repo.String(), "count", len(tagList))
+
+	// Resolve every tag, not only matching ones, to know whether an image
+	// is still referenced by a tag that is kept.
+	images, err := tags.Resolve(ctx, repo, tagList, opts.Concurrency, auth)
+	if err != nil {
+		return err
+	}
+
+	now := opts.now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	deletions, kept := planPrune(repo, images, filter, opts.KeepLast, maxAge, now)
+
+	data := &view.DeleteData{
+		Repository: repo.String(),
+		DryRun:     opts.DryRun,
+		Kept:       kept,
+	}
+	for _, d := range deletions {
+		data.Deletions = append(data.Deletions, view.Deletion{
+			Ref:     d.ref.String(),
+			Digest:  d.image.Digest.String(),
+			Tags:    d.tags,
+			Created: d.image.Created,
+		})
+	}
+
+	if opts.DryRun || len(deletions) == 0 {
+		return cli.Delete().Render(data)
+	}
+
+	if !opts.Yes {
+		ok, err := confirmDelete(opts.in, opts.prompt, fmt.Sprintf("Delete %d references from %s?", len(deletions), repo))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errAborted
+		}
+	}
+
+	for i, d := range deletions {
+		if err := oci.Delete(d.ref, remoteOpts...); err != nil {
+			if i > 0 {
+				return fmt.Errorf("deleted %d of %d references: %w", i, len(deletions), err)
+			}
+			return err
+		}
+		logger.Debug("Deleted", "ref", d.ref.String())
+	}
+
+	return cli.Delete().Render(data)
+}
+
+// pruneDeletion is a reference to delete and the tags it removes.
+type pruneDeletion struct {
+	ref   name.Reference
+	image *tags.Image
+	tags  []string
+}
+
+// planPrune selects the images whose matching tags are pruned, newest
+// first, and returns the references to delete and the number of matching
+// tags kept.
+func planPrune(repo name.Repository, images []*tags.Image, filter *tags.Filter, keepLast int, maxAge time.Duration, now time.Time) ([]pruneDeletion, int) {
+	type candidate struct {
+		image   *tags.Image
+		matched []string
+	}
+
+	var candidates []candidate
+	for _, img := range images {
+		if matched := filter.Apply(img.Tags); len(matched) > 0 {
+			candidates = append(candidates, candidate{image: img, matched: matched})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].image.Created.After(candidates[j].image.Created)
+	})
+
+	var deletions []pruneDeletion
+	kept := 0
+	for i, c := range candidates {
+		keep := i < keepLast ||
+			c.image.Created.IsZero() ||
+			(maxAge > 0 && now.Sub(c.image.Created) < maxAge)
+		if keep {
+			kept += len(c.matched)
+			continue
+		}
+
+		if len(c.matched) == len(c.image.Tags) {
+			deletions = append(deletions, pruneDeletion{
+				ref:   repo.Digest(c.image.Digest.String()),
+				image: c.image,
+				tags:  c.matched,
+			})
+			continue
+		}
+		for _, tag := range c.matched {
+			deletions = append(deletions, pruneDeletion{
+				ref:   repo.Tag(tag),
+				image: c.image,
+			})
+		}
+	}
+
+	return deletions, kept
+}
+
+// parseAge parses durations such as 30d, 2w and 12h. Days and weeks are
+// accepted in addition to the units of time.ParseDuration.
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid age %q: use e.g. 30d, 2w or 12h", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q: use e.g. 30d, 2w or 12h", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,127 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pushAgedImage pushes an image created age ago under every tag and returns
+// its digest.
+func pushAgedImage(t *testing.T, repo string, age time.Duration, tags ...string) v1.Hash {
+	t.Helper()
+
+	img, err := mutate.CreatedAt(newTestImage(t, map[string]string{"tag": tags[0]}), v1.Time{Time: time.Now().Add(-age)})
+	require.NoError(t, err)
+	for _, tag := range tags {
+		pushTestImage(t, repo+":"+tag, img)
+	}
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	return digest
+}
+
+func TestNewPruneCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewPruneCommand(cli)
+
+	assert.Equal(t, "prune", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	for _, flag := range []string{"keep-last", "older-than", "match", "dry-run", "yes", "concurrency"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), flag)
+	}
+}
+
+func TestRunPrune(t *testing.T) {
+	host := newTestRegistry(t)
+	repo := host + "/app"
+	day := 24 * time.Hour
+
+	// pr-1 shares its image with a release tag outside the match, so only
+	// the tag may go; pr-2 is the only tag of its image.
+	pushAgedImage(t, repo, 90*day, "pr-1", "release-1")
+	old := pushAgedImage(t, repo, 60*day, "pr-2")
+	pushAgedImage(t, repo, day, "pr-3")
+
+	t.Run("dry run keep last", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+		cmd := command.NewPruneCommand(cli)
+		cmd.SetArgs([]string{"--dry-run", "--match", "pr-.*", "--keep-last", "1", repo})
+		require.NoError(t, cmd.Execute())
+
+		var out struct {
+			DryRun  bool `json:"dry_run"`
+			Deleted []struct {
+				Ref  string   `json:"ref"`
+				Tags []string `json:"tags"`
+			} `json:"deleted"`
+			Kept int `json:"kept"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+		assert.True(t, out.DryRun)
+		assert.Equal(t, 1, out.Kept)
+		require.Len(t, out.Deleted, 2)
+		assert.Equal(t, repo+"@"+old.String(), out.Deleted[0].Ref)
+		assert.Equal(t, []string{"pr-2"}, out.Deleted[0].Tags)
+		assert.Equal(t, repo+":pr-1", out.Deleted[1].Ref)
+	})
+
+	t.Run("older than", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewPruneCommand(cli)
+		cmd.SetArgs([]string{"--yes", "--match", "pr-.*", "--older-than", "30d", repo})
+		require.NoError(t, cmd.Execute())
+		assert.Contains(t, buf.String(), "Deleted from")
+		assert.Contains(t, buf.String(), "1 tags kept")
+
+		head := func(ref string) error {
+			r, err := name.ParseReference(ref)
+			require.NoError(t, err)
+			_, err = remote.Head(r)
+			return err
+		}
+		assert.Error(t, head(repo+":pr-1"))
+		assert.Error(t, head(repo+"@"+old.String()))
+		assert.NoError(t, head(repo+":release-1"))
+		assert.NoError(t, head(repo+":pr-3"))
+	})
+}
+
+func TestRunPrune_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no policy", []string{"registry.internal/app"}, "--keep-last, --older-than"},
+		{"invalid age", []string{"--older-than", "soon", "registry.internal/app"}, `invalid age "soon"`},
+		{"invalid match", []string{"--keep-last", "1", "--match", "(", "registry.internal/app"}, "invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+			cmd := command.NewPruneCommand(cli)
+			cmd.SetArgs(tt.args)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			assert.ErrorContains(t, cmd.Execute(), tt.want)
+		})
+	}
+}
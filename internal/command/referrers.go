@@ -0,0 +1,131 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/referrers"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+type ReferrersOptions struct {
+	ArtifactType string
+	Platform     string
+}
+
+func NewReferrersCommand(cli *CLI) *cobra.Command {
+	opts := ReferrersOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "referrers <image>",
+		Short: "List artifacts attached to an image",
+		Long: highlight("cek referrers my-app:latest") + "\n\n" +
+			"List the artifacts attached to an image, such as signatures, SBOMs and\n" +
+			"provenance attestations, with their artifact type, digest and\n" +
+			"annotations.\n\n" +
+			"Artifacts are discovered through the OCI 1.1 referrers API. Registries\n" +
+			"without it are queried using the referrers tag schema instead. The\n" +
+			"sha256-<digest>.sig, .att and .sbom tags used by cosign are listed too.\n\n" +
+			"This queries the remote registry, not the local daemon. By default the\n" +
+			"digest the reference points to is used, which is the index for\n" +
+			"multi-platform images. Use --platform to list the artifacts attached to\n" +
+			"a single platform image instead.\n\n" +
+			"Examples:\n" +
+			"  cek referrers my-app:latest\n" +
+			"  cek referrers --platform linux/arm64 my-app:latest\n" +
+			"  cek referrers --artifact-type application/spdx+json my-app:latest\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			imageRef := args[0]
+			return RunReferrers(cmd.Context(), cli, imageRef, &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.ArtifactType, "artifact-type", "", "Only list artifacts of this artifact type")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+
+	return cmd
+}
+
+func RunReferrers(ctx context.Context, cli *CLI, imageRef string, opts *ReferrersOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Listing referrers", "image", imageRef)
+
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	remoteOpts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+
+	_, subject, err := resolveSubject(ref, opts.Platform, remoteOpts)
+	if err != nil {
+		return err
+	}
+
+	artifacts, err := referrers.List(ref.Context(), subject, remoteOpts...)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Listed referrers", "subject", subject.String(), "count", len(artifacts))
+
+	data := &view.ReferrersData{
+		ImageRef: imageRef,
+		Digest:   subject.String(),
+	}
+	for _, a := range artifacts {
+		if opts.ArtifactType != "" && a.ArtifactType != opts.ArtifactType {
+			continue
+		}
+		data.Artifacts = append(data.Artifacts, view.ReferrerArtifact{
+			Digest:       a.Digest.String(),
+			MediaType:    string(a.MediaType),
+			ArtifactType: a.ArtifactType,
+			Size:         a.Size,
+			Source:       string(a.Source),
+			Tag:          a.Tag,
+			Annotations:  a.Annotations,
+		})
+	}
+
+	return cli.Referrers().Render(data)
+}
+
+// resolveSubject resolves ref in the registry. It returns the descriptor
+// ref points to and the digest artifacts are looked up for: the child
+// manifest matching platform, or the top-level digest without a platform.
+func resolveSubject(ref name.Reference, platform string, remoteOpts []remote.Option) (*remote.Descriptor, v1.Hash, error) {
+	desc, err := remote.Get(ref, remoteOpts...)
+	if err != nil {
+		return nil, v1.Hash{}, fmt.Errorf("failed to fetch image: %w", err)
+	}
+
+	if platform == "" || !desc.MediaType.IsIndex() {
+		return desc, desc.Digest, nil
+	}
+
+	idx, err := desc.ImageIndex()
+	if err != nil {
+		return nil, v1.Hash{}, fmt.Errorf("failed to get image index: %w", err)
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, v1.Hash{}, fmt.Errorf("failed to get index manifest: %w", err)
+	}
+	child, err := oci.SelectPlatform(manifest, platform)
+	if err != nil {
+		return nil, v1.Hash{}, err
+	}
+
+	return desc, child.Digest, nil
+}
@@ -0,0 +1,92 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/referrers"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// attachTestArtifact attaches a single-layer artifact to ref through the
+// referrers API.
+func attachTestArtifact(t *testing.T, ref string, artifactType string, layerType types.MediaType, content string) {
+	t.Helper()
+
+	r, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	subject, err := remote.Head(r)
+	require.NoError(t, err)
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: static.NewLayer([]byte(content), layerType)})
+	require.NoError(t, err)
+	img = mutate.ConfigMediaType(img, types.MediaType(artifactType))
+	img = mutate.Subject(img, *subject).(v1.Image)
+
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(r.Context().Digest(digest.String()), img))
+}
+
+func TestNewReferrersCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewReferrersCommand(cli)
+
+	assert.Equal(t, "referrers", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.NotNil(t, cmd.Flags().Lookup("artifact-type"))
+	assert.NotNil(t, cmd.Flags().Lookup("platform"))
+}
+
+func TestRunReferrers(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{"app/main": "v1"}))
+	attachTestArtifact(t, ref, "application/spdx+json", "application/spdx+json", `{"spdxVersion":"SPDX-2.3"}`)
+	attachTestArtifact(t, ref, "application/vnd.example.report", "text/plain", "ok")
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+	cmd := command.NewReferrersCommand(cli)
+	cmd.SetArgs([]string{ref, "--artifact-type", "application/spdx+json"})
+	require.NoError(t, cmd.Execute())
+
+	var output struct {
+		Digest    string `json:"digest"`
+		Artifacts []struct {
+			ArtifactType string `json:"artifact_type"`
+			Source       string `json:"source"`
+		} `json:"artifacts"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &output))
+	assert.Contains(t, output.Digest, "sha256:")
+	require.Len(t, output.Artifacts, 1)
+	assert.Equal(t, "application/spdx+json", output.Artifacts[0].ArtifactType)
+	assert.Equal(t, string(referrers.SourceReferrers), output.Artifacts[0].Source)
+}
+
+func TestRunReferrers_None(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{"app/main": "v1"}))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewReferrersCommand(cli)
+	cmd.SetArgs([]string{ref})
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, buf.String(), "No artifacts attached")
+}
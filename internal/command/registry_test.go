@@ -0,0 +1,125 @@
+package command_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRegistry starts an in-process registry and returns its host, e.g.
+// "127.0.0.1:34567". Loopback registries are reached over plain HTTP.
+func newTestRegistry(t *testing.T) string {
+	t.Helper()
+
+	srv := httptest.NewServer(registry.New(
+		registry.Logger(log.New(io.Discard, "", 0)),
+		registry.WithReferrersSupport(true),
+	))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+// newAuthTestRegistry starts an in-process registry that requires basic
+// auth and points the default keychain at credentials for it.
+func newAuthTestRegistry(t *testing.T) string {
+	t.Helper()
+
+	handler := registry.New(
+		registry.Logger(log.New(io.Discard, "", 0)),
+		registry.WithReferrersSupport(true),
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "cek" || pass != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	dir := t.TempDir()
+	auth := base64.StdEncoding.EncodeToString([]byte("cek:secret"))
+	config := fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, host, auth)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0o600))
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	return host
+}
+
+// newTestImage builds an image with one layer per map, each mapping file
+// paths to contents.
+func newTestImage(t *testing.T, layers ...map[string]string) v1.Image {
+	t.Helper()
+
+	img := empty.Image
+	for _, files := range layers {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		// Entries are written in path order so equal maps give equal
+		// layer digests.
+		for _, p := range slices.Sorted(maps.Keys(files)) {
+			content := files[p]
+			require.NoError(t, tw.WriteHeader(&tar.Header{
+				Name:     p,
+				Mode:     0o644,
+				Size:     int64(len(content)),
+				Typeflag: tar.TypeReg,
+			}))
+			_, err := tw.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+
+		data := buf.Bytes()
+		layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		})
+		require.NoError(t, err)
+
+		img, err = mutate.AppendLayers(img, layer)
+		require.NoError(t, err)
+	}
+
+	cfg, err := img.ConfigFile()
+	require.NoError(t, err)
+	cfg = cfg.DeepCopy()
+	cfg.OS = "linux"
+	cfg.Architecture = "amd64"
+
+	img, err = mutate.ConfigFile(img, cfg)
+	require.NoError(t, err)
+	return img
+}
+
+// pushTestImage writes img to the test registry under ref and returns ref.
+func pushTestImage(t *testing.T, ref string, img v1.Image) string {
+	t.Helper()
+
+	r, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(r, img, remote.WithAuthFromKeychain(authn.DefaultKeychain)))
+	return ref
+}
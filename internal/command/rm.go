@@ -0,0 +1,121 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+// errAborted is returned when a deletion is declined at the prompt.
+var errAborted = errors.New("aborted, nothing was deleted (use --yes to skip the confirmation)")
+
+type RmOptions struct {
+	DryRun bool
+	Yes    bool
+
+	in     io.Reader
+	prompt io.Writer
+}
+
+func NewRmCommand(cli *CLI) *cobra.Command {
+	opts := RmOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "rm <image>",
+		Short: "Delete a tag or manifest from a registry",
+		Long: highlight("cek rm registry.internal/app:pr-1234") + "\n\n" +
+			"Delete an image from a registry. A tag reference deletes only that\n" +
+			"tag, while a digest reference deletes the manifest and, on most\n" +
+			"registries, every tag pointing at it.\n\n" +
+			"You are asked to confirm before anything is deleted. Use --yes to skip\n" +
+			"the prompt, e.g. in CI, or --dry-run to only show what would be deleted.\n\n" +
+			"Many registries disable deletion by default. Distribution based\n" +
+			"registries need REGISTRY_STORAGE_DELETE_ENABLED=true, and some only\n" +
+			"accept digest references.\n\n" +
+			"Examples:\n" +
+			"  cek rm registry.internal/app:pr-1234\n" +
+			"  cek rm --yes registry.internal/app@sha256:4c0e...\n" +
+			"  cek rm --dry-run registry.internal/app:pr-1234\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.in, opts.prompt = cmd.InOrStdin(), cmd.ErrOrStderr()
+			return RunRm(cmd.Context(), cli, args[0], &opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Show what would be deleted without deleting anything")
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Delete without asking for confirmation")
+
+	return cmd
+}
+
+func RunRm(ctx context.Context, cli *CLI, imageRef string, opts *RmOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Deleting image", "image", imageRef, "dryRun", opts.DryRun)
+
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	remoteOpts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+
+	desc, err := remote.Head(ref, remoteOpts...)
+	if err != nil {
+		if oci.IsNotFound(err) {
+			return fmt.Errorf("%s not found", ref)
+		}
+		return fmt.Errorf("failed to check %s: %w", ref, err)
+	}
+
+	data := &view.DeleteData{
+		Repository: ref.Context().String(),
+		DryRun:     opts.DryRun,
+		Deletions: []view.Deletion{{
+			Ref:    ref.String(),
+			Digest: desc.Digest.String(),
+		}},
+	}
+
+	if !opts.DryRun {
+		if !opts.Yes {
+			ok, err := confirmDelete(opts.in, opts.prompt, fmt.Sprintf("Delete %s (%s)?", ref, desc.Digest))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errAborted
+			}
+		}
+		if err := oci.Delete(ref, remoteOpts...); err != nil {
+			return err
+		}
+		logger.Debug("Deleted image", "ref", ref.String(), "digest", desc.Digest.String())
+	}
+
+	return cli.Delete().Render(data)
+}
+
+// confirmDelete asks for confirmation on the given streams, defaulting to
+// the terminal.
+func confirmDelete(in io.Reader, out io.Writer, question string) (bool, error) {
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+	return confirm(in, out, question)
+}
@@ -0,0 +1,129 @@
+package command_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newNoDeleteRegistry starts a registry that rejects deletes the way
+// distribution does when storage deletion is disabled.
+func newNoDeleteRegistry(t *testing.T) string {
+	t.Helper()
+
+	reg := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			_, _ = io.WriteString(w, `{"errors":[{"code":"UNSUPPORTED","message":"The operation is unsupported."}]}`)
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestNewRmCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewRmCommand(cli)
+
+	assert.Equal(t, "rm", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.Equal(t, "false", cmd.Flags().Lookup("dry-run").DefValue)
+	assert.Equal(t, "y", cmd.Flags().Lookup("yes").Shorthand)
+}
+
+func TestRunRm(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:pr-1", newTestImage(t, map[string]string{"app": "1"}))
+	exists := func() bool {
+		r, err := name.ParseReference(ref)
+		require.NoError(t, err)
+		_, err = remote.Head(r)
+		return err == nil
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewRmCommand(cli)
+		cmd.SetArgs([]string{"--dry-run", ref})
+		require.NoError(t, cmd.Execute())
+
+		assert.Contains(t, buf.String(), "Would delete from")
+		assert.Contains(t, buf.String(), ref)
+		assert.True(t, exists())
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+		cmd := command.NewRmCommand(cli)
+		cmd.SetIn(strings.NewReader("n\n"))
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{ref})
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		assert.ErrorContains(t, cmd.Execute(), "aborted")
+		assert.True(t, exists())
+	})
+
+	t.Run("confirmed", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		prompt := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewRmCommand(cli)
+		cmd.SetIn(strings.NewReader("y\n"))
+		cmd.SetErr(prompt)
+		cmd.SetArgs([]string{ref})
+		require.NoError(t, cmd.Execute())
+
+		assert.Contains(t, prompt.String(), "Delete "+ref)
+		assert.Contains(t, buf.String(), "Deleted from")
+		assert.False(t, exists())
+	})
+}
+
+func TestRunRm_NotFound(t *testing.T) {
+	host := newTestRegistry(t)
+
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewRmCommand(cli)
+	cmd.SetArgs([]string{"--yes", host + "/app:missing"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	assert.ErrorContains(t, cmd.Execute(), "not found")
+}
+
+func TestRunRm_DeleteDisabled(t *testing.T) {
+	host := newNoDeleteRegistry(t)
+	ref := pushTestImage(t, host+"/app:pr-1", newTestImage(t, map[string]string{"app": "1"}))
+
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewRmCommand(cli)
+	cmd.SetArgs([]string{"--yes", ref})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, "does not allow deleting")
+	assert.ErrorContains(t, err, "REGISTRY_STORAGE_DELETE_ENABLED")
+}
@@ -135,5 +135,27 @@ func AddCommands(root *cobra.Command, cli *CLI) {
 		NewTagsCommand(cli),
 		NewExportCommand(cli),
 		NewTreeCommand(cli),
+		NewPackagesCommand(cli),
+		NewVulnCommand(cli),
+		NewSecretsCommand(cli),
+		NewLintCommand(cli),
+		NewVerifyCommand(cli),
+		NewReferrersCommand(cli),
+		NewAttestationsCommand(cli),
+		NewManifestCommand(cli),
+		NewConfigCommand(cli),
+		NewCopyCommand(cli),
+		NewMirrorCommand(cli),
+		NewCatalogCommand(cli),
+		NewRmCommand(cli),
+		NewPruneCommand(cli),
+		NewWatchCommand(cli),
+		NewLockCommand(cli),
+		NewDiffCommand(cli),
+		NewLayersCommand(cli),
+		NewBaseCommand(cli),
+		NewBrowseCommand(cli),
+		NewShellCommand(cli),
+		NewServeCommand(cli),
 	)
 }
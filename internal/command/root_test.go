@@ -59,7 +59,7 @@ func TestAddCommands(t *testing.T) {
 	root := command.NewRootCommand()
 	command.AddCommands(root, cli)
 
-	expectedCommands := []string{"version", "inspect", "ls", "cat", "tree", "tags", "export"}
+	expectedCommands := []string{"version", "inspect", "ls", "cat", "tree", "tags", "export", "packages", "vuln", "secrets", "lint", "verify", "referrers", "attestations", "manifest", "config", "copy", "mirror", "catalog", "rm", "prune", "watch", "lock", "diff", "layers", "base", "browse", "shell", "serve"}
 	for _, name := range expectedCommands {
 		cmd, _, err := root.Find([]string{name})
 		assert.NoError(t, err, "command %s should exist", name)
@@ -73,5 +73,5 @@ func TestAddCommands_Count(t *testing.T) {
 	command.AddCommands(root, cli)
 
 	assert.True(t, root.HasSubCommands())
-	assert.Len(t, root.Commands(), 7)
+	assert.Len(t, root.Commands(), 29)
 }
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/secrets"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/spf13/cobra"
+)
+
+type SecretsOptions struct {
+	Rules      []string
+	NoDefaults bool
+	Platform   string
+	Pull       string
+}
+
+func NewSecretsCommand(cli *CLI) *cobra.Command {
+	opts := SecretsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "secrets <image>",
+		Short: "Scan image layers and config for leaked secrets",
+		Long: highlight("cek secrets my-app:latest") + "\n\n" +
+			"Scan every layer of an OCI image for private keys, cloud credentials,\n" +
+			"tokens and sensitive files such as .env, .npmrc and .docker/config.json.\n\n" +
+			"Each layer is scanned individually, so files deleted by a later layer\n" +
+			"are still reported: they remain in the image and can be extracted by\n" +
+			"anyone who pulls it. Such findings are marked as removed.\n\n" +
+			"The config Env and the history created_by strings are scanned too.\n" +
+			"Findings include the layer index, so they can be traced back to the\n" +
+			"Dockerfile step that introduced them.\n\n" +
+			"Add your own rules with --rule id=regex. When the regex has a capture\n" +
+			"group, the first group is reported as the secret.\n\n" +
+			"Examples:\n" +
+			"  cek secrets my-app:latest\n" +
+			"  cek secrets --rule 'internal-token=acme_[a-z0-9]{32}' my-app:latest\n" +
+			"  cek secrets --no-defaults --rule 'db-url=postgres://[^ ]+' my-app:latest\n" +
+			"  cek secrets --json my-app:latest\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			imageRef := args[0]
+			return RunSecrets(cmd.Context(), cli, imageRef, &opts)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&opts.Rules, "rule", nil, "Additional rule as id=regex (repeatable)")
+	cmd.Flags().BoolVar(&opts.NoDefaults, "no-defaults", false, "Disable the built-in rule set")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+
+	return cmd
+}
+
+func RunSecrets(ctx context.Context, cli *CLI, imageRef string, opts *SecretsOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Scanning image for secrets", "image", imageRef)
+
+	var rules []secrets.Rule
+	if !opts.NoDefaults {
+		rules = append(rules, secrets.DefaultRules...)
+	}
+	for _, r := range opts.Rules {
+		rule, err := secrets.ParseRule(r)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("no rules to scan with: --no-defaults requires at least one --rule")
+	}
+
+	fetchOpts := &oci.FetchOptions{
+		Platform:   opts.Platform,
+		PullPolicy: oci.PullPolicy(opts.Pull),
+	}
+	img, _, err := oci.FetchImage(ctx, imageRef, fetchOpts)
+	if err != nil {
+		return err
+	}
+
+	findings, err := secrets.NewScanner(rules).ScanImage(img)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Scan complete", "findings", len(findings))
+
+	data := &view.SecretsData{
+		ImageRef: imageRef,
+		Findings: make([]view.SecretFinding, len(findings)),
+	}
+	for i, f := range findings {
+		data.Findings[i] = view.SecretFinding{
+			RuleID:      f.RuleID,
+			Description: f.Description,
+			Source:      string(f.Source),
+			Layer:       f.Layer,
+			Path:        f.Path,
+			Line:        f.Line,
+			Secret:      f.Secret,
+			Removed:     f.Removed,
+		}
+	}
+
+	return cli.Secrets().Render(data)
+}
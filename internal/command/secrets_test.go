@@ -0,0 +1,53 @@
+package command_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSecretsCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewSecretsCommand(cli)
+
+	assert.Equal(t, "secrets", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.NotNil(t, cmd.Flags().Lookup("rule"))
+	assert.Equal(t, "false", cmd.Flags().Lookup("no-defaults").DefValue)
+}
+
+func TestSecretsCommand_NoRules(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewSecretsCommand(cli)
+	cmd.SetArgs([]string{"alpine:latest", "--no-defaults"})
+
+	err := cmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requires at least one --rule")
+}
+
+func TestRunSecrets_RemovedFile(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t,
+		map[string]string{"app/.env": "SECRET=1\n"},
+		map[string]string{"app/.wh..env": ""},
+	))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewSecretsCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always"})
+	require.NoError(t, cmd.Execute())
+
+	output := buf.String()
+	assert.Contains(t, output, "/app/.env (removed)")
+	assert.Contains(t, output, "dotenv-file")
+	assert.Contains(t, output, "1 secrets found")
+}
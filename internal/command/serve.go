@@ -0,0 +1,293 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/bschaatsbergen/cek/internal/web"
+	"github.com/spf13/cobra"
+)
+
+type ServeOptions struct {
+	Addr   string
+	Pull   string
+	layers *oci.LayerCache
+}
+
+func NewServeCommand(cli *CLI) *cobra.Command {
+	opts := ServeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Explore images in a local web UI",
+		Long: highlight("cek serve --addr 127.0.0.1:8080") + "\n\n" +
+			"Serve a web app for exploring images from the browser: enter an image\n" +
+			"to see its layers, build history and file tree, view files with syntax\n" +
+			"highlighting, and compare two images.\n\n" +
+			"The app is backed by a JSON API whose payloads are the --json output\n" +
+			"of the command of the same name:\n" +
+			"  GET /api/inspect?image=<ref>[&platform=]\n" +
+			"  GET /api/config?image=<ref>[&platform=]\n" +
+			"  GET /api/ls?image=<ref>[&platform=][&layer=][&path=][&filter=]\n" +
+			"  GET /api/cat?image=<ref>&path=<file>[&platform=][&layer=]\n" +
+			"  GET /api/diff?old=<ref>&new=<ref>[&platform=]\n" +
+			"Errors are returned as {\"error\": \"...\"} with a 4xx or 5xx status.\n\n" +
+			"Layers are cached on disk while the server runs, so browsing an image\n" +
+			"fetches each layer once. The server has no authentication and uses\n" +
+			"your registry credentials, so it listens on localhost by default.\n" +
+			"Requests are only answered when their Host header names the listen\n" +
+			"address, localhost or 127.0.0.1, which keeps other websites from\n" +
+			"reaching the API through DNS rebinding.\n\n" +
+			"Examples:\n" +
+			"  cek serve\n" +
+			"  cek serve --addr 127.0.0.1:9000\n" +
+			"  curl 'localhost:8080/api/inspect?image=nginx:latest'\n",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunServe(cmd.Context(), cli, &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Addr, "addr", "127.0.0.1:8080", "Address to listen on")
+	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+
+	return cmd
+}
+
+func RunServe(ctx context.Context, cli *CLI, opts *ServeOptions) error {
+	logger := cli.Logger()
+
+	dir, err := os.MkdirTemp("", "cek-layers-")
+	if err != nil {
+		return fmt.Errorf("failed to create layer cache: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	serveOpts := *opts
+	serveOpts.layers = oci.NewLayerCache(dir)
+
+	listener, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	server := &http.Server{
+		Handler:           NewServeHandler(cli, &serveOpts),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Stop on interrupt so the layer cache is removed.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	cli.Printf("Serving cek on http://%s\n", listener.Addr())
+	logger.Debug("Layer cache", "dir", dir)
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
+
+// NewServeHandler returns the web app and its JSON API. Each API call runs
+// a command with a JSON view into a buffer, so payloads are exactly what
+// the command prints with --json.
+func NewServeHandler(cli *CLI, opts *ServeOptions) http.Handler {
+	mux := newServeMux(cli, opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowedHost(r.Host, opts.Addr) {
+			cli.Logger().Debug("Rejected request", "host", r.Host, "path", r.URL.Path)
+			writeAPIError(w, http.StatusForbidden, fmt.Errorf("invalid Host header %q", r.Host))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost reports whether a request for host may be served by a server
+// listening on addr. A page on another site can make the browser resolve its
+// own name to the loopback address, so only names the user could have typed
+// for this server are accepted. When listening on every interface, IP
+// addresses are accepted too, since those cannot be rebound.
+func allowedHost(host, addr string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	switch host {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+
+	listenHost, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if listenHost == "" || net.ParseIP(listenHost).IsUnspecified() {
+		return net.ParseIP(host) != nil
+	}
+	return strings.EqualFold(host, listenHost)
+}
+
+func newServeMux(cli *CLI, opts *ServeOptions) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("GET /", web.Handler())
+
+	api := func(pattern string, run func(ctx context.Context, cli *CLI, q url.Values) error) {
+		mux.HandleFunc("GET /api/"+pattern, func(w http.ResponseWriter, r *http.Request) {
+			cli.Logger().Debug("API request", "path", r.URL.Path, "query", r.URL.RawQuery)
+
+			buf := new(bytes.Buffer)
+			err := run(r.Context(), NewCLI(view.ViewJSON, buf, view.LogLevelSilent), r.URL.Query())
+			if err != nil {
+				status := http.StatusInternalServerError
+				var badRequest *apiError
+				switch {
+				case errors.As(err, &badRequest):
+					status = http.StatusBadRequest
+				case oci.IsNotFound(err):
+					status = http.StatusNotFound
+				}
+				cli.Logger().Debug("API request failed", "path", r.URL.Path, "error", err)
+				writeAPIError(w, status, err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(buf.Bytes())
+		})
+	}
+
+	api("inspect", func(ctx context.Context, cli *CLI, q url.Values) error {
+		image, err := requireParam(q, "image")
+		if err != nil {
+			return err
+		}
+		// The web app shows no OS details, so OS detection, which reads
+		// every layer, is left off.
+		return RunInspect(ctx, cli, image, &InspectOptions{
+			Platform: q.Get("platform"),
+			Pull:     opts.Pull,
+			layers:   opts.layers,
+		})
+	})
+	api("config", func(ctx context.Context, cli *CLI, q url.Values) error {
+		image, err := requireParam(q, "image")
+		if err != nil {
+			return err
+		}
+		return RunConfig(ctx, cli, image, &ConfigOptions{Platform: q.Get("platform")})
+	})
+	api("ls", func(ctx context.Context, cli *CLI, q url.Values) error {
+		image, err := requireParam(q, "image")
+		if err != nil {
+			return err
+		}
+		layer, err := layerParam(q)
+		if err != nil {
+			return err
+		}
+		return RunLs(ctx, cli, image, &LsOptions{
+			Layer:    layer,
+			Filter:   q.Get("filter"),
+			Platform: q.Get("platform"),
+			Pull:     opts.Pull,
+			Path:     q.Get("path"),
+			layers:   opts.layers,
+		})
+	})
+	api("cat", func(ctx context.Context, cli *CLI, q url.Values) error {
+		image, err := requireParam(q, "image")
+		if err != nil {
+			return err
+		}
+		path, err := requireParam(q, "path")
+		if err != nil {
+			return err
+		}
+		layer, err := layerParam(q)
+		if err != nil {
+			return err
+		}
+		return RunCat(ctx, cli, image, path, &CatOptions{
+			Layer:    layer,
+			Platform: q.Get("platform"),
+			Pull:     opts.Pull,
+			layers:   opts.layers,
+		})
+	})
+	api("diff", func(ctx context.Context, cli *CLI, q url.Values) error {
+		oldRef, err := requireParam(q, "old")
+		if err != nil {
+			return err
+		}
+		newRef, err := requireParam(q, "new")
+		if err != nil {
+			return err
+		}
+		return RunDiff(ctx, cli, oldRef, newRef, &DiffOptions{
+			Config:   true,
+			Platform: q.Get("platform"),
+			Pull:     opts.Pull,
+			layers:   opts.layers,
+		})
+	})
+
+	return mux
+}
+
+// apiError is a request the API cannot serve as given.
+type apiError struct {
+	msg string
+}
+
+func (e *apiError) Error() string {
+	return e.msg
+}
+
+func requireParam(q url.Values, name string) (string, error) {
+	value := q.Get(name)
+	if value == "" {
+		return "", &apiError{msg: fmt.Sprintf("missing %s parameter", name)}
+	}
+	return value, nil
+}
+
+// layerParam returns the 1-indexed layer parameter, or -1 for the merged
+// filesystem when it is absent.
+func layerParam(q url.Values) (int, error) {
+	value := q.Get("layer")
+	if value == "" {
+		return -1, nil
+	}
+	layer, err := strconv.Atoi(value)
+	if err != nil || layer < 1 {
+		return 0, &apiError{msg: fmt.Sprintf("invalid layer %q", value)}
+	}
+	return layer, nil
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+}
@@ -0,0 +1,130 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getJSON(t *testing.T, srv *httptest.Server, path string, params url.Values) (int, map[string]any) {
+	t.Helper()
+
+	resp, err := http.Get(srv.URL + path + "?" + params.Encode())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var data map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&data))
+	return resp.StatusCode, data
+}
+
+func TestServeHandler(t *testing.T) {
+	host := newTestRegistry(t)
+	oldRef := pushTestImage(t, host+"/app:1", newTestImage(t,
+		map[string]string{"etc/os-release": "ID=alpine\n"},
+	))
+	newRef := pushTestImage(t, host+"/app:2", newTestImage(t,
+		map[string]string{"etc/os-release": "ID=alpine\n"},
+		map[string]string{"app/config.yaml": "port: 8080\n"},
+	))
+
+	cli := command.NewCLI(view.ViewHuman, new(bytes.Buffer), view.LogLevelSilent)
+	srv := httptest.NewServer(command.NewServeHandler(cli, &command.ServeOptions{Pull: "always"}))
+	t.Cleanup(srv.Close)
+
+	t.Run("web app", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), "<title>cek</title>")
+	})
+
+	t.Run("inspect", func(t *testing.T) {
+		status, data := getJSON(t, srv, "/api/inspect", url.Values{"image": {newRef}})
+		require.Equal(t, http.StatusOK, status, data)
+		assert.Equal(t, newRef, data["image"])
+		assert.Len(t, data["layers"], 2)
+		assert.NotContains(t, data, "os_info")
+	})
+
+	t.Run("ls", func(t *testing.T) {
+		status, data := getJSON(t, srv, "/api/ls", url.Values{"image": {newRef}, "layer": {"2"}})
+		require.Equal(t, http.StatusOK, status, data)
+		files := data["files"].([]any)
+		require.Len(t, files, 1)
+		assert.Equal(t, "/app/config.yaml", files[0].(map[string]any)["path"])
+	})
+
+	t.Run("cat", func(t *testing.T) {
+		status, data := getJSON(t, srv, "/api/cat", url.Values{"image": {newRef}, "path": {"/app/config.yaml"}})
+		require.Equal(t, http.StatusOK, status, data)
+		assert.Equal(t, "port: 8080\n", data["content"])
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		status, data := getJSON(t, srv, "/api/diff", url.Values{"old": {oldRef}, "new": {newRef}})
+		require.Equal(t, http.StatusOK, status, data)
+		assert.Len(t, data["layers"], 2)
+	})
+
+	t.Run("missing parameter", func(t *testing.T) {
+		status, data := getJSON(t, srv, "/api/cat", url.Values{"image": {newRef}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "missing path parameter", data["error"])
+	})
+
+	t.Run("invalid layer", func(t *testing.T) {
+		status, data := getJSON(t, srv, "/api/ls", url.Values{"image": {newRef}, "layer": {"x"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, `invalid layer "x"`, data["error"])
+	})
+
+	t.Run("unknown image", func(t *testing.T) {
+		status, data := getJSON(t, srv, "/api/inspect", url.Values{"image": {host + "/missing:1"}})
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.NotEmpty(t, data["error"])
+	})
+}
+
+func TestServeHandler_Host(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, new(bytes.Buffer), view.LogLevelSilent)
+
+	tests := []struct {
+		name   string
+		addr   string
+		host   string
+		status int
+	}{
+		{"localhost", "127.0.0.1:8080", "localhost:8080", http.StatusOK},
+		{"loopback", "127.0.0.1:8080", "127.0.0.1:8080", http.StatusOK},
+		{"listen address", "devbox:8080", "devbox:8080", http.StatusOK},
+		{"rebound name", "127.0.0.1:8080", "attacker.example:8080", http.StatusForbidden},
+		{"ip on specific address", "127.0.0.1:8080", "10.0.0.5:8080", http.StatusForbidden},
+		{"ip on every interface", "0.0.0.0:8080", "10.0.0.5:8080", http.StatusOK},
+		{"name on every interface", ":8080", "attacker.example:8080", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := command.NewServeHandler(cli, &command.ServeOptions{Addr: tt.addr})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/shell"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+type ShellOptions struct {
+	Platform string
+	Pull     string
+}
+
+func NewShellCommand(cli *CLI) *cobra.Command {
+	opts := ShellOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "shell <image>",
+		Short: "Explore the filesystem of an image from an interactive prompt",
+		Long: highlight("cek shell nginx:latest") + "\n\n" +
+			"Load an image once and explore its filesystem from a prompt. The\n" +
+			"entries of every layer are kept in memory, so commands run without\n" +
+			"fetching or scanning the image again; file contents are read from the\n" +
+			"layers on demand.\n\n" +
+			"Commands:\n" +
+			"  cd, pwd, ls [-l]     move around and list directories\n" +
+			"  cat, stat            print files and show entry details\n" +
+			"  find, grep           find entries by name or type, search contents\n" +
+			"  layer [n]            list layers, or view the image as of layer n\n" +
+			"  diff <path>          show the layers that changed a path\n\n" +
+			"Tab completes commands and paths, and the arrow keys recall earlier\n" +
+			"commands. When standard input is not a terminal, commands are read from\n" +
+			"it one per line, stopping at the first that fails.\n\n" +
+			"Besides registry references, the image can be read from an OCI layout,\n" +
+			"a docker save archive or the container daemon using the transports of\n" +
+			"cek copy.\n\n" +
+			"Examples:\n" +
+			"  cek shell nginx:latest\n" +
+			"  cek shell --platform linux/arm64 golang:1.24\n" +
+			"  echo 'grep -l nginx /etc' | cek shell nginx:latest\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunShell(cmd.Context(), cli, args[0], cmd.InOrStdin(), &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+
+	return cmd
+}
+
+func RunShell(ctx context.Context, cli *CLI, imageRef string, in io.Reader, opts *ShellOptions) error {
+	logger := cli.Logger()
+
+	if _, ok := cli.Viewer.(*view.JSONView); ok {
+		return errors.New("shell is interactive and does not support --json")
+	}
+
+	// File contents are read from the layers for every cat and grep, so
+	// registry layers are cached on disk.
+	dir, err := os.MkdirTemp("", "cek-layers-")
+	if err != nil {
+		return fmt.Errorf("failed to create layer cache: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	img, err := readImage(ctx, imageRef, opts.Platform, opts.Pull, oci.NewLayerCache(dir))
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Indexing layers", "image", imageRef)
+	index, err := shell.Load(imageRef, img)
+	if err != nil {
+		return err
+	}
+	if len(index.Layers) == 0 {
+		return fmt.Errorf("image %s has no layers", imageRef)
+	}
+
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		return runInteractiveShell(index, f, cli.Writer)
+	}
+
+	sh := shell.New(index, cli.Writer)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := sh.Exec(line); err != nil {
+			if errors.Is(err, shell.ErrExit) {
+				return nil
+			}
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read commands: %w", err)
+	}
+	return nil
+}
+
+// runInteractiveShell reads commands from the terminal in until the user
+// exits, printing the errors of failed commands.
+func runInteractiveShell(index *shell.Index, in *os.File, out io.Writer) error {
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to open terminal: %w", err)
+	}
+	defer func() {
+		_ = term.Restore(int(in.Fd()), state)
+	}()
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{in, out}, "")
+	sh := shell.New(index, t)
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		newLine, newPos, candidates := sh.Complete(line, pos)
+		if len(candidates) > 0 {
+			_, _ = fmt.Fprintln(t, strings.Join(candidates, "  "))
+		}
+		return newLine, newPos, true
+	}
+
+	if width, height, err := term.GetSize(int(in.Fd())); err == nil && width > 0 {
+		_ = t.SetSize(width, height)
+	}
+
+	files := 0
+	for _, l := range index.Layers {
+		files += l.Files
+	}
+	_, _ = fmt.Fprintf(t, "%s: %d layers, %d files. Type help for commands.\n", index.Ref, len(index.Layers), files)
+
+	for {
+		t.SetPrompt(sh.Prompt())
+		line, err := t.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read command: %w", err)
+		}
+		if err := sh.Exec(line); err != nil {
+			if errors.Is(err, shell.ErrExit) {
+				return nil
+			}
+			_, _ = fmt.Fprintln(t, err)
+		}
+	}
+}
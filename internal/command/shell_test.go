@@ -0,0 +1,66 @@
+package command_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runShell(t *testing.T, ref, script string) (string, error) {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewShellCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always"})
+	cmd.SetIn(strings.NewReader(script))
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	err := cmd.Execute()
+	return buf.String(), err
+}
+
+func TestShellCommand(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1", newTestImage(t,
+		map[string]string{"etc/os-release": "ID=alpine\n"},
+		map[string]string{"app/config.yaml": "port: 8080\n"},
+	))
+
+	t.Run("script", func(t *testing.T) {
+		out, err := runShell(t, ref, "# explore\ncd /app\nls\n\ncat config.yaml\nlayer 1\nls /\n")
+		require.NoError(t, err)
+		assert.Equal(t, "config.yaml\nport: 8080\nViewing the image as of layer 1 of 2\netc/\n", out)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		out, err := runShell(t, ref, "cat /nope\npwd\n")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "/nope")
+		assert.Empty(t, out)
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		out, err := runShell(t, ref, "exit\npwd\n")
+		require.NoError(t, err)
+		assert.Empty(t, out)
+	})
+}
+
+func TestShellCommand_RejectsJSON(t *testing.T) {
+	cli := command.NewCLI(view.ViewJSON, new(bytes.Buffer), view.LogLevelSilent)
+	cmd := command.NewShellCommand(cli)
+	cmd.SetArgs([]string{"nginx:latest"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support --json")
+}
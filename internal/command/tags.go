@@ -4,14 +4,23 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/bschaatsbergen/cek/internal/tags"
 	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/spf13/cobra"
 )
 
 type TagsOptions struct {
-	Limit int
+	Limit       int
+	Sort        string
+	Semver      string
+	Match       string
+	Latest      bool
+	Prerelease  bool
+	Long        bool
+	Concurrency int
 }
 
 func NewTagsCommand(cli *CLI) *cobra.Command {
@@ -22,6 +31,16 @@ func NewTagsCommand(cli *CLI) *cobra.Command {
 		Short: "List all tags for an image repository",
 		Long: highlight("cek tags nginx") + "\n\n" +
 			"List all tags for an image repository from the registry.\n\n" +
+			"Tags are sorted newest first by semantic version, so 1.10 comes before\n" +
+			"1.9. Variants such as -alpine and -slim are listed in their own groups,\n" +
+			"and tags that are not versions, like latest, follow the versions. Use\n" +
+			"--sort date for date-stamped tags, or --sort lexical for name order.\n\n" +
+			"Prereleases such as 1.25.0-rc1 are hidden unless --prerelease is set.\n" +
+			"--latest prints only the highest stable version.\n\n" +
+			"Use --long to resolve each tag and show its digest, media type, platforms\n" +
+			"and creation date. Tags sharing a digest are shown together, so aliases\n" +
+			"such as latest, 1.27 and 1.27.3 are easy to spot. Combine it with\n" +
+			"--limit or filters on large repositories.\n\n" +
 			"This queries the remote registry, not the local daemon.\n" +
 			"For large repositories with many tags, pipe to less for pagination:\n" +
 			"  cek tags nginx | less\n\n" +
@@ -29,7 +48,10 @@ func NewTagsCommand(cli *CLI) *cobra.Command {
 			"  cek tags nginx\n" +
 			"  cek tags gcr.io/distroless/static-debian12\n" +
 			"  cek tags nginx | less\n" +
-			"  cek tags nginx | grep '^1\\.2'",
+			"  cek tags golang --semver '~1.25'\n" +
+			"  cek tags nginx --match '.*-alpine' --latest\n" +
+			"  cek tags --sort date myorg/nightly\n" +
+			"  cek tags nginx --long --limit 20\n",
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			imageRef := args[0]
@@ -38,6 +60,13 @@ func NewTagsCommand(cli *CLI) *cobra.Command {
 	}
 
 	cmd.Flags().IntVar(&opts.Limit, "limit", 0, "Limit the number of tags returned (0 = unlimited)")
+	cmd.Flags().StringVar(&opts.Sort, "sort", string(tags.OrderSemver), "Sort order: semver, lexical or date")
+	cmd.Flags().StringVar(&opts.Semver, "semver", "", "Only show tags satisfying this semver constraint (e.g., '~1.25')")
+	cmd.Flags().StringVar(&opts.Match, "match", "", "Only show tags matching this regular expression")
+	cmd.Flags().BoolVar(&opts.Latest, "latest", false, "Only show the highest stable version")
+	cmd.Flags().BoolVar(&opts.Prerelease, "prerelease", false, "Include prereleases such as 1.25.0-rc1")
+	cmd.Flags().BoolVarP(&opts.Long, "long", "l", false, "Show the digest, platforms and creation date of each tag")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Maximum concurrent registry requests with --long")
 
 	return cmd
 }
@@ -54,32 +83,75 @@ func RunTags(ctx context.Context, cli *CLI, imageRef string, opts *TagsOptions)
 
 	repo := ref.Context()
 
-	logger.Debug("Fetching tags from registry", "repository", repo.String())
+	filter, err := tags.NewFilter(opts.Match, opts.Semver)
+	if err != nil {
+		return err
+	}
+	filter.IgnoreVariants = true
+	filter.ExcludePrereleases = !opts.Prerelease
 
-	// List tags from remote registry
-	remoteOpts := []remote.Option{
-		remote.WithContext(ctx),
+	order, err := tags.ParseOrder(opts.Sort)
+	if err != nil {
+		return err
 	}
 
-	tags, err := remote.List(repo, remoteOpts...)
+	logger.Debug("Fetching tags from registry", "repository", repo.String())
+
+	// List tags from remote registry. Resolve sets the context of each of
+	// its requests itself, so it only gets the credentials.
+	auth := remote.WithAuthFromKeychain(authn.DefaultKeychain)
+	remoteOpts := []remote.Option{remote.WithContext(ctx), auth}
+
+	tagList, err := remote.List(repo, remoteOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to list tags: %w", err)
 	}
 
-	// Reverse to display newest tags first.
-	// Users typically care most about the latest versions.
-	for i, j := 0, len(tags)-1; i < j; i, j = i+1, j-1 {
-		tags[i], tags[j] = tags[j], tags[i]
+	tagList = filter.Apply(tagList)
+
+	data := &view.TagsData{
+		Repository: repo.String(),
+	}
+
+	if opts.Latest {
+		latest, ok := tags.Latest(tagList)
+		if !ok {
+			return fmt.Errorf("no stable version found among %d matching tags", len(tagList))
+		}
+		logger.Debug("Found latest tag", "tag", latest)
+		data.Tags = []string{latest}
+	} else {
+		for _, group := range tags.Sort(tagList, order) {
+			if opts.Limit > 0 && len(data.Tags)+len(group.Tags) > opts.Limit {
+				group.Tags = group.Tags[:opts.Limit-len(data.Tags)]
+			}
+			if len(group.Tags) == 0 {
+				break
+			}
+			data.Tags = append(data.Tags, group.Tags...)
+			data.Groups = append(data.Groups, view.TagGroup(group))
+		}
 	}
 
-	if opts.Limit > 0 && opts.Limit < len(tags) {
-		tags = tags[:opts.Limit]
+	if opts.Long && len(data.Tags) > 0 {
+		logger.Debug("Resolving tags", "count", len(data.Tags), "concurrency", opts.Concurrency)
+
+		images, err := tags.Resolve(ctx, repo, data.Tags, opts.Concurrency, auth)
+		if err != nil {
+			return err
+		}
+		for _, img := range images {
+			data.Images = append(data.Images, view.TagImage{
+				Digest:    img.Digest.String(),
+				MediaType: string(img.MediaType),
+				Platforms: img.Platforms,
+				Created:   img.Created,
+				Tags:      img.Tags,
+			})
+		}
 	}
 
-	logger.Debug("Listed tags", "count", len(tags))
+	logger.Debug("Listed tags", "count", len(data.Tags))
 
-	return cli.Tags().Render(&view.TagsData{
-		Repository: repo.String(),
-		Tags:       tags,
-	})
+	return cli.Tags().Render(data)
 }
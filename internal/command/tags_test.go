@@ -0,0 +1,111 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTagsCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewTagsCommand(cli)
+
+	assert.Equal(t, "tags", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.Equal(t, "semver", cmd.Flags().Lookup("sort").DefValue)
+	assert.Equal(t, "false", cmd.Flags().Lookup("latest").DefValue)
+	assert.Equal(t, "false", cmd.Flags().Lookup("prerelease").DefValue)
+	assert.NotNil(t, cmd.Flags().Lookup("semver"))
+	assert.NotNil(t, cmd.Flags().Lookup("match"))
+}
+
+func TestRunTags(t *testing.T) {
+	host := newTestRegistry(t)
+	repo := host + "/app"
+	img := newTestImage(t, map[string]string{"app": "x"})
+	for _, tag := range []string{"1.9", "1.10", "1.10.1", "1.11.0-rc1", "1.10-alpine", "1.9-alpine", "latest"} {
+		pushTestImage(t, repo+":"+tag, img)
+	}
+
+	run := func(t *testing.T, args ...string) string {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewTagsCommand(cli)
+		cmd.SetArgs(append(args, repo))
+		require.NoError(t, cmd.Execute())
+		return buf.String()
+	}
+
+	t.Run("semver order groups variants", func(t *testing.T) {
+		assert.Equal(t, "1.10.1\n1.10\n1.9\nlatest\n\n1.10-alpine\n1.9-alpine\n", run(t))
+	})
+
+	t.Run("prereleases", func(t *testing.T) {
+		assert.Contains(t, run(t, "--prerelease"), "1.11.0-rc1\n1.10.1\n")
+	})
+
+	t.Run("lexical", func(t *testing.T) {
+		assert.Equal(t, "latest\n1.9-alpine\n1.9\n1.10.1\n1.10-alpine\n1.10\n", run(t, "--sort", "lexical"))
+	})
+
+	t.Run("constraint and match", func(t *testing.T) {
+		assert.Equal(t, "1.10-alpine\n", run(t, "--semver", "~1.10", "--match", ".*-alpine"))
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		assert.Equal(t, "1.10.1\n", run(t, "--latest"))
+		assert.Equal(t, "1.10-alpine\n", run(t, "--latest", "--match", ".*-alpine"))
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		assert.Equal(t, "1.10.1\n1.10\n", run(t, "--limit", "2"))
+	})
+
+	t.Run("json groups", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+		cmd := command.NewTagsCommand(cli)
+		cmd.SetArgs([]string{repo})
+		require.NoError(t, cmd.Execute())
+
+		var out struct {
+			Tags   []string `json:"tags"`
+			Groups []struct {
+				Variant string   `json:"variant"`
+				Tags    []string `json:"tags"`
+			} `json:"groups"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+		assert.Len(t, out.Tags, 6)
+		require.Len(t, out.Groups, 2)
+		assert.Equal(t, "alpine", out.Groups[1].Variant)
+	})
+
+	t.Run("long groups tags by digest", func(t *testing.T) {
+		out := run(t, "--long", "--match", "1\\.10.*|latest")
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], "Digest")
+		assert.Contains(t, lines[1], "1.10.1, 1.10, latest, 1.10-alpine")
+		assert.Contains(t, lines[1], "linux/amd64")
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+		cmd := command.NewTagsCommand(cli)
+		cmd.SetArgs([]string{"--sort", "newest", repo})
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		assert.ErrorContains(t, cmd.Execute(), "invalid sort order")
+	})
+}
@@ -0,0 +1,180 @@
+package command
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+
+	"github.com/bschaatsbergen/cek/internal/signature"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+type VerifyOptions struct {
+	Key      string
+	Bundle   bool
+	RekorKey string
+	Platform string
+}
+
+func NewVerifyCommand(cli *CLI) *cobra.Command {
+	opts := VerifyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "verify <image>",
+		Short: "Verify cosign signatures with a public key",
+		Long: highlight("cek verify --key cosign.pub my-app:latest") + "\n\n" +
+			"Verify cosign signatures attached to an image using a public key.\n" +
+			"Signatures are discovered through the sha256-<digest>.sig tag and the\n" +
+			"OCI 1.1 referrers API, so both signing modes of cosign are supported.\n\n" +
+			"A signature is valid when it was made by the given ECDSA or Ed25519\n" +
+			"key and the digest it signs matches the digest the image resolves to.\n" +
+			"Signatures of the image index and of the selected platform image are\n" +
+			"both checked.\n\n" +
+			"With --bundle, every signature must also carry a Rekor bundle, which\n" +
+			"is verified offline against the Rekor public key given by --rekor-key.\n\n" +
+			"Signatures live in the registry, so the image is always resolved\n" +
+			"remotely. The command fails unless at least one signature is valid.\n\n" +
+			"Examples:\n" +
+			"  cek verify --key cosign.pub my-app:latest\n" +
+			"  cek verify --key cosign.pub --platform linux/arm64 my-app:latest\n" +
+			"  cek verify --key cosign.pub --bundle --rekor-key rekor.pub my-app:latest\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			imageRef := args[0]
+			return RunVerify(cmd.Context(), cli, imageRef, &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Key, "key", "", "Path to the PEM encoded public key (required)")
+	_ = cmd.MarkFlagRequired("key")
+	cmd.Flags().BoolVar(&opts.Bundle, "bundle", false, "Require and verify Rekor bundles offline")
+	cmd.Flags().StringVar(&opts.RekorKey, "rekor-key", "", "Path to the Rekor public key used with --bundle")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+
+	return cmd
+}
+
+func RunVerify(ctx context.Context, cli *CLI, imageRef string, opts *VerifyOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Verifying image signatures", "image", imageRef)
+
+	verifier, err := newVerifier(opts)
+	if err != nil {
+		return err
+	}
+
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	remoteOpts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+
+	// cosign signs the digest the reference points to, which is the index
+	// for multi-platform images. The reference is resolved once, so a tag
+	// moving meanwhile cannot mix the subjects of two images.
+	getOpts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+	if opts.Platform != "" {
+		platform, err := v1.ParsePlatform(opts.Platform)
+		if err != nil {
+			return fmt.Errorf("failed to parse platform: %w", err)
+		}
+		getOpts = append(getOpts, remote.WithPlatform(*platform))
+	}
+	desc, err := remote.Get(ref, getOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch image: %w", err)
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return fmt.Errorf("failed to get image: %w", err)
+	}
+	imgDigest, err := img.Digest()
+	if err != nil {
+		return fmt.Errorf("failed to get image digest: %w", err)
+	}
+
+	subjects := []v1.Hash{desc.Digest}
+	if imgDigest != desc.Digest {
+		subjects = append(subjects, imgDigest)
+	}
+
+	data := &view.VerifyData{
+		ImageRef: imageRef,
+		Digest:   desc.Digest.String(),
+	}
+
+	verified := 0
+	for _, subject := range subjects {
+		sigs, err := signature.Fetch(ref.Context(), subject, remoteOpts...)
+		if err != nil {
+			return err
+		}
+		logger.Debug("Found signatures", "subject", subject.String(), "count", len(sigs))
+
+		for i := range sigs {
+			result := view.VerifySignature{
+				Subject: subject.String(),
+				Source:  string(sigs[i].Source),
+			}
+			res, err := verifier.Verify(&sigs[i])
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				verified++
+				result.Verified = true
+				result.DockerReference = res.Payload.Critical.Identity.DockerReference
+				if res.Bundle != nil {
+					result.LogIndex = res.Bundle.Payload.LogIndex
+					result.IntegratedTime = res.Bundle.IntegratedTime()
+				}
+			}
+			data.Signatures = append(data.Signatures, result)
+		}
+	}
+
+	if err := cli.Verify().Render(data); err != nil {
+		return err
+	}
+
+	if len(data.Signatures) == 0 {
+		return fmt.Errorf("no signatures found for %s", imageRef)
+	}
+	if verified == 0 {
+		return fmt.Errorf("no valid signatures found for %s", imageRef)
+	}
+
+	return nil
+}
+
+func newVerifier(opts *VerifyOptions) (*signature.Verifier, error) {
+	if opts.Bundle && opts.RekorKey == "" {
+		return nil, fmt.Errorf("--bundle requires --rekor-key")
+	}
+
+	key, err := signature.LoadPublicKey(opts.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	var rekorKey crypto.PublicKey
+	if opts.Bundle {
+		rekorKey, err = signature.LoadPublicKey(opts.RekorKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &signature.Verifier{Key: key, RekorKey: rekorKey}, nil
+}
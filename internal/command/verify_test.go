@@ -0,0 +1,149 @@
+package command_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/signature"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signTestImage attaches a cosign signature for ref under its .sig tag.
+func signTestImage(t *testing.T, ref string, key *ecdsa.PrivateKey) {
+	t.Helper()
+
+	r, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	keychain := remote.WithAuthFromKeychain(authn.DefaultKeychain)
+	desc, err := remote.Head(r, keychain)
+	require.NoError(t, err)
+
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`,
+		r.Context().String(), desc.Digest.String()))
+	sum := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	require.NoError(t, err)
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(payload, signature.SimpleSigningMediaType),
+		Annotations: map[string]string{signature.SignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(signature.SignatureTag(r.Context(), desc.Digest), img, keychain))
+}
+
+func writePublicKey(t *testing.T, key *ecdsa.PrivateKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644))
+	return path
+}
+
+func TestNewVerifyCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewVerifyCommand(cli)
+
+	assert.Equal(t, "verify", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.NotNil(t, cmd.Flags().Lookup("key"))
+	assert.Equal(t, "false", cmd.Flags().Lookup("bundle").DefValue)
+	assert.NotNil(t, cmd.Flags().Lookup("rekor-key"))
+}
+
+func TestVerifyCommand_BundleRequiresRekorKey(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewVerifyCommand(cli)
+	cmd.SetArgs([]string{"alpine:latest", "--key", "cosign.pub", "--bundle"})
+
+	err := cmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--bundle requires --rekor-key")
+}
+
+func TestRunVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	host := newTestRegistry(t)
+	signed := pushTestImage(t, host+"/app:signed", newTestImage(t, map[string]string{"app/main": "v1"}))
+	signTestImage(t, signed, key)
+	unsigned := pushTestImage(t, host+"/app:unsigned", newTestImage(t, map[string]string{"app/main": "v2"}))
+
+	t.Run("valid", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewVerifyCommand(cli)
+		cmd.SetArgs([]string{signed, "--key", writePublicKey(t, key)})
+		require.NoError(t, cmd.Execute())
+
+		assert.Contains(t, buf.String(), "verified")
+		assert.Contains(t, buf.String(), "1 of 1 signatures verified")
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewVerifyCommand(cli)
+		cmd.SetArgs([]string{signed, "--key", writePublicKey(t, otherKey)})
+
+		err := cmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no valid signatures found")
+		assert.Contains(t, buf.String(), "invalid: invalid signature")
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+		cmd := command.NewVerifyCommand(cli)
+		cmd.SetArgs([]string{unsigned, "--key", writePublicKey(t, key)})
+
+		err := cmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no signatures found")
+	})
+}
+
+func TestRunVerify_PrivateRegistry(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	host := newAuthTestRegistry(t)
+	signed := pushTestImage(t, host+"/app:signed", newTestImage(t, map[string]string{"app/main": "v1"}))
+	signTestImage(t, signed, key)
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewVerifyCommand(cli)
+	cmd.SetArgs([]string{signed, "--key", writePublicKey(t, key)})
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, buf.String(), "1 of 1 signatures verified")
+}
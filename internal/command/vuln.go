@@ -0,0 +1,242 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/osinfo"
+	"github.com/bschaatsbergen/cek/internal/packages"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/bschaatsbergen/cek/internal/vuln"
+	"github.com/spf13/cobra"
+)
+
+type VulnOptions struct {
+	DB         string
+	FailOn     string
+	Ecosystems []string
+	Platform   string
+	Pull       string
+}
+
+func NewVulnCommand(cli *CLI) *cobra.Command {
+	opts := VulnOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "vuln <image>",
+		Short: "Match image packages against a local vulnerability database",
+		Long: highlight("cek vuln nginx:latest --db ./osv") + "\n\n" +
+			"Match the packages installed in an OCI image against a locally\n" +
+			"downloaded OSV advisory database. No network access is needed beyond\n" +
+			"fetching the image, so this works in air-gapped environments.\n\n" +
+			"The database can be a directory of OSV JSON files or a zip archive,\n" +
+			"such as the per-ecosystem all.zip exports:\n" +
+			"  https://osv-vulnerabilities.storage.googleapis.com/Debian/all.zip\n\n" +
+			"Versions are compared using each ecosystem's own rules (dpkg, apk, rpm,\n" +
+			"semver, PEP 440, Maven or RubyGems). Distro advisories are scoped to\n" +
+			"the release found in /etc/os-release. OS packages are checked on\n" +
+			"Debian, Ubuntu, Alpine, Red Hat Enterprise Linux, AlmaLinux, Rocky\n" +
+			"Linux, openSUSE and SUSE Linux Enterprise Server images; on other\n" +
+			"distributions only language packages are checked and the report says\n" +
+			"so.\n\n" +
+			"Use --fail-on to exit with an error when a finding at or above the\n" +
+			"given severity is present, e.g. in CI pipelines.\n\n" +
+			"Examples:\n" +
+			"  cek vuln --db ./osv nginx:latest\n" +
+			"  cek vuln --db Debian-all.zip --fail-on high my-app:latest\n" +
+			"  cek vuln --db ./osv --ecosystem go,npm --json my-app:latest\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			imageRef := args[0]
+			return RunVuln(cmd.Context(), cli, imageRef, &opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.DB, "db", "", "Path to an OSV advisory directory or zip archive (required)")
+	_ = cmd.MarkFlagRequired("db")
+	cmd.Flags().StringVar(&opts.FailOn, "fail-on", "", "Exit with an error if findings at or above this severity exist (low, medium, high, critical)")
+	cmd.Flags().StringSliceVar(&opts.Ecosystems, "ecosystem", nil, "Only check packages from these ecosystems (comma-separated)")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specify platform (e.g., linux/amd64, linux/arm64)")
+	cmd.Flags().StringVar(&opts.Pull, "pull", "if-not-present", "Image pull policy (always, if-not-present, never)")
+
+	return cmd
+}
+
+func RunVuln(ctx context.Context, cli *CLI, imageRef string, opts *VulnOptions) error {
+	logger := cli.Logger()
+	logger.Debug("Scanning image for vulnerabilities", "image", imageRef, "db", opts.DB)
+
+	var failOn vuln.Severity
+	if opts.FailOn != "" {
+		var err error
+		failOn, err = vuln.ParseSeverity(opts.FailOn)
+		if err != nil {
+			return err
+		}
+	}
+
+	detectOpts, err := packageDetectOptions(logger, opts.Ecosystems)
+	if err != nil {
+		return err
+	}
+	// A partly unreadable package database must not pass for a clean
+	// report, so the files are listed alongside the findings.
+	var unreadable []string
+	warn := detectOpts.Warn
+	detectOpts.Warn = func(path string, err error) {
+		warn(path, err)
+		unreadable = append(unreadable, path)
+	}
+
+	fetchOpts := &oci.FetchOptions{
+		Platform:   opts.Platform,
+		PullPolicy: oci.PullPolicy(opts.Pull),
+	}
+	img, _, err := oci.FetchImage(ctx, imageRef, fetchOpts)
+	if err != nil {
+		return err
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return fmt.Errorf("failed to get layers: %w", err)
+	}
+
+	pkgs, err := packages.Detect(layers, detectOpts)
+	if err != nil {
+		return fmt.Errorf("failed to detect packages: %w", err)
+	}
+
+	logger.Debug("Detected packages", "count", len(pkgs))
+
+	info, err := osinfo.Detect(layers, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to detect distribution release: %w", err)
+	}
+	pkgs, releases, supported := scopeOSPackages(pkgs, info)
+
+	logger.Debug("Detected distribution releases", "distro", info.Distro, "releases", releases)
+
+	db, err := vuln.LoadDB(opts.DB, vuln.PackageFilter(pkgs))
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Loaded advisories", "count", db.Len())
+
+	findings := db.Match(pkgs, &vuln.MatchOptions{Releases: releases})
+
+	data := &view.VulnData{
+		ImageRef:   imageRef,
+		Packages:   len(pkgs),
+		Advisories: db.Len(),
+		Findings:   make([]view.VulnFinding, len(findings)),
+		Unreadable: unreadable,
+	}
+	if !supported {
+		data.UnsupportedDistro = info.Distro
+	}
+	failing := 0
+	for i, f := range findings {
+		data.Findings[i] = view.VulnFinding{
+			ID:           f.ID,
+			Aliases:      f.Aliases,
+			Summary:      f.Summary,
+			Severity:     f.Severity.String(),
+			Score:        f.Score,
+			Package:      f.Package.Name,
+			Ecosystem:    string(f.Package.Ecosystem),
+			Version:      f.Package.Version,
+			FixedVersion: f.FixedVersion,
+			Path:         f.Package.Path,
+			Layer:        f.Package.Layer,
+		}
+		if opts.FailOn != "" && f.Severity >= failOn {
+			failing++
+		}
+	}
+
+	if err := cli.Vuln().Render(data); err != nil {
+		return err
+	}
+
+	if failing > 0 {
+		return fmt.Errorf("found %d vulnerabilities with severity %s or higher", failing, failOn)
+	}
+
+	return nil
+}
+
+// scopeOSPackages prepares the OS packages in pkgs for matching against the
+// advisories of the image's distribution. It returns the packages to match,
+// the release identifiers OSV uses to scope distro advisories, such as "12"
+// for Debian and "v3.19" for Alpine, and whether the distribution's OS
+// packages are checked at all.
+//
+// dpkg packages are detected as Debian, so on Ubuntu they are matched as
+// Ubuntu packages, and rpm packages are matched as packages of the RPM-based
+// distribution. On any other distribution, OS packages would match the
+// advisories of the wrong one, e.g. Debian advisories for a Debian
+// derivative, so they are left out.
+func scopeOSPackages(pkgs []packages.Package, info *osinfo.Info) ([]packages.Package, map[packages.Ecosystem]string, bool) {
+	major, minor, _ := strings.Cut(info.Version, ".")
+	minor, _, _ = strings.Cut(minor, ".")
+
+	var detected, ecosystem packages.Ecosystem
+	var release string
+	switch info.Distro {
+	case "debian":
+		detected, ecosystem, release = packages.EcosystemDebian, packages.EcosystemDebian, major
+	case "ubuntu":
+		detected, ecosystem, release = packages.EcosystemDebian, packages.EcosystemUbuntu, info.Version
+	case "alpine":
+		detected, ecosystem = packages.EcosystemAlpine, packages.EcosystemAlpine
+		if major != "" && minor != "" {
+			release = "v" + major + "." + minor
+		}
+	case "rhel":
+		detected, ecosystem, release = packages.EcosystemRPM, packages.EcosystemRedHat, major
+	case "almalinux":
+		detected, ecosystem, release = packages.EcosystemRPM, packages.EcosystemAlmaLinux, major
+	case "rocky":
+		detected, ecosystem, release = packages.EcosystemRPM, packages.EcosystemRocky, major
+	case "opensuse-leap":
+		detected, ecosystem = packages.EcosystemRPM, packages.EcosystemOpenSUSE
+		if info.Version != "" {
+			release = "Leap " + info.Version
+		}
+	case "opensuse-tumbleweed":
+		detected, ecosystem, release = packages.EcosystemRPM, packages.EcosystemOpenSUSE, "Tumbleweed"
+	case "sles":
+		detected, ecosystem = packages.EcosystemRPM, packages.EcosystemSUSE
+		if major != "" {
+			release = "Linux Enterprise Server " + major
+			if minor != "" && minor != "0" {
+				release += " SP" + minor
+			}
+		}
+	}
+
+	releases := make(map[packages.Ecosystem]string)
+	if release != "" {
+		releases[ecosystem] = release
+	}
+
+	scoped := make([]packages.Package, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		switch {
+		case pkg.Ecosystem != packages.EcosystemDebian && pkg.Ecosystem != packages.EcosystemAlpine && pkg.Ecosystem != packages.EcosystemRPM:
+		case pkg.Ecosystem != detected:
+			continue
+		default:
+			pkg.Ecosystem = ecosystem
+		}
+		scoped = append(scoped, pkg)
+	}
+
+	supported := detected != "" || info.Scratch()
+	return scoped, releases, supported
+}
@@ -0,0 +1,210 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVulnCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewVulnCommand(cli)
+
+	assert.Equal(t, "vuln", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.NotNil(t, cmd.Flags().Lookup("db"))
+	assert.Equal(t, "", cmd.Flags().Lookup("fail-on").DefValue)
+}
+
+func TestVulnCommand_RequiresDB(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewVulnCommand(cli)
+	cmd.SetArgs([]string{"alpine:latest"})
+
+	err := cmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"db" not set`)
+}
+
+func TestRunVuln_FailOn(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{
+		"etc/os-release":       "ID=alpine\nVERSION_ID=3.19.1\n",
+		"lib/apk/db/installed": "P:libcrypto3\nV:3.1.4-r1\no:openssl\n",
+	}))
+
+	db := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(db, "ALPINE-1.json"), []byte(`{
+		"id": "ALPINE-CVE-2024-0001",
+		"affected": [{
+			"package": {"ecosystem": "Alpine:v3.19", "name": "openssl"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "3.1.4-r5"}]}]
+		}],
+		"severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N"}]
+	}`), 0o644))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewVulnCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always", "--db", db, "--fail-on", "critical"})
+	require.NoError(t, cmd.Execute())
+
+	output := buf.String()
+	assert.Contains(t, output, "ALPINE-CVE-2024-0001")
+	assert.Contains(t, output, "high")
+	assert.Contains(t, output, "3.1.4-r5")
+
+	cmd = command.NewVulnCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always", "--db", db, "--fail-on", "high"})
+	err := cmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "found 1 vulnerabilities with severity high or higher")
+}
+
+func TestRunVuln_Ubuntu(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{
+		"etc/os-release":      "ID=ubuntu\nVERSION_ID=\"22.04\"\nVERSION_CODENAME=jammy\n",
+		"var/lib/dpkg/status": "Package: libssl3\nStatus: install ok installed\nSource: openssl\nVersion: 3.0.2-0ubuntu1.10\n\n",
+	}))
+
+	// Debian advisories must not match dpkg packages on Ubuntu.
+	db := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(db, "DSA-1.json"), []byte(`{
+		"id": "DSA-0001",
+		"affected": [{
+			"package": {"ecosystem": "Debian:12", "name": "openssl"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "3.0.13-1~deb12u1"}]}]
+		}]
+	}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(db, "USN-1.json"), []byte(`{
+		"id": "USN-0001",
+		"affected": [{
+			"package": {"ecosystem": "Ubuntu:22.04:LTS", "name": "openssl"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "3.0.2-0ubuntu1.15"}]}]
+		}]
+	}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(db, "USN-2.json"), []byte(`{
+		"id": "USN-0002",
+		"affected": [{
+			"package": {"ecosystem": "Ubuntu:20.04:LTS", "name": "openssl"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "3.0.2-0ubuntu1.15"}]}]
+		}]
+	}`), 0o644))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewVulnCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always", "--db", db})
+	require.NoError(t, cmd.Execute())
+
+	output := buf.String()
+	assert.Contains(t, output, "USN-0001")
+	assert.NotContains(t, output, "USN-0002")
+	assert.NotContains(t, output, "DSA-0001")
+}
+
+func TestRunVuln_UnsupportedDistro(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{
+		"etc/os-release": "ID=arch\nBUILD_ID=rolling\n",
+	}))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewVulnCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always", "--db", t.TempDir()})
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, buf.String(), "OS packages of arch images are not checked")
+}
+
+// rpmNDB returns an NDB rpm database, as SUSE and some minimal images use,
+// holding a single package.
+func rpmNDB(name, version, release string) string {
+	le := binary.LittleEndian
+	var index, store []byte
+	for i, value := range []string{name, version, release} {
+		index = binary.BigEndian.AppendUint32(index, uint32(1000+i))
+		index = binary.BigEndian.AppendUint32(index, 6)
+		index = binary.BigEndian.AppendUint32(index, uint32(len(store)))
+		index = binary.BigEndian.AppendUint32(index, 1)
+		store = append(store, value+"\x00"...)
+	}
+	blob := binary.BigEndian.AppendUint32(nil, 3)
+	blob = binary.BigEndian.AppendUint32(blob, uint32(len(store)))
+	blob = append(append(blob, index...), store...)
+
+	db := make([]byte, 4096)
+	copy(db, "RpmP")
+	le.PutUint32(db[12:], 1)
+	for offset := 32; offset < len(db); offset += 16 {
+		copy(db[offset:], "Slot")
+	}
+	le.PutUint32(db[36:], 1)
+	le.PutUint32(db[40:], 4096/16)
+
+	head := make([]byte, 16)
+	copy(head, "BlbS")
+	le.PutUint32(head[4:], 1)
+	le.PutUint32(head[12:], uint32(len(blob)))
+	return string(append(append(db, head...), blob...))
+}
+
+func TestRunVuln_Rocky(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{
+		"etc/os-release":                   "ID=\"rocky\"\nVERSION_ID=\"9.3\"\n",
+		"usr/lib/sysimage/rpm/Packages.db": rpmNDB("openssl-libs", "3.0.7", "24.el9"),
+	}))
+
+	db := t.TempDir()
+	for name, ecosystem := range map[string]string{"RLSA-1": "Rocky Linux:9", "RLSA-2": "Rocky Linux:8", "ALSA-1": "AlmaLinux:9"} {
+		require.NoError(t, os.WriteFile(filepath.Join(db, name+".json"), []byte(`{
+			"id": "`+name+`",
+			"affected": [{
+				"package": {"ecosystem": "`+ecosystem+`", "name": "openssl-libs"},
+				"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1:3.0.7-25.el9_3"}]}]
+			}]
+		}`), 0o644))
+	}
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewVulnCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always", "--db", db})
+	require.NoError(t, cmd.Execute())
+
+	output := buf.String()
+	assert.Contains(t, output, "RLSA-1")
+	assert.Contains(t, output, "1:3.0.7-25.el9_3")
+	assert.NotContains(t, output, "RLSA-2")
+	assert.NotContains(t, output, "ALSA-1")
+	assert.NotContains(t, output, "not checked")
+}
+
+func TestRunVuln_UnreadableDatabase(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/app:1.0", newTestImage(t, map[string]string{
+		"etc/os-release":                   "ID=\"rocky\"\nVERSION_ID=\"9.3\"\n",
+		"usr/lib/sysimage/rpm/Packages.db": "RpmP",
+	}))
+
+	buf := new(bytes.Buffer)
+	cli := command.NewCLI(view.ViewHuman, buf, view.LogLevelSilent)
+	cmd := command.NewVulnCommand(cli)
+	cmd.SetArgs([]string{ref, "--pull", "always", "--db", t.TempDir()})
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, buf.String(), "Warning: /usr/lib/sysimage/rpm/Packages.db could not be fully read")
+}
@@ -0,0 +1,181 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/bschaatsbergen/cek/internal/watch"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+type WatchOptions struct {
+	Interval     time.Duration
+	Platform     string
+	Exec         string
+	ExitOnChange bool
+
+	// hookOutput receives the output of the --exec hook, defaulting to
+	// stderr so NDJSON on stdout stays parseable.
+	hookOutput io.Writer
+}
+
+func NewWatchCommand(cli *CLI) *cobra.Command {
+	opts := WatchOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "watch <image>",
+		Short: "Watch a tag for digest changes",
+		Long: highlight("cek watch nginx:1.27 --interval 10m") + "\n\n" +
+			"Poll a tag and report when the digest behind it changes, e.g. when\n" +
+			"upstream silently re-pushes a tag. The registry is polled with HEAD\n" +
+			"requests, which do not count against Docker Hub pull limits. Each change\n" +
+			"reports the old and new digests and the layers added and removed.\n\n" +
+			"Use --json to emit one JSON event per line (NDJSON). Use --exec to run a\n" +
+			"command on every change; it runs with sh and receives the event as JSON\n" +
+			"on stdin and in these environment variables:\n" +
+			"  CEK_IMAGE            the watched image\n" +
+			"  CEK_OLD_DIGEST       the previous digest\n" +
+			"  CEK_NEW_DIGEST       the new digest\n" +
+			"  CEK_LAYERS_ADDED     number of layers added\n" +
+			"  CEK_LAYERS_REMOVED   number of layers removed\n\n" +
+			"Registry errors while polling are logged and the watch continues.\n\n" +
+			"Examples:\n" +
+			"  cek watch nginx:1.27\n" +
+			"  cek watch --json --interval 1h golang:1.24 >> events.ndjson\n" +
+			"  cek watch --exec 'notify-send \"$CEK_IMAGE changed\"' nginx:1.27\n" +
+			"  cek watch --exit-on-change --interval 30s registry.internal/app:main\n",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.hookOutput = cmd.ErrOrStderr()
+			return RunWatch(cmd.Context(), cli, args[0], &opts)
+		},
+	}
+
+	cmd.Flags().DurationVar(&opts.Interval, "interval", 10*time.Minute, "Time between registry checks")
+	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Platform whose layers are compared for multi-platform images (default linux/amd64)")
+	cmd.Flags().StringVar(&opts.Exec, "exec", "", "Command to run when the digest changes")
+	cmd.Flags().BoolVar(&opts.ExitOnChange, "exit-on-change", false, "Exit after the first change")
+
+	return cmd
+}
+
+func RunWatch(ctx context.Context, cli *CLI, imageRef string, opts *WatchOptions) error {
+	logger := cli.Logger()
+
+	if opts.Interval <= 0 {
+		return errors.New("--interval must be greater than zero")
+	}
+
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	w, err := watch.New(ctx, ref, opts.Platform, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return err
+	}
+
+	if err := cli.Watch().Render(&view.WatchData{
+		Image:    ref.String(),
+		Time:     time.Now().UTC(),
+		Interval: opts.Interval,
+		Digest:   w.Digest().String(),
+	}); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		logger.Debug("Checking digest", "image", ref.String())
+		event, err := w.Poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Warn("Failed to check image", "image", ref.String(), "error", err)
+			continue
+		}
+		if event == nil {
+			continue
+		}
+
+		data := watchData(ref, event)
+		if err := cli.Watch().Render(data); err != nil {
+			return err
+		}
+		if opts.Exec != "" {
+			if err := runWatchHook(ctx, opts, data); err != nil {
+				logger.Warn("Hook failed", "command", opts.Exec, "error", err)
+			}
+		}
+		if opts.ExitOnChange {
+			return nil
+		}
+	}
+}
+
+func watchData(ref name.Reference, event *watch.Event) *view.WatchData {
+	toView := func(layers []watch.Layer) []view.WatchLayer {
+		out := make([]view.WatchLayer, len(layers))
+		for i, l := range layers {
+			out[i] = view.WatchLayer{Digest: l.Digest.String(), Size: l.Size}
+		}
+		return out
+	}
+
+	return &view.WatchData{
+		Image:     ref.String(),
+		Time:      event.Time,
+		Digest:    event.New.String(),
+		Previous:  event.Old.String(),
+		Added:     toView(event.Layers.Added),
+		Removed:   toView(event.Layers.Removed),
+		Unchanged: event.Layers.Unchanged,
+	}
+}
+
+// runWatchHook runs the --exec command with the event as JSON on stdin.
+func runWatchHook(ctx context.Context, opts *WatchOptions, data *view.WatchData) error {
+	var event bytes.Buffer
+	if err := view.NewJSONView(view.NewStream(&event), view.LogLevelSilent).Watch().Render(data); err != nil {
+		return err
+	}
+
+	out := opts.hookOutput
+	if out == nil {
+		out = os.Stderr
+	}
+
+	hook := exec.CommandContext(ctx, "sh", "-c", opts.Exec)
+	hook.Stdin = &event
+	hook.Stdout = out
+	hook.Stderr = out
+	hook.Env = append(os.Environ(),
+		"CEK_IMAGE="+data.Image,
+		"CEK_OLD_DIGEST="+data.Previous,
+		"CEK_NEW_DIGEST="+data.Digest,
+		"CEK_LAYERS_ADDED="+strconv.Itoa(len(data.Added)),
+		"CEK_LAYERS_REMOVED="+strconv.Itoa(len(data.Removed)),
+	)
+	return hook.Run()
+}
@@ -0,0 +1,116 @@
+package command_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/command"
+	"github.com/bschaatsbergen/cek/internal/view"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe to read while a command writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestNewWatchCommand(t *testing.T) {
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewWatchCommand(cli)
+
+	assert.Equal(t, "watch", cmd.Name())
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.Equal(t, "10m0s", cmd.Flags().Lookup("interval").DefValue)
+	for _, flag := range []string{"platform", "exec", "exit-on-change"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), flag)
+	}
+}
+
+func TestRunWatch(t *testing.T) {
+	host := newTestRegistry(t)
+	ref := pushTestImage(t, host+"/nginx:1.27", newTestImage(t, map[string]string{"etc/nginx/nginx.conf": "v1"}))
+	hookOut := filepath.Join(t.TempDir(), "hook.txt")
+
+	buf := new(syncBuffer)
+	cli := command.NewCLI(view.ViewJSON, buf, view.LogLevelSilent)
+	cmd := command.NewWatchCommand(cli)
+	cmd.SetArgs([]string{
+		"--interval", "10ms",
+		"--exit-on-change",
+		"--exec", `echo "$CEK_OLD_DIGEST $CEK_NEW_DIGEST $CEK_LAYERS_ADDED" > ` + hookOut,
+		ref,
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Execute() }()
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), `"event":"watching"`)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	pushTestImage(t, ref, newTestImage(t,
+		map[string]string{"etc/nginx/nginx.conf": "v1"},
+		map[string]string{"etc/nginx/conf.d/extra.conf": "v2"},
+	))
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not exit after the tag changed")
+	}
+
+	var events []map[string]any
+	scanner := bufio.NewScanner(strings.NewReader(buf.String()))
+	for scanner.Scan() {
+		var event map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event), "each line is a JSON event")
+		events = append(events, event)
+	}
+	require.Len(t, events, 2)
+	assert.Equal(t, "changed", events[1]["event"])
+	assert.Equal(t, events[0]["digest"], events[1]["previous_digest"])
+	layers := events[1]["layers"].(map[string]any)
+	assert.Len(t, layers["added"], 1)
+	assert.EqualValues(t, 1, layers["unchanged"])
+
+	hook, err := os.ReadFile(hookOut)
+	require.NoError(t, err)
+	assert.Equal(t, events[1]["previous_digest"].(string)+" "+events[1]["digest"].(string)+" 1\n", string(hook))
+}
+
+func TestRunWatch_NotFound(t *testing.T) {
+	host := newTestRegistry(t)
+
+	cli := command.NewCLI(view.ViewHuman, &bytes.Buffer{}, view.LogLevelSilent)
+	cmd := command.NewWatchCommand(cli)
+	cmd.SetArgs([]string{host + "/nginx:missing"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	assert.ErrorContains(t, cmd.Execute(), "failed to resolve")
+}
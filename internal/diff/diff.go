@@ -0,0 +1,280 @@
+// Package diff compares the metadata of two images.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Base image annotations set by buildkit and other builders.
+const (
+	AnnotationBaseName   = "org.opencontainers.image.base.name"
+	AnnotationBaseDigest = "org.opencontainers.image.base.digest"
+)
+
+// ChangeType is how a field differs between two images.
+type ChangeType string
+
+const (
+	Added   ChangeType = "added"
+	Removed ChangeType = "removed"
+	Changed ChangeType = "changed"
+)
+
+// Change is a difference in one config field, or one key of a field such
+// as an environment variable or label.
+type Change struct {
+	Type ChangeType
+	// Field is the Dockerfile instruction the field comes from, e.g. ENV,
+	// LABEL, USER or EXPOSE, or BASE and OS for the base image.
+	Field string
+	// Key identifies the entry within fields holding several, e.g. the
+	// variable name for ENV.
+	Key string
+	Old string
+	New string
+}
+
+// String describes the change for release notes, e.g. "ENV FOO added".
+func (c Change) String() string {
+	if c.Key == "" {
+		return c.Field + " " + string(c.Type)
+	}
+	return c.Field + " " + c.Key + " " + string(c.Type)
+}
+
+// LayerStatus is how a layer position compares between two images.
+type LayerStatus string
+
+const (
+	LayerUnchanged LayerStatus = "unchanged"
+	LayerRebuilt   LayerStatus = "rebuilt"
+	LayerAdded     LayerStatus = "added"
+	LayerRemoved   LayerStatus = "removed"
+)
+
+// Layer compares the layers at one position, starting at 1 for the base.
+type Layer struct {
+	Index  int
+	Status LayerStatus
+	// Old and New are zero for added and removed layers.
+	Old v1.Hash
+	New v1.Hash
+}
+
+// Layers compares two images layer by layer.
+type Layers struct {
+	Layers []Layer
+	// Shared counts layers present in both images, wherever they are.
+	Shared int
+}
+
+// Count returns the number of positions with the given status.
+func (l Layers) Count(status LayerStatus) int {
+	n := 0
+	for _, layer := range l.Layers {
+		if layer.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// BaseChanged reports whether the first layer differs.
+func (l Layers) BaseChanged() bool {
+	return len(l.Layers) > 0 && l.Layers[0].Status != LayerUnchanged
+}
+
+// CompareLayers compares layer digests position by position.
+func CompareLayers(old, updated []v1.Hash) Layers {
+	var result Layers
+
+	inOld := make(map[v1.Hash]bool, len(old))
+	for _, h := range old {
+		inOld[h] = true
+	}
+	for _, h := range updated {
+		if inOld[h] {
+			result.Shared++
+		}
+	}
+
+	for i := 0; i < max(len(old), len(updated)); i++ {
+		layer := Layer{Index: i + 1}
+		switch {
+		case i >= len(old):
+			layer.Status, layer.New = LayerAdded, updated[i]
+		case i >= len(updated):
+			layer.Status, layer.Old = LayerRemoved, old[i]
+		default:
+			layer.Old, layer.New = old[i], updated[i]
+			layer.Status = LayerUnchanged
+			if old[i] != updated[i] {
+				layer.Status = LayerRebuilt
+			}
+		}
+		result.Layers = append(result.Layers, layer)
+	}
+	return result
+}
+
+// CompareConfigs returns the differences between two image configs in
+// the order of the Dockerfile instructions they come from.
+func CompareConfigs(old, updated *v1.ConfigFile) []Change {
+	var o, n v1.Config
+	if old != nil {
+		o = old.Config
+	}
+	if updated != nil {
+		n = updated.Config
+	}
+
+	var changes []Change
+	changes = append(changes, compareMaps("ENV", envMap(o.Env), envMap(n.Env))...)
+	changes = append(changes, compareValue("ENTRYPOINT", "", jsonList(o.Entrypoint), jsonList(n.Entrypoint))...)
+	changes = append(changes, compareValue("CMD", "", jsonList(o.Cmd), jsonList(n.Cmd))...)
+	changes = append(changes, compareValue("USER", "", o.User, n.User)...)
+	changes = append(changes, compareValue("WORKDIR", "", o.WorkingDir, n.WorkingDir)...)
+	changes = append(changes, compareMaps("EXPOSE", setMap(o.ExposedPorts), setMap(n.ExposedPorts))...)
+	changes = append(changes, compareMaps("VOLUME", setMap(o.Volumes), setMap(n.Volumes))...)
+	changes = append(changes, compareMaps("LABEL", o.Labels, n.Labels)...)
+	changes = append(changes, compareValue("STOPSIGNAL", "", o.StopSignal, n.StopSignal)...)
+	changes = append(changes, compareValue("HEALTHCHECK", "", healthcheck(o.Healthcheck), healthcheck(n.Healthcheck))...)
+	if old != nil && updated != nil {
+		changes = append(changes, compareValue("PLATFORM", "", platform(old), platform(updated))...)
+	}
+	return changes
+}
+
+// CompareBase compares the base image annotations of two manifests.
+func CompareBase(old, updated map[string]string) []Change {
+	var changes []Change
+	changes = append(changes, compareValue("BASE", "name", old[AnnotationBaseName], updated[AnnotationBaseName])...)
+	changes = append(changes, compareValue("BASE", "digest", old[AnnotationBaseDigest], updated[AnnotationBaseDigest])...)
+	return changes
+}
+
+// Summary describes the differences in one line for release notes, e.g.
+// "base layer changed, 2 layers rebuilt, ENV FOO added".
+func Summary(layers Layers, changes []Change) string {
+	var parts []string
+	if layers.BaseChanged() {
+		parts = append(parts, "base layer changed")
+	}
+	rebuilt := layers.Count(LayerRebuilt)
+	if layers.BaseChanged() && layers.Layers[0].Status == LayerRebuilt {
+		rebuilt--
+	}
+	for _, c := range []struct {
+		n    int
+		verb string
+	}{
+		{rebuilt, "rebuilt"},
+		{layers.Count(LayerAdded), "added"},
+		{layers.Count(LayerRemoved), "removed"},
+	} {
+		switch {
+		case c.n == 1:
+			parts = append(parts, "1 layer "+c.verb)
+		case c.n > 1:
+			parts = append(parts, fmt.Sprintf("%d layers %s", c.n, c.verb))
+		}
+	}
+	for _, c := range changes {
+		parts = append(parts, c.String())
+	}
+
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func compareValue(field, key, old, updated string) []Change {
+	switch {
+	case old == updated:
+		return nil
+	case old == "":
+		return []Change{{Type: Added, Field: field, Key: key, New: updated}}
+	case updated == "":
+		return []Change{{Type: Removed, Field: field, Key: key, Old: old}}
+	default:
+		return []Change{{Type: Changed, Field: field, Key: key, Old: old, New: updated}}
+	}
+}
+
+// compareMaps compares entries by key, in key order.
+func compareMaps(field string, old, updated map[string]string) []Change {
+	keys := make(map[string]bool)
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range updated {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []Change
+	for _, k := range sorted {
+		o, inOld := old[k]
+		n, inUpdated := updated[k]
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Type: Added, Field: field, Key: k, New: n})
+		case !inUpdated:
+			changes = append(changes, Change{Type: Removed, Field: field, Key: k, Old: o})
+		case o != n:
+			changes = append(changes, Change{Type: Changed, Field: field, Key: k, Old: o, New: n})
+		}
+	}
+	return changes
+}
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, e := range env {
+		k, v, _ := strings.Cut(e, "=")
+		m[k] = v
+	}
+	return m
+}
+
+func setMap(set map[string]struct{}) map[string]string {
+	m := make(map[string]string, len(set))
+	for k := range set {
+		m[k] = ""
+	}
+	return m
+}
+
+// jsonList formats commands in the exec form used in Dockerfiles.
+func jsonList(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+func healthcheck(h *v1.HealthConfig) string {
+	if h == nil || len(h.Test) == 0 {
+		return ""
+	}
+	return jsonList(h.Test)
+}
+
+func platform(cfg *v1.ConfigFile) string {
+	p := cfg.OS + "/" + cfg.Architecture
+	if cfg.Variant != "" {
+		p += "/" + cfg.Variant
+	}
+	return p
+}
@@ -0,0 +1,108 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/diff"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func hash(s string) v1.Hash {
+	return v1.Hash{Algorithm: "sha256", Hex: s}
+}
+
+func TestCompareConfigs(t *testing.T) {
+	old := &v1.ConfigFile{OS: "linux", Architecture: "amd64", Config: v1.Config{
+		Env:          []string{"PATH=/usr/bin", "VERSION=1.4.2", "DEBUG=1"},
+		Entrypoint:   []string{"/app"},
+		User:         "root",
+		ExposedPorts: map[string]struct{}{"80/tcp": {}},
+		Labels:       map[string]string{"maintainer": "ops"},
+	}}
+	updated := &v1.ConfigFile{OS: "linux", Architecture: "amd64", Config: v1.Config{
+		Env:          []string{"PATH=/usr/bin", "VERSION=1.4.3", "FOO=bar"},
+		Entrypoint:   []string{"/app"},
+		Cmd:          []string{"serve"},
+		User:         "app",
+		ExposedPorts: map[string]struct{}{"80/tcp": {}, "443/tcp": {}},
+	}}
+
+	assert.Equal(t, []diff.Change{
+		{Type: diff.Removed, Field: "ENV", Key: "DEBUG", Old: "1"},
+		{Type: diff.Added, Field: "ENV", Key: "FOO", New: "bar"},
+		{Type: diff.Changed, Field: "ENV", Key: "VERSION", Old: "1.4.2", New: "1.4.3"},
+		{Type: diff.Added, Field: "CMD", New: `["serve"]`},
+		{Type: diff.Changed, Field: "USER", Old: "root", New: "app"},
+		{Type: diff.Added, Field: "EXPOSE", Key: "443/tcp"},
+		{Type: diff.Removed, Field: "LABEL", Key: "maintainer", Old: "ops"},
+	}, diff.CompareConfigs(old, updated))
+
+	assert.Empty(t, diff.CompareConfigs(old, old))
+}
+
+func TestCompareBase(t *testing.T) {
+	changes := diff.CompareBase(
+		map[string]string{diff.AnnotationBaseName: "debian:12", diff.AnnotationBaseDigest: "sha256:aaa"},
+		map[string]string{diff.AnnotationBaseName: "debian:12", diff.AnnotationBaseDigest: "sha256:bbb"},
+	)
+	assert.Equal(t, []diff.Change{
+		{Type: diff.Changed, Field: "BASE", Key: "digest", Old: "sha256:aaa", New: "sha256:bbb"},
+	}, changes)
+
+	assert.Empty(t, diff.CompareBase(nil, nil))
+}
+
+func TestCompareLayers(t *testing.T) {
+	layers := diff.CompareLayers(
+		[]v1.Hash{hash("base1"), hash("deps"), hash("app1"), hash("assets")},
+		[]v1.Hash{hash("base2"), hash("deps"), hash("app2"), hash("assets2"), hash("extra")},
+	)
+
+	assert.Equal(t, 1, layers.Shared)
+	assert.True(t, layers.BaseChanged())
+	assert.Equal(t, 3, layers.Count(diff.LayerRebuilt))
+	assert.Equal(t, 1, layers.Count(diff.LayerUnchanged))
+	assert.Equal(t, 1, layers.Count(diff.LayerAdded))
+	assert.Equal(t, diff.Layer{Index: 5, Status: diff.LayerAdded, New: hash("extra")}, layers.Layers[4])
+
+	removed := diff.CompareLayers([]v1.Hash{hash("a"), hash("b")}, []v1.Hash{hash("a")})
+	assert.False(t, removed.BaseChanged())
+	assert.Equal(t, diff.Layer{Index: 2, Status: diff.LayerRemoved, Old: hash("b")}, removed.Layers[1])
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		layers  diff.Layers
+		changes []diff.Change
+		want    string
+	}{
+		{
+			name: "release",
+			layers: diff.CompareLayers(
+				[]v1.Hash{hash("base1"), hash("deps"), hash("app1"), hash("assets1")},
+				[]v1.Hash{hash("base2"), hash("deps"), hash("app2"), hash("assets2")},
+			),
+			changes: []diff.Change{{Type: diff.Added, Field: "ENV", Key: "FOO", New: "bar"}},
+			want:    "base layer changed, 2 layers rebuilt, ENV FOO added",
+		},
+		{
+			name:    "single layer",
+			layers:  diff.CompareLayers([]v1.Hash{hash("a")}, []v1.Hash{hash("a"), hash("b")}),
+			changes: []diff.Change{{Type: diff.Changed, Field: "USER", Old: "root", New: "app"}},
+			want:    "1 layer added, USER changed",
+		},
+		{
+			name:   "identical",
+			layers: diff.CompareLayers([]v1.Hash{hash("a")}, []v1.Hash{hash("a")}),
+			want:   "no changes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diff.Summary(tt.layers, tt.changes))
+		})
+	}
+}
@@ -0,0 +1,179 @@
+// Package layershare analyzes how layers are shared across a set of images.
+package layershare
+
+import (
+	"sort"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Layer is a layer of an image, sized as stored in the registry.
+type Layer struct {
+	Digest v1.Hash
+	Size   int64
+}
+
+// Image is an image and its layers, base first.
+type Image struct {
+	Ref    string
+	Layers []Layer
+}
+
+// ImageUsage describes how much of an image is shared with other images.
+type ImageUsage struct {
+	Ref    string
+	Layers int
+	Size   int64
+	// Shared is the size of the layers also used by another image, and
+	// Unique the size of the layers only this image uses.
+	Shared int64
+	Unique int64
+}
+
+// SharedLayer is a layer used by more than one image.
+type SharedLayer struct {
+	Digest v1.Hash
+	Size   int64
+	Images []string
+}
+
+// Base is a stack of layers several images start with.
+type Base struct {
+	// From is an image starting with the base, whose first Layers layers
+	// form it.
+	From   string
+	Layers int
+	Size   int64
+	Images []string
+	// Others are the images not built on the base.
+	Others []string
+}
+
+// Analysis is the result of Analyze.
+type Analysis struct {
+	Images []ImageUsage
+	Shared []SharedLayer
+	// PullSize is the size of pulling every image onto one node, with
+	// each layer downloaded and stored once. TotalSize is the sum of the
+	// image sizes, as if no layers were shared.
+	PullSize  int64
+	TotalSize int64
+	// Base is the most valuable base in common, nil when no two images
+	// start with the same layer.
+	Base *Base
+}
+
+// Saved returns the bytes layer sharing saves when pulling every image.
+func (a *Analysis) Saved() int64 {
+	return a.TotalSize - a.PullSize
+}
+
+// Analyze computes which layers the images share. A layer appearing more
+// than once in one image is counted once for it.
+func Analyze(images []Image) *Analysis {
+	users := make(map[v1.Hash][]string)
+	sizes := make(map[v1.Hash]int64)
+	var order []v1.Hash
+	for _, img := range images {
+		seen := make(map[v1.Hash]bool)
+		for _, l := range img.Layers {
+			if seen[l.Digest] {
+				continue
+			}
+			seen[l.Digest] = true
+			if _, ok := users[l.Digest]; !ok {
+				order = append(order, l.Digest)
+			}
+			users[l.Digest] = append(users[l.Digest], img.Ref)
+			sizes[l.Digest] = l.Size
+		}
+	}
+
+	a := &Analysis{}
+	for _, d := range order {
+		a.PullSize += sizes[d]
+		if len(users[d]) > 1 {
+			a.Shared = append(a.Shared, SharedLayer{Digest: d, Size: sizes[d], Images: users[d]})
+		}
+	}
+	// Largest savings first.
+	sort.SliceStable(a.Shared, func(i, j int) bool {
+		return a.Shared[i].Size*int64(len(a.Shared[i].Images)-1) > a.Shared[j].Size*int64(len(a.Shared[j].Images)-1)
+	})
+
+	for _, img := range images {
+		usage := ImageUsage{Ref: img.Ref, Layers: len(img.Layers)}
+		seen := make(map[v1.Hash]bool)
+		for _, l := range img.Layers {
+			if seen[l.Digest] {
+				continue
+			}
+			seen[l.Digest] = true
+			usage.Size += l.Size
+			if len(users[l.Digest]) > 1 {
+				usage.Shared += l.Size
+			} else {
+				usage.Unique += l.Size
+			}
+		}
+		a.TotalSize += usage.Size
+		a.Images = append(a.Images, usage)
+	}
+
+	a.Base = suggestBase(images)
+	return a
+}
+
+// suggestBase finds the stack of leading layers that saves the most bytes
+// through sharing, weighing its size by the number of images beyond the
+// first that start with it. Deeper stacks win ties.
+func suggestBase(images []Image) *Base {
+	var best *Base
+	var bestSaved int64
+	for i, img := range images {
+		var size int64
+		for depth := 1; depth <= len(img.Layers); depth++ {
+			prefix := img.Layers[:depth]
+			size += prefix[depth-1].Size
+
+			var on, others []string
+			seen := false
+			for j, other := range images {
+				switch {
+				case !hasPrefix(other.Layers, prefix):
+					others = append(others, other.Ref)
+				case j < i:
+					seen = true
+				default:
+					on = append(on, other.Ref)
+				}
+			}
+			if seen {
+				// An earlier image with this stack already covered it.
+				continue
+			}
+			if len(on) < 2 {
+				break
+			}
+
+			saved := size * int64(len(on)-1)
+			if best == nil || saved > bestSaved || (saved == bestSaved && depth > best.Layers) {
+				bestSaved = saved
+				best = &Base{From: img.Ref, Layers: depth, Size: size, Images: on, Others: others}
+			}
+		}
+	}
+	return best
+}
+
+func hasPrefix(layers, prefix []Layer) bool {
+	if len(layers) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if layers[i].Digest != prefix[i].Digest {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package layershare_test
+
+import (
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/layershare"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func layer(hex string, size int64) layershare.Layer {
+	return layershare.Layer{Digest: v1.Hash{Algorithm: "sha256", Hex: hex}, Size: size}
+}
+
+func TestAnalyze(t *testing.T) {
+	os := layer("os", 30)
+	runtime := layer("runtime", 50)
+	images := []layershare.Image{
+		{Ref: "api", Layers: []layershare.Layer{os, runtime, layer("api", 10)}},
+		{Ref: "worker", Layers: []layershare.Layer{os, runtime, layer("worker", 5)}},
+		{Ref: "web", Layers: []layershare.Layer{os, layer("nginx", 20), layer("web", 2)}},
+		{Ref: "tool", Layers: []layershare.Layer{layer("alpine", 3), layer("tool", 1)}},
+	}
+
+	a := layershare.Analyze(images)
+
+	assert.Equal(t, int64(90+85+52+4), a.TotalSize)
+	assert.Equal(t, int64(30+50+10+5+20+2+3+1), a.PullSize)
+	assert.Equal(t, a.TotalSize-a.PullSize, a.Saved())
+
+	require.Len(t, a.Shared, 2)
+	assert.Equal(t, []string{"api", "worker", "web"}, a.Shared[0].Images, "largest savings first")
+	assert.Equal(t, runtime.Digest, a.Shared[1].Digest)
+
+	assert.Equal(t, layershare.ImageUsage{Ref: "web", Layers: 3, Size: 52, Shared: 30, Unique: 22}, a.Images[2])
+	assert.Equal(t, layershare.ImageUsage{Ref: "tool", Layers: 2, Size: 4, Unique: 4}, a.Images[3])
+
+	// os+runtime saves 80 bytes once, os alone 60 bytes over two images.
+	require.NotNil(t, a.Base)
+	assert.Equal(t, &layershare.Base{
+		From:   "api",
+		Layers: 2,
+		Size:   80,
+		Images: []string{"api", "worker"},
+		Others: []string{"web", "tool"},
+	}, a.Base)
+}
+
+func TestAnalyze_NoCommonBase(t *testing.T) {
+	a := layershare.Analyze([]layershare.Image{
+		{Ref: "a", Layers: []layershare.Layer{layer("a", 1), layer("shared", 5)}},
+		{Ref: "b", Layers: []layershare.Layer{layer("b", 1), layer("shared", 5)}},
+	})
+
+	assert.Nil(t, a.Base)
+	require.Len(t, a.Shared, 1)
+	assert.Equal(t, int64(5), a.Saved())
+}
@@ -0,0 +1,113 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config enables, disables and tunes rules. It is usually loaded from a
+// YAML file:
+//
+//	rules:
+//	  no-healthcheck:
+//	    enabled: false
+//	  setuid-binaries:
+//	    severity: error
+//	    ignore: ["/usr/bin/sudo"]
+//	  missing-annotations:
+//	    required:
+//	      - org.opencontainers.image.source
+//	      - org.opencontainers.image.revision
+type Config struct {
+	Rules map[string]RuleConfig `yaml:"rules"`
+}
+
+// RuleConfig holds the settings for a single rule.
+type RuleConfig struct {
+	// Enabled defaults to true when unset.
+	Enabled *bool `yaml:"enabled"`
+	// Severity overrides the rule's default severity.
+	Severity string `yaml:"severity"`
+	// Ignore lists path.Match patterns of file paths to suppress findings
+	// for.
+	Ignore []string `yaml:"ignore"`
+	// Required lists annotation keys for the missing-annotations rule.
+	Required []string `yaml:"required"`
+}
+
+// LoadConfig reads a YAML config file.
+func LoadConfig(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lint config: %w", err)
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse lint config %s: %w", filename, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid lint config %s: %w", filename, err)
+	}
+
+	return cfg, nil
+}
+
+// SetEnabled enables or disables a rule, overriding the config file.
+func (c *Config) SetEnabled(id string, enabled bool) error {
+	if !isRule(id) {
+		return fmt.Errorf("unknown rule %q", id)
+	}
+	if c.Rules == nil {
+		c.Rules = make(map[string]RuleConfig)
+	}
+	rc := c.Rules[id]
+	rc.Enabled = &enabled
+	c.Rules[id] = rc
+	return nil
+}
+
+func (c *Config) enabled(id string) bool {
+	rc, ok := c.Rules[id]
+	return !ok || rc.Enabled == nil || *rc.Enabled
+}
+
+func (c *Config) validate() error {
+	for id, rc := range c.Rules {
+		if !isRule(id) {
+			return fmt.Errorf("unknown rule %q", id)
+		}
+		if rc.Severity != "" {
+			if _, err := ParseSeverity(rc.Severity); err != nil {
+				return fmt.Errorf("rule %s: %w", id, err)
+			}
+		}
+		for _, pattern := range rc.Ignore {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule %s: invalid ignore pattern %q: %w", id, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (rc *RuleConfig) ignores(p string) bool {
+	for _, pattern := range rc.Ignore {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isRule(id string) bool {
+	for _, rule := range builtinRules {
+		if rule.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package lint
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Instruction is an instruction of a Dockerfile.
+type Instruction struct {
+	// Keyword is the instruction in upper case, such as RUN.
+	Keyword string
+	// Args is the rest of the instruction, with continuation lines joined.
+	Args string
+	// Line is the 1-indexed line the instruction starts on.
+	Line int
+}
+
+// String returns the instruction with its whitespace normalized.
+func (in *Instruction) String() string {
+	return strings.Join(append([]string{in.Keyword}, strings.Fields(in.Args)...), " ")
+}
+
+// ParseDockerfile reads the instructions of a Dockerfile. Comments and
+// blank lines are skipped, also between continuation lines. Heredocs are
+// not interpreted.
+func ParseDockerfile(r io.Reader) ([]Instruction, error) {
+	var (
+		instructions []Instruction
+		current      *Instruction
+		text         strings.Builder
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		trimmed := strings.TrimSpace(scanner.Text())
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if current == nil {
+			current = &Instruction{Line: line}
+			text.Reset()
+		}
+		cont := strings.HasSuffix(trimmed, `\`)
+		text.WriteString(strings.TrimSuffix(trimmed, `\`))
+		text.WriteByte(' ')
+		if cont {
+			continue
+		}
+
+		keyword, args, _ := strings.Cut(strings.TrimSpace(text.String()), " ")
+		current.Keyword = strings.ToUpper(keyword)
+		current.Args = strings.TrimSpace(args)
+		instructions = append(instructions, *current)
+		current = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+	return instructions, nil
+}
+
+// Locate returns the instruction of dockerfile that f comes from, or nil
+// when it is not known. Findings about files come from the instruction that
+// created their layer, which is looked up in the image history. Other
+// findings come from the last instruction with their Instruction keyword.
+func Locate(f *Finding, history []v1.History, dockerfile []Instruction) *Instruction {
+	if f.Layer > 0 {
+		// History has entries for instructions that created no layer too.
+		layer := 0
+		for _, h := range history {
+			if h.EmptyLayer {
+				continue
+			}
+			if layer++; layer == f.Layer {
+				createdBy := normalizeCreatedBy(h.CreatedBy)
+				for i := len(dockerfile) - 1; i >= 0; i-- {
+					if dockerfile[i].String() == createdBy {
+						return &dockerfile[i]
+					}
+				}
+				return nil
+			}
+		}
+		return nil
+	}
+
+	if f.Instruction == "" {
+		return nil
+	}
+	for i := len(dockerfile) - 1; i >= 0; i-- {
+		in := &dockerfile[i]
+		if in.Keyword == f.Instruction && (f.Key == "" || setsKey(in.Args, f.Key)) {
+			return in
+		}
+	}
+	return nil
+}
+
+// normalizeCreatedBy turns the history of a layer back into the instruction
+// that created it. BuildKit records the instruction with a "# buildkit"
+// suffix and the shell of RUN instructions; the classic builder records
+// only the command of RUN instructions.
+func normalizeCreatedBy(createdBy string) string {
+	createdBy = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(createdBy), "# buildkit"))
+	if rest, ok := strings.CutPrefix(createdBy, "/bin/sh -c "); ok {
+		if strings.HasPrefix(rest, "#(nop)") {
+			return ""
+		}
+		createdBy = "RUN " + rest
+	}
+	if rest, ok := strings.CutPrefix(createdBy, "RUN /bin/sh -c "); ok {
+		createdBy = "RUN " + rest
+	}
+	return strings.Join(strings.Fields(createdBy), " ")
+}
+
+// setsKey reports whether the arguments of an ENV, ARG or LABEL instruction
+// set key, in either the key=value or the legacy "key value" form.
+func setsKey(args, key string) bool {
+	fields := strings.Fields(args)
+	if len(fields) > 0 && fields[0] == key {
+		return true
+	}
+	for _, field := range fields {
+		if name, _, ok := strings.Cut(field, "="); ok && name == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Fingerprint identifies f across runs, for code scanning tools to track
+// it as the same result. It depends on the rule, the instruction f comes
+// from, if known, and the file or key f is about, and not on line numbers
+// or messages, which change as the Dockerfile and the image do.
+func Fingerprint(f *Finding, in *Instruction) string {
+	parts := []string{f.RuleID, "", f.Path, f.Key}
+	if in != nil {
+		parts[1] = in.String()
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
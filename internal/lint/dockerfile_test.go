@@ -0,0 +1,102 @@
+package lint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/lint"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dockerfile = `# syntax=docker/dockerfile:1
+FROM golang:1.24 AS build
+RUN go build -o /out/app .
+
+FROM alpine:latest
+RUN apk add --no-cache ca-certificates && \
+    # keep the index for later
+    touch /var/cache/apk/keep
+ENV PATH=/usr/local/bin:/usr/bin GITHUB_TOKEN=ghp_abc
+COPY --from=build /out/app /app
+user root
+`
+
+func parseDockerfile(t *testing.T) []lint.Instruction {
+	t.Helper()
+
+	instructions, err := lint.ParseDockerfile(strings.NewReader(dockerfile))
+	require.NoError(t, err)
+	return instructions
+}
+
+func TestParseDockerfile(t *testing.T) {
+	instructions := parseDockerfile(t)
+	require.Len(t, instructions, 7)
+
+	run := instructions[3]
+	assert.Equal(t, "RUN", run.Keyword)
+	assert.Equal(t, 6, run.Line)
+	assert.Equal(t, "RUN apk add --no-cache ca-certificates && touch /var/cache/apk/keep", run.String())
+
+	user := instructions[6]
+	assert.Equal(t, "USER", user.Keyword, "keywords are case-insensitive")
+	assert.Equal(t, 11, user.Line)
+}
+
+func TestLocate(t *testing.T) {
+	instructions := parseDockerfile(t)
+	history := []v1.History{
+		{CreatedBy: "/bin/sh -c #(nop) ADD file:abc in / "},
+		{CreatedBy: `/bin/sh -c #(nop)  CMD ["/bin/sh"]`, EmptyLayer: true},
+		{CreatedBy: "RUN /bin/sh -c apk add --no-cache ca-certificates &&     touch /var/cache/apk/keep # buildkit"},
+		{CreatedBy: "ENV PATH=/usr/local/bin:/usr/bin GITHUB_TOKEN=ghp_abc", EmptyLayer: true},
+		{CreatedBy: "COPY --from=build /out/app /app # buildkit"},
+	}
+
+	tests := []struct {
+		name    string
+		finding lint.Finding
+		line    int
+	}{
+		{"buildkit RUN layer", lint.Finding{Layer: 2}, 6},
+		{"COPY layer", lint.Finding{Layer: 3}, 10},
+		{"base image layer", lint.Finding{Layer: 1}, 0},
+		{"missing layer", lint.Finding{Layer: 9}, 0},
+		{"keyword", lint.Finding{Instruction: "USER"}, 11},
+		{"last FROM", lint.Finding{Instruction: "FROM"}, 5},
+		{"ENV key", lint.Finding{Instruction: "ENV", Key: "GITHUB_TOKEN"}, 9},
+		{"ENV key not set", lint.Finding{Instruction: "ENV", Key: "AWS_SECRET"}, 0},
+		{"no instruction", lint.Finding{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := lint.Locate(&tt.finding, history, instructions)
+			if tt.line == 0 {
+				assert.Nil(t, in)
+				return
+			}
+			require.NotNil(t, in)
+			assert.Equal(t, tt.line, in.Line)
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	finding := &lint.Finding{RuleID: "setuid-binaries", Path: "/usr/bin/passwd", Message: "a"}
+	in := &lint.Instruction{Keyword: "RUN", Args: "apk add shadow", Line: 3}
+
+	moved := *in
+	moved.Line = 12
+	reworded := *finding
+	reworded.Message = "b"
+	assert.Equal(t, lint.Fingerprint(finding, in), lint.Fingerprint(&reworded, &moved),
+		"line numbers and messages do not change the fingerprint")
+
+	other := *finding
+	other.Path = "/usr/bin/su"
+	assert.NotEqual(t, lint.Fingerprint(finding, in), lint.Fingerprint(&other, in))
+	assert.NotEqual(t, lint.Fingerprint(finding, in), lint.Fingerprint(finding, nil))
+}
@@ -0,0 +1,232 @@
+// Package lint evaluates best-practice rules against an image's config,
+// manifest and filesystem.
+package lint
+
+import (
+	"archive/tar"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Severity is the level a rule reports findings at. The values match SARIF
+// result levels.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityNote    Severity = "note"
+)
+
+// rank orders severities from lowest to highest.
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityNote:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// AtLeast reports whether s is as severe as other or more.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+// ParseSeverity parses a severity name. "info" is accepted as an alias for
+// "note".
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "error":
+		return SeverityError, nil
+	case "warning", "warn":
+		return SeverityWarning, nil
+	case "note", "info":
+		return SeverityNote, nil
+	}
+	return "", fmt.Errorf("unknown severity %q (expected error, warning or note)", s)
+}
+
+// Finding is a single rule violation.
+type Finding struct {
+	RuleID   string
+	Severity Severity
+	Message  string
+	// Path is the file in the image the finding refers to, if any.
+	Path string
+	// Layer is the 1-indexed layer providing Path, or 0.
+	Layer int
+	// Instruction is the Dockerfile instruction, such as USER, that set the
+	// part of the image config a finding is about.
+	Instruction string
+	// Key is the name of the environment variable or annotation a finding
+	// is about, if any.
+	Key string
+}
+
+// File is an entry of the merged filesystem as seen by filesystem rules.
+type File struct {
+	Path     string
+	Mode     int64
+	Typeflag byte
+	Size     int64
+	Layer    int
+}
+
+// IsDir reports whether the entry is a directory.
+func (f *File) IsDir() bool {
+	return f.Typeflag == tar.TypeDir
+}
+
+// Target is everything rules are evaluated against.
+type Target struct {
+	Config   *v1.ConfigFile
+	Manifest *v1.Manifest
+	// Files is only populated when an enabled rule needs the filesystem.
+	Files []File
+}
+
+// Annotation looks up a key in the manifest annotations, falling back to
+// the config labels, where most build tools also record OCI annotations.
+func (t *Target) Annotation(key string) (string, bool) {
+	if t.Manifest != nil {
+		if v, ok := t.Manifest.Annotations[key]; ok {
+			return v, true
+		}
+	}
+	if t.Config != nil {
+		if v, ok := t.Config.Config.Labels[key]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Rule is a single best-practice check.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+	// Filesystem marks rules that need Target.Files.
+	Filesystem bool
+	Check      func(t *Target, cfg *RuleConfig) []Finding
+}
+
+// Rules returns the built-in rules, sorted by ID.
+func Rules() []Rule {
+	rules := make([]Rule, len(builtinRules))
+	copy(rules, builtinRules)
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// Linter runs the enabled rules against images.
+type Linter struct {
+	rules  []Rule
+	config *Config
+}
+
+// NewLinter returns a linter for the rules enabled by cfg. A nil cfg enables
+// every built-in rule with its default settings.
+func NewLinter(cfg *Config) (*Linter, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	for _, rule := range Rules() {
+		if !cfg.enabled(rule.ID) {
+			continue
+		}
+		if sev := cfg.Rules[rule.ID].Severity; sev != "" {
+			parsed, err := ParseSeverity(sev)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+			}
+			rule.Severity = parsed
+		}
+		rules = append(rules, rule)
+	}
+
+	return &Linter{rules: rules, config: cfg}, nil
+}
+
+// Rules returns the rules the linter evaluates, with configured severities.
+func (l *Linter) Rules() []Rule {
+	return l.rules
+}
+
+// NeedsFilesystem reports whether any enabled rule inspects files.
+func (l *Linter) NeedsFilesystem() bool {
+	for _, rule := range l.rules {
+		if rule.Filesystem {
+			return true
+		}
+	}
+	return false
+}
+
+// LintImage collects the target from img and evaluates all enabled rules.
+func (l *Linter) LintImage(img v1.Image) ([]Finding, error) {
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config file: %w", err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+
+	target := &Target{Config: cfg, Manifest: manifest}
+
+	if l.NeedsFilesystem() {
+		layers, err := img.Layers()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get layers: %w", err)
+		}
+		err = oci.WalkMerged(layers, func(f *oci.FileEntry) error {
+			target.Files = append(target.Files, File{
+				Path:     f.Path,
+				Mode:     f.Header.Mode,
+				Typeflag: f.Header.Typeflag,
+				Size:     f.Header.Size,
+				Layer:    f.Layer,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk merged filesystem: %w", err)
+		}
+		sort.Slice(target.Files, func(i, j int) bool { return target.Files[i].Path < target.Files[j].Path })
+	}
+
+	return l.Lint(target), nil
+}
+
+// Lint evaluates all enabled rules against target.
+func (l *Linter) Lint(target *Target) []Finding {
+	var findings []Finding
+	for _, rule := range l.rules {
+		ruleCfg := l.config.Rules[rule.ID]
+		for _, f := range rule.Check(target, &ruleCfg) {
+			if f.Path != "" && ruleCfg.ignores(f.Path) {
+				continue
+			}
+			f.RuleID = rule.ID
+			f.Severity = rule.Severity
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
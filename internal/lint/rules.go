@@ -0,0 +1,276 @@
+package lint
+
+import (
+	"archive/tar"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/secrets"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Well-known OCI annotation keys used by the rules.
+const (
+	AnnotationSource   = "org.opencontainers.image.source"
+	AnnotationBaseName = "org.opencontainers.image.base.name"
+)
+
+var builtinRules = []Rule{
+	{
+		ID:          "root-user",
+		Description: "Image runs as root",
+		Severity:    SeverityWarning,
+		Check:       checkRootUser,
+	},
+	{
+		ID:          "no-healthcheck",
+		Description: "Image does not define a HEALTHCHECK",
+		Severity:    SeverityNote,
+		Check:       checkHealthcheck,
+	},
+	{
+		ID:          "latest-base",
+		Description: "Base image is referenced by the latest tag",
+		Severity:    SeverityWarning,
+		Check:       checkLatestBase,
+	},
+	{
+		ID:          "secrets-in-env",
+		Description: "Secret stored in an environment variable",
+		Severity:    SeverityError,
+		Check:       checkSecretsInEnv,
+	},
+	{
+		ID:          "package-manager-cache",
+		Description: "Package manager cache left in the image",
+		Severity:    SeverityNote,
+		Filesystem:  true,
+		Check:       checkPackageManagerCache,
+	},
+	{
+		ID:          "setuid-binaries",
+		Description: "File has the setuid or setgid bit set",
+		Severity:    SeverityWarning,
+		Filesystem:  true,
+		Check:       checkSetuid,
+	},
+	{
+		ID:          "missing-annotations",
+		Description: "Required OCI annotations are missing",
+		Severity:    SeverityNote,
+		Check:       checkAnnotations,
+	},
+	{
+		ID:          "world-writable",
+		Description: "Path is writable by any user",
+		Severity:    SeverityWarning,
+		Filesystem:  true,
+		Check:       checkWorldWritable,
+	},
+}
+
+func checkRootUser(t *Target, _ *RuleConfig) []Finding {
+	user := strings.TrimSpace(t.Config.Config.User)
+	uid, _, _ := strings.Cut(user, ":")
+	if uid != "" && uid != "root" && uid != "0" {
+		return nil
+	}
+	if user == "" {
+		return []Finding{{Message: "No USER is set, so the container runs as root"}}
+	}
+	return []Finding{{Message: fmt.Sprintf("USER %s runs the container as root", user), Instruction: "USER"}}
+}
+
+func checkHealthcheck(t *Target, _ *RuleConfig) []Finding {
+	hc := t.Config.Config.Healthcheck
+	if hc == nil || len(hc.Test) == 0 {
+		return []Finding{{Message: "No HEALTHCHECK is defined"}}
+	}
+	if hc.Test[0] == "NONE" {
+		return []Finding{{Message: "HEALTHCHECK is disabled with NONE", Instruction: "HEALTHCHECK"}}
+	}
+	return nil
+}
+
+func checkLatestBase(t *Target, _ *RuleConfig) []Finding {
+	base, ok := t.Annotation(AnnotationBaseName)
+	if !ok || base == "" {
+		return nil
+	}
+	ref, err := name.ParseReference(base)
+	if err != nil {
+		return nil
+	}
+	tag, ok := ref.(name.Tag)
+	if !ok || tag.TagStr() != "latest" {
+		return nil
+	}
+	return []Finding{{
+		Message:     fmt.Sprintf("Base image %s uses the latest tag, pin a version or digest", base),
+		Instruction: "FROM",
+	}}
+}
+
+func checkSecretsInEnv(t *Target, _ *RuleConfig) []Finding {
+	cfg := &v1.ConfigFile{Config: v1.Config{Env: t.Config.Config.Env}}
+	// Several secret rules can match the same variable; report it once.
+	seen := make(map[string]bool)
+	var findings []Finding
+	for _, f := range secrets.NewScanner(secrets.DefaultRules).ScanConfig(cfg) {
+		if seen[f.Path] {
+			continue
+		}
+		seen[f.Path] = true
+		findings = append(findings, Finding{
+			Message:     fmt.Sprintf("ENV %s contains a secret (%s: %s)", f.Path, f.Description, f.Secret),
+			Instruction: "ENV",
+			Key:         f.Path,
+		})
+	}
+	return findings
+}
+
+// packageCaches lists directories whose contents are caches that package
+// managers leave behind unless explicitly cleaned in the same layer.
+var packageCaches = []string{
+	"/var/lib/apt/lists",
+	"/var/cache/apt/archives",
+	"/var/cache/apk",
+	"/var/cache/yum",
+	"/var/cache/dnf",
+	"/root/.cache/pip",
+	"/root/.npm/_cacache",
+	"/usr/local/share/.cache/yarn",
+	"/root/.cache/go-build",
+}
+
+func checkPackageManagerCache(t *Target, _ *RuleConfig) []Finding {
+	type usage struct {
+		files int
+		size  int64
+		layer int
+	}
+	found := make(map[string]*usage)
+
+	for _, f := range t.Files {
+		if f.Typeflag != tar.TypeReg {
+			continue
+		}
+		for _, dir := range packageCaches {
+			if !strings.HasPrefix(f.Path, dir+"/") {
+				continue
+			}
+			// apt keeps an empty lock file and partial/ directory around
+			// even after the lists are removed.
+			if strings.HasSuffix(f.Path, "/lock") {
+				continue
+			}
+			u := found[dir]
+			if u == nil {
+				u = &usage{}
+				found[dir] = u
+			}
+			u.files++
+			u.size += f.Size
+			u.layer = max(u.layer, f.Layer)
+		}
+	}
+
+	var findings []Finding
+	for _, dir := range packageCaches {
+		u, ok := found[dir]
+		if !ok {
+			continue
+		}
+		findings = append(findings, Finding{
+			Message: fmt.Sprintf("%s holds %d cached files (%d bytes)", dir, u.files, u.size),
+			Path:    dir,
+			Layer:   u.layer,
+		})
+	}
+	return findings
+}
+
+func checkSetuid(t *Target, _ *RuleConfig) []Finding {
+	var findings []Finding
+	for _, f := range t.Files {
+		if f.Typeflag != tar.TypeReg {
+			continue
+		}
+		var bits []string
+		if f.Mode&tarSetuid != 0 {
+			bits = append(bits, "setuid")
+		}
+		if f.Mode&tarSetgid != 0 {
+			bits = append(bits, "setgid")
+		}
+		if len(bits) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Message: fmt.Sprintf("%s has the %s bit set", f.Path, strings.Join(bits, " and ")),
+			Path:    f.Path,
+			Layer:   f.Layer,
+		})
+	}
+	return findings
+}
+
+// defaultRequiredAnnotations is used when the rule config lists none.
+var defaultRequiredAnnotations = []string{AnnotationSource}
+
+func checkAnnotations(t *Target, cfg *RuleConfig) []Finding {
+	required := cfg.Required
+	if len(required) == 0 {
+		required = defaultRequiredAnnotations
+	}
+
+	var missing []string
+	for _, key := range required {
+		if v, ok := t.Annotation(key); !ok || v == "" {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+
+	findings := make([]Finding, len(missing))
+	for i, key := range missing {
+		findings[i] = Finding{Message: fmt.Sprintf("Annotation %s is not set", key), Key: key}
+	}
+	return findings
+}
+
+func checkWorldWritable(t *Target, _ *RuleConfig) []Finding {
+	var findings []Finding
+	for _, f := range t.Files {
+		if f.Typeflag != tar.TypeReg && f.Typeflag != tar.TypeDir {
+			continue
+		}
+		if f.Mode&0o002 == 0 {
+			continue
+		}
+		// Sticky directories such as /tmp only let owners remove their files.
+		if f.IsDir() && f.Mode&tarSticky != 0 {
+			continue
+		}
+		kind := "File"
+		if f.IsDir() {
+			kind = "Directory"
+		}
+		findings = append(findings, Finding{
+			Message: fmt.Sprintf("%s %s is world-writable", kind, f.Path),
+			Path:    f.Path,
+			Layer:   f.Layer,
+		})
+	}
+	return findings
+}
+
+// Mode bits as stored in tar headers.
+const (
+	tarSetuid = 0o4000
+	tarSetgid = 0o2000
+	tarSticky = 0o1000
+)
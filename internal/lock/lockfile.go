@@ -0,0 +1,88 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// DefaultLockfile is the lockfile written when no path is given.
+const DefaultLockfile = "cek.lock"
+
+// lockfileVersion is bumped on incompatible format changes.
+const lockfileVersion = 1
+
+// Lockfile records the digest each image reference resolved to:
+//
+//	{
+//	  "version": 1,
+//	  "images": {
+//	    "nginx:1.27": {
+//	      "digest": "sha256:...",
+//	      "platforms": {"linux/amd64": "sha256:..."},
+//	      "sources": ["deploy/web.yaml"]
+//	    }
+//	  }
+//	}
+type Lockfile struct {
+	Version int               `json:"version"`
+	Images  map[string]*Entry `json:"images"`
+}
+
+// Entry is a locked image reference.
+type Entry struct {
+	Digest string `json:"digest"`
+	// Platforms maps platforms, e.g. "linux/arm64", to the digest of
+	// their image in a multi-platform index.
+	Platforms map[string]string `json:"platforms,omitempty"`
+	// Sources lists the files the reference was found in.
+	Sources []string `json:"sources"`
+}
+
+// NewLockfile returns an empty lockfile.
+func NewLockfile() *Lockfile {
+	return &Lockfile{Version: lockfileVersion, Images: make(map[string]*Entry)}
+}
+
+// ReadLockfile reads the lockfile at path. A missing file yields an empty
+// lockfile.
+func ReadLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewLockfile(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	lf := NewLockfile()
+	if err := json.Unmarshal(data, lf); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+	if lf.Version != lockfileVersion {
+		return nil, fmt.Errorf("lockfile %s has unsupported version %d", path, lf.Version)
+	}
+	if lf.Images == nil {
+		lf.Images = make(map[string]*Entry)
+	}
+	return lf, nil
+}
+
+// Write writes the lockfile to path, replacing it atomically.
+func (l *Lockfile) Write(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	data = append(data, '\n')
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
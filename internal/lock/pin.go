@@ -0,0 +1,82 @@
+package lock
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Pin rewrites references in place to "name@digest", taking the digest for
+// each reference name from digests. References without a name, or already
+// pinned to their digest, are left alone. It returns the references that
+// were rewritten.
+func Pin(refs []Reference, digests map[string]string) ([]Reference, error) {
+	byFile := make(map[string][]Reference)
+	var files []string
+	for _, ref := range refs {
+		digest, ok := digests[ref.Name]
+		if ref.Name == "" || !ok || ref.Digest == digest {
+			continue
+		}
+		if _, seen := byFile[ref.File]; !seen {
+			files = append(files, ref.File)
+		}
+		byFile[ref.File] = append(byFile[ref.File], ref)
+	}
+
+	var pinned []Reference
+	for _, file := range files {
+		rewritten, err := pinFile(file, byFile[file], digests)
+		if err != nil {
+			return pinned, err
+		}
+		pinned = append(pinned, rewritten...)
+	}
+	return pinned, nil
+}
+
+func pinFile(path string, refs []Reference, digests map[string]string) ([]Reference, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+
+	// Rewrite from the end of each line so earlier columns stay valid.
+	sort.SliceStable(refs, func(i, j int) bool {
+		if refs[i].Line != refs[j].Line {
+			return refs[i].Line < refs[j].Line
+		}
+		return refs[i].Column > refs[j].Column
+	})
+
+	var pinned []Reference
+	for _, ref := range refs {
+		if ref.Line < 1 || ref.Line > len(lines) {
+			return pinned, fmt.Errorf("failed to pin %s: line out of range", ref.Source())
+		}
+		line := lines[ref.Line-1]
+		start := max(ref.Column-1, 0)
+		if start > len(line) {
+			start = 0
+		}
+		i := strings.Index(line[start:], ref.Raw)
+		if i < 0 {
+			return pinned, fmt.Errorf("failed to pin %s: %s not found, was the file changed?", ref.Source(), ref.Raw)
+		}
+		i += start
+
+		lines[ref.Line-1] = line[:i] + ref.Name + "@" + digests[ref.Name] + line[i+len(ref.Raw):]
+		pinned = append(pinned, ref)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), info.Mode().Perm()); err != nil {
+		return pinned, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return pinned, nil
+}
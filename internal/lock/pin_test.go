@@ -0,0 +1,46 @@
+package lock_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/lock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPin(t *testing.T) {
+	const updated = "sha256:0000000000000000000000000000000000000000000000000000000000000002"
+
+	dir := t.TempDir()
+	path := writeFile(t, dir, "compose.yaml", `services:
+  web:
+    image: "nginx:1.27"  # front
+  cache:
+    image: redis:7@`+digest+`
+  db:
+    image: postgres:17@`+updated+`
+`)
+
+	refs, err := lock.ScanFile(path, lock.KindCompose)
+	require.NoError(t, err)
+
+	pinned, err := lock.Pin(refs, map[string]string{
+		"nginx:1.27":  digest,
+		"redis:7":     updated,
+		"postgres:17": updated,
+	})
+	require.NoError(t, err)
+	assert.Len(t, pinned, 2, "postgres is already pinned")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `services:
+  web:
+    image: "nginx:1.27@`+digest+`"  # front
+  cache:
+    image: redis:7@`+updated+`
+  db:
+    image: postgres:17@`+updated+`
+`, string(data))
+}
@@ -0,0 +1,103 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"golang.org/x/sync/errgroup"
+)
+
+// Resolved is the digest an image reference currently points at.
+type Resolved struct {
+	Digest v1.Hash
+	// Platforms maps each requested platform to the digest of its image,
+	// for references pointing at a multi-platform index.
+	Platforms map[string]v1.Hash
+}
+
+// Matches reports whether digest is the resolved digest or the digest of
+// one of its platforms.
+func (r *Resolved) Matches(digest string) bool {
+	if r.Digest.String() == digest {
+		return true
+	}
+	for _, d := range r.Platforms {
+		if d.String() == digest {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve looks up the current digest of each image name with HEAD
+// requests, making at most concurrency requests at once. When platforms
+// are given, the index of multi-platform images is fetched to resolve the
+// digest of each platform.
+func Resolve(ctx context.Context, names []string, platforms []string, concurrency int, opts ...remote.Option) (map[string]*Resolved, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]*Resolved, len(names))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, n := range names {
+		g.Go(func() error {
+			resolved, err := resolve(gctx, n, platforms, opts)
+			if err != nil {
+				return err
+			}
+			results[i] = resolved
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]*Resolved, len(names))
+	for i, n := range names {
+		resolved[n] = results[i]
+	}
+	return resolved, nil
+}
+
+func resolve(ctx context.Context, image string, platforms []string, opts []remote.Option) (*Resolved, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse image reference %s: %w", image, err)
+	}
+	opts = append([]remote.Option{remote.WithContext(ctx)}, opts...)
+
+	desc, err := remote.Head(ref, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", image, err)
+	}
+	resolved := &Resolved{Digest: desc.Digest}
+	if len(platforms) == 0 || !desc.MediaType.IsIndex() {
+		return resolved, nil
+	}
+
+	idx, err := remote.Index(ref.Context().Digest(desc.Digest.String()), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch index of %s: %w", image, err)
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index of %s: %w", image, err)
+	}
+
+	resolved.Platforms = make(map[string]v1.Hash, len(platforms))
+	for _, p := range platforms {
+		child, err := oci.SelectPlatform(manifest, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", image, err)
+		}
+		resolved.Platforms[p] = child.Digest
+	}
+	return resolved, nil
+}
@@ -0,0 +1,314 @@
+// Package lock finds image references in Dockerfiles, Compose files and
+// Kubernetes manifests and pins them to digests.
+package lock
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is the type of file a reference was found in.
+type Kind string
+
+const (
+	KindDockerfile Kind = "dockerfile"
+	KindCompose    Kind = "compose"
+	KindKubernetes Kind = "kubernetes"
+)
+
+// Reference is an image reference found in a file.
+type Reference struct {
+	File string
+	// Line and Column locate Raw in the file, starting at 1.
+	Line   int
+	Column int
+	Kind   Kind
+	// Raw is the reference as written, e.g. "nginx:1.27@sha256:...".
+	Raw string
+	// Name is Raw without a digest, e.g. "nginx:1.27". It is empty for
+	// references that only have a digest.
+	Name string
+	// Digest is the digest Raw is pinned to, if any.
+	Digest string
+}
+
+// Source returns the location of the reference, e.g. "Dockerfile:3".
+func (r Reference) Source() string {
+	return r.File + ":" + strconv.Itoa(r.Line)
+}
+
+// Pinned reports whether the reference has a digest.
+func (r Reference) Pinned() bool {
+	return r.Digest != ""
+}
+
+// Scan finds image references in the given files and directories.
+// Directories are walked, skipping hidden directories, node_modules and
+// vendor. YAML files found while walking that cannot be parsed, such as
+// Helm templates, are skipped; files given explicitly must parse.
+func Scan(paths []string) ([]Reference, error) {
+	var refs []Reference
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+		if !info.IsDir() {
+			kind, ok := classify(root)
+			if !ok {
+				// Explicit files are scanned as Kubernetes manifests
+				// unless they look like a Dockerfile.
+				kind = KindKubernetes
+			}
+			found, err := ScanFile(root, kind)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, found...)
+			continue
+		}
+
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && skipDir(d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			kind, ok := classify(path)
+			if !ok {
+				return nil
+			}
+			found, err := ScanFile(path, kind)
+			if err != nil {
+				var perr *parseError
+				if errors.As(err, &perr) {
+					return nil
+				}
+				return err
+			}
+			refs = append(refs, found...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+	}
+	return refs, nil
+}
+
+// ScanFile finds image references in a single file of the given kind.
+func ScanFile(path string, kind Kind) ([]Reference, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return ScanData(path, data, kind)
+}
+
+// ScanData finds image references in data of the given kind, such as
+// manifests read from stdin. References are attributed to path.
+func ScanData(path string, data []byte, kind Kind) ([]Reference, error) {
+	var refs []Reference
+	var err error
+	if kind == KindDockerfile {
+		refs = scanDockerfile(data)
+	} else {
+		refs, err = scanYAML(data)
+		if err != nil {
+			return nil, &parseError{path: path, err: err}
+		}
+	}
+
+	for i := range refs {
+		refs[i].File = path
+		refs[i].Kind = kind
+	}
+	return refs, nil
+}
+
+type parseError struct {
+	path string
+	err  error
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("failed to parse %s: %v", e.path, e.err)
+}
+
+func (e *parseError) Unwrap() error {
+	return e.err
+}
+
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor"
+}
+
+// classify reports the kind of file by its name.
+func classify(path string) (Kind, bool) {
+	base := filepath.Base(path)
+	lower := strings.ToLower(base)
+
+	switch {
+	case base == "Dockerfile", base == "Containerfile",
+		strings.HasPrefix(base, "Dockerfile."), strings.HasSuffix(lower, ".dockerfile"):
+		return KindDockerfile, true
+	case strings.HasSuffix(lower, ".yaml"), strings.HasSuffix(lower, ".yml"):
+		if strings.HasPrefix(lower, "docker-compose") || strings.HasPrefix(lower, "compose.") {
+			return KindCompose, true
+		}
+		return KindKubernetes, true
+	}
+	return "", false
+}
+
+// scanDockerfile finds the images of FROM instructions and COPY --from
+// flags, skipping build stages, scratch and references using build args.
+func scanDockerfile(data []byte) []Reference {
+	var refs []Reference
+	stages := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		var candidate, stage string
+		switch strings.ToUpper(fields[0]) {
+		case "FROM":
+			args := withoutFlags(fields[1:])
+			if len(args) == 0 {
+				continue
+			}
+			candidate = args[0]
+			if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+				stage = strings.ToLower(args[2])
+			}
+		case "COPY":
+			for _, f := range fields[1:] {
+				if v, ok := strings.CutPrefix(f, "--from="); ok {
+					candidate = v
+				}
+			}
+		}
+
+		if isImage(candidate, stages) {
+			if ref, ok := parseReference(candidate); ok {
+				ref.Line = lineNo
+				ref.Column = strings.Index(line, candidate) + 1
+				refs = append(refs, ref)
+			}
+		}
+		if stage != "" {
+			stages[stage] = true
+		}
+	}
+	return refs
+}
+
+// isImage reports whether a FROM or COPY --from value names an image rather
+// than a build stage or stage index.
+func isImage(candidate string, stages map[string]bool) bool {
+	if candidate == "" || candidate == "scratch" || stages[strings.ToLower(candidate)] {
+		return false
+	}
+	_, err := strconv.Atoi(candidate)
+	return err != nil
+}
+
+func withoutFlags(fields []string) []string {
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		fields = fields[1:]
+	}
+	return fields
+}
+
+// scanYAML finds scalar values of "image" keys in every document, which
+// covers Compose services and the containers of Kubernetes workloads.
+func scanYAML(data []byte) ([]Reference, error) {
+	var refs []Reference
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				if key.Value == "image" && value.Kind == yaml.ScalarNode {
+					if ref, ok := parseReference(value.Value); ok {
+						ref.Line = value.Line
+						ref.Column = value.Column
+						refs = append(refs, ref)
+					}
+					continue
+				}
+				walk(value)
+			}
+			return
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return refs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		walk(&doc)
+	}
+}
+
+// parseReference splits s into a name and digest, skipping values that
+// are templated or not image references.
+func parseReference(s string) (Reference, bool) {
+	if s == "" || strings.ContainsAny(s, "${}") {
+		return Reference{}, false
+	}
+
+	ref := Reference{Raw: s, Name: s}
+	if n, digest, ok := strings.Cut(s, "@"); ok {
+		if _, err := v1.NewHash(digest); err != nil {
+			return Reference{}, false
+		}
+		t, err := name.NewTag(n)
+		if err != nil {
+			return Reference{}, false
+		}
+		ref.Digest = digest
+		ref.Name = ""
+		// A name without a tag, e.g. "alpine@sha256:...", only pins a
+		// digest and has nothing to resolve.
+		if strings.HasSuffix(n, ":"+t.TagStr()) {
+			ref.Name = n
+		}
+		return ref, true
+	}
+
+	if _, err := name.NewTag(s); err != nil {
+		return Reference{}, false
+	}
+	return ref, true
+}
@@ -0,0 +1,129 @@
+package lock_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/lock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const digest = "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func raws(refs []lock.Reference) []string {
+	out := make([]string, len(refs))
+	for i, r := range refs {
+		out[i] = r.Raw
+	}
+	return out
+}
+
+func TestScanFile_Dockerfile(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "Dockerfile", strings.Join([]string{
+		"ARG BASE=alpine:3.20",
+		"FROM --platform=$BUILDPLATFORM golang:1.24 AS build",
+		"FROM build AS test",
+		"FROM ${BASE}",
+		"COPY --from=build /out /app",
+		"COPY --from=ghcr.io/org/tools:v1 /bin/tool /bin/tool",
+		"FROM scratch",
+		"from gcr.io/distroless/static:nonroot@" + digest,
+	}, "\n"))
+
+	refs, err := lock.ScanFile(path, lock.KindDockerfile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"golang:1.24",
+		"ghcr.io/org/tools:v1",
+		"gcr.io/distroless/static:nonroot@" + digest,
+	}, raws(refs))
+
+	assert.Equal(t, 2, refs[0].Line)
+	assert.Equal(t, 32, refs[0].Column)
+	assert.False(t, refs[0].Pinned())
+	assert.Equal(t, "gcr.io/distroless/static:nonroot", refs[2].Name)
+	assert.Equal(t, digest, refs[2].Digest)
+}
+
+func TestScanFile_YAML(t *testing.T) {
+	dir := t.TempDir()
+	compose := writeFile(t, dir, "docker-compose.yml", `services:
+  web:
+    image: nginx:1.27
+  db:
+    image: "postgres:17"
+  app:
+    build: .
+`)
+	manifests := writeFile(t, dir, "deploy.yaml", `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: registry.internal/app:v2
+      containers:
+        - name: app
+          image: registry.internal/app:v2
+        - name: sidecar
+          image: "{{ .Values.sidecar }}"
+---
+apiVersion: v1
+kind: Pod
+spec:
+  containers:
+    - image: busybox@`+digest+`
+`)
+
+	refs, err := lock.ScanFile(compose, lock.KindCompose)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nginx:1.27", "postgres:17"}, raws(refs))
+	assert.Equal(t, 3, refs[0].Line)
+
+	refs, err = lock.ScanFile(manifests, lock.KindKubernetes)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry.internal/app:v2", "registry.internal/app:v2", "busybox@" + digest}, raws(refs))
+	assert.Empty(t, refs[2].Name, "digest without tag has nothing to resolve")
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Dockerfile", "FROM alpine:3.20\n")
+	writeFile(t, dir, "compose.yaml", "services:\n  web:\n    image: nginx:1.27\n")
+	writeFile(t, dir, "deploy/app.yaml", "spec:\n  containers:\n    - image: busybox:1.37\n")
+	writeFile(t, dir, "deploy/templates/helm.yaml", "image: {{ .Values.image }\n  bad: [\n")
+	writeFile(t, dir, "node_modules/x/Dockerfile", "FROM node:22\n")
+	writeFile(t, dir, ".git/Dockerfile", "FROM ignored:1\n")
+	writeFile(t, dir, "README.md", "FROM nothing:1\n")
+
+	refs, err := lock.Scan([]string{dir})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alpine:3.20", "nginx:1.27", "busybox:1.37"}, raws(refs))
+
+	for _, r := range refs {
+		switch r.Raw {
+		case "alpine:3.20":
+			assert.Equal(t, lock.KindDockerfile, r.Kind)
+		case "nginx:1.27":
+			assert.Equal(t, lock.KindCompose, r.Kind)
+		case "busybox:1.37":
+			assert.Equal(t, lock.KindKubernetes, r.Kind)
+		}
+	}
+
+	_, err = lock.Scan([]string{filepath.Join(dir, "deploy/templates/helm.yaml")})
+	assert.Error(t, err, "explicit files must parse")
+}
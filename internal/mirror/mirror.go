@@ -0,0 +1,212 @@
+// Package mirror keeps the tags of one repository in sync with another.
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/internal/tags"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Action is what a sync does, or would do in a dry run, for a tag.
+type Action string
+
+const (
+	// ActionCopy copies the image, uploading or mounting missing blobs.
+	ActionCopy Action = "copy"
+	// ActionRetag points the tag at a manifest already in the destination.
+	ActionRetag Action = "retag"
+	// ActionUnchanged means the tag already has the source digest.
+	ActionUnchanged Action = "unchanged"
+	// ActionPrune deletes a destination tag that is gone from the source.
+	ActionPrune Action = "prune"
+)
+
+// Step is the action taken for one tag.
+type Step struct {
+	Tag    string
+	Action Action
+	// Digest is the source digest, or the deleted digest for prunes.
+	Digest v1.Hash
+}
+
+type Options struct {
+	// Filter selects the source tags to mirror, and the destination tags
+	// considered for pruning. Nil mirrors every tag.
+	Filter *tags.Filter
+	// Prune deletes destination tags that no longer exist in the source.
+	Prune bool
+	// DryRun reports the steps without changing the destination or the
+	// state file.
+	DryRun bool
+	// StatePath records progress so an interrupted sync can resume. It is
+	// removed once a sync completes. Empty disables state.
+	StatePath     string
+	RemoteOptions []remote.Option
+}
+
+// Sync mirrors every selected tag of src to dst. Images are copied with all
+// platforms so digests match. Sync stops at the first error and returns the
+// steps completed until then along with it, so a partial sync can still be
+// reported; with a state file, running it again resumes where it stopped.
+func Sync(ctx context.Context, src, dst name.Repository, opts *Options) ([]Step, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	filter := opts.Filter
+	if filter == nil {
+		filter = &tags.Filter{}
+	}
+	remoteOpts := append([]remote.Option{remote.WithContext(ctx)}, opts.RemoteOptions...)
+
+	srcTags, err := remote.List(src, remoteOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source tags: %w", err)
+	}
+	dstTags, err := remote.List(dst, remoteOpts...)
+	if err != nil && !oci.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to list destination tags: %w", err)
+	}
+
+	inSource := make(map[string]bool, len(srcTags))
+	for _, tag := range srcTags {
+		inSource[tag] = true
+	}
+	inDestination := make(map[string]bool, len(dstTags))
+	for _, tag := range dstTags {
+		inDestination[tag] = true
+	}
+
+	state := &State{Completed: make(map[string]string)}
+	if opts.StatePath != "" {
+		state, err = LoadState(opts.StatePath, src.String(), dst.String())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	selected := filter.Apply(srcTags)
+	sort.Strings(selected)
+
+	var steps []Step
+	for _, tag := range selected {
+		step, err := planTag(src, dst, tag, inDestination[tag], state, remoteOpts)
+		if err != nil {
+			return steps, err
+		}
+
+		if !opts.DryRun && step.Action != ActionUnchanged {
+			if err := apply(ctx, src, dst, step, opts.RemoteOptions, remoteOpts); err != nil {
+				return steps, fmt.Errorf("failed to mirror tag %s: %w", tag, err)
+			}
+		}
+		steps = append(steps, step)
+
+		if !opts.DryRun && opts.StatePath != "" {
+			state.Completed[tag] = step.Digest.String()
+			if err := state.Save(opts.StatePath); err != nil {
+				return steps, err
+			}
+		}
+	}
+
+	if opts.Prune {
+		var stale []string
+		for _, tag := range filter.Apply(dstTags) {
+			if !inSource[tag] {
+				stale = append(stale, tag)
+			}
+		}
+		sort.Strings(stale)
+
+		for _, tag := range stale {
+			desc, err := remote.Head(dst.Tag(tag), remoteOpts...)
+			if err != nil {
+				return steps, fmt.Errorf("failed to check %s: %w", dst.Tag(tag), err)
+			}
+			step := Step{Tag: tag, Action: ActionPrune, Digest: desc.Digest}
+			if !opts.DryRun {
+				if err := oci.Delete(dst.Tag(tag), remoteOpts...); err != nil {
+					return steps, err
+				}
+			}
+			steps = append(steps, step)
+		}
+	}
+
+	if !opts.DryRun && opts.StatePath != "" {
+		if err := os.Remove(opts.StatePath); err != nil && !os.IsNotExist(err) {
+			return steps, fmt.Errorf("failed to remove mirror state: %w", err)
+		}
+	}
+
+	return steps, nil
+}
+
+// planTag decides how to sync a single tag.
+func planTag(src, dst name.Repository, tag string, inDestination bool, state *State, remoteOpts []remote.Option) (Step, error) {
+	desc, err := remote.Head(src.Tag(tag), remoteOpts...)
+	if err != nil {
+		return Step{}, fmt.Errorf("failed to check %s: %w", src.Tag(tag), err)
+	}
+	step := Step{Tag: tag, Digest: desc.Digest}
+
+	if state.Completed[tag] == desc.Digest.String() {
+		step.Action = ActionUnchanged
+		return step, nil
+	}
+
+	if inDestination {
+		current, err := remote.Head(dst.Tag(tag), remoteOpts...)
+		if err != nil && !oci.IsNotFound(err) {
+			return Step{}, fmt.Errorf("failed to check %s: %w", dst.Tag(tag), err)
+		}
+		if err == nil && current.Digest == desc.Digest {
+			step.Action = ActionUnchanged
+			return step, nil
+		}
+	}
+
+	_, err = remote.Head(dst.Digest(desc.Digest.String()), remoteOpts...)
+	switch {
+	case err == nil:
+		step.Action = ActionRetag
+	case oci.IsNotFound(err):
+		step.Action = ActionCopy
+	default:
+		return Step{}, fmt.Errorf("failed to check %s: %w", dst.Digest(desc.Digest.String()), err)
+	}
+	return step, nil
+}
+
+func apply(ctx context.Context, src, dst name.Repository, step Step, copyOpts, remoteOpts []remote.Option) error {
+	target := dst.Tag(step.Tag)
+
+	switch step.Action {
+	case ActionRetag:
+		desc, err := remote.Get(dst.Digest(step.Digest.String()), remoteOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", step.Digest, err)
+		}
+		if err := remote.Tag(target, desc, remoteOpts...); err != nil {
+			return fmt.Errorf("failed to tag %s: %w", target, err)
+		}
+	case ActionCopy:
+		// Copy by digest so a tag moving mid-sync cannot change what
+		// was planned.
+		source := oci.RegistryLocation(src.Digest(step.Digest.String()))
+		if _, err := oci.Copy(ctx, source, oci.RegistryLocation(target), &oci.CopyOptions{
+			AllPlatforms:  true,
+			RemoteOptions: copyOpts,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
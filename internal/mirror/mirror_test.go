@@ -0,0 +1,191 @@
+package mirror_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/mirror"
+	"github.com/bschaatsbergen/cek/internal/tags"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setup pushes 1.0, 1.1 and latest to a source repository, and a
+// destination holding 1.0 at the source digest, 1.1's manifest under
+// another tag and a tag that is gone from the source.
+func setup(t *testing.T) (src, dst name.Repository, digests map[string]v1.Hash) {
+	t.Helper()
+	return setupWith(t, func(h http.Handler) http.Handler { return h })
+}
+
+// setupWith is setup with the registry handler wrapped by wrap.
+func setupWith(t *testing.T, wrap func(http.Handler) http.Handler) (src, dst name.Repository, digests map[string]v1.Hash) {
+	t.Helper()
+
+	srv := httptest.NewServer(wrap(registry.New(registry.Logger(log.New(io.Discard, "", 0)))))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	src, err := name.NewRepository(host + "/src")
+	require.NoError(t, err)
+	dst, err = name.NewRepository(host + "/dst")
+	require.NoError(t, err)
+
+	digests = make(map[string]v1.Hash)
+	push := func(tag name.Tag, img v1.Image) {
+		require.NoError(t, remote.Write(tag, img))
+	}
+	for _, tag := range []string{"1.0", "1.1", "latest"} {
+		img, err := random.Image(64, 1)
+		require.NoError(t, err)
+		push(src.Tag(tag), img)
+		digests[tag], err = img.Digest()
+		require.NoError(t, err)
+
+		switch tag {
+		case "1.0":
+			push(dst.Tag("1.0"), img)
+		case "1.1":
+			push(dst.Tag("other"), img)
+		}
+	}
+	old, err := random.Image(64, 1)
+	require.NoError(t, err)
+	push(dst.Tag("0.9"), old)
+
+	return src, dst, digests
+}
+
+func actions(steps []mirror.Step) map[string]mirror.Action {
+	out := make(map[string]mirror.Action)
+	for _, s := range steps {
+		out[s.Tag] = s.Action
+	}
+	return out
+}
+
+func TestSync(t *testing.T) {
+	src, dst, digests := setup(t)
+	ctx := context.Background()
+
+	t.Run("dry run", func(t *testing.T) {
+		steps, err := mirror.Sync(ctx, src, dst, &mirror.Options{Prune: true, DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]mirror.Action{
+			"1.0":    mirror.ActionUnchanged,
+			"1.1":    mirror.ActionRetag,
+			"latest": mirror.ActionCopy,
+			"0.9":    mirror.ActionPrune,
+			"other":  mirror.ActionPrune,
+		}, actions(steps))
+
+		_, err = remote.Head(dst.Tag("latest"))
+		assert.Error(t, err, "dry run must not copy")
+		_, err = remote.Head(dst.Tag("0.9"))
+		assert.NoError(t, err, "dry run must not prune")
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		steps, err := mirror.Sync(ctx, src, dst, &mirror.Options{Prune: true})
+		require.NoError(t, err)
+		assert.Len(t, steps, 5)
+
+		for _, tag := range []string{"1.0", "1.1", "latest"} {
+			desc, err := remote.Head(dst.Tag(tag))
+			require.NoError(t, err)
+			assert.Equal(t, digests[tag], desc.Digest, tag)
+		}
+		_, err = remote.Head(dst.Tag("0.9"))
+		assert.Error(t, err)
+	})
+
+	t.Run("second sync is a no-op", func(t *testing.T) {
+		steps, err := mirror.Sync(ctx, src, dst, &mirror.Options{Prune: true})
+		require.NoError(t, err)
+		for _, s := range steps {
+			assert.Equal(t, mirror.ActionUnchanged, s.Action, s.Tag)
+		}
+	})
+}
+
+func TestSync_StopsAtFailedTag(t *testing.T) {
+	src, dst, _ := setupWith(t, func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut && r.URL.Path == "/v2/dst/manifests/latest" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	})
+
+	steps, err := mirror.Sync(context.Background(), src, dst, &mirror.Options{Prune: true})
+	assert.ErrorContains(t, err, "failed to mirror tag latest")
+	// The tags before the failed one are still reported.
+	assert.Equal(t, map[string]mirror.Action{
+		"1.0": mirror.ActionUnchanged,
+		"1.1": mirror.ActionRetag,
+	}, actions(steps))
+}
+
+func TestSync_Filter(t *testing.T) {
+	src, dst, _ := setup(t)
+
+	filter, err := tags.NewFilter("", ">=1.1")
+	require.NoError(t, err)
+
+	steps, err := mirror.Sync(context.Background(), src, dst, &mirror.Options{Filter: filter, Prune: true, DryRun: true})
+	require.NoError(t, err)
+	// 0.9 does not match the filter, so it is not pruned.
+	assert.Equal(t, map[string]mirror.Action{"1.1": mirror.ActionRetag}, actions(steps))
+}
+
+func TestSync_ResumesFromState(t *testing.T) {
+	src, dst, digests := setup(t)
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	state := &mirror.State{
+		Source:      src.String(),
+		Destination: dst.String(),
+		Completed:   map[string]string{"latest": digests["latest"].String()},
+	}
+	require.NoError(t, state.Save(statePath))
+
+	steps, err := mirror.Sync(context.Background(), src, dst, &mirror.Options{StatePath: statePath})
+	require.NoError(t, err)
+	assert.Equal(t, mirror.ActionUnchanged, actions(steps)["latest"])
+	assert.Equal(t, mirror.ActionRetag, actions(steps)["1.1"])
+
+	_, err = os.Stat(statePath)
+	assert.True(t, os.IsNotExist(err), "state is removed after a complete sync")
+}
+
+func TestLoadState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := mirror.LoadState(path, "a", "b")
+	require.NoError(t, err)
+	assert.Empty(t, state.Completed)
+
+	state.Completed["1.0"] = "sha256:abc"
+	require.NoError(t, state.Save(path))
+
+	loaded, err := mirror.LoadState(path, "a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, state.Completed, loaded.Completed)
+
+	_, err = mirror.LoadState(path, "a", "c")
+	assert.ErrorContains(t, err, "belongs to a mirror of a to b")
+}
@@ -0,0 +1,71 @@
+package mirror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// State records the tags a mirror run has finished, so an interrupted run
+// can resume without checking them again.
+type State struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	// Completed maps each finished tag to the source digest it was synced
+	// at. A tag whose source digest changed since is synced again.
+	Completed map[string]string `json:"completed"`
+}
+
+// LoadState reads the state at path. A missing file yields an empty state.
+// State recorded for a different source or destination is an error, since
+// resuming from it would skip tags that were never synced.
+func LoadState(path, source, destination string) (*State, error) {
+	state := &State{
+		Source:      source,
+		Destination: destination,
+		Completed:   make(map[string]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mirror state: %w", err)
+	}
+
+	var saved State
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse mirror state %s: %w", path, err)
+	}
+	if saved.Source != source || saved.Destination != destination {
+		return nil, fmt.Errorf("mirror state %s belongs to a mirror of %s to %s", path, saved.Source, saved.Destination)
+	}
+	for tag, digest := range saved.Completed {
+		state.Completed[tag] = digest
+	}
+
+	return state, nil
+}
+
+// Save writes the state to path, replacing it atomically.
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode mirror state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write mirror state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write mirror state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/daemon"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// refNameAnnotation names images in an OCI layout index.
+const refNameAnnotation = "org.opencontainers.image.ref.name"
+
+// defaultPlatform is used to pick an image from an index when neither a
+// platform nor all platforms are requested, matching the registry client.
+const defaultPlatform = "linux/amd64"
+
+type CopyOptions struct {
+	// AllPlatforms copies every image of an index instead of one platform.
+	AllPlatforms bool
+	Platform     string
+	// RemoteOptions are used for registry sources and destinations.
+	RemoteOptions []remote.Option
+}
+
+// CopyResult summarizes a completed copy.
+type CopyResult struct {
+	Digest    v1.Hash
+	MediaType types.MediaType
+	// Platforms lists the platforms copied from an index.
+	Platforms []string
+	// Blobs and Size count the distinct config and layer blobs referenced
+	// by the copied manifests.
+	Blobs int
+	Size  int64
+}
+
+// artifact is either a single image or an index.
+type artifact struct {
+	img v1.Image
+	idx v1.ImageIndex
+}
+
+// Copy reads the image at src and writes it to dst. Registry and OCI layout
+// sources keep their manifests byte for byte, so digests are preserved.
+// Registry destinations skip blobs that already exist and mount blobs from
+// other repositories on the same registry instead of uploading them.
+func Copy(ctx context.Context, src, dst *Location, opts *CopyOptions) (*CopyResult, error) {
+	if opts == nil {
+		opts = &CopyOptions{}
+	}
+
+	a, err := readLocation(ctx, src, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := summarize(a)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeLocation(ctx, dst, src, a, opts); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ReadImage reads a single image from loc, selecting platform, or
+// linux/amd64 when empty, from multi-platform images.
+func ReadImage(ctx context.Context, loc *Location, platform string, remoteOpts ...remote.Option) (v1.Image, error) {
+	a, err := readLocation(ctx, loc, &CopyOptions{Platform: platform, RemoteOptions: remoteOpts})
+	if err != nil {
+		return nil, err
+	}
+	return a.img, nil
+}
+
+func readLocation(ctx context.Context, loc *Location, opts *CopyOptions) (*artifact, error) {
+	switch loc.Transport {
+	case TransportRegistry:
+		remoteOpts := append([]remote.Option{remote.WithContext(ctx)}, opts.RemoteOptions...)
+		if opts.Platform != "" {
+			platform, err := v1.ParsePlatform(opts.Platform)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse platform: %w", err)
+			}
+			remoteOpts = append(remoteOpts, remote.WithPlatform(*platform))
+		}
+		desc, err := remote.Get(loc.Ref, remoteOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", loc, err)
+		}
+		if desc.MediaType.IsIndex() && opts.AllPlatforms {
+			idx, err := desc.ImageIndex()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get image index: %w", err)
+			}
+			return &artifact{idx: idx}, nil
+		}
+		img, err := desc.Image()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get image: %w", err)
+		}
+		return &artifact{img: img}, nil
+
+	case TransportDaemon:
+		if opts.AllPlatforms {
+			return nil, errors.New("--all-platforms is not supported for daemon sources")
+		}
+		img, err := daemon.Image(loc.Ref, daemon.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch from daemon: %w", err)
+		}
+		return &artifact{img: img}, nil
+
+	case TransportArchive:
+		var tag *name.Tag
+		if t, ok := loc.Ref.(name.Tag); ok {
+			tag = &t
+		}
+		img, err := tarball.ImageFromPath(loc.Path, tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive %s: %w", loc.Path, err)
+		}
+		return &artifact{img: img}, nil
+
+	case TransportLayout:
+		return readLayout(loc, opts)
+	}
+
+	return nil, fmt.Errorf("unsupported transport %q", loc.Transport)
+}
+
+// readLayout selects an image or index from an OCI layout by its ref name
+// annotation, or the only entry when no tag is given.
+func readLayout(loc *Location, opts *CopyOptions) (*artifact, error) {
+	root, err := layout.ImageIndexFromPath(loc.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read layout %s: %w", loc.Path, err)
+	}
+	manifest, err := root.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read layout index: %w", err)
+	}
+
+	var desc *v1.Descriptor
+	tag := loc.layoutTag()
+	for i := range manifest.Manifests {
+		if tag == "" || manifest.Manifests[i].Annotations[refNameAnnotation] == tag {
+			if desc != nil {
+				return nil, fmt.Errorf("layout %s contains several images, select one with oci:%s:<tag>", loc.Path, loc.Path)
+			}
+			desc = &manifest.Manifests[i]
+		}
+	}
+	if desc == nil {
+		if tag != "" {
+			return nil, fmt.Errorf("no image tagged %q in layout %s", tag, loc.Path)
+		}
+		return nil, fmt.Errorf("layout %s is empty", loc.Path)
+	}
+
+	if !desc.MediaType.IsIndex() {
+		img, err := root.Image(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image %s: %w", desc.Digest, err)
+		}
+		return &artifact{img: img}, nil
+	}
+
+	idx, err := root.ImageIndex(desc.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index %s: %w", desc.Digest, err)
+	}
+	if opts.AllPlatforms {
+		return &artifact{idx: idx}, nil
+	}
+
+	platform := opts.Platform
+	if platform == "" {
+		platform = defaultPlatform
+	}
+	childManifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index %s: %w", desc.Digest, err)
+	}
+	child, err := SelectPlatform(childManifest, platform)
+	if err != nil {
+		return nil, err
+	}
+	img, err := idx.Image(child.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %s: %w", child.Digest, err)
+	}
+	return &artifact{img: img}, nil
+}
+
+func writeLocation(ctx context.Context, dst, src *Location, a *artifact, opts *CopyOptions) error {
+	switch dst.Transport {
+	case TransportRegistry:
+		remoteOpts := append([]remote.Option{remote.WithContext(ctx)}, opts.RemoteOptions...)
+		if a.idx != nil {
+			if err := remote.WriteIndex(dst.Ref, a.idx, remoteOpts...); err != nil {
+				return fmt.Errorf("failed to push index to %s: %w", dst, err)
+			}
+			return nil
+		}
+		if err := remote.Write(dst.Ref, a.img, remoteOpts...); err != nil {
+			return fmt.Errorf("failed to push image to %s: %w", dst, err)
+		}
+		return nil
+
+	case TransportDaemon:
+		if a.idx != nil {
+			return errors.New("the daemon stores a single platform, use --platform instead of --all-platforms")
+		}
+		tag, ok := dst.Ref.(name.Tag)
+		if !ok {
+			return fmt.Errorf("daemon destination %s must be a tag", dst)
+		}
+		if _, err := daemon.Write(tag, a.img, daemon.WithContext(ctx)); err != nil {
+			return fmt.Errorf("failed to write to daemon: %w", err)
+		}
+		return nil
+
+	case TransportArchive:
+		if a.idx != nil {
+			return errors.New("docker archives store a single platform, use --platform instead of --all-platforms")
+		}
+		ref := dst.Ref
+		if ref == nil && (src.Transport == TransportRegistry || src.Transport == TransportDaemon) {
+			ref = src.Ref
+		}
+		if ref == nil {
+			return fmt.Errorf("docker-archive destination needs a reference, e.g. docker-archive:%s:name:tag", dst.Path)
+		}
+		if err := tarball.WriteToFile(dst.Path, ref, a.img); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		return nil
+
+	case TransportLayout:
+		return writeLayout(dst, a)
+	}
+
+	return fmt.Errorf("unsupported transport %q", dst.Transport)
+}
+
+// writeLayout adds the artifact to an OCI layout, creating it if needed. An
+// existing entry with the same tag is replaced. Blobs already in the layout
+// are not written again.
+func writeLayout(dst *Location, a *artifact) error {
+	p, err := layout.FromPath(dst.Path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to open layout %s: %w", dst.Path, err)
+		}
+		p, err = layout.Write(dst.Path, empty.Index)
+		if err != nil {
+			return fmt.Errorf("failed to create layout %s: %w", dst.Path, err)
+		}
+	}
+
+	var layoutOpts []layout.Option
+	tag := dst.layoutTag()
+	if tag != "" {
+		layoutOpts = append(layoutOpts, layout.WithAnnotations(map[string]string{refNameAnnotation: tag}))
+	}
+
+	switch {
+	case a.idx != nil && tag != "":
+		err = p.ReplaceIndex(a.idx, match.Annotation(refNameAnnotation, tag), layoutOpts...)
+	case a.idx != nil:
+		err = p.AppendIndex(a.idx, layoutOpts...)
+	case tag != "":
+		err = p.ReplaceImage(a.img, match.Annotation(refNameAnnotation, tag), layoutOpts...)
+	default:
+		err = p.AppendImage(a.img, layoutOpts...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write to layout %s: %w", dst.Path, err)
+	}
+	return nil
+}
+
+func summarize(a *artifact) (*CopyResult, error) {
+	result := &CopyResult{}
+	blobs := make(map[v1.Hash]int64)
+
+	addImage := func(img v1.Image) error {
+		manifest, err := img.Manifest()
+		if err != nil {
+			return fmt.Errorf("failed to get manifest: %w", err)
+		}
+		blobs[manifest.Config.Digest] = manifest.Config.Size
+		for _, layer := range manifest.Layers {
+			blobs[layer.Digest] = layer.Size
+		}
+		return nil
+	}
+
+	if a.idx != nil {
+		digest, err := a.idx.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get index digest: %w", err)
+		}
+		mediaType, err := a.idx.MediaType()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get index media type: %w", err)
+		}
+		result.Digest, result.MediaType = digest, mediaType
+
+		manifest, err := a.idx.IndexManifest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get index manifest: %w", err)
+		}
+		for _, desc := range manifest.Manifests {
+			if !desc.MediaType.IsImage() {
+				continue
+			}
+			img, err := a.idx.Image(desc.Digest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get image %s: %w", desc.Digest, err)
+			}
+			if err := addImage(img); err != nil {
+				return nil, err
+			}
+			if desc.Platform != nil {
+				result.Platforms = append(result.Platforms, desc.Platform.String())
+			}
+		}
+	} else {
+		digest, err := a.img.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get image digest: %w", err)
+		}
+		mediaType, err := a.img.MediaType()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get image media type: %w", err)
+		}
+		result.Digest, result.MediaType = digest, mediaType
+		if err := addImage(a.img); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Blobs = len(blobs)
+	for _, size := range blobs {
+		result.Size += size
+	}
+
+	return result, nil
+}
@@ -0,0 +1,150 @@
+package oci_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		in        string
+		transport oci.Transport
+		path      string
+		ref       string
+	}{
+		{"nginx:latest", oci.TransportRegistry, "", "index.docker.io/library/nginx:latest"},
+		{"docker://ghcr.io/org/app:1.0", oci.TransportRegistry, "", "ghcr.io/org/app:1.0"},
+		{"localhost:5000/app", oci.TransportRegistry, "", "localhost:5000/app:latest"},
+		{"docker-daemon:nginx:1.27", oci.TransportDaemon, "", "index.docker.io/library/nginx:1.27"},
+		{"docker-archive:nginx.tar", oci.TransportArchive, "nginx.tar", ""},
+		{"docker-archive:out/nginx.tar:nginx:1.0", oci.TransportArchive, "out/nginx.tar", "index.docker.io/library/nginx:1.0"},
+		{"oci:./layout", oci.TransportLayout, "./layout", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			loc, err := oci.ParseLocation(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.transport, loc.Transport)
+			assert.Equal(t, tt.path, loc.Path)
+			if tt.ref == "" {
+				assert.Nil(t, loc.Ref)
+			} else {
+				assert.Equal(t, tt.ref, loc.Ref.Name())
+			}
+			assert.Equal(t, tt.in, loc.String())
+		})
+	}
+
+	loc, err := oci.ParseLocation("oci:./layout:1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "./layout", loc.Path)
+	assert.Equal(t, "1.0", loc.Ref.Identifier())
+
+	for _, in := range []string{"oci:", "docker-archive:", "docker-daemon:", "Invalid Ref"} {
+		_, err := oci.ParseLocation(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func newIndex(t *testing.T) v1.ImageIndex {
+	t.Helper()
+
+	var adds []mutate.IndexAddendum
+	for _, arch := range []string{"amd64", "arm64"} {
+		img, err := mutate.AppendLayers(empty.Image, newLayer(t, tarEntry{name: "arch", content: arch}))
+		require.NoError(t, err)
+		cfg, err := img.ConfigFile()
+		require.NoError(t, err)
+		cfg = cfg.DeepCopy()
+		cfg.OS, cfg.Architecture = "linux", arch
+		img, err = mutate.ConfigFile(img, cfg)
+		require.NoError(t, err)
+		adds = append(adds, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: arch}},
+		})
+	}
+	return mutate.AppendManifests(empty.Index, adds...)
+}
+
+func TestCopy_LayoutRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	idx := newIndex(t)
+	want, err := idx.Digest()
+	require.NoError(t, err)
+
+	_, err = layout.Write(filepath.Join(dir, "src"), empty.Index)
+	require.NoError(t, err)
+	p, err := layout.FromPath(filepath.Join(dir, "src"))
+	require.NoError(t, err)
+	require.NoError(t, p.AppendIndex(idx, layout.WithAnnotations(map[string]string{
+		"org.opencontainers.image.ref.name": "1.0",
+	})))
+
+	src, err := oci.ParseLocation("oci:" + filepath.Join(dir, "src") + ":1.0")
+	require.NoError(t, err)
+
+	t.Run("all platforms", func(t *testing.T) {
+		dst, err := oci.ParseLocation("oci:" + filepath.Join(dir, "all") + ":copy")
+		require.NoError(t, err)
+
+		result, err := oci.Copy(context.Background(), src, dst, &oci.CopyOptions{AllPlatforms: true})
+		require.NoError(t, err)
+		assert.Equal(t, want, result.Digest)
+		assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, result.Platforms)
+		assert.Equal(t, 4, result.Blobs)
+
+		// Copying again replaces the tagged entry instead of adding one.
+		_, err = oci.Copy(context.Background(), src, dst, &oci.CopyOptions{AllPlatforms: true})
+		require.NoError(t, err)
+
+		copied, err := layout.ImageIndexFromPath(filepath.Join(dir, "all"))
+		require.NoError(t, err)
+		manifest, err := copied.IndexManifest()
+		require.NoError(t, err)
+		require.Len(t, manifest.Manifests, 1)
+		assert.Equal(t, want, manifest.Manifests[0].Digest)
+	})
+
+	t.Run("single platform to archive", func(t *testing.T) {
+		archive := filepath.Join(dir, "arm64.tar")
+		dst, err := oci.ParseLocation("docker-archive:" + archive + ":app:arm64")
+		require.NoError(t, err)
+
+		result, err := oci.Copy(context.Background(), src, dst, &oci.CopyOptions{Platform: "linux/arm64"})
+		require.NoError(t, err)
+		assert.Empty(t, result.Platforms)
+
+		back, err := oci.ParseLocation("docker-archive:" + archive)
+		require.NoError(t, err)
+		img, err := oci.Copy(context.Background(), back, &oci.Location{Transport: oci.TransportLayout, Path: filepath.Join(dir, "back")}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, result.Digest, img.Digest)
+	})
+
+	t.Run("all platforms to archive", func(t *testing.T) {
+		dst, err := oci.ParseLocation("docker-archive:" + filepath.Join(dir, "all.tar") + ":app:all")
+		require.NoError(t, err)
+
+		_, err = oci.Copy(context.Background(), src, dst, &oci.CopyOptions{AllPlatforms: true})
+		assert.ErrorContains(t, err, "single platform")
+	})
+
+	t.Run("unknown tag", func(t *testing.T) {
+		missing, err := oci.ParseLocation("oci:" + filepath.Join(dir, "src") + ":2.0")
+		require.NoError(t, err)
+
+		_, err = oci.Copy(context.Background(), missing, src, nil)
+		assert.ErrorContains(t, err, `no image tagged "2.0"`)
+	})
+}
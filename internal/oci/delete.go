@@ -0,0 +1,59 @@
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+var (
+	// ErrDeleteDisabled is returned when a registry refuses deletes
+	// altogether, or for the kind of reference given.
+	ErrDeleteDisabled = errors.New("the registry does not allow deleting images")
+	// ErrDeleteDenied is returned when the credentials in use may not
+	// delete from the repository.
+	ErrDeleteDenied = errors.New("not authorized to delete from this repository")
+)
+
+// Delete deletes a tag or manifest from a registry. Common refusals are
+// reported as ErrDeleteDisabled or ErrDeleteDenied with a hint on how to
+// resolve them.
+func Delete(ref name.Reference, opts ...remote.Option) error {
+	err := remote.Delete(ref, opts...)
+	if err == nil {
+		return nil
+	}
+
+	var terr *transport.Error
+	if errors.As(err, &terr) {
+		switch {
+		case terr.StatusCode == http.StatusMethodNotAllowed || hasErrorCode(terr, transport.UnsupportedErrorCode):
+			hint := "on registries built on distribution, set REGISTRY_STORAGE_DELETE_ENABLED=true"
+			if _, ok := ref.(name.Tag); ok {
+				hint = "some registries only delete by digest; " + hint
+			}
+			return fmt.Errorf("failed to delete %s: %w (%s): %w", ref, ErrDeleteDisabled, hint, err)
+		case terr.StatusCode == http.StatusUnauthorized || terr.StatusCode == http.StatusForbidden ||
+			hasErrorCode(terr, transport.DeniedErrorCode) || hasErrorCode(terr, transport.UnauthorizedErrorCode):
+			return fmt.Errorf("failed to delete %s: %w, check your registry credentials: %w", ref, ErrDeleteDenied, err)
+		}
+	}
+	if IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s: not found: %w", ref, err)
+	}
+
+	return fmt.Errorf("failed to delete %s: %w", ref, err)
+}
+
+func hasErrorCode(err *transport.Error, code transport.ErrorCode) bool {
+	for _, d := range err.Errors {
+		if d.Code == code {
+			return true
+		}
+	}
+	return false
+}
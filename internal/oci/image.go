@@ -2,12 +2,16 @@ package oci
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/daemon"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 )
 
 type PullPolicy string
@@ -21,6 +25,12 @@ const (
 type FetchOptions struct {
 	Platform   string
 	PullPolicy PullPolicy
+	// Layers, when set, shares the layers of images pulled from a registry
+	// with other images fetched through the same cache.
+	Layers *LayerCache
+	// Keychain, when set, provides credentials for the registry. Images are
+	// pulled anonymously otherwise.
+	Keychain authn.Keychain
 }
 
 // FetchImage retrieves an OCI image from either the local daemon or remote registry
@@ -67,6 +77,9 @@ func fetchFromRemote(ctx context.Context, ref name.Reference, opts *FetchOptions
 	remoteOpts := []remote.Option{
 		remote.WithContext(ctx),
 	}
+	if opts != nil && opts.Keychain != nil {
+		remoteOpts = append(remoteOpts, remote.WithAuthFromKeychain(opts.Keychain))
+	}
 
 	if opts != nil && opts.Platform != "" {
 		platform, err := v1.ParsePlatform(opts.Platform)
@@ -85,6 +98,9 @@ func fetchFromRemote(ctx context.Context, ref name.Reference, opts *FetchOptions
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get image: %w", err)
 	}
+	if opts != nil && opts.Layers != nil {
+		img = opts.Layers.Image(img)
+	}
 
 	return img, ref, nil
 }
@@ -101,3 +117,39 @@ func FormatBytes(bytes int64) string {
 	}
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
+
+// IsNotFound reports whether err is a registry error for a missing
+// manifest, blob or repository.
+func IsNotFound(err error) bool {
+	var terr *transport.Error
+	if !errors.As(err, &terr) {
+		return false
+	}
+	if terr.StatusCode == http.StatusNotFound {
+		return true
+	}
+	for _, d := range terr.Errors {
+		if d.Code == transport.ManifestUnknownErrorCode || d.Code == transport.NameUnknownErrorCode {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectPlatform returns the index entry matching platform, e.g.
+// "linux/arm64" or "linux/arm/v7".
+func SelectPlatform(index *v1.IndexManifest, platform string) (*v1.Descriptor, error) {
+	want, err := v1.ParsePlatform(platform)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse platform: %w", err)
+	}
+
+	for i := range index.Manifests {
+		desc := &index.Manifests[i]
+		if desc.Platform != nil && desc.Platform.Satisfies(*want) {
+			return desc, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no manifest found for platform %s", platform)
+}
@@ -0,0 +1,182 @@
+package oci
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/klauspost/compress/zstd"
+)
+
+// LayerCache stores compressed layers on disk so that images sharing layers,
+// such as a common base image, download them once. It is safe for
+// concurrent use; a layer requested by several images at once is fetched by
+// the first and read from disk by the others.
+type LayerCache struct {
+	dir string
+
+	mu    sync.Mutex
+	locks map[v1.Hash]*sync.Mutex
+}
+
+// NewLayerCache returns a cache storing layers in dir, which must exist.
+func NewLayerCache(dir string) *LayerCache {
+	return &LayerCache{dir: dir, locks: make(map[v1.Hash]*sync.Mutex)}
+}
+
+// Image wraps img so its layers are read through the cache.
+func (c *LayerCache) Image(img v1.Image) v1.Image {
+	return &cachedImage{Image: img, cache: c}
+}
+
+// fetch returns the path of the cached compressed layer, downloading it
+// first if needed.
+func (c *LayerCache) fetch(layer v1.Layer) (string, error) {
+	digest, err := layer.Digest()
+	if err != nil {
+		return "", fmt.Errorf("failed to get layer digest: %w", err)
+	}
+	path := filepath.Join(c.dir, digest.Algorithm+"-"+digest.Hex)
+
+	c.mu.Lock()
+	lock, ok := c.locks[digest]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.locks[digest] = lock
+	}
+	c.mu.Unlock()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	rc, err := layer.Compressed()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch layer %s: %w", digest, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	tmp, err := os.CreateTemp(c.dir, "layer-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to cache layer %s: %w", digest, err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := io.Copy(tmp, rc); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("failed to fetch layer %s: %w", digest, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to cache layer %s: %w", digest, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to cache layer %s: %w", digest, err)
+	}
+	return path, nil
+}
+
+type cachedImage struct {
+	v1.Image
+	cache *LayerCache
+}
+
+func (i *cachedImage) Layers() ([]v1.Layer, error) {
+	layers, err := i.Image.Layers()
+	if err != nil {
+		return nil, err
+	}
+	cached := make([]v1.Layer, len(layers))
+	for idx, l := range layers {
+		cached[idx] = &cachedLayer{Layer: l, cache: i.cache}
+	}
+	return cached, nil
+}
+
+// cachedLayer reads layer contents from the cache. Metadata such as
+// digests and sizes comes from the wrapped layer.
+type cachedLayer struct {
+	v1.Layer
+	cache *LayerCache
+}
+
+func (l *cachedLayer) Compressed() (io.ReadCloser, error) {
+	path, err := l.cache.fetch(l.Layer)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (l *cachedLayer) Uncompressed() (io.ReadCloser, error) {
+	path, err := l.cache.fetch(l.Layer)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached layer: %w", err)
+	}
+
+	// The cached blob is decompressed as it is read, based on its magic
+	// bytes, rather than verified against its digest first.
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to read cached layer: %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to decompress cached layer: %w", err)
+		}
+		return &decompressReader{Reader: zr, close: zr.Close, file: f}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to decompress cached layer: %w", err)
+		}
+		return &decompressReader{Reader: zr, close: func() error { zr.Close(); return nil }, file: f}, nil
+	default:
+		return &decompressReader{Reader: br, file: f}, nil
+	}
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompressReader closes the decompressor and then the underlying file.
+type decompressReader struct {
+	io.Reader
+	close func() error
+	file  *os.File
+}
+
+func (r *decompressReader) Close() error {
+	if r.close != nil {
+		if err := r.close(); err != nil {
+			_ = r.file.Close()
+			return err
+		}
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,114 @@
+package oci_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/google/go-containerregistry/pkg/compression"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayerCache(t *testing.T) {
+	var mu sync.Mutex
+	blobGets := make(map[string]int)
+	reg := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/blobs/") {
+			mu.Lock()
+			blobGets[r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]]++
+			mu.Unlock()
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	// Two images sharing a base layer.
+	base, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	baseLayers, err := base.Layers()
+	require.NoError(t, err)
+	baseDigest, err := baseLayers[0].Digest()
+	require.NoError(t, err)
+
+	var refs []string
+	for _, repo := range []string{"app-a", "app-b"} {
+		top, err := random.Layer(512, "application/vnd.oci.image.layer.v1.tar+gzip")
+		require.NoError(t, err)
+		img, err := mutate.AppendLayers(base, top)
+		require.NoError(t, err)
+		ref := host + "/" + repo + ":1"
+		tag, err := name.NewTag(ref)
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(tag, img))
+		refs = append(refs, ref)
+	}
+
+	cache := oci.NewLayerCache(t.TempDir())
+	var wg sync.WaitGroup
+	for _, ref := range refs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			img, _, err := oci.FetchImage(context.Background(), ref, &oci.FetchOptions{
+				PullPolicy: oci.PullAlways,
+				Layers:     cache,
+			})
+			assert.NoError(t, err)
+			layers, err := img.Layers()
+			assert.NoError(t, err)
+			for _, l := range layers {
+				rc, err := l.Uncompressed()
+				if assert.NoError(t, err) {
+					_, err = io.Copy(io.Discard, rc)
+					assert.NoError(t, err)
+					assert.NoError(t, rc.Close())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, blobGets[baseDigest.String()], "shared layer is downloaded once")
+}
+
+func TestLayerCache_Uncompressed(t *testing.T) {
+	content := []byte("layer contents, not a real tarball")
+	opener := func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+
+	for _, comp := range []compression.Compression{compression.GZip, compression.ZStd} {
+		t.Run(string(comp), func(t *testing.T) {
+			layer, err := tarball.LayerFromOpener(opener, tarball.WithCompression(comp))
+			require.NoError(t, err)
+			img, err := mutate.AppendLayers(empty.Image, layer)
+			require.NoError(t, err)
+
+			layers, err := oci.NewLayerCache(t.TempDir()).Image(img).Layers()
+			require.NoError(t, err)
+			rc, err := layers[0].Uncompressed()
+			require.NoError(t, err)
+			data, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			require.NoError(t, rc.Close())
+			assert.Equal(t, content, data)
+		})
+	}
+}
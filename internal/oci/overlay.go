@@ -0,0 +1,113 @@
+package oci
+
+import (
+	"archive/tar"
+	"path"
+	"strings"
+)
+
+// Overlay decides which entries of a stack of layers a container sees. The
+// layers are visited top-down, so the first entry of a path wins: for each
+// layer, report its whiteouts with Whiteout, ask Visible about each of its
+// entries, and call NextLayer before moving to the layer below.
+//
+// An entry is hidden when an upper layer has an entry at its path, or
+// deletes it or one of its parent directories with a whiteout. An opaque
+// whiteout hides the contents of a directory, but not the directory itself.
+// A non-directory hides whatever is below it in lower layers, and a
+// directory implied by the entries of upper layers hides a non-directory at
+// its path.
+type Overlay struct {
+	seen   map[string]bool
+	hidden map[string]bool
+	dirs   map[string]bool
+	// layerHidden holds what the current layer hides. It only applies to the
+	// layers below, so it is merged into hidden by NextLayer.
+	layerHidden map[string]bool
+}
+
+// NewOverlay returns an Overlay for a walk starting at the top layer.
+func NewOverlay() *Overlay {
+	return &Overlay{
+		seen:        make(map[string]bool),
+		hidden:      make(map[string]bool),
+		dirs:        make(map[string]bool),
+		layerHidden: make(map[string]bool),
+	}
+}
+
+// Whiteout records a whiteout of the current layer deleting the clean
+// absolute path p from the layers below, or only the contents of the
+// directory p when opaque is set.
+func (o *Overlay) Whiteout(p string, opaque bool) {
+	if !opaque {
+		o.layerHidden[p] = true
+	}
+	o.layerHidden[dirKey(p)] = true
+}
+
+// Visible reports whether the entry of the current layer at the clean
+// absolute path p is part of the merged filesystem, and records it if so.
+func (o *Overlay) Visible(p string, hdr *tar.Header) bool {
+	isDir := hdr.Typeflag == tar.TypeDir
+	if o.seen[p] || isHidden(o.hidden, p) || (!isDir && o.dirs[p]) {
+		return false
+	}
+	o.seen[p] = true
+
+	if !isDir {
+		o.layerHidden[dirKey(p)] = true
+	}
+	for dir := path.Dir(p); dir != "/" && !o.dirs[dir]; dir = path.Dir(dir) {
+		o.dirs[dir] = true
+	}
+	return true
+}
+
+// NextLayer moves on to the layer below the current one.
+func (o *Overlay) NextLayer() {
+	for p := range o.layerHidden {
+		o.hidden[p] = true
+	}
+	clear(o.layerHidden)
+}
+
+// isHidden reports whether p or one of its parent directories was removed by
+// a whiteout in a higher layer. Directory keys carry a trailing slash.
+func isHidden(hidden map[string]bool, p string) bool {
+	if p == "/" {
+		return false
+	}
+	if hidden[p] {
+		return true
+	}
+	for dir := path.Dir(p); ; dir = path.Dir(dir) {
+		if hidden[dirKey(dir)] {
+			return true
+		}
+		if dir == "/" {
+			return false
+		}
+	}
+}
+
+func dirKey(dir string) string {
+	if dir == "/" {
+		return dir
+	}
+	return dir + "/"
+}
+
+// parseWhiteout reports whether the clean absolute path p is a whiteout
+// marker, and returns the path it deletes. Opaque whiteouts return the
+// directory whose lower contents are hidden.
+func parseWhiteout(p string) (target string, opaque, ok bool) {
+	base := path.Base(p)
+	if base == whiteoutOpaque {
+		return path.Dir(p), true, true
+	}
+	if strings.HasPrefix(base, whiteoutPrefix) {
+		return path.Join(path.Dir(p), strings.TrimPrefix(base, whiteoutPrefix)), false, true
+	}
+	return "", false, false
+}
@@ -0,0 +1,110 @@
+package oci
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Transport identifies where an image is read from or written to.
+type Transport string
+
+const (
+	TransportRegistry Transport = "registry"
+	TransportDaemon   Transport = "daemon"
+	TransportArchive  Transport = "docker-archive"
+	TransportLayout   Transport = "oci"
+)
+
+// Location is an image in one of the supported transports, written using
+// the skopeo syntax:
+//
+//	nginx:latest, docker://nginx:latest   registry
+//	docker-daemon:nginx:latest            container daemon
+//	docker-archive:nginx.tar[:nginx:1.0]  docker save tarball
+//	oci:./layout[:1.0]                    OCI image layout directory
+type Location struct {
+	Transport Transport
+	// Path is the archive file or layout directory.
+	Path string
+	// Ref is the image reference. It is optional for archives, and for
+	// layouts only its tag is used, to select the image by its
+	// org.opencontainers.image.ref.name annotation.
+	Ref name.Reference
+	raw string
+}
+
+func (l *Location) String() string {
+	return l.raw
+}
+
+// RegistryLocation returns the registry location of ref.
+func RegistryLocation(ref name.Reference) *Location {
+	return &Location{Transport: TransportRegistry, Ref: ref, raw: ref.String()}
+}
+
+// ParseLocation parses an image location in skopeo syntax. Strings without
+// a transport prefix are registry references.
+func ParseLocation(s string) (*Location, error) {
+	loc := &Location{raw: s}
+
+	prefix, rest, _ := strings.Cut(s, ":")
+	switch prefix {
+	case "docker-daemon":
+		loc.Transport = TransportDaemon
+		ref, err := name.ParseReference(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid daemon reference %q: %w", rest, err)
+		}
+		loc.Ref = ref
+	case "docker-archive":
+		loc.Transport = TransportArchive
+		path, refStr, _ := strings.Cut(rest, ":")
+		if path == "" {
+			return nil, fmt.Errorf("invalid location %q: missing archive path", s)
+		}
+		loc.Path = path
+		if refStr != "" {
+			ref, err := name.ParseReference(refStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid archive reference %q: %w", refStr, err)
+			}
+			loc.Ref = ref
+		}
+	case "oci":
+		loc.Transport = TransportLayout
+		path, tag, _ := strings.Cut(rest, ":")
+		if path == "" {
+			return nil, fmt.Errorf("invalid location %q: missing layout path", s)
+		}
+		loc.Path = path
+		if tag != "" {
+			// The tag is only used as an annotation value, so any
+			// repository name will do.
+			ref, err := name.NewTag("layout:" + tag)
+			if err != nil {
+				return nil, fmt.Errorf("invalid layout tag %q: %w", tag, err)
+			}
+			loc.Ref = ref
+		}
+	default:
+		refStr := strings.TrimPrefix(s, "docker://")
+		ref, err := name.ParseReference(refStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse image reference: %w", err)
+		}
+		loc.Transport = TransportRegistry
+		loc.Ref = ref
+	}
+
+	return loc, nil
+}
+
+// layoutTag returns the tag used to select the image in an OCI layout.
+func (l *Location) layoutTag() string {
+	if tag, ok := l.Ref.(name.Tag); ok {
+		return tag.TagStr()
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package oci
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// FileEntry describes a single tar entry visited while walking image layers.
+// Reader is only valid for the duration of the callback.
+type FileEntry struct {
+	Path   string
+	Header *tar.Header
+	Layer  int // 1-indexed layer the entry originates from
+	Reader io.Reader
+}
+
+// IsRegular reports whether the entry is a regular file.
+func (f *FileEntry) IsRegular() bool {
+	return f.Header.Typeflag == tar.TypeReg || f.Header.Typeflag == tar.TypeRegA
+}
+
+// WalkFunc is called for every entry visited by WalkLayer and WalkMerged.
+// Returning fs.SkipAll stops the walk without an error.
+type WalkFunc func(f *FileEntry) error
+
+// WalkLayer visits every entry in a single layer, skipping whiteout markers.
+// The index is reported back as FileEntry.Layer.
+func WalkLayer(layer v1.Layer, index int, fn WalkFunc) error {
+	err := walkTar(layer, func(hdr *tar.Header, p string, r io.Reader) error {
+		if strings.HasPrefix(path.Base(p), whiteoutPrefix) {
+			return nil
+		}
+		return fn(&FileEntry{Path: p, Header: hdr, Layer: index, Reader: r})
+	})
+	if errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// WhiteoutFunc is called by WalkChanges for every whiteout in a layer with
+// the path it deletes. Opaque whiteouts report the directory whose lower
+// contents are hidden.
+type WhiteoutFunc func(p string, opaque bool) error
+
+// WalkChanges visits the entries of a single layer like WalkLayer, and calls
+// deleted for its whiteouts, so that the changes a layer makes to the layers
+// below it can be reconstructed.
+func WalkChanges(layer v1.Layer, index int, fn WalkFunc, deleted WhiteoutFunc) error {
+	err := walkTar(layer, func(hdr *tar.Header, p string, r io.Reader) error {
+		if target, opaque, ok := parseWhiteout(p); ok {
+			return deleted(target, opaque)
+		}
+		return fn(&FileEntry{Path: p, Header: hdr, Layer: index, Reader: r})
+	})
+	if errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// WalkMerged visits the entries of the merged overlay filesystem, which is
+// what a running container would see, in a single pass over each layer.
+// Layers are processed top-down and an Overlay decides which entries are
+// visible, as it does for the filesystems of pkg/imagefs.
+func WalkMerged(layers []v1.Layer, fn WalkFunc) error {
+	overlay := NewOverlay()
+	for i := len(layers) - 1; i >= 0; i-- {
+		err := walkTar(layers[i], func(hdr *tar.Header, p string, r io.Reader) error {
+			if target, opaque, ok := parseWhiteout(p); ok {
+				overlay.Whiteout(target, opaque)
+				return nil
+			}
+			if !overlay.Visible(p, hdr) {
+				return nil
+			}
+			return fn(&FileEntry{Path: p, Header: hdr, Layer: i + 1, Reader: r})
+		})
+		if errors.Is(err, fs.SkipAll) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("layer %d: %w", i+1, err)
+		}
+		overlay.NextLayer()
+	}
+
+	return nil
+}
+
+func walkTar(layer v1.Layer, fn func(hdr *tar.Header, p string, r io.Reader) error) error {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return fmt.Errorf("failed to get uncompressed layer: %w", err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	tr := tar.NewReader(rc)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar header: %w", err)
+		}
+
+		if err := fn(header, CleanPath(header.Name), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// CleanPath normalizes a tar entry name to an absolute slash-separated path.
+func CleanPath(name string) string {
+	return path.Clean("/" + strings.TrimPrefix(name, "./"))
+}
@@ -0,0 +1,186 @@
+package oci_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tarEntry struct {
+	name    string
+	content string
+	dir     bool
+}
+
+func newLayer(t *testing.T, entries ...tarEntry) v1.Layer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		if e.dir {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0o755
+			hdr.Size = 0
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(e.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	data := buf.Bytes()
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+	require.NoError(t, err)
+	return layer
+}
+
+func collectMerged(t *testing.T, layers ...v1.Layer) map[string]string {
+	t.Helper()
+
+	files := make(map[string]string)
+	err := oci.WalkMerged(layers, func(f *oci.FileEntry) error {
+		data, err := io.ReadAll(f.Reader)
+		if err != nil {
+			return err
+		}
+		files[f.Path] = string(data)
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestWalkMerged_UpperLayerWins(t *testing.T) {
+	files := collectMerged(t,
+		newLayer(t, tarEntry{name: "etc/motd", content: "lower"}),
+		newLayer(t, tarEntry{name: "etc/motd", content: "upper"}),
+	)
+
+	assert.Equal(t, "upper", files["/etc/motd"])
+}
+
+func TestWalkMerged_Whiteout(t *testing.T) {
+	files := collectMerged(t,
+		newLayer(t,
+			tarEntry{name: "app", dir: true},
+			tarEntry{name: "app/keep", content: "k"},
+			tarEntry{name: "app/secret", content: "s"},
+			tarEntry{name: "cache", dir: true},
+			tarEntry{name: "cache/a", content: "a"},
+		),
+		newLayer(t,
+			tarEntry{name: "app/.wh.secret"},
+			tarEntry{name: ".wh.cache"},
+		),
+	)
+
+	assert.Contains(t, files, "/app/keep")
+	assert.NotContains(t, files, "/app/secret")
+	assert.NotContains(t, files, "/cache")
+	assert.NotContains(t, files, "/cache/a")
+}
+
+func TestWalkMerged_OpaqueDirectory(t *testing.T) {
+	files := collectMerged(t,
+		newLayer(t,
+			tarEntry{name: "data", dir: true},
+			tarEntry{name: "data/old", content: "old"},
+		),
+		newLayer(t,
+			tarEntry{name: "data", dir: true},
+			tarEntry{name: "data/.wh..wh..opq"},
+			tarEntry{name: "data/new", content: "new"},
+		),
+	)
+
+	assert.Contains(t, files, "/data")
+	assert.Contains(t, files, "/data/new")
+	assert.NotContains(t, files, "/data/old")
+}
+
+func TestWalkMerged_FileReplacedByDirectory(t *testing.T) {
+	files := collectMerged(t,
+		newLayer(t,
+			tarEntry{name: "opt/app", content: "binary"},
+			tarEntry{name: "srv", dir: true},
+			tarEntry{name: "srv/index.html", content: "<html>"},
+		),
+		newLayer(t,
+			tarEntry{name: "opt/app/run", content: "script"},
+			tarEntry{name: "srv", content: "file"},
+		),
+	)
+
+	assert.Contains(t, files, "/opt/app/run")
+	assert.NotContains(t, files, "/opt/app")
+	assert.Equal(t, "file", files["/srv"])
+	assert.NotContains(t, files, "/srv/index.html")
+}
+
+func TestWalkMerged_ReportsOriginLayer(t *testing.T) {
+	layers := []v1.Layer{
+		newLayer(t, tarEntry{name: "a", content: "1"}),
+		newLayer(t, tarEntry{name: "b", content: "2"}),
+	}
+
+	origin := make(map[string]int)
+	err := oci.WalkMerged(layers, func(f *oci.FileEntry) error {
+		origin[f.Path] = f.Layer
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, origin["/a"])
+	assert.Equal(t, 2, origin["/b"])
+}
+
+func TestWalkLayer_SkipsWhiteouts(t *testing.T) {
+	layer := newLayer(t,
+		tarEntry{name: "./etc/passwd", content: "root"},
+		tarEntry{name: "etc/.wh.shadow"},
+	)
+
+	var paths []string
+	err := oci.WalkLayer(layer, 3, func(f *oci.FileEntry) error {
+		assert.Equal(t, 3, f.Layer)
+		paths = append(paths, f.Path)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/etc/passwd"}, paths)
+}
+
+func TestWalkChanges_ReportsWhiteouts(t *testing.T) {
+	layer := newLayer(t,
+		tarEntry{name: "./etc/passwd", content: "root"},
+		tarEntry{name: "etc/.wh.shadow"},
+		tarEntry{name: "var/cache/.wh..wh..opq"},
+	)
+
+	var paths []string
+	deleted := make(map[string]bool)
+	err := oci.WalkChanges(layer, 2, func(f *oci.FileEntry) error {
+		assert.Equal(t, 2, f.Layer)
+		paths = append(paths, f.Path)
+		return nil
+	}, func(p string, opaque bool) error {
+		deleted[p] = opaque
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/etc/passwd"}, paths)
+	assert.Equal(t, map[string]bool{"/etc/shadow": false, "/var/cache": true}, deleted)
+}
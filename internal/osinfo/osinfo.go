@@ -0,0 +1,247 @@
+// Package osinfo identifies the operating system and base distribution of
+// an image from well-known release files in its merged filesystem.
+package osinfo
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// EOLStatus describes where a release is in its support lifecycle.
+type EOLStatus string
+
+const (
+	EOLSupported EOLStatus = "supported"
+	EOLExpired   EOLStatus = "eol"
+	EOLUnknown   EOLStatus = "unknown"
+)
+
+// Info describes the operating system found in an image.
+type Info struct {
+	// Distro is the os-release ID, e.g. "debian" or "alpine". It is
+	// "scratch" when no OS files are present at all.
+	Distro     string
+	Name       string
+	PrettyName string
+	Version    string
+	Codename   string
+	// Distroless is set for Google distroless and similar images, which are
+	// built from distro packages but ship no shell or package manager.
+	Distroless bool
+	// Static is set when no dynamic loader is present, which means only
+	// statically linked binaries can run in the image.
+	Static    bool
+	EOL       time.Time
+	EOLStatus EOLStatus
+}
+
+// Scratch reports whether the image carries no OS release files.
+func (i *Info) Scratch() bool {
+	return i.Distro == "scratch"
+}
+
+// release files that are read while walking the filesystem.
+const (
+	osReleasePath       = "/etc/os-release"
+	usrOSReleasePath    = "/usr/lib/os-release"
+	alpineReleasePath   = "/etc/alpine-release"
+	debianVersionPath   = "/etc/debian_version"
+	dpkgStatusPath      = "/var/lib/dpkg/status"
+	dpkgStatusDirPath   = "/var/lib/dpkg/status.d"
+	maxReleaseFileBytes = 64 * 1024
+)
+
+// Detect walks the merged filesystem of layers and identifies the OS. The
+// EOL status is evaluated against now.
+func Detect(layers []v1.Layer, now time.Time) (*Info, error) {
+	files := make(map[string]string)
+	hasLoader := false
+	hasShell := false
+	hasDpkgStatus := false
+	hasDpkgStatusDir := false
+
+	err := oci.WalkMerged(layers, func(f *oci.FileEntry) error {
+		switch f.Path {
+		case osReleasePath, usrOSReleasePath, alpineReleasePath, debianVersionPath:
+			if !f.IsRegular() {
+				return nil
+			}
+			data, err := io.ReadAll(io.LimitReader(f.Reader, maxReleaseFileBytes))
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", f.Path, err)
+			}
+			files[f.Path] = string(data)
+		case "/bin/sh", "/usr/bin/sh":
+			hasShell = true
+		case dpkgStatusPath:
+			hasDpkgStatus = true
+		case dpkgStatusDirPath:
+			hasDpkgStatusDir = true
+		}
+		if isDynamicLoader(f.Path) {
+			hasLoader = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{Static: !hasLoader}
+
+	release := files[osReleasePath]
+	if release == "" {
+		release = files[usrOSReleasePath]
+	}
+	fields := parseOSRelease(release)
+
+	info.Distro = fields["ID"]
+	info.Name = fields["NAME"]
+	info.PrettyName = fields["PRETTY_NAME"]
+	info.Version = fields["VERSION_ID"]
+	info.Codename = fields["VERSION_CODENAME"]
+
+	// The distro specific files carry the full point release, which
+	// os-release often omits (e.g. VERSION_ID="12" vs debian_version 12.5).
+	if v := strings.TrimSpace(files[alpineReleasePath]); v != "" {
+		if info.Distro == "" {
+			info.Distro = "alpine"
+		}
+		if info.Distro == "alpine" {
+			info.Version = v
+		}
+	}
+	if v := strings.TrimSpace(files[debianVersionPath]); v != "" && (info.Distro == "" || info.Distro == "debian") {
+		info.Distro = "debian"
+		if isNumericVersion(v) {
+			info.Version = v
+		} else if info.Codename == "" {
+			// Testing and unstable report e.g. "trixie/sid".
+			info.Codename, _, _ = strings.Cut(v, "/")
+		}
+	}
+
+	// Distroless images ship Debian's os-release with a custom PRETTY_NAME
+	// and keep one dpkg status file per package instead of a database.
+	if strings.Contains(strings.ToLower(info.PrettyName), "distroless") ||
+		(hasDpkgStatusDir && !hasDpkgStatus && !hasShell) {
+		info.Distroless = true
+	}
+
+	if info.Distro == "" {
+		info.Distro = "scratch"
+	}
+
+	info.EOLStatus = EOLUnknown
+	if entry := lookupLifecycle(info.Distro, info.Version, info.Codename); entry != nil {
+		info.EOL = entry.EOL
+		if info.Codename == "" {
+			info.Codename = entry.Codename
+		}
+		if now.Before(entry.EOL) {
+			info.EOLStatus = EOLSupported
+		} else {
+			info.EOLStatus = EOLExpired
+		}
+	}
+
+	return info, nil
+}
+
+// isDynamicLoader reports whether p is a glibc or musl dynamic loader, e.g.
+// /lib64/ld-linux-x86-64.so.2 or /lib/ld-musl-aarch64.so.1.
+func isDynamicLoader(p string) bool {
+	dir, base := path.Split(p)
+	switch dir {
+	case "/lib/", "/lib64/", "/usr/lib/", "/usr/lib64/":
+	default:
+		return false
+	}
+	return (strings.HasPrefix(base, "ld-linux") || strings.HasPrefix(base, "ld-musl")) && strings.Contains(base, ".so")
+}
+
+func isNumericVersion(v string) bool {
+	return v != "" && strings.Trim(v, "0123456789.") == ""
+}
+
+// parseOSRelease parses the KEY=value format of os-release(5).
+func parseOSRelease(content string) map[string]string {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		fields[k] = strings.Trim(v, `"'`)
+	}
+	return fields
+}
+
+//go:embed lifecycle.json
+var lifecycleJSON []byte
+
+type lifecycleEntry struct {
+	Distro   string    `json:"distro"`
+	Version  string    `json:"version"`
+	Codename string    `json:"codename"`
+	EOL      time.Time `json:"-"`
+	EOLDate  string    `json:"eol"`
+}
+
+var lifecycle = mustLoadLifecycle()
+
+func mustLoadLifecycle() []lifecycleEntry {
+	var entries []lifecycleEntry
+	if err := json.Unmarshal(lifecycleJSON, &entries); err != nil {
+		panic(fmt.Sprintf("invalid embedded lifecycle table: %v", err))
+	}
+	for i := range entries {
+		eol, err := time.Parse(time.DateOnly, entries[i].EOLDate)
+		if err != nil {
+			panic(fmt.Sprintf("invalid EOL date for %s %s: %v", entries[i].Distro, entries[i].Version, err))
+		}
+		entries[i].EOL = eol
+	}
+	return entries
+}
+
+// lookupLifecycle finds the release entry for a distro version. Table
+// versions match on component boundaries, so "3.19" matches "3.19.1" but
+// not "3.1", and the most specific entry wins. Releases without a version,
+// such as Debian testing, are matched by codename.
+func lookupLifecycle(distro, version, codename string) *lifecycleEntry {
+	var best *lifecycleEntry
+	for i := range lifecycle {
+		entry := &lifecycle[i]
+		if entry.Distro != distro {
+			continue
+		}
+		if version == "" {
+			if codename != "" && entry.Codename == codename {
+				return entry
+			}
+			continue
+		}
+		if version != entry.Version && !strings.HasPrefix(version, entry.Version+".") {
+			continue
+		}
+		if best == nil || len(entry.Version) > len(best.Version) {
+			best = entry
+		}
+	}
+	return best
+}
@@ -0,0 +1,136 @@
+package osinfo_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/osinfo"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newLayer(t *testing.T, files map[string]string) v1.Layer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if content == "/" {
+			hdr = &tar.Header{Name: name, Mode: 0o755, Typeflag: tar.TypeDir}
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		if hdr.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(content))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+
+	data := buf.Bytes()
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+	require.NoError(t, err)
+	return layer
+}
+
+func detect(t *testing.T, files map[string]string) *osinfo.Info {
+	t.Helper()
+
+	info, err := osinfo.Detect([]v1.Layer{newLayer(t, files)}, now)
+	require.NoError(t, err)
+	return info
+}
+
+func TestDetect_Debian(t *testing.T) {
+	info := detect(t, map[string]string{
+		"etc/os-release": `PRETTY_NAME="Debian GNU/Linux 12 (bookworm)"
+NAME="Debian GNU/Linux"
+VERSION_ID="12"
+VERSION_CODENAME=bookworm
+ID=debian
+`,
+		"etc/debian_version":         "12.5\n",
+		"var/lib/dpkg/status":        "",
+		"bin/sh":                     "",
+		"lib64/ld-linux-x86-64.so.2": "",
+	})
+
+	assert.Equal(t, "debian", info.Distro)
+	assert.Equal(t, "12.5", info.Version)
+	assert.Equal(t, "bookworm", info.Codename)
+	assert.Equal(t, "Debian GNU/Linux 12 (bookworm)", info.PrettyName)
+	assert.False(t, info.Distroless)
+	assert.False(t, info.Static)
+	assert.Equal(t, osinfo.EOLSupported, info.EOLStatus)
+	assert.Equal(t, "2028-06-30", info.EOL.Format(time.DateOnly))
+}
+
+func TestDetect_AlpineExpired(t *testing.T) {
+	info := detect(t, map[string]string{
+		"etc/alpine-release":      "3.18.4\n",
+		"lib/ld-musl-x86_64.so.1": "",
+		"usr/lib/os-release":      "ID=alpine\nVERSION_ID=3.18.4\n",
+	})
+
+	assert.Equal(t, "alpine", info.Distro)
+	assert.Equal(t, "3.18.4", info.Version)
+	assert.Equal(t, osinfo.EOLExpired, info.EOLStatus)
+}
+
+func TestDetect_Distroless(t *testing.T) {
+	info := detect(t, map[string]string{
+		"etc/os-release":             "PRETTY_NAME=\"Distroless\"\nID=debian\nVERSION_ID=\"12\"\n",
+		"etc/debian_version":         "12.5\n",
+		"var/lib/dpkg/status.d":      "/",
+		"var/lib/dpkg/status.d/base": "Package: base-files\n",
+	})
+
+	assert.Equal(t, "debian", info.Distro)
+	assert.True(t, info.Distroless)
+	assert.True(t, info.Static)
+	assert.Equal(t, "bookworm", info.Codename, "codename is filled in from the lifecycle table")
+}
+
+func TestDetect_Scratch(t *testing.T) {
+	info := detect(t, map[string]string{
+		"app": "\x7fELF",
+	})
+
+	assert.True(t, info.Scratch())
+	assert.True(t, info.Static)
+	assert.Equal(t, osinfo.EOLUnknown, info.EOLStatus)
+	assert.True(t, info.EOL.IsZero())
+}
+
+func TestDetect_UbuntuIgnoresDebianVersion(t *testing.T) {
+	info := detect(t, map[string]string{
+		"etc/os-release":     "ID=ubuntu\nVERSION_ID=\"22.04\"\nVERSION_CODENAME=jammy\n",
+		"etc/debian_version": "bookworm/sid\n",
+	})
+
+	assert.Equal(t, "ubuntu", info.Distro)
+	assert.Equal(t, "22.04", info.Version)
+	assert.Equal(t, "jammy", info.Codename)
+	assert.Equal(t, osinfo.EOLSupported, info.EOLStatus)
+}
+
+func TestDetect_DebianTestingByCodename(t *testing.T) {
+	info := detect(t, map[string]string{
+		"etc/os-release":     "ID=debian\n",
+		"etc/debian_version": "trixie/sid\n",
+	})
+
+	assert.Equal(t, "debian", info.Distro)
+	assert.Empty(t, info.Version)
+	assert.Equal(t, "trixie", info.Codename)
+	assert.Equal(t, osinfo.EOLSupported, info.EOLStatus)
+}
@@ -0,0 +1,129 @@
+package packages
+
+import (
+	"bytes"
+	"compress/zlib"
+	"debug/buildinfo"
+	"debug/elf"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func matchELF(_ string, head []byte) bool {
+	return bytes.Equal(head, []byte("\x7fELF"))
+}
+
+// parseBinary reads an ELF binary and reports Go modules from the embedded
+// build info and Rust crates from a cargo auditable section. Both are read
+// at their offsets in the binary, so the binary is spooled to a temporary
+// file rather than held in memory.
+func parseBinary(_ string, r io.Reader) ([]Package, error) {
+	spool, err := os.CreateTemp("", "cek-binary-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create binary spool: %w", err)
+	}
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+	if _, err := io.Copy(spool, r); err != nil {
+		return nil, fmt.Errorf("failed to spool binary: %w", err)
+	}
+
+	if pkgs := goModules(spool); len(pkgs) > 0 {
+		return pkgs, nil
+	}
+	return cargoAuditable(spool), nil
+}
+
+func goModules(ra io.ReaderAt) []Package {
+	info, err := buildinfo.Read(ra)
+	if err != nil {
+		return nil
+	}
+
+	pkgs := []Package{{
+		Name:      "stdlib",
+		Version:   strings.TrimPrefix(info.GoVersion, "go"),
+		Ecosystem: EcosystemGo,
+	}}
+
+	// Binaries built from a local checkout report "(devel)" for the main
+	// module, which carries no useful version.
+	if info.Main.Path != "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		pkgs = append(pkgs, Package{
+			Name:      info.Main.Path,
+			Version:   info.Main.Version,
+			Ecosystem: EcosystemGo,
+		})
+	}
+
+	for _, dep := range info.Deps {
+		mod := dep
+		if dep.Replace != nil {
+			mod = dep.Replace
+		}
+		pkgs = append(pkgs, Package{
+			Name:      mod.Path,
+			Version:   mod.Version,
+			Ecosystem: EcosystemGo,
+		})
+	}
+	return pkgs
+}
+
+// cargoAuditableSection is the ELF section cargo-auditable embeds the
+// zlib-compressed dependency tree into.
+const cargoAuditableSection = ".dep-v0"
+
+func cargoAuditable(ra io.ReaderAt) []Package {
+	f, err := elf.NewFile(ra)
+	if err != nil {
+		return nil
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	section := f.Section(cargoAuditableSection)
+	if section == nil {
+		return nil
+	}
+
+	zr, err := zlib.NewReader(section.Open())
+	if err != nil {
+		return nil
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+
+	var tree struct {
+		Packages []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+			Source  string `json:"source"`
+		} `json:"packages"`
+	}
+	if err := json.NewDecoder(zr).Decode(&tree); err != nil {
+		return nil
+	}
+
+	var pkgs []Package
+	for _, p := range tree.Packages {
+		// Only crates from the registry can be matched against advisories;
+		// local and git sources are part of the application itself.
+		if p.Source != "" && p.Source != "crates.io" && p.Source != "registry" {
+			continue
+		}
+		pkgs = append(pkgs, Package{
+			Name:      p.Name,
+			Version:   p.Version,
+			Ecosystem: EcosystemCratesIO,
+		})
+	}
+	return pkgs
+}
@@ -0,0 +1,242 @@
+package packages
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+func matchNPM(p string, _ []byte) bool {
+	if path.Base(p) != "package.json" {
+		return false
+	}
+	// Only package.json files that describe an installed module count,
+	// i.e. node_modules/<name>/package.json or node_modules/@scope/<name>/package.json.
+	dir := path.Dir(p)
+	parent := path.Dir(dir)
+	if path.Base(parent) == "node_modules" {
+		return true
+	}
+	return strings.HasPrefix(path.Base(parent), "@") && path.Base(path.Dir(parent)) == "node_modules"
+}
+
+func parseNPM(_ string, r io.Reader) ([]Package, error) {
+	var manifest struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Name == "" || manifest.Version == "" {
+		return nil, nil
+	}
+	return []Package{{
+		Name:      manifest.Name,
+		Version:   manifest.Version,
+		Ecosystem: EcosystemNPM,
+	}}, nil
+}
+
+func matchPython(p string, _ []byte) bool {
+	dir := path.Base(path.Dir(p))
+	switch path.Base(p) {
+	case "METADATA":
+		return strings.HasSuffix(dir, ".dist-info")
+	case "PKG-INFO":
+		return strings.HasSuffix(dir, ".egg-info")
+	}
+	return false
+}
+
+// parsePython reads the header block of a core metadata file, which ends at
+// the first blank line.
+func parsePython(_ string, r io.Reader) ([]Package, error) {
+	var name, version string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Name":
+			name = strings.TrimSpace(value)
+		case "Version":
+			version = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if name == "" || version == "" {
+		return nil, nil
+	}
+	return []Package{{
+		Name:      name,
+		Version:   version,
+		Ecosystem: EcosystemPyPI,
+	}}, nil
+}
+
+func matchJar(p string, _ []byte) bool {
+	switch path.Ext(p) {
+	case ".jar", ".war", ".ear":
+		return true
+	}
+	return false
+}
+
+// maxJarDepth limits how deep nested archives (e.g. Spring Boot fat jars
+// with BOOT-INF/lib/*.jar) are opened.
+const maxJarDepth = 2
+
+func parseJar(_ string, r io.Reader) ([]Package, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseJarBytes(data, 0)
+}
+
+func parseJarBytes(data []byte, depth int) ([]Package, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	var pkgs []Package
+	for _, f := range zr.File {
+		switch {
+		case strings.HasPrefix(f.Name, "META-INF/maven/") && path.Base(f.Name) == "pom.properties":
+			pkg, err := readPomProperties(f)
+			if err != nil || pkg == nil {
+				continue
+			}
+			pkgs = append(pkgs, *pkg)
+		case depth < maxJarDepth && matchJar(f.Name, nil):
+			nested, err := readZipFile(f)
+			if err != nil {
+				continue
+			}
+			found, err := parseJarBytes(nested, depth+1)
+			if err != nil {
+				continue
+			}
+			pkgs = append(pkgs, found...)
+		}
+	}
+	return pkgs, nil
+}
+
+func readPomProperties(f *zip.File) (*Package, error) {
+	data, err := readZipFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	props := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		props[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	group, artifact, version := props["groupId"], props["artifactId"], props["version"]
+	if group == "" || artifact == "" || version == "" {
+		return nil, nil
+	}
+	return &Package{
+		Name:      group + ":" + artifact,
+		Version:   version,
+		Ecosystem: EcosystemMaven,
+	}, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxFileSize {
+		return nil, fmt.Errorf("%s is too large", f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	return io.ReadAll(rc)
+}
+
+func matchGemspec(p string, _ []byte) bool {
+	return path.Ext(p) == ".gemspec" && hasDirComponent(p, "specifications")
+}
+
+var gemspecFieldRe = regexp.MustCompile(`^\s*s\.(name|version)\s*=\s*["']([^"']+)["']`)
+
+// gemspecFileName splits the base name of a gemspec into the gem name and
+// version. Gem names may contain dashes but the version starts at the first
+// dash followed by a digit. Versions never contain dashes, so anything after
+// one is the platform of a native gem:
+//
+//	rails-7.1.3                  -> rails, 7.1.3
+//	nokogiri-1.15.4-x86_64-linux -> nokogiri, 1.15.4
+func gemspecFileName(base string) (name, version string) {
+	for i := 1; i < len(base)-1; i++ {
+		if base[i] != '-' || base[i+1] < '0' || base[i+1] > '9' {
+			continue
+		}
+		version, _, _ = strings.Cut(base[i+1:], "-")
+		return base[:i], version
+	}
+	return "", ""
+}
+
+// parseGemspec reads the installed gem specification. Rubygems writes these
+// with `s.name = "..."` assignments; the file name is used as a fallback.
+func parseGemspec(p string, r io.Reader) ([]Package, error) {
+	var name, version string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := gemspecFieldRe.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		switch m[1] {
+		case "name":
+			name = m[2]
+		case "version":
+			version = m[2]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if name == "" || version == "" {
+		name, version = gemspecFileName(strings.TrimSuffix(path.Base(p), ".gemspec"))
+	}
+	if name == "" || version == "" {
+		return nil, nil
+	}
+	return []Package{{
+		Name:      name,
+		Version:   version,
+		Ecosystem: EcosystemRubyGems,
+	}}, nil
+}
@@ -0,0 +1,105 @@
+package packages
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"strings"
+)
+
+func matchDpkg(p string, _ []byte) bool {
+	// Distroless images ship one status file per package in status.d.
+	return p == "/var/lib/dpkg/status" || path.Dir(p) == "/var/lib/dpkg/status.d"
+}
+
+// parseDpkg reads a dpkg status database, which consists of RFC 822 style
+// stanzas separated by blank lines.
+func parseDpkg(_ string, r io.Reader) ([]Package, error) {
+	var pkgs []Package
+	err := readStanzas(r, ':', func(fields map[string]string) {
+		name, version := fields["Package"], fields["Version"]
+		if name == "" || version == "" {
+			return
+		}
+		// status.d entries have no Status field; only the main database
+		// keeps records of removed packages.
+		if status, ok := fields["Status"]; ok && !strings.HasSuffix(status, " installed") {
+			return
+		}
+		source := name
+		if s := fields["Source"]; s != "" {
+			// "Source: openssl (3.0.11-1)" carries the source version when it
+			// differs from the binary package version.
+			source, _, _ = strings.Cut(s, " ")
+		}
+		pkgs = append(pkgs, Package{
+			Name:      name,
+			Version:   version,
+			Ecosystem: EcosystemDebian,
+			Source:    source,
+		})
+	})
+	return pkgs, err
+}
+
+func matchApk(p string, _ []byte) bool {
+	return p == "/lib/apk/db/installed"
+}
+
+// parseApk reads the apk installed database, where each package is a block
+// of single letter keys (P: name, V: version, o: origin).
+func parseApk(_ string, r io.Reader) ([]Package, error) {
+	var pkgs []Package
+	err := readStanzas(r, ':', func(fields map[string]string) {
+		name, version := fields["P"], fields["V"]
+		if name == "" || version == "" {
+			return
+		}
+		source := fields["o"]
+		if source == "" {
+			source = name
+		}
+		pkgs = append(pkgs, Package{
+			Name:      name,
+			Version:   version,
+			Ecosystem: EcosystemAlpine,
+			Source:    source,
+		})
+	})
+	return pkgs, err
+}
+
+// readStanzas splits r into blank line separated blocks of "Key<sep> value"
+// lines. Continuation lines, which start with whitespace, are ignored since
+// none of the fields we read span multiple lines.
+func readStanzas(r io.Reader, sep byte, fn func(fields map[string]string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	fields := make(map[string]string)
+	flush := func() {
+		if len(fields) > 0 {
+			fn(fields)
+			fields = make(map[string]string)
+		}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, string(sep))
+		if !ok {
+			continue
+		}
+		fields[key] = strings.TrimSpace(value)
+	}
+	flush()
+
+	return scanner.Err()
+}
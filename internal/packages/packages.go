@@ -0,0 +1,218 @@
+// Package packages detects OS and language packages installed in an image by
+// inspecting package databases and metadata files in the merged filesystem.
+package packages
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Ecosystem identifies the package ecosystem. Values match the OSV schema so
+// detected packages can be matched against advisories directly.
+type Ecosystem string
+
+const (
+	EcosystemDebian    Ecosystem = "Debian"
+	EcosystemAlpine    Ecosystem = "Alpine"
+	EcosystemRPM       Ecosystem = "RPM"
+	EcosystemGo        Ecosystem = "Go"
+	EcosystemNPM       Ecosystem = "npm"
+	EcosystemPyPI      Ecosystem = "PyPI"
+	EcosystemMaven     Ecosystem = "Maven"
+	EcosystemRubyGems  Ecosystem = "RubyGems"
+	EcosystemCratesIO  Ecosystem = "crates.io"
+	EcosystemUndefined Ecosystem = ""
+
+	// EcosystemUbuntu is never detected: dpkg packages are reported as
+	// Debian, and are matched against Ubuntu advisories on Ubuntu images.
+	EcosystemUbuntu Ecosystem = "Ubuntu"
+
+	// OSV has no RPM ecosystem: rpm packages are matched against the
+	// advisories of the distribution the image is based on.
+	EcosystemRedHat    Ecosystem = "Red Hat"
+	EcosystemAlmaLinux Ecosystem = "AlmaLinux"
+	EcosystemRocky     Ecosystem = "Rocky Linux"
+	EcosystemOpenSUSE  Ecosystem = "openSUSE"
+	EcosystemSUSE      Ecosystem = "SUSE"
+)
+
+// Ecosystems lists every ecosystem that can be detected.
+var Ecosystems = []Ecosystem{
+	EcosystemDebian,
+	EcosystemAlpine,
+	EcosystemRPM,
+	EcosystemGo,
+	EcosystemNPM,
+	EcosystemPyPI,
+	EcosystemMaven,
+	EcosystemRubyGems,
+	EcosystemCratesIO,
+}
+
+// ParseEcosystem resolves a user supplied ecosystem name, case-insensitively.
+// Common aliases such as "deb", "apk", "rpm", "python", "java", "ruby" and "rust"
+// are accepted.
+func ParseEcosystem(s string) (Ecosystem, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debian", "deb", "dpkg":
+		return EcosystemDebian, nil
+	case "alpine", "apk":
+		return EcosystemAlpine, nil
+	case "rpm", "redhat", "rhel":
+		return EcosystemRPM, nil
+	case "go", "golang":
+		return EcosystemGo, nil
+	case "npm", "node":
+		return EcosystemNPM, nil
+	case "pypi", "python", "pip":
+		return EcosystemPyPI, nil
+	case "maven", "java", "jar":
+		return EcosystemMaven, nil
+	case "rubygems", "ruby", "gem":
+		return EcosystemRubyGems, nil
+	case "crates.io", "cargo", "rust":
+		return EcosystemCratesIO, nil
+	}
+	return EcosystemUndefined, fmt.Errorf("unknown ecosystem %q", s)
+}
+
+// Package is a single detected package.
+type Package struct {
+	Name      string
+	Version   string
+	Ecosystem Ecosystem
+	// Source is the source package an OS package was built from, which is
+	// the name distro advisories are published under. Empty when unknown.
+	Source string
+	// Path is the file the package was detected from.
+	Path string
+	// Layer is the 1-indexed layer that provides Path.
+	Layer int
+}
+
+// Options controls which ecosystems are scanned.
+type Options struct {
+	// Ecosystems restricts detection to the given ecosystems. Empty means all.
+	Ecosystems []Ecosystem
+	// Warn is called for every file that could not be fully parsed. Packages
+	// read from the file before the failure are still reported.
+	Warn func(path string, err error)
+}
+
+func (o *Options) wants(e Ecosystem) bool {
+	if o == nil || len(o.Ecosystems) == 0 {
+		return true
+	}
+	for _, want := range o.Ecosystems {
+		if want == e {
+			return true
+		}
+	}
+	return false
+}
+
+// cataloger extracts packages from a single file. match is called for every
+// regular file; parse is only called when match returns true, so catalogers
+// never read contents they are not interested in.
+type cataloger struct {
+	ecosystems []Ecosystem
+	match      func(p string, head []byte) bool
+	parse      func(p string, r io.Reader) ([]Package, error)
+}
+
+// maxFileSize bounds how much of a single file is read. Larger
+// binaries and archives are skipped.
+const maxFileSize = 512 << 20
+
+// Detect walks the merged filesystem of the given layers and returns all
+// packages found, sorted by ecosystem, name and version.
+func Detect(layers []v1.Layer, opts *Options) ([]Package, error) {
+	var active []cataloger
+	for _, c := range catalogers {
+		for _, e := range c.ecosystems {
+			if opts.wants(e) {
+				active = append(active, c)
+				break
+			}
+		}
+	}
+
+	var pkgs []Package
+	err := oci.WalkMerged(layers, func(f *oci.FileEntry) error {
+		if !f.IsRegular() || f.Header.Size == 0 || f.Header.Size > maxFileSize {
+			return nil
+		}
+
+		// Binary catalogers sniff the file header, so peek at the first few
+		// bytes without consuming the rest of the stream.
+		head := make([]byte, 4)
+		n, _ := io.ReadFull(f.Reader, head)
+		head = head[:n]
+		r := io.MultiReader(bytes.NewReader(head), f.Reader)
+
+		for _, c := range active {
+			if !c.match(f.Path, head) {
+				continue
+			}
+			// Malformed metadata should not abort the whole scan.
+			found, err := c.parse(f.Path, r)
+			if err != nil && opts != nil && opts.Warn != nil {
+				opts.Warn(f.Path, err)
+			}
+			for _, pkg := range found {
+				if !opts.wants(pkg.Ecosystem) {
+					continue
+				}
+				pkg.Path = f.Path
+				pkg.Layer = f.Layer
+				pkgs = append(pkgs, pkg)
+			}
+			return nil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		if pkgs[i].Ecosystem != pkgs[j].Ecosystem {
+			return pkgs[i].Ecosystem < pkgs[j].Ecosystem
+		}
+		if pkgs[i].Name != pkgs[j].Name {
+			return pkgs[i].Name < pkgs[j].Name
+		}
+		return pkgs[i].Version < pkgs[j].Version
+	})
+
+	return pkgs, nil
+}
+
+var catalogers = []cataloger{
+	{ecosystems: []Ecosystem{EcosystemDebian}, match: matchDpkg, parse: parseDpkg},
+	{ecosystems: []Ecosystem{EcosystemAlpine}, match: matchApk, parse: parseApk},
+	{ecosystems: []Ecosystem{EcosystemRPM}, match: matchRPM, parse: parseRPM},
+	{ecosystems: []Ecosystem{EcosystemNPM}, match: matchNPM, parse: parseNPM},
+	{ecosystems: []Ecosystem{EcosystemPyPI}, match: matchPython, parse: parsePython},
+	{ecosystems: []Ecosystem{EcosystemMaven}, match: matchJar, parse: parseJar},
+	{ecosystems: []Ecosystem{EcosystemRubyGems}, match: matchGemspec, parse: parseGemspec},
+	// Go build info and cargo auditable data both live in ELF binaries, so a
+	// single cataloger reads each binary once and looks for either.
+	{ecosystems: []Ecosystem{EcosystemGo, EcosystemCratesIO}, match: matchELF, parse: parseBinary},
+}
+
+func hasDirComponent(p, dir string) bool {
+	for _, part := range strings.Split(path.Dir(p), "/") {
+		if part == dir {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package packages_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/packages"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLayer(t *testing.T, files map[string][]byte) v1.Layer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o755,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	data := buf.Bytes()
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+	require.NoError(t, err)
+	return layer
+}
+
+func find(pkgs []packages.Package, ecosystem packages.Ecosystem, name string) *packages.Package {
+	for i := range pkgs {
+		if pkgs[i].Ecosystem == ecosystem && pkgs[i].Name == name {
+			return &pkgs[i]
+		}
+	}
+	return nil
+}
+
+func TestDetect_OSPackages(t *testing.T) {
+	layer := newLayer(t, map[string][]byte{
+		"var/lib/dpkg/status": []byte("Package: libssl3\n" +
+			"Status: install ok installed\n" +
+			"Source: openssl (3.0.11-1~deb12u2)\n" +
+			"Version: 3.0.11-1~deb12u2\n" +
+			"Description: Secure Sockets Layer toolkit\n" +
+			" continuation line\n" +
+			"\n" +
+			"Package: removed\n" +
+			"Status: deinstall ok config-files\n" +
+			"Version: 1.0\n"),
+		"lib/apk/db/installed": []byte("P:musl\nV:1.2.4-r2\no:musl\n\nP:busybox\nV:1.36.1-r15\n"),
+	})
+
+	pkgs, err := packages.Detect([]v1.Layer{layer}, nil)
+	require.NoError(t, err)
+
+	libssl := find(pkgs, packages.EcosystemDebian, "libssl3")
+	require.NotNil(t, libssl)
+	assert.Equal(t, "3.0.11-1~deb12u2", libssl.Version)
+	assert.Equal(t, "openssl", libssl.Source)
+	assert.Equal(t, "/var/lib/dpkg/status", libssl.Path)
+	assert.Equal(t, 1, libssl.Layer)
+	assert.Nil(t, find(pkgs, packages.EcosystemDebian, "removed"))
+
+	musl := find(pkgs, packages.EcosystemAlpine, "musl")
+	require.NotNil(t, musl)
+	assert.Equal(t, "1.2.4-r2", musl.Version)
+	assert.NotNil(t, find(pkgs, packages.EcosystemAlpine, "busybox"))
+}
+
+func TestDetect_LanguagePackages(t *testing.T) {
+	var jar bytes.Buffer
+	zw := zip.NewWriter(&jar)
+	w, err := zw.Create("META-INF/maven/org.apache.commons/commons-text/pom.properties")
+	require.NoError(t, err)
+	_, _ = w.Write([]byte("#Generated\ngroupId=org.apache.commons\nartifactId=commons-text\nversion=1.9\n"))
+	require.NoError(t, zw.Close())
+
+	layer := newLayer(t, map[string][]byte{
+		"app/node_modules/lodash/package.json":                             []byte(`{"name":"lodash","version":"4.17.20"}`),
+		"app/node_modules/@babel/core/package.json":                        []byte(`{"name":"@babel/core","version":"7.24.0"}`),
+		"app/package.json":                                                 []byte(`{"name":"my-app","version":"1.0.0"}`),
+		"usr/lib/python3/site-packages/requests-2.31.0.dist-info/METADATA": []byte("Metadata-Version: 2.1\nName: requests\nVersion: 2.31.0\n\nName: not-a-header\n"),
+		"opt/app/lib/commons-text.jar":                                     jar.Bytes(),
+		"usr/local/bundle/specifications/rack-3.0.8.gemspec":               []byte("Gem::Specification.new do |s|\n  s.name = \"rack\".freeze\n  s.version = \"3.0.8\"\nend\n"),
+	})
+
+	pkgs, err := packages.Detect([]v1.Layer{layer}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "4.17.20", find(pkgs, packages.EcosystemNPM, "lodash").Version)
+	assert.Equal(t, "7.24.0", find(pkgs, packages.EcosystemNPM, "@babel/core").Version)
+	assert.Nil(t, find(pkgs, packages.EcosystemNPM, "my-app"))
+	assert.Equal(t, "2.31.0", find(pkgs, packages.EcosystemPyPI, "requests").Version)
+	assert.Equal(t, "1.9", find(pkgs, packages.EcosystemMaven, "org.apache.commons:commons-text").Version)
+	assert.Equal(t, "3.0.8", find(pkgs, packages.EcosystemRubyGems, "rack").Version)
+}
+
+func TestDetect_GemspecFileName(t *testing.T) {
+	tests := []struct {
+		file    string
+		name    string
+		version string
+	}{
+		{"rails-7.1.3.gemspec", "rails", "7.1.3"},
+		{"aws-sdk-s3-1.143.0.gemspec", "aws-sdk-s3", "1.143.0"},
+		{"nokogiri-1.15.4-x86_64-linux.gemspec", "nokogiri", "1.15.4"},
+		{"google-protobuf-3.25.1-aarch64-linux-musl.gemspec", "google-protobuf", "3.25.1"},
+		{"ffi-1.16.3-java.gemspec", "ffi", "1.16.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			// Without name and version fields, the file name is used.
+			layer := newLayer(t, map[string][]byte{
+				"usr/local/bundle/specifications/" + tt.file: []byte("Gem::Specification.new do |s|\nend\n"),
+			})
+
+			pkgs, err := packages.Detect([]v1.Layer{layer}, nil)
+			require.NoError(t, err)
+
+			require.Len(t, pkgs, 1)
+			assert.Equal(t, tt.name, pkgs[0].Name)
+			assert.Equal(t, tt.version, pkgs[0].Version)
+			assert.Equal(t, packages.EcosystemRubyGems, pkgs[0].Ecosystem)
+		})
+	}
+}
+
+func TestDetect_GoBinary(t *testing.T) {
+	// The test binary itself is a Go binary with embedded build info.
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	data, err := os.ReadFile(exe)
+	require.NoError(t, err)
+	if !bytes.HasPrefix(data, []byte("\x7fELF")) {
+		t.Skip("test binary is not an ELF file on this platform")
+	}
+
+	layer := newLayer(t, map[string][]byte{"usr/local/bin/app": data})
+
+	pkgs, err := packages.Detect([]v1.Layer{layer}, &packages.Options{
+		Ecosystems: []packages.Ecosystem{packages.EcosystemGo},
+	})
+	require.NoError(t, err)
+
+	assert.NotNil(t, find(pkgs, packages.EcosystemGo, "stdlib"))
+	testify := find(pkgs, packages.EcosystemGo, "github.com/stretchr/testify")
+	require.NotNil(t, testify)
+	assert.Equal(t, "/usr/local/bin/app", testify.Path)
+}
+
+func TestDetect_EcosystemFilter(t *testing.T) {
+	layer := newLayer(t, map[string][]byte{
+		"lib/apk/db/installed":                 []byte("P:musl\nV:1.2.4-r2\n"),
+		"app/node_modules/lodash/package.json": []byte(`{"name":"lodash","version":"4.17.20"}`),
+	})
+
+	pkgs, err := packages.Detect([]v1.Layer{layer}, &packages.Options{
+		Ecosystems: []packages.Ecosystem{packages.EcosystemNPM},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, pkgs, 1)
+	assert.Equal(t, "lodash", pkgs[0].Name)
+}
+
+func TestParseEcosystem(t *testing.T) {
+	for input, want := range map[string]packages.Ecosystem{
+		"deb":    packages.EcosystemDebian,
+		"Alpine": packages.EcosystemAlpine,
+		"rpm":    packages.EcosystemRPM,
+		"golang": packages.EcosystemGo,
+		"python": packages.EcosystemPyPI,
+		"java":   packages.EcosystemMaven,
+		"gem":    packages.EcosystemRubyGems,
+		"rust":   packages.EcosystemCratesIO,
+	} {
+		got, err := packages.ParseEcosystem(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	_, err := packages.ParseEcosystem("cobol")
+	assert.Error(t, err)
+}
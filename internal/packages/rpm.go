@@ -0,0 +1,523 @@
+package packages
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+)
+
+// rpmdbDirs are the directories rpm keeps its database in. Newer Fedora and
+// SUSE releases moved it under /usr.
+var rpmdbDirs = []string{"/var/lib/rpm", "/usr/lib/sysimage/rpm"}
+
+func matchRPM(p string, _ []byte) bool {
+	switch path.Base(p) {
+	case "rpmdb.sqlite", "Packages", "Packages.db":
+	default:
+		return false
+	}
+	for _, dir := range rpmdbDirs {
+		if path.Dir(p) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRPM reads an rpm database. The package headers are the same in every
+// backend: rpmdb.sqlite (RHEL 9, Fedora), the Berkeley DB hash Packages
+// (RHEL 8 and earlier) and the NDB Packages.db (SUSE).
+func parseRPM(p string, r io.Reader) ([]Package, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs [][]byte
+	switch path.Base(p) {
+	case "rpmdb.sqlite":
+		blobs, err = readSQLiteBlobs(data, "Packages")
+	case "Packages.db":
+		blobs, err = readNDBBlobs(data)
+	default:
+		blobs, err = readBDBHashValues(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A malformed header only loses that package, so the rest of the
+	// database is still reported along with the error.
+	var pkgs []Package
+	var skipped int
+	var firstErr error
+	for _, blob := range blobs {
+		pkg, err := parseRPMHeader(blob)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			skipped++
+			continue
+		}
+		// Imported signing keys are stored as packages too.
+		if pkg.Name == "" || pkg.Name == "gpg-pubkey" {
+			continue
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	if skipped > 0 {
+		return pkgs, fmt.Errorf("skipped %d of %d rpm headers: %w", skipped, len(blobs), firstErr)
+	}
+	return pkgs, nil
+}
+
+// Header tags and data types of the rpm header format.
+const (
+	rpmTagName    = 1000
+	rpmTagVersion = 1001
+	rpmTagRelease = 1002
+	rpmTagEpoch   = 1003
+
+	rpmTypeInt32  = 4
+	rpmTypeString = 6
+)
+
+// parseRPMHeader reads the name and [epoch:]version-release of a package
+// from an rpm header blob as stored in the database: an index of tag
+// entries followed by the data they point into.
+func parseRPMHeader(blob []byte) (Package, error) {
+	if len(blob) < 8 {
+		return Package{}, errors.New("rpm header too short")
+	}
+	count := int(binary.BigEndian.Uint32(blob[0:4]))
+	size := int(binary.BigEndian.Uint32(blob[4:8]))
+	indexEnd := 8 + count*16
+	if count < 0 || size < 0 || indexEnd > len(blob) || indexEnd+size > len(blob) {
+		return Package{}, errors.New("rpm header is truncated")
+	}
+	store := blob[indexEnd : indexEnd+size]
+
+	var name, version, release string
+	epoch := -1
+	for i := 0; i < count; i++ {
+		entry := blob[8+i*16:]
+		tag := binary.BigEndian.Uint32(entry[0:4])
+		typ := binary.BigEndian.Uint32(entry[4:8])
+		offset := int(binary.BigEndian.Uint32(entry[8:12]))
+		if offset < 0 || offset >= len(store) {
+			continue
+		}
+		switch {
+		case typ == rpmTypeString && tag >= rpmTagName && tag <= rpmTagRelease:
+			value, _, _ := bytes.Cut(store[offset:], []byte{0})
+			switch tag {
+			case rpmTagName:
+				name = string(value)
+			case rpmTagVersion:
+				version = string(value)
+			case rpmTagRelease:
+				release = string(value)
+			}
+		case typ == rpmTypeInt32 && tag == rpmTagEpoch && offset+4 <= len(store):
+			epoch = int(binary.BigEndian.Uint32(store[offset:]))
+		}
+	}
+
+	full := version
+	if release != "" {
+		full += "-" + release
+	}
+	if epoch > 0 {
+		full = strconv.Itoa(epoch) + ":" + full
+	}
+	// RPM advisories are published for binary package names, so no source
+	// package is recorded.
+	return Package{Name: name, Version: full, Ecosystem: EcosystemRPM}, nil
+}
+
+// readSQLiteBlobs returns the last column of every row of the named table in
+// an SQLite database, which for rpm's Packages table is the header blob.
+func readSQLiteBlobs(data []byte, table string) ([][]byte, error) {
+	if len(data) < 100 || !bytes.HasPrefix(data, []byte("SQLite format 3\x00")) {
+		return nil, errors.New("not an SQLite database")
+	}
+	db := &sqliteDB{data: data, pageSize: int(binary.BigEndian.Uint16(data[16:18]))}
+	if db.pageSize == 1 {
+		db.pageSize = 65536
+	}
+	db.usable = db.pageSize - int(data[20])
+	if db.pageSize < 512 || db.pageSize&(db.pageSize-1) != 0 || db.usable < 480 {
+		return nil, errors.New("invalid SQLite page size")
+	}
+
+	// The schema table is rooted on page 1; its rows are type, name,
+	// tbl_name, rootpage and sql.
+	root := 0
+	err := db.walk(1, 0, func(record []any) error {
+		if len(record) >= 4 && record[0] == "table" && record[1] == table {
+			if n, ok := record[3].(int64); ok {
+				root = int(n)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if root == 0 {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+
+	var blobs [][]byte
+	err = db.walk(root, 0, func(record []any) error {
+		if len(record) > 0 {
+			if blob, ok := record[len(record)-1].([]byte); ok {
+				blobs = append(blobs, blob)
+			}
+		}
+		return nil
+	})
+	return blobs, err
+}
+
+type sqliteDB struct {
+	data     []byte
+	pageSize int
+	usable   int
+}
+
+// page returns the 1-indexed page n.
+func (db *sqliteDB) page(n int) ([]byte, error) {
+	start := (n - 1) * db.pageSize
+	if n < 1 || start+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("sqlite page %d out of range", n)
+	}
+	return db.data[start : start+db.pageSize], nil
+}
+
+// walk calls fn with the decoded record of every row of the table b-tree
+// rooted at page n.
+func (db *sqliteDB) walk(n, depth int, fn func(record []any) error) error {
+	if depth > 64 {
+		return errors.New("sqlite b-tree too deep")
+	}
+	page, err := db.page(n)
+	if err != nil {
+		return err
+	}
+	// Page 1 starts with the database header.
+	hdr := 0
+	if n == 1 {
+		hdr = 100
+	}
+	if hdr+8 > len(page) {
+		return errors.New("sqlite page too short")
+	}
+	kind := page[hdr]
+	cells := int(binary.BigEndian.Uint16(page[hdr+3:]))
+	pointers := hdr + 8
+	if kind == 0x05 {
+		pointers = hdr + 12
+	}
+	if pointers+cells*2 > len(page) {
+		return errors.New("sqlite cell pointers out of range")
+	}
+
+	for i := 0; i < cells; i++ {
+		offset := int(binary.BigEndian.Uint16(page[pointers+i*2:]))
+		if offset >= len(page) {
+			return errors.New("sqlite cell out of range")
+		}
+		cell := page[offset:]
+		switch kind {
+		case 0x05: // interior table page: left child and key
+			if len(cell) < 4 {
+				return errors.New("sqlite cell too short")
+			}
+			if err := db.walk(int(binary.BigEndian.Uint32(cell)), depth+1, fn); err != nil {
+				return err
+			}
+		case 0x0d: // leaf table page: payload size, rowid and payload
+			payload, err := db.payload(cell)
+			if err != nil {
+				return err
+			}
+			record, err := decodeSQLiteRecord(payload)
+			if err != nil {
+				return err
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected sqlite page type %#x", kind)
+		}
+	}
+	if kind == 0x05 {
+		return db.walk(int(binary.BigEndian.Uint32(page[hdr+8:])), depth+1, fn)
+	}
+	return nil
+}
+
+// payload returns the payload of a leaf table cell, following its overflow
+// pages.
+func (db *sqliteDB) payload(cell []byte) ([]byte, error) {
+	size, n := sqliteVarint(cell)
+	_, m := sqliteVarint(cell[n:])
+	cell = cell[n+m:]
+	// A payload is never larger than the database holding it, however its
+	// overflow pages are chained.
+	if size > uint64(len(db.data)) {
+		return nil, errors.New("sqlite payload out of range")
+	}
+	total := int(size)
+
+	local := total
+	if maxLocal := db.usable - 35; total > maxLocal {
+		minLocal := (db.usable-12)*32/255 - 23
+		local = minLocal + (total-minLocal)%(db.usable-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if local > len(cell) || (local < total && local+4 > len(cell)) {
+		return nil, errors.New("sqlite payload out of range")
+	}
+	payload := append([]byte(nil), cell[:local]...)
+	if local == total {
+		return payload, nil
+	}
+
+	next := int(binary.BigEndian.Uint32(cell[local:]))
+	for len(payload) < total {
+		page, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		chunk := page[4:db.usable]
+		if rest := total - len(payload); rest < len(chunk) {
+			chunk = chunk[:rest]
+		}
+		payload = append(payload, chunk...)
+		next = int(binary.BigEndian.Uint32(page))
+	}
+	return payload, nil
+}
+
+// decodeSQLiteRecord decodes integers, text and blobs of a record. Other
+// values decode as nil.
+func decodeSQLiteRecord(payload []byte) ([]any, error) {
+	headerSize, n := sqliteVarint(payload)
+	if n == 0 || headerSize > uint64(len(payload)) || int(headerSize) < n {
+		return nil, errors.New("sqlite record header out of range")
+	}
+	header := payload[n:headerSize]
+	body := payload[headerSize:]
+
+	var record []any
+	for len(header) > 0 {
+		serial, n := sqliteVarint(header)
+		header = header[n:]
+
+		var size int
+		switch {
+		case serial >= 12:
+			size = int(serial-12) / 2
+		case serial >= 1 && serial <= 4:
+			size = int(serial)
+		case serial == 5:
+			size = 6
+		case serial == 6 || serial == 7:
+			size = 8
+		}
+		if size < 0 || size > len(body) {
+			return nil, errors.New("sqlite record value out of range")
+		}
+		value := body[:size]
+		body = body[size:]
+
+		switch {
+		case serial >= 1 && serial <= 6:
+			var v int64
+			for _, b := range value {
+				v = v<<8 | int64(b)
+			}
+			// Sign-extend from the stored width.
+			shift := 64 - 8*uint(size)
+			record = append(record, v<<shift>>shift)
+		case serial == 8:
+			record = append(record, int64(0))
+		case serial == 9:
+			record = append(record, int64(1))
+		case serial >= 12 && serial%2 == 0:
+			record = append(record, value)
+		case serial >= 13:
+			record = append(record, string(value))
+		default:
+			record = append(record, nil)
+		}
+	}
+	return record, nil
+}
+
+// sqliteVarint decodes a big-endian SQLite varint, returning the value and
+// the number of bytes read.
+func sqliteVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return v, len(b)
+}
+
+// Berkeley DB hash database layout.
+const (
+	bdbHashMagic       = 0x061561
+	bdbPageHeaderSize  = 26
+	bdbPageHashSorted  = 13
+	bdbPageHashLegacy  = 2
+	bdbPageOverflow    = 7
+	bdbItemKeyData     = 1
+	bdbItemOffPage     = 3
+	bdbOffPageItemSize = 12
+)
+
+// readBDBHashValues returns the values of a Berkeley DB hash database, as
+// rpm stores one header per value. Pages are in the byte order of the host
+// that wrote them, which the magic number tells.
+func readBDBHashValues(data []byte) ([][]byte, error) {
+	if len(data) < 512 {
+		return nil, errors.New("not a Berkeley DB database")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(data[12:]) != bdbHashMagic {
+		order = binary.BigEndian
+		if order.Uint32(data[12:]) != bdbHashMagic {
+			return nil, errors.New("not a Berkeley DB hash database")
+		}
+	}
+	pageSize := int(order.Uint32(data[20:]))
+	lastPage := int(order.Uint32(data[32:]))
+	if pageSize < 512 {
+		return nil, errors.New("invalid Berkeley DB page size")
+	}
+	page := func(n int) ([]byte, error) {
+		start := n * pageSize
+		if n < 0 || start+pageSize > len(data) {
+			return nil, fmt.Errorf("Berkeley DB page %d out of range", n)
+		}
+		return data[start : start+pageSize], nil
+	}
+
+	var values [][]byte
+	for n := 1; n <= lastPage; n++ {
+		p, err := page(n)
+		if err != nil {
+			return nil, err
+		}
+		if p[25] != bdbPageHashSorted && p[25] != bdbPageHashLegacy {
+			continue
+		}
+		entries := int(order.Uint16(p[20:]))
+		if bdbPageHeaderSize+entries*2 > len(p) {
+			return nil, errors.New("Berkeley DB index out of range")
+		}
+		// Entries alternate between keys and values.
+		for i := 1; i < entries; i += 2 {
+			offset := int(order.Uint16(p[bdbPageHeaderSize+i*2:]))
+			if offset+bdbOffPageItemSize > len(p) || p[offset] != bdbItemOffPage {
+				// Headers never fit on the hash page itself.
+				continue
+			}
+			next := int(order.Uint32(p[offset+4:]))
+			length := int(order.Uint32(p[offset+8:]))
+
+			// A chain can never be longer than the database, which also
+			// stops corrupt chains that loop back on themselves.
+			value := make([]byte, 0, min(length, len(data)))
+			for hops := 0; next != 0 && len(value) < length; hops++ {
+				if hops >= lastPage {
+					return nil, errors.New("Berkeley DB overflow chain is too long")
+				}
+				o, err := page(next)
+				if err != nil {
+					return nil, err
+				}
+				if o[25] != bdbPageOverflow {
+					return nil, errors.New("Berkeley DB overflow chain is broken")
+				}
+				// The free-area offset holds the length of data on the page.
+				used := int(order.Uint16(o[22:]))
+				if used == 0 || bdbPageHeaderSize+used > len(o) {
+					return nil, errors.New("Berkeley DB overflow page out of range")
+				}
+				value = append(value, o[bdbPageHeaderSize:bdbPageHeaderSize+used]...)
+				next = int(order.Uint32(o[16:]))
+			}
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// NDB database layout, rpm's native format on SUSE. Blocks are 16 bytes.
+const (
+	ndbHeaderMagic  = 'R' | 'p'<<8 | 'm'<<16 | 'P'<<24
+	ndbSlotMagic    = 'S' | 'l'<<8 | 'o'<<16 | 't'<<24
+	ndbBlobMagic    = 'B' | 'l'<<8 | 'b'<<16 | 'S'<<24
+	ndbPageSize     = 4096
+	ndbHeaderSize   = 32
+	ndbSlotSize     = 16
+	ndbBlockSize    = 16
+	ndbBlobHeadSize = 16
+)
+
+// readNDBBlobs returns the package blobs of an NDB database. The first pages
+// hold a header and a slot per package pointing at its blob.
+func readNDBBlobs(data []byte) ([][]byte, error) {
+	le := binary.LittleEndian
+	if len(data) < ndbHeaderSize || le.Uint32(data) != ndbHeaderMagic {
+		return nil, errors.New("not an NDB database")
+	}
+	slotsEnd := int(le.Uint32(data[12:])) * ndbPageSize
+	if slotsEnd > len(data) {
+		return nil, errors.New("NDB slot pages out of range")
+	}
+
+	var blobs [][]byte
+	for offset := ndbHeaderSize; offset+ndbSlotSize <= slotsEnd; offset += ndbSlotSize {
+		slot := data[offset:]
+		if le.Uint32(slot) != ndbSlotMagic {
+			return nil, errors.New("invalid NDB slot")
+		}
+		index := le.Uint32(slot[4:])
+		if index == 0 {
+			continue
+		}
+		start := int(le.Uint32(slot[8:])) * ndbBlockSize
+		if start+ndbBlobHeadSize > len(data) {
+			return nil, errors.New("NDB blob out of range")
+		}
+		head := data[start:]
+		if le.Uint32(head) != ndbBlobMagic || le.Uint32(head[4:]) != index {
+			return nil, errors.New("invalid NDB blob")
+		}
+		length := int(le.Uint32(head[12:]))
+		if start+ndbBlobHeadSize+length > len(data) {
+			return nil, errors.New("NDB blob out of range")
+		}
+		blobs = append(blobs, data[start+ndbBlobHeadSize:start+ndbBlobHeadSize+length])
+	}
+	return blobs, nil
+}
@@ -0,0 +1,38 @@
+package packages
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeSQLiteRecord_Truncated(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":                  {},
+		"header size zero":       {0x00},
+		"header size below size": {0x80, 0x01},
+		"header past payload":    {0x05, 0x0d},
+		"value past body":        {0x02, 0x11, 'a'},
+		"value size overflow":    {0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+	for name, payload := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.NotPanics(t, func() {
+				_, err := decodeSQLiteRecord(payload)
+				assert.Error(t, err)
+			})
+		})
+	}
+}
+
+func FuzzParseRPM(f *testing.F) {
+	f.Add([]byte("SQLite format 3\x00"))
+	f.Add(make([]byte, 512))
+	f.Add([]byte("RpmP"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, base := range []string{"rpmdb.sqlite", "Packages", "Packages.db"} {
+			_, _ = parseRPM("/var/lib/rpm/"+base, bytes.NewReader(data))
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package packages_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/packages"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rpmPackage struct {
+	name, version, release string
+	epoch                  int
+}
+
+// rpmHeader encodes an rpm header blob with the name, version, release and
+// epoch tags, padded to the given size to exercise overflow pages.
+func rpmHeader(pkg rpmPackage, size int) []byte {
+	var index, store bytes.Buffer
+	entry := func(tag, typ uint32) {
+		_ = binary.Write(&index, binary.BigEndian, []uint32{tag, typ, uint32(store.Len()), 1})
+	}
+	for _, s := range []struct {
+		tag   uint32
+		value string
+	}{{1000, pkg.name}, {1001, pkg.version}, {1002, pkg.release}, {1004, strings.Repeat("x", size)}} {
+		entry(s.tag, 6)
+		store.WriteString(s.value + "\x00")
+	}
+	if pkg.epoch > 0 {
+		for store.Len()%4 != 0 {
+			store.WriteByte(0)
+		}
+		entry(1003, 4)
+		_ = binary.Write(&store, binary.BigEndian, uint32(pkg.epoch))
+	}
+
+	var blob bytes.Buffer
+	_ = binary.Write(&blob, binary.BigEndian, []uint32{uint32(index.Len() / 16), uint32(store.Len())})
+	blob.Write(index.Bytes())
+	blob.Write(store.Bytes())
+	return blob.Bytes()
+}
+
+func sqliteVarint(v uint64) []byte {
+	if v < 0x80 {
+		return []byte{byte(v)}
+	}
+	var out []byte
+	for v > 0 {
+		out = append([]byte{byte(v&0x7f) | 0x80}, out...)
+		v >>= 7
+	}
+	out[len(out)-1] &^= 0x80
+	return out
+}
+
+// sqliteRecord encodes values, which are strings, blobs, ints or nil.
+func sqliteRecord(values ...any) []byte {
+	var header, body []byte
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			header = append(header, 0)
+		case int:
+			header = append(header, 1)
+			body = append(body, byte(v))
+		case string:
+			header = append(header, sqliteVarint(uint64(13+2*len(v)))...)
+			body = append(body, v...)
+		case []byte:
+			header = append(header, sqliteVarint(uint64(12+2*len(v)))...)
+			body = append(body, v...)
+		}
+	}
+	size := len(header) + 1
+	if size >= 0x80 {
+		size++
+	}
+	return append(append(sqliteVarint(uint64(size)), header...), body...)
+}
+
+// newRPMSQLite builds an rpmdb.sqlite with the schema on page 1 and the
+// Packages table on a single leaf page 2. Payloads that do not fit are
+// continued on overflow pages after it.
+func newRPMSQLite(t *testing.T, blobs ...[]byte) []byte {
+	t.Helper()
+
+	const pageSize = 512
+	usable := pageSize
+	pages := [][]byte{make([]byte, pageSize), make([]byte, pageSize)}
+
+	leaf := func(page []byte, hdr int, payloads [][]byte) {
+		end := len(page)
+		binary.BigEndian.PutUint16(page[hdr+3:], uint16(len(payloads)))
+		page[hdr] = 0x0d
+		for i, payload := range payloads {
+			local := len(payload)
+			if maxLocal := usable - 35; local > maxLocal {
+				minLocal := (usable-12)*32/255 - 23
+				local = minLocal + (len(payload)-minLocal)%(usable-4)
+				if local > maxLocal {
+					local = minLocal
+				}
+			}
+			cell := append(sqliteVarint(uint64(len(payload))), sqliteVarint(uint64(i+1))...)
+			cell = append(cell, payload[:local]...)
+			if rest := payload[local:]; len(rest) > 0 {
+				cell = binary.BigEndian.AppendUint32(cell, uint32(len(pages)+1))
+				for len(rest) > 0 {
+					overflow := make([]byte, pageSize)
+					n := copy(overflow[4:], rest)
+					rest = rest[n:]
+					if len(rest) > 0 {
+						binary.BigEndian.PutUint32(overflow, uint32(len(pages)+2))
+					}
+					pages = append(pages, overflow)
+				}
+			}
+			end -= len(cell)
+			require.Greater(t, end, hdr+8+2*len(payloads))
+			copy(page[end:], cell)
+			binary.BigEndian.PutUint16(page[hdr+8+2*i:], uint16(end))
+		}
+	}
+
+	leaf(pages[0], 100, [][]byte{sqliteRecord("table", "Packages", "Packages", 2, "CREATE TABLE Packages (hnum INTEGER PRIMARY KEY, blob BLOB)")})
+	var payloads [][]byte
+	for _, blob := range blobs {
+		payloads = append(payloads, sqliteRecord(nil, blob))
+	}
+	leaf(pages[1], 0, payloads)
+
+	copy(pages[0], "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(pages[0][16:], pageSize)
+	return bytes.Join(pages, nil)
+}
+
+// newRPMBDB builds a little-endian Berkeley DB hash database with one hash
+// page holding every package, each value on its own overflow pages.
+func newRPMBDB(blobs ...[]byte) []byte {
+	const pageSize = 512
+	le := binary.LittleEndian
+	meta, hash := make([]byte, pageSize), make([]byte, pageSize)
+	pages := [][]byte{meta, hash}
+
+	hash[25] = 13
+	le.PutUint16(hash[20:], uint16(2*len(blobs)))
+	end := pageSize
+	for i, blob := range blobs {
+		key := []byte{1, byte(i + 1), 0, 0, 0}
+		value := make([]byte, 12)
+		value[0] = 3
+		le.PutUint32(value[4:], uint32(len(pages)))
+		le.PutUint32(value[8:], uint32(len(blob)))
+		for j, item := range [][]byte{key, value} {
+			end -= len(item)
+			copy(hash[end:], item)
+			le.PutUint16(hash[26+2*(2*i+j):], uint16(end))
+		}
+
+		for rest := blob; len(rest) > 0; {
+			overflow := make([]byte, pageSize)
+			overflow[25] = 7
+			n := copy(overflow[26:], rest)
+			rest = rest[n:]
+			le.PutUint16(overflow[22:], uint16(n))
+			if len(rest) > 0 {
+				le.PutUint32(overflow[16:], uint32(len(pages)+1))
+			}
+			pages = append(pages, overflow)
+		}
+	}
+
+	le.PutUint32(meta[12:], 0x061561)
+	le.PutUint32(meta[20:], pageSize)
+	le.PutUint32(meta[32:], uint32(len(pages)-1))
+	return bytes.Join(pages, nil)
+}
+
+// newRPMNDB builds an NDB Packages.db with one slot page and the blobs
+// after it.
+func newRPMNDB(blobs ...[]byte) []byte {
+	le := binary.LittleEndian
+	slots := make([]byte, 4096)
+	copy(slots, "RpmP")
+	le.PutUint32(slots[12:], 1)
+	for offset := 32; offset < len(slots); offset += 16 {
+		copy(slots[offset:], "Slot")
+	}
+
+	var data []byte
+	for i, blob := range blobs {
+		slot := slots[32+16*i:]
+		le.PutUint32(slot[4:], uint32(i+1))
+		le.PutUint32(slot[8:], uint32((len(slots)+len(data))/16))
+
+		head := make([]byte, 16)
+		copy(head, "BlbS")
+		le.PutUint32(head[4:], uint32(i+1))
+		le.PutUint32(head[12:], uint32(len(blob)))
+		data = append(data, head...)
+		data = append(data, blob...)
+		for len(data)%16 != 0 {
+			data = append(data, 0)
+		}
+	}
+	return append(slots, data...)
+}
+
+func TestDetect_RPM(t *testing.T) {
+	blobs := [][]byte{
+		rpmHeader(rpmPackage{name: "openssl-libs", version: "3.0.7", release: "27.el9", epoch: 1}, 1500),
+		rpmHeader(rpmPackage{name: "bash", version: "5.1.8", release: "9.el9"}, 0),
+		rpmHeader(rpmPackage{name: "gpg-pubkey", version: "fd431d51", release: "4ae0493b"}, 0),
+	}
+
+	for name, db := range map[string][]byte{
+		"var/lib/rpm/rpmdb.sqlite":          newRPMSQLite(t, blobs...),
+		"var/lib/rpm/Packages":              newRPMBDB(blobs...),
+		"usr/lib/sysimage/rpm/Packages.db":  newRPMNDB(blobs...),
+		"usr/lib/sysimage/rpm/rpmdb.sqlite": newRPMSQLite(t, blobs...),
+	} {
+		t.Run(name, func(t *testing.T) {
+			pkgs, err := packages.Detect([]v1.Layer{newLayer(t, map[string][]byte{name: db})}, nil)
+			require.NoError(t, err)
+			require.Len(t, pkgs, 2)
+
+			openssl := find(pkgs, packages.EcosystemRPM, "openssl-libs")
+			require.NotNil(t, openssl)
+			assert.Equal(t, "1:3.0.7-27.el9", openssl.Version)
+			assert.Equal(t, "/"+name, openssl.Path)
+			assert.Equal(t, "5.1.8-9.el9", find(pkgs, packages.EcosystemRPM, "bash").Version)
+		})
+	}
+}
+
+func TestDetect_RPMMalformedHeader(t *testing.T) {
+	db := newRPMNDB(
+		rpmHeader(rpmPackage{name: "bash", version: "5.1.8", release: "9.el9"}, 0),
+		[]byte{0, 0, 0, 9},
+	)
+	layer := newLayer(t, map[string][]byte{"usr/lib/sysimage/rpm/Packages.db": db})
+
+	var warned []string
+	pkgs, err := packages.Detect([]v1.Layer{layer}, &packages.Options{
+		Warn: func(path string, err error) {
+			warned = append(warned, path+": "+err.Error())
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, pkgs, 1)
+	assert.Equal(t, "bash", pkgs[0].Name)
+	require.Len(t, warned, 1)
+	assert.Contains(t, warned[0], "/usr/lib/sysimage/rpm/Packages.db: skipped 1 of 2 rpm headers")
+}
+
+func TestDetect_RPMBDBOverflowLoop(t *testing.T) {
+	db := newRPMBDB(rpmHeader(rpmPackage{name: "bash", version: "5.1.8", release: "9.el9"}, 1500))
+	const pageSize = 512
+	for _, used := range []uint16{0, 100} {
+		// Point the first overflow page back at itself.
+		looped := bytes.Clone(db)
+		overflow := looped[2*pageSize:]
+		binary.LittleEndian.PutUint16(overflow[22:], used)
+		binary.LittleEndian.PutUint32(overflow[16:], 2)
+
+		var warned int
+		pkgs, err := packages.Detect([]v1.Layer{newLayer(t, map[string][]byte{"var/lib/rpm/Packages": looped})}, &packages.Options{
+			Warn: func(string, error) { warned++ },
+		})
+		require.NoError(t, err)
+		assert.Empty(t, pkgs)
+		assert.Equal(t, 1, warned)
+	}
+}
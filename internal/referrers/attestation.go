@@ -0,0 +1,274 @@
+package referrers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// Media types of attestation layers.
+const (
+	DSSEMediaType        types.MediaType = "application/vnd.dsse.envelope.v1+json"
+	InTotoMediaType      types.MediaType = "application/vnd.in-toto+json"
+	sigstoreBundlePrefix                 = "application/vnd.dev.sigstore.bundle"
+	inTotoPayloadType                    = "application/vnd.in-toto+json"
+)
+
+// BuildKit annotations marking attestation manifests in an image index.
+const (
+	dockerReferenceType   = "vnd.docker.reference.type"
+	dockerReferenceDigest = "vnd.docker.reference.digest"
+	attestationManifest   = "attestation-manifest"
+)
+
+// Envelope is a DSSE envelope. See
+// https://github.com/secure-systems-lab/dsse/blob/master/envelope.md.
+type Envelope struct {
+	PayloadType string              `json:"payloadType"`
+	Payload     []byte              `json:"payload"`
+	Signatures  []EnvelopeSignature `json:"signatures"`
+}
+
+type EnvelopeSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   []byte `json:"sig"`
+}
+
+// Statement is an in-toto statement. See
+// https://github.com/in-toto/attestation/blob/main/spec/v1/statement.md.
+type Statement struct {
+	Type          string            `json:"_type"`
+	Subject       []StatementDigest `json:"subject"`
+	PredicateType string            `json:"predicateType"`
+	Predicate     json.RawMessage   `json:"predicate"`
+}
+
+type StatementDigest struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Attestation is a decoded in-toto statement attached to an image.
+type Attestation struct {
+	Source Source
+	// Manifest is the digest of the manifest carrying the attestation.
+	Manifest v1.Hash
+	// Subject is the image digest the attestation was found for.
+	Subject v1.Hash
+	// Envelope is nil for unsigned statements, such as BuildKit's.
+	Envelope  *Envelope
+	Statement *Statement
+}
+
+// Fetch returns the attestations attached to digest under the cosign .att
+// tag and through the referrers API. Only artifacts whose artifact type or
+// media type is that of an attestation are fetched; skipped, if non-nil, is
+// called for every other artifact.
+func Fetch(repo name.Repository, digest v1.Hash, skipped func(Artifact), opts ...remote.Option) ([]Attestation, error) {
+	artifacts, err := List(repo, digest, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var attestations []Attestation
+	for _, artifact := range artifacts {
+		if !isAttestation(artifact) {
+			if skipped != nil {
+				skipped(artifact)
+			}
+			continue
+		}
+		img, err := remote.Image(repo.Digest(artifact.Digest.String()), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch artifact %s: %w", artifact.Digest, err)
+		}
+		found, err := readAttestations(img, artifact.Source, artifact.Digest, digest)
+		if err != nil {
+			return nil, err
+		}
+		attestations = append(attestations, found...)
+	}
+
+	return attestations, nil
+}
+
+// isAttestation reports whether artifact carries in-toto attestations, as a
+// DSSE envelope, a sigstore bundle or a bare statement. Artifacts found
+// under a cosign tag other than .att are signatures or SBOMs.
+func isAttestation(artifact Artifact) bool {
+	if artifact.Source == SourceTag {
+		return strings.HasSuffix(artifact.Tag, ".att")
+	}
+	for _, t := range []string{artifact.ArtifactType, string(artifact.MediaType)} {
+		if isAttestationType(types.MediaType(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAttestationType reports whether mt is the media type of an attestation
+// layer.
+func isAttestationType(mt types.MediaType) bool {
+	return mt == DSSEMediaType || mt == InTotoMediaType || strings.HasPrefix(string(mt), sigstoreBundlePrefix)
+}
+
+// FromIndex returns the BuildKit attestation manifests stored in idx. When
+// subject is non-nil, only attestations for that image are returned.
+func FromIndex(idx v1.ImageIndex, subject *v1.Hash) ([]Attestation, error) {
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index manifest: %w", err)
+	}
+
+	var attestations []Attestation
+	for _, desc := range manifest.Manifests {
+		if desc.Annotations[dockerReferenceType] != attestationManifest {
+			continue
+		}
+		ref, err := v1.NewHash(desc.Annotations[dockerReferenceDigest])
+		if err != nil {
+			continue
+		}
+		if subject != nil && ref != *subject {
+			continue
+		}
+		img, err := idx.Image(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get attestation manifest %s: %w", desc.Digest, err)
+		}
+		found, err := readAttestations(img, SourceIndex, desc.Digest, ref)
+		if err != nil {
+			return nil, err
+		}
+		attestations = append(attestations, found...)
+	}
+
+	return attestations, nil
+}
+
+// maxAttestationSize bounds the size of attestation layers read into
+// memory. SBOMs of large images can be tens of megabytes.
+const maxAttestationSize = 256 << 20
+
+// readAttestations decodes every attestation layer of an artifact manifest.
+// Layers of other media types, such as signature payloads, are skipped.
+func readAttestations(img v1.Image, source Source, manifestDigest, subject v1.Hash) ([]Attestation, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", manifestDigest, err)
+	}
+
+	var attestations []Attestation
+	for _, desc := range manifest.Layers {
+		mt := desc.MediaType
+		if !isAttestationType(mt) {
+			continue
+		}
+
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get layer %s: %w", desc.Digest, err)
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read layer %s: %w", desc.Digest, err)
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, maxAttestationSize))
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read layer %s: %w", desc.Digest, err)
+		}
+
+		envelope, statement, err := Decode(mt, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode layer %s: %w", desc.Digest, err)
+		}
+		attestations = append(attestations, Attestation{
+			Source:    source,
+			Manifest:  manifestDigest,
+			Subject:   subject,
+			Envelope:  envelope,
+			Statement: statement,
+		})
+	}
+
+	return attestations, nil
+}
+
+// Decode parses an attestation layer. DSSE envelopes, sigstore bundles
+// carrying a DSSE envelope and bare in-toto statements are supported. The
+// envelope is nil for bare statements.
+func Decode(mediaType types.MediaType, data []byte) (*Envelope, *Statement, error) {
+	var envelope *Envelope
+
+	switch {
+	case strings.HasPrefix(string(mediaType), sigstoreBundlePrefix):
+		var bundle struct {
+			DSSEEnvelope *Envelope `json:"dsseEnvelope"`
+		}
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			return nil, nil, fmt.Errorf("invalid sigstore bundle: %w", err)
+		}
+		if bundle.DSSEEnvelope == nil {
+			return nil, nil, fmt.Errorf("sigstore bundle does not contain a DSSE envelope")
+		}
+		envelope = bundle.DSSEEnvelope
+	case mediaType == DSSEMediaType:
+		envelope = &Envelope{}
+		if err := json.Unmarshal(data, envelope); err != nil {
+			return nil, nil, fmt.Errorf("invalid DSSE envelope: %w", err)
+		}
+	}
+
+	payload := data
+	if envelope != nil {
+		if envelope.PayloadType != inTotoPayloadType {
+			return nil, nil, fmt.Errorf("unsupported DSSE payload type %q", envelope.PayloadType)
+		}
+		payload = envelope.Payload
+	}
+
+	statement := &Statement{}
+	if err := json.Unmarshal(payload, statement); err != nil {
+		return nil, nil, fmt.Errorf("invalid in-toto statement: %w", err)
+	}
+	if statement.PredicateType == "" {
+		return nil, nil, fmt.Errorf("in-toto statement has no predicate type")
+	}
+
+	return envelope, statement, nil
+}
+
+// predicateAliases maps the short names cosign accepts for --type to
+// predicate type prefixes.
+var predicateAliases = map[string][]string{
+	"slsaprovenance": {"https://slsa.dev/provenance/"},
+	"spdx":           {"https://spdx.dev/Document"},
+	"spdxjson":       {"https://spdx.dev/Document"},
+	"cyclonedx":      {"https://cyclonedx.org/bom"},
+	"vuln":           {"https://cosign.sigstore.dev/attestation/vuln/"},
+	"openvex":        {"https://openvex.dev/ns"},
+	"link":           {"https://in-toto.io/Link/"},
+}
+
+// MatchPredicateType reports whether predicateType matches filter, which is
+// either a full predicate type URI or one of the short names slsaprovenance,
+// spdx, spdxjson, cyclonedx, vuln, openvex and link.
+func MatchPredicateType(filter, predicateType string) bool {
+	if prefixes, ok := predicateAliases[strings.ToLower(filter)]; ok {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(predicateType, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return filter == predicateType
+}
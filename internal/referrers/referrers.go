@@ -0,0 +1,148 @@
+// Package referrers lists artifacts attached to images, such as signatures,
+// SBOMs and provenance, and decodes in-toto attestations.
+package referrers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// Source records how an artifact was discovered.
+type Source string
+
+const (
+	// SourceReferrers is the OCI 1.1 referrers API, or the referrers tag
+	// schema on registries without it.
+	SourceReferrers Source = "referrers"
+	// SourceTag is one of the cosign tags, e.g. sha256-<digest>.att.
+	SourceTag Source = "tag"
+	// SourceIndex is an attestation manifest stored next to the image in
+	// its index, as BuildKit does.
+	SourceIndex Source = "index"
+)
+
+// cosignTagSuffixes are the tags cosign attaches artifacts under, keyed by
+// the artifact type reported for them.
+var cosignTagSuffixes = []struct {
+	suffix       string
+	artifactType string
+}{
+	{".sig", "application/vnd.dev.cosign.artifact.sig.v1+json"},
+	{".att", "application/vnd.dsse.envelope.v1+json"},
+	{".sbom", "application/vnd.dev.cosign.artifact.sbom.v1+json"},
+}
+
+// Artifact is a manifest attached to an image.
+type Artifact struct {
+	Digest       v1.Hash
+	MediaType    types.MediaType
+	ArtifactType string
+	Size         int64
+	Annotations  map[string]string
+	Source       Source
+	// Tag is set for artifacts found under a cosign tag.
+	Tag string
+}
+
+// CosignTag returns the tag cosign stores artifacts of the given suffix
+// (".sig", ".att" or ".sbom") for digest under.
+func CosignTag(repo name.Repository, digest v1.Hash, suffix string) name.Tag {
+	return repo.Tag(fmt.Sprintf("%s-%s%s", digest.Algorithm, digest.Hex, suffix))
+}
+
+// List returns the artifacts attached to digest through the referrers API,
+// followed by those attached under cosign tags. Artifacts found both ways
+// are reported once.
+func List(repo name.Repository, digest v1.Hash, opts ...remote.Option) ([]Artifact, error) {
+	index, err := remote.Referrers(repo.Digest(digest.String()), opts...)
+	if err != nil && !oci.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to fetch referrers: %w", err)
+	}
+
+	var artifacts []Artifact
+	seen := make(map[v1.Hash]bool)
+
+	if index != nil {
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read referrers: %w", err)
+		}
+		for _, desc := range manifest.Manifests {
+			seen[desc.Digest] = true
+			artifact := Artifact{
+				Digest:       desc.Digest,
+				MediaType:    desc.MediaType,
+				ArtifactType: desc.ArtifactType,
+				Size:         desc.Size,
+				Annotations:  desc.Annotations,
+				Source:       SourceReferrers,
+			}
+			// Registries should copy the manifest annotations into the
+			// referrers response, but not all of them do.
+			if artifact.Annotations == nil {
+				if err := fillFromManifest(repo, &artifact, opts); err != nil {
+					return nil, err
+				}
+			}
+			artifacts = append(artifacts, artifact)
+		}
+		sort.SliceStable(artifacts, func(i, j int) bool {
+			return artifacts[i].ArtifactType < artifacts[j].ArtifactType
+		})
+	}
+
+	for _, t := range cosignTagSuffixes {
+		tag := CosignTag(repo, digest, t.suffix)
+		desc, err := remote.Head(tag, opts...)
+		if oci.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", tag.TagStr(), err)
+		}
+		if seen[desc.Digest] {
+			continue
+		}
+		seen[desc.Digest] = true
+		artifacts = append(artifacts, Artifact{
+			Digest:       desc.Digest,
+			MediaType:    desc.MediaType,
+			ArtifactType: t.artifactType,
+			Size:         desc.Size,
+			Source:       SourceTag,
+			Tag:          tag.TagStr(),
+		})
+	}
+
+	return artifacts, nil
+}
+
+// fillFromManifest reads the annotations and artifact type of an artifact
+// from its manifest.
+func fillFromManifest(repo name.Repository, artifact *Artifact, opts []remote.Option) error {
+	desc, err := remote.Get(repo.Digest(artifact.Digest.String()), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch artifact %s: %w", artifact.Digest, err)
+	}
+
+	var manifest struct {
+		ArtifactType string            `json:"artifactType"`
+		Annotations  map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(desc.Manifest, &manifest); err != nil {
+		return fmt.Errorf("failed to parse artifact %s: %w", artifact.Digest, err)
+	}
+
+	artifact.Annotations = manifest.Annotations
+	if manifest.ArtifactType != "" {
+		artifact.ArtifactType = manifest.ArtifactType
+	}
+	return nil
+}
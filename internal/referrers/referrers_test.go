@@ -0,0 +1,213 @@
+package referrers_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/referrers"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const provenance = "https://slsa.dev/provenance/v1"
+
+func statement(predicateType string) string {
+	return fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"app","digest":{"sha256":"abc"}}],"predicateType":%q,"predicate":{"builder":{"id":"ci"}}}`, predicateType)
+}
+
+func envelope(payload string) string {
+	return fmt.Sprintf(`{"payloadType":"application/vnd.in-toto+json","payload":%q,"signatures":[{"keyid":"k1","sig":"c2ln"}]}`,
+		base64.StdEncoding.EncodeToString([]byte(payload)))
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name         string
+		mediaType    types.MediaType
+		data         string
+		wantEnvelope bool
+		wantErr      string
+	}{
+		{"dsse", referrers.DSSEMediaType, envelope(statement(provenance)), true, ""},
+		{"sigstore bundle", "application/vnd.dev.sigstore.bundle.v0.3+json", `{"dsseEnvelope":` + envelope(statement(provenance)) + `}`, true, ""},
+		{"bare statement", referrers.InTotoMediaType, statement(provenance), false, ""},
+		{"bundle without envelope", "application/vnd.dev.sigstore.bundle.v0.3+json", `{"messageSignature":{}}`, false, "does not contain a DSSE envelope"},
+		{"other payload type", referrers.DSSEMediaType, `{"payloadType":"text/plain","payload":"aGk="}`, false, "unsupported DSSE payload type"},
+		{"no predicate type", referrers.InTotoMediaType, `{"_type":"https://in-toto.io/Statement/v1"}`, false, "no predicate type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, stmt, err := referrers.Decode(tt.mediaType, []byte(tt.data))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEnvelope, env != nil)
+			assert.Equal(t, provenance, stmt.PredicateType)
+			require.Len(t, stmt.Subject, 1)
+			assert.Equal(t, "abc", stmt.Subject[0].Digest["sha256"])
+			assert.JSONEq(t, `{"builder":{"id":"ci"}}`, string(stmt.Predicate))
+			if env != nil {
+				require.Len(t, env.Signatures, 1)
+				assert.Equal(t, "k1", env.Signatures[0].KeyID)
+			}
+		})
+	}
+}
+
+func TestMatchPredicateType(t *testing.T) {
+	tests := []struct {
+		filter        string
+		predicateType string
+		want          bool
+	}{
+		{"slsaprovenance", "https://slsa.dev/provenance/v0.2", true},
+		{"SLSAProvenance", "https://slsa.dev/provenance/v1", true},
+		{"spdx", "https://spdx.dev/Document", true},
+		{"cyclonedx", "https://spdx.dev/Document", false},
+		{"https://spdx.dev/Document", "https://spdx.dev/Document", true},
+		{"https://spdx.dev", "https://spdx.dev/Document", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, referrers.MatchPredicateType(tt.filter, tt.predicateType), "%s vs %s", tt.filter, tt.predicateType)
+	}
+}
+
+func newRegistry(t *testing.T) string {
+	t.Helper()
+
+	srv := httptest.NewServer(registry.New(
+		registry.Logger(log.New(io.Discard, "", 0)),
+		registry.WithReferrersSupport(true),
+	))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func artifactImage(t *testing.T, mediaType types.MediaType, content string) v1.Image {
+	t.Helper()
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer([]byte(content), mediaType),
+	})
+	require.NoError(t, err)
+	return img
+}
+
+func TestListAndFetch(t *testing.T) {
+	host := newRegistry(t)
+
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(host + "/app:1.0")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	repo := ref.Context()
+
+	// An SBOM attached through the referrers API.
+	sbom := mutate.ConfigMediaType(artifactImage(t, referrers.InTotoMediaType, statement("https://spdx.dev/Document")), "application/vnd.in-toto+json")
+	sbom = mutate.Annotations(sbom, map[string]string{"org.opencontainers.image.created": "2024-01-01T00:00:00Z"}).(v1.Image)
+	sbom = mutate.Subject(sbom, v1.Descriptor{MediaType: types.OCIManifestSchema1, Digest: digest, Size: 1}).(v1.Image)
+	sbomDigest, err := sbom.Digest()
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(repo.Digest(sbomDigest.String()), sbom))
+
+	// Provenance attached under the cosign .att tag.
+	att := artifactImage(t, referrers.DSSEMediaType, envelope(statement(provenance)))
+	require.NoError(t, remote.Write(referrers.CosignTag(repo, digest, ".att"), att))
+
+	// A report of an unknown type, which Fetch must not pull.
+	report := mutate.ConfigMediaType(artifactImage(t, "text/plain", "ok"), "application/vnd.example.report")
+	report = mutate.Subject(report, v1.Descriptor{MediaType: types.OCIManifestSchema1, Digest: digest, Size: 1}).(v1.Image)
+	reportDigest, err := report.Digest()
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(repo.Digest(reportDigest.String()), report))
+
+	artifacts, err := referrers.List(repo, digest)
+	require.NoError(t, err)
+	require.Len(t, artifacts, 3)
+
+	assert.Equal(t, referrers.SourceReferrers, artifacts[0].Source)
+	assert.Equal(t, reportDigest, artifacts[0].Digest)
+	assert.Equal(t, referrers.SourceReferrers, artifacts[1].Source)
+	assert.Equal(t, sbomDigest, artifacts[1].Digest)
+	assert.Equal(t, "application/vnd.in-toto+json", artifacts[1].ArtifactType)
+	assert.Equal(t, "2024-01-01T00:00:00Z", artifacts[1].Annotations["org.opencontainers.image.created"])
+
+	assert.Equal(t, referrers.SourceTag, artifacts[2].Source)
+	assert.True(t, strings.HasSuffix(artifacts[2].Tag, ".att"))
+
+	var skipped []v1.Hash
+	attestations, err := referrers.Fetch(repo, digest, func(a referrers.Artifact) {
+		skipped = append(skipped, a.Digest)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []v1.Hash{reportDigest}, skipped)
+	require.Len(t, attestations, 2)
+	assert.Equal(t, "https://spdx.dev/Document", attestations[0].Statement.PredicateType)
+	assert.Nil(t, attestations[0].Envelope)
+	assert.Equal(t, provenance, attestations[1].Statement.PredicateType)
+	assert.NotNil(t, attestations[1].Envelope)
+	assert.Equal(t, digest, attestations[1].Subject)
+}
+
+func TestFromIndex(t *testing.T) {
+	amd64, err := random.Image(64, 1)
+	require.NoError(t, err)
+	arm64, err := random.Image(64, 1)
+	require.NoError(t, err)
+	amd64Digest, err := amd64.Digest()
+	require.NoError(t, err)
+	arm64Digest, err := arm64.Digest()
+	require.NoError(t, err)
+
+	attestationFor := func(d v1.Hash) mutate.IndexAddendum {
+		return mutate.IndexAddendum{
+			Add: artifactImage(t, referrers.InTotoMediaType, statement(provenance)),
+			Descriptor: v1.Descriptor{
+				Platform: &v1.Platform{OS: "unknown", Architecture: "unknown"},
+				Annotations: map[string]string{
+					"vnd.docker.reference.type":   "attestation-manifest",
+					"vnd.docker.reference.digest": d.String(),
+				},
+			},
+		}
+	}
+
+	idx := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: amd64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+		mutate.IndexAddendum{Add: arm64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+		attestationFor(amd64Digest),
+		attestationFor(arm64Digest),
+	)
+
+	all, err := referrers.FromIndex(idx, nil)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	only, err := referrers.FromIndex(idx, &arm64Digest)
+	require.NoError(t, err)
+	require.Len(t, only, 1)
+	assert.Equal(t, arm64Digest, only[0].Subject)
+	assert.Equal(t, referrers.SourceIndex, only[0].Source)
+}
@@ -0,0 +1,168 @@
+// Package secrets scans image layers and configuration for leaked
+// credentials, private keys and sensitive files.
+package secrets
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Scope controls which parts of an image a rule is applied to.
+type Scope int
+
+const (
+	// ScopeAll applies the rule to file contents and configuration strings.
+	ScopeAll Scope = iota
+	// ScopeFiles applies the rule to file contents only.
+	ScopeFiles
+	// ScopeConfig applies the rule to config Env and history only.
+	ScopeConfig
+)
+
+// Rule describes a single kind of secret.
+type Rule struct {
+	ID          string
+	Description string
+	Scope       Scope
+	// Path, when set, restricts the rule to files whose path matches. A
+	// rule with a Path but no Pattern flags the file itself.
+	Path *regexp.Regexp
+	// Pattern is matched line by line. When the pattern has a capture
+	// group, the first group is reported as the secret, otherwise the whole
+	// match is.
+	Pattern *regexp.Regexp
+}
+
+// DefaultRules is the built-in rule set.
+var DefaultRules = []Rule{
+	{
+		ID:          "private-key",
+		Description: "Private key",
+		Pattern:     regexp.MustCompile(`-----BEGIN[ A-Z0-9]*PRIVATE KEY( BLOCK)?-----`),
+	},
+	{
+		ID:          "aws-access-key-id",
+		Description: "AWS access key ID",
+		Pattern:     regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[A-Z2-7]{16})\b`),
+	},
+	{
+		ID:          "aws-secret-access-key",
+		Description: "AWS secret access key",
+		Pattern:     regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`),
+	},
+	{
+		ID:          "gcp-service-account",
+		Description: "Google Cloud service account key",
+		Pattern:     regexp.MustCompile(`"type"\s*:\s*"service_account"`),
+	},
+	{
+		ID:          "azure-storage-key",
+		Description: "Azure storage account key",
+		Pattern:     regexp.MustCompile(`AccountKey=([A-Za-z0-9+/]{86}==)`),
+	},
+	{
+		ID:          "github-token",
+		Description: "GitHub token",
+		Pattern:     regexp.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`),
+	},
+	{
+		ID:          "gitlab-token",
+		Description: "GitLab personal access token",
+		Pattern:     regexp.MustCompile(`\b(glpat-[A-Za-z0-9_-]{20})\b`),
+	},
+	{
+		ID:          "slack-token",
+		Description: "Slack token",
+		Pattern:     regexp.MustCompile(`\b(xox[baprs]-[A-Za-z0-9-]{10,})\b`),
+	},
+	{
+		ID:          "stripe-key",
+		Description: "Stripe secret key",
+		Pattern:     regexp.MustCompile(`\b((?:sk|rk)_live_[A-Za-z0-9]{24,})\b`),
+	},
+	{
+		ID:          "npm-token",
+		Description: "npm access token",
+		Pattern:     regexp.MustCompile(`\b(npm_[A-Za-z0-9]{36})\b`),
+	},
+	{
+		ID:          "npmrc-auth",
+		Description: "Registry credentials in .npmrc",
+		Path:        regexp.MustCompile(`(^|/)\.npmrc$`),
+		Pattern:     regexp.MustCompile(`_(?:authToken|auth|password)\s*=\s*(\S+)`),
+	},
+	{
+		ID:          "docker-config-auth",
+		Description: "Registry credentials in Docker config.json",
+		Path:        regexp.MustCompile(`(^|/)\.docker/config\.json$`),
+		Pattern:     regexp.MustCompile(`"(?:auth|identitytoken)"\s*:\s*"([^"]+)"`),
+	},
+	{
+		ID:          "dotenv-file",
+		Description: "Environment file",
+		Scope:       ScopeFiles,
+		Path:        regexp.MustCompile(`(^|/)\.env(\.[A-Za-z0-9_-]+)?$`),
+	},
+	{
+		ID:          "credentials-file",
+		Description: "Credentials file",
+		Scope:       ScopeFiles,
+		Path:        regexp.MustCompile(`(^|/)(\.git-credentials|\.netrc|\.pypirc|\.pgpass|\.aws/credentials)$`),
+	},
+	{
+		ID:          "ssh-private-key-file",
+		Description: "SSH private key file",
+		Scope:       ScopeFiles,
+		Path:        regexp.MustCompile(`(^|/)id_(rsa|dsa|ecdsa|ed25519)$`),
+	},
+	{
+		ID:          "secret-assignment",
+		Description: "Secret assigned to a variable",
+		Scope:       ScopeConfig,
+		Pattern:     regexp.MustCompile(`(?i)\b[A-Z0-9_]*(?:PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|ACCESS_?KEY)[A-Z0-9_]*\s*=\s*["']?([^\s"'$]{6,})`),
+	},
+}
+
+// ParseRule parses a user supplied rule of the form "id=regex". User rules
+// apply to file contents as well as config Env and history.
+func ParseRule(s string) (Rule, error) {
+	id, expr, ok := strings.Cut(s, "=")
+	id = strings.TrimSpace(id)
+	if !ok || id == "" || expr == "" {
+		return Rule{}, fmt.Errorf("invalid rule %q: expected id=regex", s)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid rule %q: %w", id, err)
+	}
+
+	return Rule{
+		ID:          id,
+		Description: "Custom rule " + id,
+		Pattern:     re,
+	}, nil
+}
+
+// match returns the secret matched by the rule in line, if any.
+func (r *Rule) match(line string) (string, bool) {
+	m := r.Pattern.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	if len(m) > 1 && m[1] != "" {
+		return m[1], true
+	}
+	return m[0], true
+}
+
+// Redact masks all but the first few characters of a secret so findings can
+// be shared without leaking it again.
+func Redact(s string) string {
+	const visible = 4
+	if len(s) <= visible*2 {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:visible] + strings.Repeat("*", min(len(s)-visible, 16))
+}
@@ -0,0 +1,226 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Source identifies where in the image a finding was made.
+type Source string
+
+const (
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceHistory Source = "history"
+)
+
+// Finding is a single secret found in an image.
+type Finding struct {
+	RuleID      string
+	Description string
+	Source      Source
+	// Layer is the 1-indexed layer containing the file or created by the
+	// history step. It is 0 for config Env and for history steps that did
+	// not produce a layer.
+	Layer int
+	// Path is the file path for file findings, the variable name for Env
+	// findings and "history[n]" for history findings.
+	Path string
+	Line int
+	// Secret is the redacted matched value.
+	Secret string
+	// Removed reports whether the file is no longer visible in the merged
+	// filesystem because a later layer deleted or replaced it. The secret
+	// is still present in the image.
+	Removed bool
+}
+
+// maxScanSize bounds the size of files whose contents are scanned.
+const maxScanSize = 8 << 20
+
+// binarySniffLen is how many leading bytes are checked for NUL bytes to
+// decide whether a file is binary.
+const binarySniffLen = 8000
+
+// Scanner applies a rule set to image layers and configuration.
+type Scanner struct {
+	rules []Rule
+}
+
+func NewScanner(rules []Rule) *Scanner {
+	return &Scanner{rules: rules}
+}
+
+// ScanImage scans every layer individually, so files deleted by whiteouts
+// in later layers are still found, followed by the config Env and history.
+func (s *Scanner) ScanImage(img v1.Image) ([]Finding, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layers: %w", err)
+	}
+
+	// Record which layer provides each visible path so findings in files
+	// that were later deleted or overwritten can be flagged.
+	visible := make(map[string]int)
+	err = oci.WalkMerged(layers, func(f *oci.FileEntry) error {
+		visible[f.Path] = f.Layer
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk merged filesystem: %w", err)
+	}
+
+	var findings []Finding
+	for i, layer := range layers {
+		err := oci.WalkLayer(layer, i+1, func(f *oci.FileEntry) error {
+			found, err := s.scanFile(f)
+			if err != nil {
+				return err
+			}
+			for _, finding := range found {
+				finding.Removed = visible[f.Path] != f.Layer
+				findings = append(findings, finding)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan layer %d: %w", i+1, err)
+		}
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config file: %w", err)
+	}
+	findings = append(findings, s.ScanConfig(cfg)...)
+
+	return findings, nil
+}
+
+func (s *Scanner) scanFile(f *oci.FileEntry) ([]Finding, error) {
+	if !f.IsRegular() {
+		return nil, nil
+	}
+
+	var findings []Finding
+	var contentRules []*Rule
+	for i := range s.rules {
+		rule := &s.rules[i]
+		if rule.Scope == ScopeConfig {
+			continue
+		}
+		if rule.Path != nil && !rule.Path.MatchString(f.Path) {
+			continue
+		}
+		if rule.Pattern == nil {
+			findings = append(findings, Finding{
+				RuleID:      rule.ID,
+				Description: rule.Description,
+				Source:      SourceFile,
+				Layer:       f.Layer,
+				Path:        f.Path,
+			})
+			continue
+		}
+		contentRules = append(contentRules, rule)
+	}
+
+	if len(contentRules) == 0 || f.Header.Size == 0 || f.Header.Size > maxScanSize {
+		return findings, nil
+	}
+
+	data, err := io.ReadAll(f.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Path, err)
+	}
+	if bytes.IndexByte(data[:min(len(data), binarySniffLen)], 0) >= 0 {
+		return findings, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxScanSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		for _, rule := range contentRules {
+			secret, ok := rule.match(text)
+			if !ok {
+				continue
+			}
+			findings = append(findings, Finding{
+				RuleID:      rule.ID,
+				Description: rule.Description,
+				Source:      SourceFile,
+				Layer:       f.Layer,
+				Path:        f.Path,
+				Line:        line,
+				Secret:      Redact(secret),
+			})
+		}
+	}
+	// Overlong lines are not worth failing the whole scan over.
+	if err := scanner.Err(); err != nil && err != bufio.ErrTooLong {
+		return nil, err
+	}
+
+	return findings, nil
+}
+
+// ScanConfig scans the config Env and history created_by strings.
+func (s *Scanner) ScanConfig(cfg *v1.ConfigFile) []Finding {
+	var findings []Finding
+
+	for _, env := range cfg.Config.Env {
+		name, _, _ := strings.Cut(env, "=")
+		for _, f := range s.scanString(env) {
+			f.Source = SourceEnv
+			f.Path = name
+			findings = append(findings, f)
+		}
+	}
+
+	// History entries without empty_layer each produced the next layer.
+	layer := 0
+	for i, h := range cfg.History {
+		historyLayer := 0
+		if !h.EmptyLayer {
+			layer++
+			historyLayer = layer
+		}
+		for _, f := range s.scanString(h.CreatedBy) {
+			f.Source = SourceHistory
+			f.Path = fmt.Sprintf("history[%d]", i)
+			f.Layer = historyLayer
+			findings = append(findings, f)
+		}
+	}
+
+	return findings
+}
+
+func (s *Scanner) scanString(text string) []Finding {
+	var findings []Finding
+	for i := range s.rules {
+		rule := &s.rules[i]
+		if rule.Scope == ScopeFiles || rule.Path != nil || rule.Pattern == nil {
+			continue
+		}
+		secret, ok := rule.match(text)
+		if !ok {
+			continue
+		}
+		findings = append(findings, Finding{
+			RuleID:      rule.ID,
+			Description: rule.Description,
+			Secret:      Redact(secret),
+		})
+	}
+	return findings
+}
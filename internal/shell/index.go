@@ -0,0 +1,358 @@
+// Package shell implements the interactive prompt behind cek shell. The
+// image is read once into an in-memory index of every layer's entries, so
+// that listing, finding and switching layers never read the layers again;
+// only file contents are read from the layers, on demand.
+package shell
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/bschaatsbergen/cek/pkg/imagefs"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Entry is a file, directory or link in the image filesystem.
+type Entry struct {
+	Path   string
+	Header *tar.Header
+	// Layer is the 1-indexed layer the entry comes from, 0 for directories
+	// only implied by their contents.
+	Layer int
+}
+
+// IsDir reports whether the entry is a directory.
+func (e *Entry) IsDir() bool {
+	return e.Header.Typeflag == tar.TypeDir
+}
+
+// IsSymlink reports whether the entry is a symbolic link.
+func (e *Entry) IsSymlink() bool {
+	return e.Header.Typeflag == tar.TypeSymlink
+}
+
+// IsRegular reports whether the entry is a regular file or a hard link to
+// one.
+func (e *Entry) IsRegular() bool {
+	switch e.Header.Typeflag {
+	case tar.TypeReg, tar.TypeRegA, tar.TypeLink:
+		return true
+	}
+	return false
+}
+
+// Layer is a layer of the image and the entries it adds and deletes.
+type Layer struct {
+	Index  int
+	Digest v1.Hash
+	Size   int64
+	// CreatedBy is the instruction that created the layer, from the image
+	// history.
+	CreatedBy string
+	// Files is the number of entries in the layer, whiteouts excluded.
+	Files int
+
+	layer   v1.Layer
+	changes *imagefs.Layer
+}
+
+// Index is the entries of every layer of an image.
+type Index struct {
+	Ref    string
+	Layers []*Layer
+}
+
+// Load reads the entries of every layer of img.
+func Load(ref string, img v1.Image) (*Index, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layers: %w", err)
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config file: %w", err)
+	}
+
+	// History has entries for instructions that created no layer too.
+	var createdBy []string
+	for _, h := range config.History {
+		if !h.EmptyLayer {
+			createdBy = append(createdBy, h.CreatedBy)
+		}
+	}
+
+	index := &Index{Ref: ref}
+	for i, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get layer digest: %w", err)
+		}
+		size, err := layer.Size()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get layer size: %w", err)
+		}
+
+		changes, err := imagefs.ReadLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read layer %d: %w", i+1, err)
+		}
+
+		l := &Layer{Index: i + 1, Digest: digest, Size: size, Files: changes.Len(), layer: layer, changes: changes}
+		if i < len(createdBy) {
+			l.CreatedBy = createdBy[i]
+		}
+		index.Layers = append(index.Layers, l)
+	}
+
+	return index, nil
+}
+
+// FS returns the filesystem a container would see if the image ended at
+// the 1-indexed layer.
+func (x *Index) FS(layer int) *FS {
+	changes := make([]*imagefs.Layer, layer)
+	for i, l := range x.Layers[:layer] {
+		changes[i] = l.changes
+	}
+	return &FS{Layer: layer, fsys: imagefs.Stack(changes...)}
+}
+
+// ReadFiles calls fn with the contents of every regular file in entries.
+// Each layer holding one of the files is read once. Hard links are read
+// from the file they link to, which must be in the same layer.
+func (x *Index) ReadFiles(entries []*Entry, fn func(e *Entry, r io.Reader) error) error {
+	byLayer := make(map[int]map[string][]*Entry)
+	for _, e := range entries {
+		if !e.IsRegular() || e.Layer < 1 {
+			continue
+		}
+		p := e.Path
+		if e.Header.Typeflag == tar.TypeLink {
+			p = oci.CleanPath(e.Header.Linkname)
+		}
+		if byLayer[e.Layer] == nil {
+			byLayer[e.Layer] = make(map[string][]*Entry)
+		}
+		byLayer[e.Layer][p] = append(byLayer[e.Layer][p], e)
+	}
+
+	layers := make([]int, 0, len(byLayer))
+	for l := range byLayer {
+		layers = append(layers, l)
+	}
+	sort.Ints(layers)
+
+	for _, l := range layers {
+		wanted := byLayer[l]
+		err := oci.WalkLayer(x.Layers[l-1].layer, l, func(f *oci.FileEntry) error {
+			if !f.IsRegular() {
+				return nil
+			}
+			targets, ok := wanted[f.Path]
+			if !ok {
+				return nil
+			}
+			delete(wanted, f.Path)
+			if len(targets) == 1 {
+				if err := fn(targets[0], f.Reader); err != nil {
+					return err
+				}
+			} else {
+				// Several hard links to the same file share its contents.
+				data, err := io.ReadAll(f.Reader)
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", f.Path, err)
+				}
+				for _, e := range targets {
+					if err := fn(e, bytes.NewReader(data)); err != nil {
+						return err
+					}
+				}
+			}
+			if len(wanted) == 0 {
+				return fs.SkipAll
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FS is the merged filesystem of the layers up to some layer. Its methods
+// take absolute paths, relative to the root of the image.
+type FS struct {
+	// Layer is the 1-indexed layer the filesystem ends at.
+	Layer int
+	fsys  *imagefs.FS
+}
+
+// name returns the io/fs name of the absolute path p.
+func name(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// pathError reports err, from the io/fs filesystem, under the absolute path
+// p the caller gave.
+func pathError(err error, p string) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return &fs.PathError{Op: pathErr.Op, Path: p, Err: pathErr.Err}
+	}
+	return err
+}
+
+func newEntry(p string, info fs.FileInfo) *Entry {
+	hdr, _ := info.Sys().(*tar.Header)
+	return &Entry{Path: p, Header: hdr, Layer: imagefs.LayerIndex(info) + 1}
+}
+
+// Lstat returns the entry at p without following a final symbolic link.
+func (f *FS) Lstat(p string) (*Entry, error) {
+	n := name(p)
+	info, err := f.fsys.Lstat(n)
+	if err != nil {
+		return nil, pathError(err, p)
+	}
+	if n == "." {
+		return newEntry("/", info), nil
+	}
+	dir, err := f.fsys.Realpath(path.Dir(n))
+	if err != nil {
+		return nil, pathError(err, p)
+	}
+	return newEntry(path.Join("/", dir, path.Base(n)), info), nil
+}
+
+// Stat returns the entry at p, following symbolic links.
+func (f *FS) Stat(p string) (*Entry, error) {
+	resolved, err := f.Resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.fsys.Stat(name(resolved))
+	if err != nil {
+		return nil, pathError(err, p)
+	}
+	return newEntry(resolved, info), nil
+}
+
+// Resolve returns p with every symbolic link in it resolved.
+func (f *FS) Resolve(p string) (string, error) {
+	resolved, err := f.fsys.Realpath(name(p))
+	if err != nil {
+		return "", pathError(err, p)
+	}
+	return path.Join("/", resolved), nil
+}
+
+// ReadDir returns the entries of the directory at p sorted by name,
+// following symbolic links.
+func (f *FS) ReadDir(p string) ([]*Entry, error) {
+	dir, err := f.Resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	dirEntries, err := f.fsys.ReadDir(name(dir))
+	if err != nil {
+		return nil, pathError(err, p)
+	}
+	entries := make([]*Entry, len(dirEntries))
+	for i, d := range dirEntries {
+		info, err := d.Info()
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = newEntry(path.Join(dir, d.Name()), info)
+	}
+	return entries, nil
+}
+
+// Walk calls fn for p and every entry below it in path order, without
+// following symbolic links below p.
+func (f *FS) Walk(p string, fn func(e *Entry) error) error {
+	root, err := f.Resolve(p)
+	if err != nil {
+		return err
+	}
+	return fs.WalkDir(f.fsys, name(root), func(n string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(newEntry(path.Join("/", n), info))
+	})
+}
+
+// Open returns the regular file at p, following symbolic links, for
+// ReadFiles.
+func (f *FS) Open(p string) (*Entry, error) {
+	e, err := f.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if e.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: p, Err: errors.New("is a directory")}
+	}
+	if !e.IsRegular() {
+		return nil, &fs.PathError{Op: "open", Path: p, Err: errors.New("not a regular file")}
+	}
+	return e, nil
+}
+
+// Version is the state of a path after a layer changed it.
+type Version struct {
+	Layer int
+	// Change is "added", "modified" or "deleted".
+	Change string
+	// Entry is the path's entry after the change, nil when deleted.
+	Entry *Entry
+	// Previous is the path's entry before the change, nil when added.
+	Previous *Entry
+}
+
+// History returns the layers that added, modified or deleted the path p,
+// oldest first. Directories repeated in a layer to hold new entries are not
+// changes.
+func (x *Index) History(p string) []Version {
+	p = path.Clean("/" + p)
+	var versions []Version
+	var before *Entry
+	for _, l := range x.Layers {
+		after, _ := x.FS(l.Index).Lstat(p)
+
+		v := Version{Layer: l.Index, Entry: after, Previous: before}
+		before = after
+		switch {
+		case v.Previous == nil && after == nil:
+			continue
+		case v.Previous == nil:
+			v.Change = "added"
+		case after == nil:
+			v.Change = "deleted"
+		case v.Previous.Header == after.Header || (v.Previous.IsDir() && after.IsDir()):
+			continue
+		default:
+			v.Change = "modified"
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
@@ -0,0 +1,705 @@
+package shell
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// ErrExit is returned by Exec for the exit command.
+var ErrExit = errors.New("exit")
+
+// diffLimit is the largest file diff compares.
+const diffLimit = 1 << 20
+
+// Commands are the names of the shell commands, for completion.
+var Commands = []string{"cat", "cd", "diff", "exit", "find", "grep", "help", "layer", "ls", "pwd", "stat"}
+
+const help = `Commands:
+  cd [dir]                          change directory, / by default
+  pwd                               print the current directory
+  ls [-l] [path...]                 list directories
+  cat <file>...                     print files
+  stat <path>...                    show entry details
+  find [path] [-name glob] [-type f|d|l]
+                                    list entries below a path
+  grep [-i] [-l] <regexp> [path...] search file contents
+  layer [n]                         list layers, or view the image as of layer n
+  diff <path>                       show the layers that changed a path, and
+                                    how its current version differs from the
+                                    one before
+  help                              show this help
+  exit                              leave the shell
+
+Paths are relative to the current directory. Tab completes commands and paths.
+`
+
+// Shell runs commands against the filesystem of an image as of one of its
+// layers.
+type Shell struct {
+	index *Index
+	fs    *FS
+	cwd   string
+	out   io.Writer
+}
+
+// New returns a shell viewing the top layer of the image in index, writing
+// command output to out.
+func New(index *Index, out io.Writer) *Shell {
+	return &Shell{
+		index: index,
+		fs:    index.FS(len(index.Layers)),
+		cwd:   "/",
+		out:   out,
+	}
+}
+
+// Prompt returns the prompt for the next command.
+func (s *Shell) Prompt() string {
+	if s.fs.Layer < len(s.index.Layers) {
+		return fmt.Sprintf("%s [layer %d/%d]:%s$ ", s.index.Ref, s.fs.Layer, len(s.index.Layers), s.cwd)
+	}
+	return fmt.Sprintf("%s:%s$ ", s.index.Ref, s.cwd)
+}
+
+// Exec runs a command line. It returns ErrExit when the user leaves the
+// shell.
+func (s *Shell) Exec(line string) error {
+	args, err := splitArgs(line)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	name, args := args[0], args[1:]
+	switch name {
+	case "cd":
+		return s.cd(args)
+	case "pwd":
+		_, err := fmt.Fprintln(s.out, s.cwd)
+		return err
+	case "ls":
+		return s.ls(args)
+	case "cat":
+		return s.cat(args)
+	case "stat":
+		return s.stat(args)
+	case "find":
+		return s.find(args)
+	case "grep":
+		return s.grep(args)
+	case "layer":
+		return s.layer(args)
+	case "diff":
+		return s.diff(args)
+	case "help", "?":
+		_, err := io.WriteString(s.out, help)
+		return err
+	case "exit", "quit":
+		return ErrExit
+	}
+	return fmt.Errorf("%s: command not found, type help for a list of commands", name)
+}
+
+// abs returns p as an absolute path relative to the current directory.
+func (s *Shell) abs(p string) string {
+	if path.IsAbs(p) {
+		return path.Clean(p)
+	}
+	return path.Join(s.cwd, p)
+}
+
+func (s *Shell) cd(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: cd [dir]")
+	}
+	dir := "/"
+	if len(args) == 1 {
+		dir = s.abs(args[0])
+	}
+	e, err := s.fs.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !e.IsDir() {
+		return &fs.PathError{Op: "cd", Path: dir, Err: errors.New("not a directory")}
+	}
+	s.cwd, err = s.fs.Resolve(dir)
+	return err
+}
+
+func (s *Shell) ls(args []string) error {
+	long := false
+	var paths []string
+	for _, arg := range args {
+		switch {
+		case arg == "-l":
+			long = true
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			return fmt.Errorf("ls: unknown flag %s", arg)
+		default:
+			paths = append(paths, arg)
+		}
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	var errs []error
+	for i, p := range paths {
+		target := s.abs(p)
+		e, err := s.fs.Stat(target)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entries := []*Entry{e}
+		if e.IsDir() {
+			if entries, err = s.fs.ReadDir(target); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if len(paths) > 1 {
+				if i > 0 {
+					_, _ = fmt.Fprintln(w)
+				}
+				_, _ = fmt.Fprintf(w, "%s:\n", p)
+			}
+		} else if link, err := s.fs.Lstat(target); err == nil {
+			// Show a link given by name as the link.
+			entries = []*Entry{link}
+		}
+		for _, entry := range entries {
+			name := path.Base(entry.Path)
+			if !e.IsDir() {
+				name = p
+			}
+			if !long {
+				_, _ = fmt.Fprintln(w, displayName(entry, name))
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				modeString(entry), owner(entry), oci.FormatBytes(entry.Header.Size), layerName(entry), displayName(entry, name))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// displayName decorates name with a slash for directories and the target
+// of links.
+func displayName(e *Entry, name string) string {
+	switch {
+	case e.IsDir():
+		return strings.TrimSuffix(name, "/") + "/"
+	case e.IsSymlink():
+		return name + " -> " + e.Header.Linkname
+	case e.Header.Typeflag == tar.TypeLink:
+		return name + " => /" + strings.TrimPrefix(e.Header.Linkname, "/")
+	}
+	return name
+}
+
+// modeString formats the type and permissions of an entry like ls -l.
+func modeString(e *Entry) string {
+	mode := e.Header.FileInfo().Mode()
+	kind := "-"
+	switch {
+	case mode&fs.ModeDir != 0:
+		kind = "d"
+	case mode&fs.ModeSymlink != 0:
+		kind = "l"
+	case mode&fs.ModeCharDevice != 0:
+		kind = "c"
+	case mode&fs.ModeDevice != 0:
+		kind = "b"
+	case mode&fs.ModeNamedPipe != 0:
+		kind = "p"
+	case mode&fs.ModeSocket != 0:
+		kind = "s"
+	}
+	return kind + mode.Perm().String()[1:]
+}
+
+func owner(e *Entry) string {
+	user, group := e.Header.Uname, e.Header.Gname
+	if user == "" {
+		user = strconv.Itoa(e.Header.Uid)
+	}
+	if group == "" {
+		group = strconv.Itoa(e.Header.Gid)
+	}
+	return user + ":" + group
+}
+
+func layerName(e *Entry) string {
+	if e.Layer == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("layer %d", e.Layer)
+}
+
+func (s *Shell) cat(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: cat <file>...")
+	}
+	for _, arg := range args {
+		e, err := s.fs.Open(s.abs(arg))
+		if err != nil {
+			return err
+		}
+		err = s.index.ReadFiles([]*Entry{e}, func(_ *Entry, r io.Reader) error {
+			_, err := io.Copy(s.out, r)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Shell) stat(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: stat <path>...")
+	}
+	w := tabwriter.NewWriter(s.out, 0, 0, 1, ' ', 0)
+	for i, arg := range args {
+		e, err := s.fs.Lstat(s.abs(arg))
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		h := e.Header
+		_, _ = fmt.Fprintf(w, "Path:\t%s\n", displayName(e, e.Path))
+		_, _ = fmt.Fprintf(w, "Type:\t%s\n", kindName(e))
+		_, _ = fmt.Fprintf(w, "Size:\t%d (%s)\n", h.Size, oci.FormatBytes(h.Size))
+		_, _ = fmt.Fprintf(w, "Mode:\t%s (%04o)\n", modeString(e), h.Mode&0o7777)
+		_, _ = fmt.Fprintf(w, "Owner:\t%s (%d:%d)\n", owner(e), h.Uid, h.Gid)
+		if !h.ModTime.IsZero() {
+			_, _ = fmt.Fprintf(w, "Modified:\t%s\n", h.ModTime.UTC().Format(time.RFC3339))
+		}
+		if e.Layer == 0 {
+			_, _ = fmt.Fprintf(w, "Layer:\t-, implied by its contents\n")
+		} else {
+			_, _ = fmt.Fprintf(w, "Layer:\t%d\t%s\n", e.Layer, createdBy(s.index.Layers[e.Layer-1]))
+		}
+	}
+	return w.Flush()
+}
+
+func kindName(e *Entry) string {
+	switch e.Header.Typeflag {
+	case tar.TypeReg, tar.TypeRegA:
+		return "regular file"
+	case tar.TypeLink:
+		return "hard link"
+	case tar.TypeSymlink:
+		return "symbolic link"
+	case tar.TypeChar:
+		return "character device"
+	case tar.TypeBlock:
+		return "block device"
+	case tar.TypeDir:
+		return "directory"
+	case tar.TypeFifo:
+		return "fifo"
+	}
+	return fmt.Sprintf("type %q", e.Header.Typeflag)
+}
+
+// createdBy shortens the instruction that created a layer for display.
+func createdBy(l *Layer) string {
+	s := strings.TrimSpace(l.CreatedBy)
+	s = strings.TrimPrefix(s, "/bin/sh -c ")
+	s = strings.TrimPrefix(s, "#(nop) ")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func (s *Shell) find(args []string) error {
+	root := "."
+	var name, kind string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "-name", "-type":
+			if i+1 == len(args) {
+				return fmt.Errorf("find: %s needs a value", arg)
+			}
+			i++
+			if arg == "-name" {
+				name = args[i]
+			} else {
+				kind = args[i]
+			}
+		default:
+			if strings.HasPrefix(arg, "-") || i > 0 {
+				return errors.New("usage: find [path] [-name glob] [-type f|d|l]")
+			}
+			root = arg
+		}
+	}
+	if name != "" {
+		if _, err := path.Match(name, ""); err != nil {
+			return fmt.Errorf("find: invalid pattern %q", name)
+		}
+	}
+	switch kind {
+	case "", "f", "d", "l":
+	default:
+		return fmt.Errorf("find: unknown type %s, use f, d or l", kind)
+	}
+
+	return s.fs.Walk(s.abs(root), func(e *Entry) error {
+		if name != "" {
+			if ok, _ := path.Match(name, path.Base(e.Path)); !ok {
+				return nil
+			}
+		}
+		switch kind {
+		case "f":
+			if !e.IsRegular() {
+				return nil
+			}
+		case "d":
+			if !e.IsDir() {
+				return nil
+			}
+		case "l":
+			if !e.IsSymlink() {
+				return nil
+			}
+		}
+		_, err := fmt.Fprintln(s.out, e.Path)
+		return err
+	})
+}
+
+func (s *Shell) grep(args []string) error {
+	ignoreCase, filesOnly := false, false
+	var rest []string
+	for _, arg := range args {
+		switch {
+		case arg == "-i":
+			ignoreCase = true
+		case arg == "-l":
+			filesOnly = true
+		case strings.HasPrefix(arg, "-") && len(rest) == 0:
+			return fmt.Errorf("grep: unknown flag %s", arg)
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	if len(rest) == 0 {
+		return errors.New("usage: grep [-i] [-l] <regexp> [path...]")
+	}
+	pattern := rest[0]
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("grep: %w", err)
+	}
+	paths := rest[1:]
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var files []*Entry
+	for _, p := range paths {
+		err := s.fs.Walk(s.abs(p), func(e *Entry) error {
+			if e.IsRegular() {
+				files = append(files, e)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Layers are read in order, so results are collected and printed in
+	// path order.
+	results := make(map[string][]string)
+	err = s.index.ReadFiles(files, func(e *Entry, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", e.Path, err)
+		}
+		if !re.Match(data) {
+			return nil
+		}
+		switch {
+		case filesOnly:
+			results[e.Path] = []string{e.Path}
+		case bytes.IndexByte(data, 0) >= 0:
+			results[e.Path] = []string{"binary file " + e.Path + " matches"}
+		default:
+			scanner := bufio.NewScanner(bytes.NewReader(data))
+			scanner.Buffer(nil, len(data)+1)
+			for n := 1; scanner.Scan(); n++ {
+				if line := scanner.Text(); re.MatchString(line) {
+					results[e.Path] = append(results[e.Path], fmt.Sprintf("%s:%d:%s", e.Path, n, line))
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	matched := make([]string, 0, len(results))
+	for p := range results {
+		matched = append(matched, p)
+	}
+	sort.Strings(matched)
+	for _, p := range matched {
+		for _, line := range results[p] {
+			if _, err := fmt.Fprintln(s.out, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Shell) layer(args []string) error {
+	switch len(args) {
+	case 0:
+		w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+		for _, l := range s.index.Layers {
+			current := " "
+			if l.Index == s.fs.Layer {
+				current = "*"
+			}
+			_, _ = fmt.Fprintf(w, "%s %d\t%s\t%d files\t%s\n", current, l.Index, oci.FormatBytes(l.Size), l.Files, createdBy(l))
+		}
+		return w.Flush()
+	case 1:
+	default:
+		return errors.New("usage: layer [n]")
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(s.index.Layers) {
+		return fmt.Errorf("layer: %s is not a layer, the image has %d", args[0], len(s.index.Layers))
+	}
+	s.fs = s.index.FS(n)
+
+	// Stay in the current directory, or the closest one that exists in the
+	// layer.
+	for dir := s.cwd; ; dir = path.Dir(dir) {
+		if e, err := s.fs.Stat(dir); err == nil && e.IsDir() {
+			s.cwd = dir
+			break
+		}
+	}
+	_, err = fmt.Fprintf(s.out, "Viewing the image as of layer %d of %d\n", n, len(s.index.Layers))
+	return err
+}
+
+func (s *Shell) diff(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: diff <path>")
+	}
+	p := s.abs(args[0])
+	versions := s.index.History(p)
+	if len(versions) == 0 {
+		return &fs.PathError{Op: "diff", Path: p, Err: fs.ErrNotExist}
+	}
+
+	w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	var current *Version
+	for i, v := range versions {
+		size := "-"
+		if v.Entry != nil {
+			size = oci.FormatBytes(v.Entry.Header.Size)
+		}
+		_, _ = fmt.Fprintf(w, "layer %d\t%s\t%s\t%s\n", v.Layer, v.Change, size, createdBy(s.index.Layers[v.Layer-1]))
+		if v.Layer <= s.fs.Layer {
+			current = &versions[i]
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Compare the version in the viewed layer with the one it replaced.
+	if current == nil || current.Change != "modified" || !current.Entry.IsRegular() || !current.Previous.IsRegular() {
+		return nil
+	}
+	old, err := s.readText(current.Previous)
+	if err != nil {
+		return err
+	}
+	cur, err := s.readText(current.Entry)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(s.out); err != nil {
+		return err
+	}
+	if old == nil || cur == nil {
+		_, err := fmt.Fprintf(s.out, "Binary or large files differ between layer %d and layer %d\n", current.Previous.Layer, current.Layer)
+		return err
+	}
+	return difflib.WriteUnifiedDiff(s.out, difflib.UnifiedDiff{
+		A:        difflib.SplitLines(*old),
+		B:        difflib.SplitLines(*cur),
+		FromFile: fmt.Sprintf("%s (layer %d)", p, current.Previous.Layer),
+		ToFile:   fmt.Sprintf("%s (layer %d)", p, current.Layer),
+		Context:  3,
+	})
+}
+
+// readText returns the contents of a text file, or nil for binary files and
+// files larger than diffLimit.
+func (s *Shell) readText(e *Entry) (*string, error) {
+	if e.Header.Size > diffLimit {
+		return nil, nil
+	}
+	var text *string
+	err := s.index.ReadFiles([]*Entry{e}, func(_ *Entry, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", e.Path, err)
+		}
+		if bytes.IndexByte(data, 0) < 0 {
+			t := string(data)
+			text = &t
+		}
+		return nil
+	})
+	return text, err
+}
+
+// splitArgs splits a command line into words. Single and double quotes
+// group words and a backslash escapes the next character.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+// Complete completes the word before pos in line: a command name for the
+// first word, a path for the others. It returns the new line and cursor
+// position, and the candidates when the word is ambiguous.
+func (s *Shell) Complete(line string, pos int) (string, int, []string) {
+	start := strings.LastIndexAny(line[:pos], " \t") + 1
+	word := line[start:pos]
+
+	var dir, prefix string
+	var names []string
+	if strings.TrimSpace(line[:start]) == "" {
+		prefix = word
+		for _, name := range Commands {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name+" ")
+			}
+		}
+	} else {
+		i := strings.LastIndex(word, "/")
+		dir, prefix = word[:i+1], word[i+1:]
+		lookup := dir
+		if lookup == "" {
+			lookup = "."
+		}
+		entries, err := s.fs.ReadDir(s.abs(lookup))
+		if err != nil {
+			return line, pos, nil
+		}
+		for _, e := range entries {
+			name := path.Base(e.Path)
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			if target, err := s.fs.Stat(e.Path); err == nil && target.IsDir() {
+				name += "/"
+			} else {
+				name += " "
+			}
+			names = append(names, name)
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		return line, pos, nil
+	case 1:
+		completed := dir + names[0]
+		return line[:start] + completed + line[pos:], start + len(completed), nil
+	}
+
+	common := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(common) > len(prefix) {
+		completed := dir + common
+		return line[:start] + completed + line[pos:], start + len(completed), nil
+	}
+	candidates := make([]string, len(names))
+	for i, name := range names {
+		candidates[i] = strings.TrimSuffix(name, " ")
+	}
+	return line, pos, candidates
+}
@@ -0,0 +1,261 @@
+package shell_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/shell"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLayer(t *testing.T, headers ...*tar.Header) v1.Layer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range headers {
+		// The contents of regular files are their link name, for brevity.
+		content := ""
+		if hdr.Typeflag == tar.TypeReg {
+			content, hdr.Linkname = hdr.Linkname, ""
+			hdr.Size = int64(len(content))
+		}
+		if hdr.Mode == 0 {
+			hdr.Mode = 0o644
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	data := buf.Bytes()
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+	require.NoError(t, err)
+	return layer
+}
+
+func dir(name string) *tar.Header {
+	return &tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0o755}
+}
+
+func file(name, content string) *tar.Header {
+	return &tar.Header{Name: name, Typeflag: tar.TypeReg, Linkname: content}
+}
+
+func symlink(name, target string) *tar.Header {
+	return &tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target, Mode: 0o777}
+}
+
+func newIndex(t *testing.T) *shell.Index {
+	t.Helper()
+
+	img, err := mutate.Append(empty.Image,
+		mutate.Addendum{
+			Layer: newLayer(t,
+				dir("etc/"),
+				file("etc/os-release", "ID=alpine\nVERSION_ID=3.19\n"),
+				file("etc/motd", "welcome\n"),
+				dir("usr/"), dir("usr/lib/"),
+				file("usr/lib/libc.so", "\x7fELF\x00libc"),
+				symlink("lib", "usr/lib"),
+				dir("var/"), dir("var/cache/"),
+				file("var/cache/index", "cached"),
+			),
+			History: v1.History{CreatedBy: "/bin/sh -c #(nop) ADD file:abc in / "},
+		},
+		mutate.Addendum{
+			Layer: newLayer(t,
+				dir("etc/"),
+				file("etc/os-release", "ID=alpine\nVERSION_ID=3.20\n"),
+				&tar.Header{Name: "etc/.wh.motd", Typeflag: tar.TypeReg},
+				&tar.Header{Name: "var/cache/.wh..wh..opq", Typeflag: tar.TypeReg},
+				dir("app/"),
+				file("app/config.yaml", "port: 8080\nhost: nginx\n"),
+				&tar.Header{Name: "app/config.link", Typeflag: tar.TypeLink, Linkname: "app/config.yaml"},
+			),
+			History: v1.History{CreatedBy: "COPY app /app # buildkit"},
+		},
+	)
+	require.NoError(t, err)
+
+	index, err := shell.Load("app:1", img)
+	require.NoError(t, err)
+	return index
+}
+
+func run(t *testing.T, sh *shell.Shell, out *bytes.Buffer, line string) string {
+	t.Helper()
+	out.Reset()
+	require.NoError(t, sh.Exec(line))
+	return out.String()
+}
+
+func TestShell(t *testing.T) {
+	index := newIndex(t)
+	require.Len(t, index.Layers, 2)
+
+	out := new(bytes.Buffer)
+	sh := shell.New(index, out)
+	assert.Equal(t, "app:1:/$ ", sh.Prompt())
+
+	t.Run("ls", func(t *testing.T) {
+		assert.Equal(t, "app/\netc/\nlib -> usr/lib\nusr/\nvar/\n", run(t, sh, out, "ls"))
+		assert.Equal(t, "os-release\n", run(t, sh, out, "ls /etc"))
+		assert.Equal(t, "libc.so\n", run(t, sh, out, "ls lib"), "links to directories are followed")
+		assert.Empty(t, run(t, sh, out, "ls /var/cache"), "opaque directory")
+
+		long := run(t, sh, out, "ls -l /app")
+		assert.Contains(t, long, "-rw-r--r--  0:0  23 B  layer 2  config.yaml")
+		assert.Contains(t, long, "config.link => /app/config.yaml")
+
+		assert.Error(t, sh.Exec("ls /etc/motd"))
+	})
+
+	t.Run("cd", func(t *testing.T) {
+		run(t, sh, out, "cd lib")
+		assert.Equal(t, "/usr/lib\n", run(t, sh, out, "pwd"))
+		run(t, sh, out, "cd ../../etc")
+		assert.Equal(t, "app:1:/etc$ ", sh.Prompt())
+		assert.Error(t, sh.Exec("cd os-release"))
+		run(t, sh, out, "cd")
+		assert.Equal(t, "/\n", run(t, sh, out, "pwd"))
+	})
+
+	t.Run("cat", func(t *testing.T) {
+		assert.Equal(t, "ID=alpine\nVERSION_ID=3.20\n", run(t, sh, out, "cat etc/os-release"))
+		assert.Equal(t, "port: 8080\nhost: nginx\n", run(t, sh, out, "cat /app/config.link"), "hard link")
+		assert.Error(t, sh.Exec("cat /etc"))
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		stat := run(t, sh, out, "stat /lib")
+		assert.Contains(t, stat, "Path:     /lib -> usr/lib\n")
+		assert.Contains(t, stat, "Type:     symbolic link\n")
+		assert.Contains(t, stat, "Mode:     lrwxrwxrwx (0777)\n")
+		assert.Contains(t, stat, "Layer:    1 ADD file:abc in /\n")
+	})
+
+	t.Run("find", func(t *testing.T) {
+		assert.Equal(t, "/app/config.yaml\n", run(t, sh, out, "find / -name '*.yaml'"))
+		assert.Equal(t, "/etc/os-release\n", run(t, sh, out, "find / -type f -name 'os-*'"))
+		assert.Equal(t, "/lib\n", run(t, sh, out, "find -type l"))
+		assert.Equal(t, "/etc\n", run(t, sh, out, "find /etc -type d"))
+	})
+
+	t.Run("grep", func(t *testing.T) {
+		assert.Equal(t,
+			"/app/config.link:2:host: nginx\n/app/config.yaml:2:host: nginx\n",
+			run(t, sh, out, "grep NGINX -i /"))
+		assert.Equal(t, "/etc/os-release\n", run(t, sh, out, "grep -l VERSION"))
+		assert.Equal(t, "binary file /usr/lib/libc.so matches\n", run(t, sh, out, "grep libc /usr"))
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		diff := run(t, sh, out, "diff /etc/os-release")
+		assert.Contains(t, diff, "layer 1  added")
+		assert.Contains(t, diff, "layer 2  modified")
+		assert.Contains(t, diff, "--- /etc/os-release (layer 1)\n+++ /etc/os-release (layer 2)\n")
+		assert.Contains(t, diff, "-VERSION_ID=3.19\n+VERSION_ID=3.20\n")
+
+		diff = run(t, sh, out, "diff /etc/motd")
+		assert.Contains(t, diff, "layer 2  deleted")
+		assert.NotContains(t, diff, "---")
+
+		assert.Error(t, sh.Exec("diff /nope"))
+	})
+
+	t.Run("layer", func(t *testing.T) {
+		layers := run(t, sh, out, "layer")
+		assert.Contains(t, layers, "  1  ")
+		assert.Contains(t, layers, "* 2  ")
+		assert.Contains(t, layers, "COPY app /app # buildkit")
+
+		run(t, sh, out, "cd /app")
+		assert.Equal(t, "Viewing the image as of layer 1 of 2\n", run(t, sh, out, "layer 1"))
+		assert.Equal(t, "app:1 [layer 1/2]:/$ ", sh.Prompt(), "/app does not exist yet")
+		assert.Equal(t, "welcome\n", run(t, sh, out, "cat /etc/motd"))
+		assert.Equal(t, "index\n", run(t, sh, out, "ls /var/cache"))
+
+		// The diff of a file compares the version in the viewed layer.
+		assert.NotContains(t, run(t, sh, out, "diff /etc/os-release"), "---")
+
+		assert.Error(t, sh.Exec("layer 3"))
+		run(t, sh, out, "layer 2")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		assert.ErrorContains(t, sh.Exec("frobnicate"), "command not found")
+		assert.ErrorContains(t, sh.Exec("cat 'unterminated"), "unterminated")
+		assert.ErrorIs(t, sh.Exec("exit"), shell.ErrExit)
+	})
+}
+
+func TestShell_Complete(t *testing.T) {
+	sh := shell.New(newIndex(t), io.Discard)
+
+	complete := func(line string) (string, []string) {
+		newLine, pos, candidates := sh.Complete(line, len(line))
+		assert.Equal(t, len(newLine), pos)
+		return newLine, candidates
+	}
+
+	line, candidates := complete("gr")
+	assert.Equal(t, "grep ", line)
+	assert.Empty(t, candidates)
+
+	line, candidates = complete("c")
+	assert.Equal(t, "c", line)
+	assert.Equal(t, []string{"cat", "cd"}, candidates)
+
+	line, _ = complete("cat /etc/os")
+	assert.Equal(t, "cat /etc/os-release ", line)
+
+	line, _ = complete("ls l")
+	assert.Equal(t, "ls lib/", line, "links to directories complete as directories")
+
+	line, _ = complete("cat /app/c")
+	assert.Equal(t, "cat /app/config.", line)
+
+	_, candidates = complete("cat /app/config.")
+	assert.Equal(t, []string{"config.link", "config.yaml"}, candidates)
+
+	line, candidates = complete("cat /nope/")
+	assert.Equal(t, "cat /nope/", line)
+	assert.Empty(t, candidates)
+
+	// Completion works in the middle of a line.
+	newLine, pos, _ := sh.Complete("cat /et /app", len("cat /et"))
+	assert.Equal(t, "cat /etc/ /app", newLine)
+	assert.Equal(t, len("cat /etc/"), pos)
+}
+
+func TestIndex_History(t *testing.T) {
+	index := newIndex(t)
+
+	versions := index.History("/var/cache/index")
+	require.Len(t, versions, 2)
+	assert.Equal(t, "added", versions[0].Change)
+	assert.Equal(t, "deleted", versions[1].Change, "hidden by an opaque directory")
+
+	assert.Len(t, index.History("/etc"), 1, "repeated directories are not changes")
+	assert.Empty(t, index.History("/nope"))
+
+	var paths []string
+	require.NoError(t, index.FS(2).Walk("/app", func(e *shell.Entry) error {
+		paths = append(paths, e.Path)
+		return nil
+	}))
+	assert.Equal(t, []string{"/app", "/app/config.link", "/app/config.yaml"}, paths)
+	assert.True(t, strings.HasPrefix(index.Layers[1].CreatedBy, "COPY"))
+}
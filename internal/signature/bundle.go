@@ -0,0 +1,118 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Bundle is the Rekor transparency log entry cosign attaches to signatures.
+// It lets the inclusion of the signature in the log be checked offline.
+type Bundle struct {
+	// SignedEntryTimestamp is Rekor's signature over the canonical JSON
+	// encoding of Payload.
+	SignedEntryTimestamp []byte        `json:"SignedEntryTimestamp"`
+	Payload              BundlePayload `json:"Payload"`
+}
+
+// BundlePayload is the log entry the signed entry timestamp covers.
+type BundlePayload struct {
+	// Body is the base64 encoded Rekor entry.
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogIndex       int64  `json:"logIndex"`
+	LogID          string `json:"logID"`
+}
+
+// IntegratedTime returns when the entry was added to the log.
+func (b *Bundle) IntegratedTime() time.Time {
+	return time.Unix(b.Payload.IntegratedTime, 0).UTC()
+}
+
+// hashedRekord is the subset of a hashedrekord v0.0.1 entry that ties the
+// log entry to a signature.
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content string `json:"content"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// Verify checks the signed entry timestamp with rekorKey and that the log
+// entry records this payload and signature.
+func (b *Bundle) Verify(rekorKey crypto.PublicKey, payload, sig []byte) error {
+	der, err := x509.MarshalPKIXPublicKey(rekorKey)
+	if err != nil {
+		return fmt.Errorf("failed to encode Rekor public key: %w", err)
+	}
+	// The log ID is the SHA-256 of the log's DER encoded public key.
+	keyID := sha256.Sum256(der)
+	if b.Payload.LogID != hex.EncodeToString(keyID[:]) {
+		return fmt.Errorf("entry was logged by %s, not by the given Rekor key", b.Payload.LogID)
+	}
+
+	canonical, err := b.Payload.canonicalJSON()
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(rekorKey, canonical, b.SignedEntryTimestamp); err != nil {
+		return fmt.Errorf("signed entry timestamp: %w", err)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(b.Payload.Body)
+	if err != nil {
+		return fmt.Errorf("failed to decode entry body: %w", err)
+	}
+	var entry hashedRekord
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return fmt.Errorf("failed to parse entry body: %w", err)
+	}
+	if entry.Kind != "hashedrekord" {
+		return fmt.Errorf("unsupported entry kind %q", entry.Kind)
+	}
+
+	sum := sha256.Sum256(payload)
+	if entry.Spec.Data.Hash.Algorithm != "sha256" || entry.Spec.Data.Hash.Value != hex.EncodeToString(sum[:]) {
+		return errors.New("entry does not record the signed payload")
+	}
+	logged, err := base64.StdEncoding.DecodeString(entry.Spec.Signature.Content)
+	if err != nil || !bytes.Equal(logged, sig) {
+		return errors.New("entry does not record the signature")
+	}
+
+	return nil
+}
+
+// canonicalJSON encodes the payload as RFC 8785 canonical JSON. All fields
+// are strings without characters that need escaping or integers, so sorted
+// keys are all canonicalization requires.
+func (p *BundlePayload) canonicalJSON() ([]byte, error) {
+	fields := map[string]any{
+		"body":           p.Body,
+		"integratedTime": p.IntegratedTime,
+		"logIndex":       p.LogIndex,
+		"logID":          p.LogID,
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(fields); err != nil {
+		return nil, fmt.Errorf("failed to encode bundle payload: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
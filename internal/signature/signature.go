@@ -0,0 +1,199 @@
+// Package signature discovers and verifies cosign image signatures without
+// contacting any service other than the registry holding the image.
+package signature
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// Media types and annotations used by cosign.
+const (
+	SimpleSigningMediaType   types.MediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	ArtifactType                             = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	SignatureAnnotation                      = "dev.cosignproject.cosign/signature"
+	BundleAnnotation                         = "dev.sigstore.cosign/bundle"
+	CertificateAnnotation                    = "dev.sigstore.cosign/certificate"
+	simpleSigningPayloadType                 = "cosign container image signature"
+)
+
+// Source records how a signature was discovered.
+type Source string
+
+const (
+	// SourceTag is the sha256-<digest>.sig tag scheme used by cosign.
+	SourceTag Source = "tag"
+	// SourceReferrers is the OCI 1.1 referrers API.
+	SourceReferrers Source = "referrers"
+)
+
+// Signature is a single signed payload attached to an image.
+type Signature struct {
+	// Subject is the digest the signature was found for.
+	Subject v1.Hash
+	Source  Source
+	// Payload is the simple signing JSON that was signed.
+	Payload   []byte
+	Signature []byte
+	// Bundle is the Rekor bundle attached to the signature, if any.
+	Bundle *Bundle
+	// Keyless reports whether the signature carries a Fulcio certificate
+	// instead of being made with a long-lived key.
+	Keyless bool
+}
+
+// SignatureTag returns the tag cosign stores signatures for digest under.
+func SignatureTag(repo name.Repository, digest v1.Hash) name.Tag {
+	return repo.Tag(fmt.Sprintf("%s-%s.sig", digest.Algorithm, digest.Hex))
+}
+
+// Fetch returns all signatures attached to digest in repo, both under the
+// cosign signature tag and through the referrers API. Having no signatures
+// is not an error.
+func Fetch(repo name.Repository, digest v1.Hash, opts ...remote.Option) ([]Signature, error) {
+	var sigs []Signature
+
+	img, err := remote.Image(SignatureTag(repo, digest), opts...)
+	switch {
+	case oci.IsNotFound(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to fetch signature tag: %w", err)
+	default:
+		found, err := readSignatures(img, digest, SourceTag)
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, found...)
+	}
+
+	index, err := remote.Referrers(repo.Digest(digest.String()), opts...)
+	if err != nil {
+		if oci.IsNotFound(err) {
+			return sigs, nil
+		}
+		return nil, fmt.Errorf("failed to fetch referrers: %w", err)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read referrers: %w", err)
+	}
+	for _, desc := range manifest.Manifests {
+		if desc.ArtifactType != ArtifactType {
+			continue
+		}
+		img, err := remote.Image(repo.Digest(desc.Digest.String()), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch signature %s: %w", desc.Digest, err)
+		}
+		found, err := readSignatures(img, digest, SourceReferrers)
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, found...)
+	}
+
+	return sigs, nil
+}
+
+// readSignatures extracts one signature per simple signing layer of a
+// signature manifest.
+func readSignatures(img v1.Image, subject v1.Hash, source Source) ([]Signature, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature manifest: %w", err)
+	}
+
+	var sigs []Signature
+	for _, desc := range manifest.Layers {
+		if desc.MediaType != SimpleSigningMediaType {
+			continue
+		}
+		encoded, ok := desc.Annotations[SignatureAnnotation]
+		if !ok {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode signature in layer %s: %w", desc.Digest, err)
+		}
+
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get payload layer %s: %w", desc.Digest, err)
+		}
+		payload, err := readBlob(layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read payload layer %s: %w", desc.Digest, err)
+		}
+
+		sig := Signature{
+			Subject:   subject,
+			Source:    source,
+			Payload:   payload,
+			Signature: raw,
+			Keyless:   desc.Annotations[CertificateAnnotation] != "",
+		}
+		if b := desc.Annotations[BundleAnnotation]; b != "" {
+			sig.Bundle = &Bundle{}
+			if err := json.Unmarshal([]byte(b), sig.Bundle); err != nil {
+				return nil, fmt.Errorf("failed to parse bundle in layer %s: %w", desc.Digest, err)
+			}
+		}
+		sigs = append(sigs, sig)
+	}
+
+	return sigs, nil
+}
+
+// maxPayloadSize bounds the size of simple signing payloads, which are
+// small JSON documents.
+const maxPayloadSize = 1 << 20
+
+func readBlob(layer v1.Layer) ([]byte, error) {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	return io.ReadAll(io.LimitReader(rc, maxPayloadSize))
+}
+
+// Payload is the simple signing document cosign signs.
+type Payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]any `json:"optional"`
+}
+
+// ParsePayload decodes and validates a simple signing payload.
+func ParsePayload(data []byte) (*Payload, error) {
+	var p Payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid signature payload: %w", err)
+	}
+	if !strings.EqualFold(p.Critical.Type, simpleSigningPayloadType) {
+		return nil, fmt.Errorf("unsupported signature payload type %q", p.Critical.Type)
+	}
+	if p.Critical.Image.DockerManifestDigest == "" {
+		return nil, fmt.Errorf("signature payload does not name an image digest")
+	}
+	return &p, nil
+}
@@ -0,0 +1,320 @@
+package signature_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/signature"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRegistry(t *testing.T) string {
+	t.Helper()
+
+	srv := httptest.NewServer(registry.New(
+		registry.Logger(log.New(io.Discard, "", 0)),
+		registry.WithReferrersSupport(true),
+	))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+// pushRandomImage pushes a random image and returns its repository and digest.
+func pushRandomImage(t *testing.T, host string) (name.Repository, v1.Hash) {
+	t.Helper()
+
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(host + "/app:1.0")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	return ref.Context(), digest
+}
+
+func payloadFor(repo name.Repository, digest v1.Hash) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`,
+		repo.String(), digest.String()))
+}
+
+func sign(t *testing.T, key crypto.Signer, payload []byte) []byte {
+	t.Helper()
+
+	var sig []byte
+	var err error
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		sum := sha256.Sum256(payload)
+		sig, err = ecdsa.SignASN1(rand.Reader, k, sum[:])
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, payload)
+	}
+	require.NoError(t, err)
+	return sig
+}
+
+// signatureImage builds a cosign signature manifest with a single payload.
+func signatureImage(t *testing.T, payload, sig []byte, bundle *signature.Bundle) v1.Image {
+	t.Helper()
+
+	annotations := map[string]string{
+		signature.SignatureAnnotation: base64.StdEncoding.EncodeToString(sig),
+	}
+	if bundle != nil {
+		b, err := json.Marshal(bundle)
+		require.NoError(t, err)
+		annotations[signature.BundleAnnotation] = string(b)
+	}
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(payload, signature.SimpleSigningMediaType),
+		Annotations: annotations,
+	})
+	require.NoError(t, err)
+	return img
+}
+
+func pushSignatureTag(t *testing.T, repo name.Repository, digest v1.Hash, img v1.Image) {
+	t.Helper()
+	require.NoError(t, remote.Write(signature.SignatureTag(repo, digest), img))
+}
+
+func publicKeyPEM(t *testing.T, key crypto.PublicKey) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestSignatureTag(t *testing.T) {
+	repo, err := name.NewRepository("registry.example.com/app")
+	require.NoError(t, err)
+	digest, err := v1.NewHash("sha256:" + strings.Repeat("ab", 32))
+	require.NoError(t, err)
+
+	assert.Equal(t, "registry.example.com/app:sha256-"+strings.Repeat("ab", 32)+".sig", signature.SignatureTag(repo, digest).String())
+}
+
+func TestFetchAndVerify(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		signer  crypto.Signer
+		key     crypto.PublicKey
+		wantErr string
+	}{
+		{"ecdsa", ecKey, ecKey.Public(), ""},
+		{"ed25519", edKey, edKey.Public(), ""},
+		{"wrong key", ecKey, otherKey.Public(), "invalid signature"},
+		{"key type mismatch", edKey, ecKey.Public(), "invalid signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, digest := pushRandomImage(t, newRegistry(t))
+			payload := payloadFor(repo, digest)
+			pushSignatureTag(t, repo, digest, signatureImage(t, payload, sign(t, tt.signer, payload), nil))
+
+			sigs, err := signature.Fetch(repo, digest)
+			require.NoError(t, err)
+			require.Len(t, sigs, 1)
+			assert.Equal(t, signature.SourceTag, sigs[0].Source)
+			assert.Equal(t, digest, sigs[0].Subject)
+
+			verifier := &signature.Verifier{Key: tt.key}
+			result, err := verifier.Verify(&sigs[0])
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, repo.String(), result.Payload.Critical.Identity.DockerReference)
+		})
+	}
+}
+
+func TestVerify_DigestMismatch(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	repo, digest := pushRandomImage(t, newRegistry(t))
+	other, err := v1.NewHash("sha256:" + strings.Repeat("0", 64))
+	require.NoError(t, err)
+
+	// A valid signature for another image copied onto this one.
+	payload := payloadFor(repo, other)
+	pushSignatureTag(t, repo, digest, signatureImage(t, payload, sign(t, key, payload), nil))
+
+	sigs, err := signature.Fetch(repo, digest)
+	require.NoError(t, err)
+	require.Len(t, sigs, 1)
+
+	_, err = (&signature.Verifier{Key: key.Public()}).Verify(&sigs[0])
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match image digest")
+}
+
+func TestFetch_Referrers(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	repo, digest := pushRandomImage(t, newRegistry(t))
+	payload := payloadFor(repo, digest)
+
+	img := mutate.ConfigMediaType(signatureImage(t, payload, sign(t, key, payload), nil), signature.ArtifactType)
+	img = mutate.Subject(img, v1.Descriptor{
+		MediaType: "application/vnd.oci.image.manifest.v1+json",
+		Digest:    digest,
+		Size:      1,
+	}).(v1.Image)
+	sigDigest, err := img.Digest()
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(repo.Digest(sigDigest.String()), img))
+
+	sigs, err := signature.Fetch(repo, digest)
+	require.NoError(t, err)
+	require.Len(t, sigs, 1)
+	assert.Equal(t, signature.SourceReferrers, sigs[0].Source)
+
+	_, err = (&signature.Verifier{Key: key.Public()}).Verify(&sigs[0])
+	assert.NoError(t, err)
+}
+
+func TestFetch_NoSignatures(t *testing.T) {
+	repo, digest := pushRandomImage(t, newRegistry(t))
+
+	sigs, err := signature.Fetch(repo, digest)
+	require.NoError(t, err)
+	assert.Empty(t, sigs)
+}
+
+// newBundle creates a Rekor bundle for payload and sig, signed by rekorKey.
+func newBundle(t *testing.T, rekorKey *ecdsa.PrivateKey, payload, sig []byte) *signature.Bundle {
+	t.Helper()
+
+	sum := sha256.Sum256(payload)
+	body := fmt.Sprintf(`{"apiVersion":"0.0.1","kind":"hashedrekord","spec":{"data":{"hash":{"algorithm":"sha256","value":%q}},"signature":{"content":%q}}}`,
+		hex.EncodeToString(sum[:]), base64.StdEncoding.EncodeToString(sig))
+
+	der, err := x509.MarshalPKIXPublicKey(rekorKey.Public())
+	require.NoError(t, err)
+	logID := sha256.Sum256(der)
+
+	bundle := &signature.Bundle{
+		Payload: signature.BundlePayload{
+			Body:           base64.StdEncoding.EncodeToString([]byte(body)),
+			IntegratedTime: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).Unix(),
+			LogIndex:       42,
+			LogID:          hex.EncodeToString(logID[:]),
+		},
+	}
+	canonical := fmt.Sprintf(`{"body":%q,"integratedTime":%d,"logID":%q,"logIndex":%d}`,
+		bundle.Payload.Body, bundle.Payload.IntegratedTime, bundle.Payload.LogID, bundle.Payload.LogIndex)
+	bundle.SignedEntryTimestamp = sign(t, rekorKey, []byte(canonical))
+	return bundle
+}
+
+func TestVerify_Bundle(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rekorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherRekorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	digest, err := v1.NewHash("sha256:" + strings.Repeat("ab", 32))
+	require.NoError(t, err)
+	repo, err := name.NewRepository("registry.example.com/app")
+	require.NoError(t, err)
+	payload := payloadFor(repo, digest)
+	sig := sign(t, key, payload)
+
+	t.Run("valid", func(t *testing.T) {
+		s := &signature.Signature{Subject: digest, Payload: payload, Signature: sig, Bundle: newBundle(t, rekorKey, payload, sig)}
+		result, err := (&signature.Verifier{Key: key.Public(), RekorKey: rekorKey.Public()}).Verify(s)
+		require.NoError(t, err)
+		require.NotNil(t, result.Bundle)
+		assert.Equal(t, int64(42), result.Bundle.Payload.LogIndex)
+		assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), result.Bundle.IntegratedTime())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		s := &signature.Signature{Subject: digest, Payload: payload, Signature: sig}
+		_, err := (&signature.Verifier{Key: key.Public(), RekorKey: rekorKey.Public()}).Verify(s)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no Rekor bundle")
+	})
+
+	t.Run("wrong log", func(t *testing.T) {
+		s := &signature.Signature{Subject: digest, Payload: payload, Signature: sig, Bundle: newBundle(t, otherRekorKey, payload, sig)}
+		_, err := (&signature.Verifier{Key: key.Public(), RekorKey: rekorKey.Public()}).Verify(s)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not by the given Rekor key")
+	})
+
+	t.Run("tampered timestamp", func(t *testing.T) {
+		bundle := newBundle(t, rekorKey, payload, sig)
+		bundle.Payload.IntegratedTime++
+		s := &signature.Signature{Subject: digest, Payload: payload, Signature: sig, Bundle: bundle}
+		_, err := (&signature.Verifier{Key: key.Public(), RekorKey: rekorKey.Public()}).Verify(s)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "signed entry timestamp")
+	})
+
+	t.Run("other signature", func(t *testing.T) {
+		otherSig := sign(t, key, payload)
+		s := &signature.Signature{Subject: digest, Payload: payload, Signature: sig, Bundle: newBundle(t, rekorKey, payload, otherSig)}
+		_, err := (&signature.Verifier{Key: key.Public(), RekorKey: rekorKey.Public()}).Verify(s)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not record the signature")
+	})
+}
+
+func TestParsePublicKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, err = signature.ParsePublicKey(publicKeyPEM(t, ecKey.Public()))
+	assert.NoError(t, err)
+	_, err = signature.ParsePublicKey(publicKeyPEM(t, edPub))
+	assert.NoError(t, err)
+
+	_, err = signature.ParsePublicKey([]byte("not a key"))
+	assert.Error(t, err)
+}
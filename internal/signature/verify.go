@@ -0,0 +1,113 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// LoadPublicKey reads a PEM encoded ECDSA or Ed25519 public key, as written
+// by cosign generate-key-pair.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	key, err := ParsePublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParsePublicKey parses a PEM encoded PKIX public key.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("no PEM encoded PUBLIC KEY found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T (expected ECDSA or Ed25519)", key)
+	}
+}
+
+// verifySignature checks sig over data. ECDSA signatures are ASN.1 encoded
+// over the SHA-256 digest of data; Ed25519 signs data directly.
+func verifySignature(key crypto.PublicKey, data, sig []byte) error {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(k, sum[:], sig) {
+			return errors.New("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, data, sig) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil
+}
+
+// Verifier checks signatures against a public key and, optionally, their
+// Rekor bundles against the Rekor public key.
+type Verifier struct {
+	Key crypto.PublicKey
+	// RekorKey enables bundle verification. Signatures without a bundle
+	// are rejected when it is set.
+	RekorKey crypto.PublicKey
+}
+
+// Result describes a verified signature.
+type Result struct {
+	Payload *Payload
+	// Bundle is set when the Rekor bundle was verified.
+	Bundle *Bundle
+}
+
+// Verify checks that sig was made by the verifier's key and that it signs
+// the digest it is attached to.
+func (v *Verifier) Verify(sig *Signature) (*Result, error) {
+	if err := verifySignature(v.Key, sig.Payload, sig.Signature); err != nil {
+		if sig.Keyless {
+			return nil, fmt.Errorf("%w: keyless signatures are not supported", err)
+		}
+		return nil, err
+	}
+
+	payload, err := ParsePayload(sig.Payload)
+	if err != nil {
+		return nil, err
+	}
+	if signed := payload.Critical.Image.DockerManifestDigest; signed != sig.Subject.String() {
+		return nil, fmt.Errorf("signed digest %s does not match image digest %s", signed, sig.Subject)
+	}
+
+	result := &Result{Payload: payload}
+
+	if v.RekorKey != nil {
+		if sig.Bundle == nil {
+			return nil, errors.New("no Rekor bundle attached to signature")
+		}
+		if err := sig.Bundle.Verify(v.RekorKey, sig.Payload, sig.Signature); err != nil {
+			return nil, fmt.Errorf("invalid Rekor bundle: %w", err)
+		}
+		result.Bundle = sig.Bundle
+	}
+
+	return result, nil
+}
@@ -0,0 +1,97 @@
+// Package tags selects image tags by pattern and semantic version.
+package tags
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Filter selects tags. The zero value matches every tag.
+type Filter struct {
+	// Match, when set, must match the tag.
+	Match *regexp.Regexp
+	// Constraint, when set, must be satisfied by the tag parsed as a
+	// semantic version. Tags that are not versions never satisfy it.
+	Constraint *semver.Constraints
+	// IgnoreVariants checks the constraint against the version of a tag
+	// without its variant, so 1.25.0-alpine satisfies ">=1.24", and
+	// against the release a prerelease such as 1.25rc1 leads up to.
+	IgnoreVariants bool
+	// ExcludePrereleases drops versions such as 1.25.0-rc1.
+	ExcludePrereleases bool
+}
+
+// NewFilter compiles a filter from a regular expression and a semver
+// constraint such as ">=1.24, <2". Empty strings disable either check.
+// The regular expression is anchored to the whole tag.
+func NewFilter(match, constraint string) (*Filter, error) {
+	f := &Filter{}
+	if match != "" {
+		re, err := regexp.Compile("^(?:" + match + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag pattern %q: %w", match, err)
+		}
+		f.Match = re
+	}
+	if constraint != "" {
+		c, err := semver.NewConstraint(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid semver constraint %q: %w", constraint, err)
+		}
+		f.Constraint = c
+	}
+	return f, nil
+}
+
+// Matches reports whether tag passes the filter.
+func (f *Filter) Matches(tag string) bool {
+	if f.Match != nil && !f.Match.MatchString(tag) {
+		return false
+	}
+	if f.Constraint == nil && !f.ExcludePrereleases {
+		return true
+	}
+
+	v, ok := ParseVersion(tag)
+	if f.ExcludePrereleases && ok && v.Prerelease() {
+		return false
+	}
+	if f.Constraint != nil && !f.checkConstraint(tag, v, ok) {
+		return false
+	}
+	return true
+}
+
+func (f *Filter) checkConstraint(tag string, v *Version, ok bool) bool {
+	if !f.IgnoreVariants {
+		sv, err := semver.NewVersion(tag)
+		return err == nil && f.Constraint.Check(sv)
+	}
+	if !ok {
+		return false
+	}
+	// Constraints never match prereleases unless they name one
+	// themselves, so compare the release a prerelease leads up to.
+	sv := v.Semver
+	if v.Prerelease() {
+		release, err := sv.SetPrerelease("")
+		if err != nil {
+			return false
+		}
+		sv = &release
+	}
+	return f.Constraint.Check(sv)
+}
+
+// Apply returns the tags passing the filter, in their original order.
+func (f *Filter) Apply(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		if f.Matches(tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
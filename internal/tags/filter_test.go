@@ -0,0 +1,60 @@
+package tags_test
+
+import (
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/tags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allTags = []string{"latest", "1.23.4", "1.24.0", "v1.24.1", "1.25rc1", "1.25.0-alpine", "2.0.0", "stable"}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		match      string
+		constraint string
+		want       []string
+	}{
+		{"no filters", "", "", allTags},
+		{"anchored pattern", "1\\.24.*", "", []string{"1.24.0"}},
+		{"alternation is anchored", "latest|stable", "", []string{"latest", "stable"}},
+		{"constraint", "", ">=1.24, <2", []string{"1.24.0", "v1.24.1"}},
+		{"tilde constraint", "", "~1.24", []string{"1.24.0", "v1.24.1"}},
+		{"both", "v.*", ">=1.24", []string{"v1.24.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tags.NewFilter(tt.match, tt.constraint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, f.Apply(allTags))
+		})
+	}
+}
+
+func TestFilter_IgnoreVariants(t *testing.T) {
+	f, err := tags.NewFilter("", ">=1.24, <2")
+	require.NoError(t, err)
+	f.IgnoreVariants = true
+
+	assert.Equal(t, []string{"1.24.0", "v1.24.1", "1.25rc1", "1.25.0-alpine"}, f.Apply(allTags))
+}
+
+func TestFilter_ExcludePrereleases(t *testing.T) {
+	f, err := tags.NewFilter("", ">=1.24")
+	require.NoError(t, err)
+	f.IgnoreVariants = true
+	f.ExcludePrereleases = true
+
+	assert.Equal(t, []string{"1.24.0", "v1.24.1", "1.25.0-alpine", "2.0.0"}, f.Apply(allTags))
+}
+
+func TestNewFilter_Invalid(t *testing.T) {
+	_, err := tags.NewFilter("(", "")
+	assert.ErrorContains(t, err, "invalid tag pattern")
+
+	_, err = tags.NewFilter("", "not a constraint")
+	assert.ErrorContains(t, err, "invalid semver constraint")
+}
@@ -0,0 +1,162 @@
+package tags
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultConcurrency is the number of registry requests Resolve makes at
+// once. It is kept low since registries such as Docker Hub rate limit
+// bursts of requests.
+const DefaultConcurrency = 4
+
+// Image is a manifest and the tags pointing at it.
+type Image struct {
+	Digest    v1.Hash
+	MediaType types.MediaType
+	// Platforms lists the platforms of an index, or the platform of an
+	// image from its config, e.g. "linux/amd64".
+	Platforms []string
+	// Created is read from the image config. For indexes the config of
+	// the linux/amd64 image, or else the first image, is used.
+	Created time.Time
+	// Tags are in the order they were passed to Resolve.
+	Tags []string
+}
+
+// Resolve looks up the manifest each tag points at. Tags are resolved with
+// HEAD requests, and each distinct manifest and its config are fetched once.
+// Images are returned in the order their first tag was given.
+func Resolve(ctx context.Context, repo name.Repository, tagList []string, concurrency int, opts ...remote.Option) ([]*Image, error) {
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+	// Share one puller so the registry handshake and token are reused
+	// across requests.
+	puller, err := remote.NewPuller(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client: %w", err)
+	}
+	opts = append(opts, remote.Reuse(puller))
+
+	descs := make([]*v1.Descriptor, len(tagList))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, tag := range tagList {
+		g.Go(func() error {
+			desc, err := remote.Head(repo.Tag(tag), withContext(opts, gctx)...)
+			if err != nil {
+				return fmt.Errorf("failed to resolve tag %s: %w", tag, err)
+			}
+			descs[i] = desc
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var images []*Image
+	byDigest := make(map[v1.Hash]*Image)
+	for i, desc := range descs {
+		img, ok := byDigest[desc.Digest]
+		if !ok {
+			img = &Image{Digest: desc.Digest, MediaType: desc.MediaType}
+			byDigest[desc.Digest] = img
+			images = append(images, img)
+		}
+		img.Tags = append(img.Tags, tagList[i])
+	}
+
+	g, gctx = errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, img := range images {
+		g.Go(func() error {
+			platforms, created, err := describe(repo.Digest(img.Digest.String()), withContext(opts, gctx))
+			if err != nil {
+				return err
+			}
+			img.Platforms, img.Created = platforms, created
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// withContext returns a copy of opts using ctx, safe to call from several
+// goroutines.
+func withContext(opts []remote.Option, ctx context.Context) []remote.Option {
+	return append(opts[:len(opts):len(opts)], remote.WithContext(ctx))
+}
+
+// describe returns the platforms and creation time of a manifest.
+func describe(ref name.Digest, opts []remote.Option) ([]string, time.Time, error) {
+	desc, err := remote.Get(ref, opts...)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch manifest %s: %w", ref.DigestStr(), err)
+	}
+
+	var platforms []string
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to read index %s: %w", ref.DigestStr(), err)
+		}
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to read index %s: %w", ref.DigestStr(), err)
+		}
+
+		var child *v1.Descriptor
+		for i, m := range manifest.Manifests {
+			// Skip attestation manifests, which have an unknown platform.
+			if m.Platform == nil || m.Platform.OS == "unknown" {
+				continue
+			}
+			platforms = append(platforms, m.Platform.String())
+			if child == nil || m.Platform.String() == "linux/amd64" {
+				child = &manifest.Manifests[i]
+			}
+		}
+		if child == nil {
+			return platforms, time.Time{}, nil
+		}
+		desc, err = remote.Get(ref.Context().Digest(child.Digest.String()), opts...)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to fetch manifest %s: %w", child.Digest, err)
+		}
+	}
+
+	if !desc.MediaType.IsImage() {
+		return platforms, time.Time{}, nil
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read image %s: %w", desc.Digest, err)
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		// Artifacts pushed as images may not carry an image config.
+		if oci.IsNotFound(err) {
+			return platforms, time.Time{}, nil
+		}
+		return nil, time.Time{}, fmt.Errorf("failed to read config of %s: %w", desc.Digest, err)
+	}
+
+	if platforms == nil && cfg.OS != "" {
+		platforms = []string{cfg.Platform().String()}
+	}
+	return platforms, cfg.Created.Time, nil
+}
@@ -0,0 +1,72 @@
+package tags_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/tags"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(srv.Close)
+	repo, err := name.NewRepository(strings.TrimPrefix(srv.URL, "http://") + "/app")
+	require.NoError(t, err)
+
+	created := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	newImage := func(arch string) v1.Image {
+		img, err := random.Image(64, 1)
+		require.NoError(t, err)
+		cfg, err := img.ConfigFile()
+		require.NoError(t, err)
+		cfg = cfg.DeepCopy()
+		cfg.OS, cfg.Architecture = "linux", arch
+		cfg.Created = v1.Time{Time: created}
+		img, err = mutate.ConfigFile(img, cfg)
+		require.NoError(t, err)
+		return img
+	}
+
+	idx := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: newImage("arm64"), Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+		mutate.IndexAddendum{Add: newImage("amd64"), Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+	)
+	for _, tag := range []string{"latest", "1.27", "1.27.3"} {
+		require.NoError(t, remote.WriteIndex(repo.Tag(tag), idx))
+	}
+	old := newImage("amd64")
+	require.NoError(t, remote.Write(repo.Tag("1.26"), old))
+
+	images, err := tags.Resolve(context.Background(), repo, []string{"1.27.3", "1.27", "1.26", "latest"}, 2)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+
+	digest, err := idx.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, digest, images[0].Digest)
+	assert.True(t, images[0].MediaType.IsIndex())
+	assert.Equal(t, []string{"1.27.3", "1.27", "latest"}, images[0].Tags)
+	assert.Equal(t, []string{"linux/arm64", "linux/amd64"}, images[0].Platforms)
+	assert.True(t, created.Equal(images[0].Created))
+
+	assert.Equal(t, []string{"1.26"}, images[1].Tags)
+	assert.Equal(t, []string{"linux/amd64"}, images[1].Platforms)
+	assert.True(t, created.Equal(images[1].Created))
+
+	_, err = tags.Resolve(context.Background(), repo, []string{"missing"}, 0)
+	assert.ErrorContains(t, err, "failed to resolve tag missing")
+}
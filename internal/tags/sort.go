@@ -0,0 +1,119 @@
+package tags
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Order is how tags are sorted. Every order lists the newest tags first.
+type Order string
+
+const (
+	// OrderSemver sorts by semantic version, grouping variants.
+	OrderSemver Order = "semver"
+	// OrderLexical sorts by name, which reverses the registry order.
+	OrderLexical Order = "lexical"
+	// OrderDate sorts by the date embedded in the tag.
+	OrderDate Order = "date"
+)
+
+// ParseOrder validates a sort order name.
+func ParseOrder(s string) (Order, error) {
+	switch o := Order(s); o {
+	case OrderSemver, OrderLexical, OrderDate:
+		return o, nil
+	}
+	return "", fmt.Errorf("invalid sort order %q: must be semver, lexical or date", s)
+}
+
+// Group is a run of sorted tags sharing a variant suffix, such as "alpine".
+// Tags without a variant have an empty Variant.
+type Group struct {
+	Variant string
+	Tags    []string
+}
+
+// Sort orders tags. Semver order returns one group per variant, tags
+// without a variant first, and lists tags that are not versions, such as
+// "latest", after the versions of the first group. Lexical and date order
+// return a single group; tags without a date sort last.
+func Sort(tags []string, order Order) []Group {
+	sorted := append([]string(nil), tags...)
+
+	switch order {
+	case OrderLexical:
+		sort.Sort(sort.Reverse(sort.StringSlice(sorted)))
+		return []Group{{Tags: sorted}}
+	case OrderDate:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			di, iok := ParseDate(sorted[i])
+			dj, jok := ParseDate(sorted[j])
+			if iok != jok {
+				return iok
+			}
+			if !di.Equal(dj) {
+				return di.After(dj)
+			}
+			return sorted[i] > sorted[j]
+		})
+		return []Group{{Tags: sorted}}
+	}
+
+	variants := make(map[string][]*Version)
+	var others []string
+	for _, tag := range sorted {
+		v, ok := ParseVersion(tag)
+		if !ok {
+			others = append(others, tag)
+			continue
+		}
+		variants[v.Variant] = append(variants[v.Variant], v)
+	}
+
+	names := make([]string, 0, len(variants))
+	for variant := range variants {
+		names = append(names, variant)
+	}
+	sort.Strings(names)
+
+	var groups []Group
+	for _, variant := range names {
+		versions := variants[variant]
+		sort.SliceStable(versions, func(i, j int) bool {
+			if c := versions[i].Semver.Compare(versions[j].Semver); c != 0 {
+				return c > 0
+			}
+			// 1.25 and 1.25.0 are equal; list the longer tag first.
+			return versions[i].Tag > versions[j].Tag
+		})
+		group := Group{Variant: variant}
+		for _, v := range versions {
+			group.Tags = append(group.Tags, v.Tag)
+		}
+		groups = append(groups, group)
+	}
+
+	if len(others) > 0 {
+		sort.Strings(others)
+		if len(groups) > 0 && groups[0].Variant == "" {
+			groups[0].Tags = append(groups[0].Tags, others...)
+		} else {
+			groups = append([]Group{{Tags: others}}, groups...)
+		}
+	}
+
+	return groups
+}
+
+// Latest returns the highest stable version among tags, preferring tags
+// without a variant. It reports false when no tag is a stable version.
+func Latest(tags []string) (string, bool) {
+	for _, group := range Sort(tags, OrderSemver) {
+		for _, tag := range group.Tags {
+			if v, ok := ParseVersion(tag); ok && !v.Prerelease() {
+				return tag, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,81 @@
+package tags_test
+
+import (
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/tags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		tag        string
+		version    string
+		variant    string
+		prerelease bool
+	}{
+		{"1.25", "1.25.0", "", false},
+		{"v1.25.3-rc.1", "1.25.3-rc.1", "", true},
+		{"1.25rc1", "1.25.0-rc1", "", true},
+		{"1.27-alpine", "1.27.0", "alpine", false},
+		{"1.25.3-rc1-alpine3.20", "1.25.3-rc1", "alpine3.20", true},
+		{"3.20-slim-bookworm", "3.20.0", "slim-bookworm", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			v, ok := tags.ParseVersion(tt.tag)
+			require.True(t, ok)
+			assert.Equal(t, tt.version, v.Semver.String())
+			assert.Equal(t, tt.variant, v.Variant)
+			assert.Equal(t, tt.prerelease, v.Prerelease())
+		})
+	}
+
+	for _, tag := range []string{"latest", "stable-alpine", "1.2.3.4"} {
+		_, ok := tags.ParseVersion(tag)
+		assert.False(t, ok, tag)
+	}
+}
+
+func TestSort_Semver(t *testing.T) {
+	groups := tags.Sort([]string{"1.9", "1.10", "latest", "1.10-alpine", "1.9-alpine", "1.10-slim", "1.10.1"}, tags.OrderSemver)
+	assert.Equal(t, []tags.Group{
+		{Variant: "", Tags: []string{"1.10.1", "1.10", "1.9", "latest"}},
+		{Variant: "alpine", Tags: []string{"1.10-alpine", "1.9-alpine"}},
+		{Variant: "slim", Tags: []string{"1.10-slim"}},
+	}, groups)
+}
+
+func TestSort_Lexical(t *testing.T) {
+	groups := tags.Sort([]string{"1.9", "1.10", "latest"}, tags.OrderLexical)
+	assert.Equal(t, []tags.Group{{Tags: []string{"latest", "1.9", "1.10"}}}, groups)
+}
+
+func TestSort_Date(t *testing.T) {
+	groups := tags.Sort([]string{"nightly-20240115", "latest", "2024.03.01", "20231231-1"}, tags.OrderDate)
+	assert.Equal(t, []tags.Group{{Tags: []string{"2024.03.01", "nightly-20240115", "20231231-1", "latest"}}}, groups)
+}
+
+func TestParseOrder(t *testing.T) {
+	order, err := tags.ParseOrder("date")
+	require.NoError(t, err)
+	assert.Equal(t, tags.OrderDate, order)
+
+	_, err = tags.ParseOrder("newest")
+	assert.ErrorContains(t, err, "invalid sort order")
+}
+
+func TestLatest(t *testing.T) {
+	latest, ok := tags.Latest([]string{"1.24.3", "1.25.0-rc1", "1.25rc2", "1.24.4-alpine", "1.26-alpine", "latest"})
+	require.True(t, ok)
+	assert.Equal(t, "1.24.3", latest)
+
+	latest, ok = tags.Latest([]string{"1.26-alpine", "1.25-alpine"})
+	require.True(t, ok)
+	assert.Equal(t, "1.26-alpine", latest)
+
+	_, ok = tags.Latest([]string{"latest", "1.0.0-beta"})
+	assert.False(t, ok)
+}
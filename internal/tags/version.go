@@ -0,0 +1,67 @@
+package tags
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// versionPattern splits tags such as "1.25", "v1.25.3-rc.1" and
+// "1.25.3-rc1-alpine3.20" into a numeric version, an optional prerelease
+// and an optional variant suffix. Go style prereleases such as "1.25rc1"
+// are recognized too.
+var versionPattern = regexp.MustCompile(`^v?(\d+(?:\.\d+){0,2})` +
+	`(?:[-.]?((?i:alpha|beta|rc|pre|preview|dev)[.-]?\d*))?` +
+	`(?:-([0-9A-Za-z][0-9A-Za-z._-]*))?$`)
+
+// Version is a tag parsed as a semantic version.
+type Version struct {
+	Tag     string
+	Semver  *semver.Version
+	Variant string
+}
+
+// ParseVersion parses tag as a semantic version with an optional variant
+// suffix. Anything after the version that is not a prerelease, such as
+// "alpine" or "slim-bookworm", is the variant. It reports false for tags
+// that are not versions, like "latest".
+func ParseVersion(tag string) (*Version, bool) {
+	m := versionPattern.FindStringSubmatch(tag)
+	if m == nil {
+		return nil, false
+	}
+
+	s := m[1]
+	if m[2] != "" {
+		s += "-" + strings.ToLower(m[2])
+	}
+	v, err := semver.NewVersion(s)
+	if err != nil {
+		return nil, false
+	}
+	return &Version{Tag: tag, Semver: v, Variant: m[3]}, true
+}
+
+// Prerelease reports whether the version is a prerelease.
+func (v *Version) Prerelease() bool {
+	return v.Semver.Prerelease() != ""
+}
+
+// datePattern finds dates such as 20240115, 2024-01-15 and 2024.01.15.
+var datePattern = regexp.MustCompile(`(?:^|\D)((?:19|20)\d{2})[-.]?(\d{2})[-.]?(\d{2})(?:\D|$)`)
+
+// ParseDate returns the date embedded in a tag, such as the one in
+// "nightly-20240115" or "2024.01.15-1".
+func ParseDate(tag string) (time.Time, bool) {
+	m := datePattern.FindStringSubmatch(tag)
+	if m == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("20060102", m[1]+m[2]+m[3])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
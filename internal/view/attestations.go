@@ -0,0 +1,170 @@
+package view
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// AttestationInfo represents a decoded in-toto attestation.
+type AttestationInfo struct {
+	Source string
+	// Manifest is the digest of the manifest carrying the attestation.
+	Manifest string
+	// Subject is the image digest the attestation is attached to.
+	Subject       string
+	StatementType string
+	PredicateType string
+	// StatementSubjects lists the statement subjects as name@digest.
+	StatementSubjects []string
+	// PayloadType and KeyIDs are empty for statements without a DSSE
+	// envelope.
+	PayloadType string
+	Signatures  int
+	KeyIDs      []string
+	Predicate   json.RawMessage
+}
+
+// AttestationsData contains the attestations to be rendered.
+type AttestationsData struct {
+	ImageRef     string
+	Digest       string
+	Attestations []AttestationInfo
+	// ShowPredicates prints the decoded predicates in the human view.
+	ShowPredicates bool
+}
+
+type AttestationsView interface {
+	Render(data *AttestationsData) error
+}
+
+// Human view implementation
+type attestationsHumanView struct {
+	*HumanView
+}
+
+func newAttestationsHumanView(hv *HumanView) *attestationsHumanView {
+	return &attestationsHumanView{HumanView: hv}
+}
+
+func (v *attestationsHumanView) Render(data *AttestationsData) error {
+	if len(data.Attestations) == 0 {
+		v.Printf("No attestations found for %s (%s)\n", data.ImageRef, data.Digest)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Predicate Type\tSubject\tSource\tSigned\n")
+
+	for _, a := range data.Attestations {
+		signed := "no"
+		if a.Signatures > 0 {
+			signed = fmt.Sprintf("yes (%d)", a.Signatures)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.PredicateType, shortDigest(a.Subject), a.Source, signed)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	if !data.ShowPredicates {
+		return nil
+	}
+
+	for _, a := range data.Attestations {
+		v.Printf("\n%s\n", a.PredicateType)
+		v.Printf("%s\n", strings.Repeat("-", len(a.PredicateType)))
+		if len(a.StatementSubjects) > 0 {
+			v.Printf("Subjects:\n")
+			for _, s := range a.StatementSubjects {
+				v.Printf("  %s\n", s)
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, a.Predicate, "", "  "); err != nil {
+			buf.Reset()
+			buf.Write(a.Predicate)
+		}
+		v.Printf("Predicate:\n%s\n", buf.String())
+	}
+
+	return nil
+}
+
+// JSON view implementation
+type attestationsJSONView struct {
+	*JSONView
+}
+
+func newAttestationsJSONView(jv *JSONView) *attestationsJSONView {
+	return &attestationsJSONView{JSONView: jv}
+}
+
+func (v *attestationsJSONView) Render(data *AttestationsData) error {
+	type jsonEnvelope struct {
+		PayloadType string   `json:"payload_type"`
+		Signatures  int      `json:"signatures"`
+		KeyIDs      []string `json:"key_ids,omitempty"`
+	}
+
+	type jsonStatement struct {
+		Type          string          `json:"_type"`
+		Subject       []string        `json:"subject"`
+		PredicateType string          `json:"predicate_type"`
+		Predicate     json.RawMessage `json:"predicate,omitempty"`
+	}
+
+	type jsonAttestation struct {
+		Source    string        `json:"source"`
+		Manifest  string        `json:"manifest"`
+		Subject   string        `json:"subject"`
+		Envelope  *jsonEnvelope `json:"envelope,omitempty"`
+		Statement jsonStatement `json:"statement"`
+	}
+
+	type jsonOutput struct {
+		Image        string            `json:"image"`
+		Digest       string            `json:"digest"`
+		Attestations []jsonAttestation `json:"attestations"`
+	}
+
+	attestations := make([]jsonAttestation, len(data.Attestations))
+	for i, a := range data.Attestations {
+		attestations[i] = jsonAttestation{
+			Source:   a.Source,
+			Manifest: a.Manifest,
+			Subject:  a.Subject,
+			Statement: jsonStatement{
+				Type:          a.StatementType,
+				Subject:       a.StatementSubjects,
+				PredicateType: a.PredicateType,
+				Predicate:     a.Predicate,
+			},
+		}
+		if a.PayloadType != "" {
+			attestations[i].Envelope = &jsonEnvelope{
+				PayloadType: a.PayloadType,
+				Signatures:  a.Signatures,
+				KeyIDs:      a.KeyIDs,
+			}
+		}
+	}
+
+	output := jsonOutput{
+		Image:        data.ImageRef,
+		Digest:       data.Digest,
+		Attestations: attestations,
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BaseMatch represents the detected base of an image.
+type BaseMatch struct {
+	// Names are the candidates matching the base, e.g. alpine:3.20 and
+	// alpine:3.20.3 for tags of the same image.
+	Names     []string
+	Digest    string
+	Layers    int
+	AppLayers int
+	// Annotated is true when the base image annotations name the base.
+	Annotated bool
+	// Current is the digest the annotated base tag points at now, empty
+	// unless the tag has moved since the build.
+	Current string
+	// Newer is a newer tag of the base, empty when there is none.
+	Newer string
+}
+
+// BaseData contains the result of base image detection to be rendered.
+type BaseData struct {
+	ImageRef         string
+	Digest           string
+	Layers           int
+	AnnotationName   string
+	AnnotationDigest string
+	Candidates       int
+	// Base is nil when no candidate matched.
+	Base *BaseMatch
+}
+
+type BaseView interface {
+	Render(data *BaseData) error
+}
+
+// Human view implementation
+type baseHumanView struct {
+	*HumanView
+}
+
+func newBaseHumanView(hv *HumanView) *baseHumanView {
+	return &baseHumanView{HumanView: hv}
+}
+
+func (v *baseHumanView) Render(data *BaseData) error {
+	v.Printf("Image: %s\n", data.ImageRef)
+	v.Printf("Digest: %s\n", data.Digest)
+	if data.AnnotationName != "" {
+		v.Printf("Annotated base: %s", data.AnnotationName)
+		if data.AnnotationDigest != "" {
+			v.Printf("@%s", data.AnnotationDigest)
+		}
+		v.Printf("\n")
+	}
+	v.Printf("\n")
+
+	b := data.Base
+	if b == nil {
+		v.Printf("No base found among %d candidates\n", data.Candidates)
+		return nil
+	}
+
+	v.Printf("Base: %s\n", strings.Join(b.Names, ", "))
+	v.Printf("  Digest: %s\n", b.Digest)
+	v.Printf("  Layers: %d from the base, %d app-specific\n", b.Layers, b.AppLayers)
+	source := "layers"
+	if b.Annotated {
+		source = "layers and annotations"
+	}
+	v.Printf("  Matched by: %s\n", source)
+	if b.Current != "" {
+		v.Printf("  Rebuild needed: %s now points at %s\n", data.AnnotationName, b.Current)
+	}
+	if b.Newer != "" {
+		v.Printf("  Newer tag: %s\n", b.Newer)
+	}
+
+	return nil
+}
+
+// JSON view implementation
+type baseJSONView struct {
+	*JSONView
+}
+
+func newBaseJSONView(jv *JSONView) *baseJSONView {
+	return &baseJSONView{JSONView: jv}
+}
+
+func (v *baseJSONView) Render(data *BaseData) error {
+	type jsonAnnotation struct {
+		Name   string `json:"name"`
+		Digest string `json:"digest,omitempty"`
+	}
+
+	type jsonBase struct {
+		Names         []string `json:"names"`
+		Digest        string   `json:"digest"`
+		Layers        int      `json:"layers"`
+		AppLayers     int      `json:"app_layers"`
+		Annotated     bool     `json:"annotated"`
+		Rebuild       bool     `json:"rebuild_needed"`
+		CurrentDigest string   `json:"current_digest,omitempty"`
+		Newer         string   `json:"newer_tag,omitempty"`
+	}
+
+	type jsonOutput struct {
+		Image      string          `json:"image"`
+		Digest     string          `json:"digest"`
+		Layers     int             `json:"layers"`
+		Annotation *jsonAnnotation `json:"annotation,omitempty"`
+		Candidates int             `json:"candidates"`
+		Base       *jsonBase       `json:"base"`
+	}
+
+	output := jsonOutput{
+		Image:      data.ImageRef,
+		Digest:     data.Digest,
+		Layers:     data.Layers,
+		Candidates: data.Candidates,
+	}
+	if data.AnnotationName != "" {
+		output.Annotation = &jsonAnnotation{Name: data.AnnotationName, Digest: data.AnnotationDigest}
+	}
+	if b := data.Base; b != nil {
+		output.Base = &jsonBase{
+			Names:         b.Names,
+			Digest:        b.Digest,
+			Layers:        b.Layers,
+			AppLayers:     b.AppLayers,
+			Annotated:     b.Annotated,
+			Rebuild:       b.Current != "",
+			CurrentDigest: b.Current,
+			Newer:         b.Newer,
+		}
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
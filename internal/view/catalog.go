@@ -0,0 +1,105 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+)
+
+// CatalogRepository represents a repository in a registry.
+type CatalogRepository struct {
+	Name string
+	// Tags is the number of tags, or -1 when they could not be listed.
+	Tags int
+}
+
+// CatalogData contains the repositories of a registry to be rendered.
+type CatalogData struct {
+	Registry     string
+	Repositories []CatalogRepository
+	// CountTags is set when tag counts were requested.
+	CountTags bool
+}
+
+type CatalogView interface {
+	Render(data *CatalogData) error
+}
+
+// Human view implementation
+type catalogHumanView struct {
+	*HumanView
+}
+
+func newCatalogHumanView(hv *HumanView) *catalogHumanView {
+	return &catalogHumanView{HumanView: hv}
+}
+
+func (v *catalogHumanView) Render(data *CatalogData) error {
+	if len(data.Repositories) == 0 {
+		v.Printf("No repositories found in %s\n", data.Registry)
+		return nil
+	}
+
+	if !data.CountTags {
+		for _, repo := range data.Repositories {
+			v.Printf("%s\n", repo.Name)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Repository\tTags\n")
+	for _, repo := range data.Repositories {
+		tags := "-"
+		if repo.Tags >= 0 {
+			tags = fmt.Sprintf("%d", repo.Tags)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", repo.Name, tags)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	return nil
+}
+
+// JSON view implementation
+type catalogJSONView struct {
+	*JSONView
+}
+
+func newCatalogJSONView(jv *JSONView) *catalogJSONView {
+	return &catalogJSONView{JSONView: jv}
+}
+
+func (v *catalogJSONView) Render(data *CatalogData) error {
+	type jsonRepository struct {
+		Name string `json:"name"`
+		Tags *int   `json:"tags,omitempty"`
+	}
+
+	type jsonOutput struct {
+		Registry     string           `json:"registry"`
+		Repositories []jsonRepository `json:"repositories"`
+	}
+
+	output := jsonOutput{
+		Registry:     data.Registry,
+		Repositories: make([]jsonRepository, len(data.Repositories)),
+	}
+	for i, repo := range data.Repositories {
+		output.Repositories[i] = jsonRepository{Name: repo.Name}
+		if data.CountTags && repo.Tags >= 0 {
+			tags := repo.Tags
+			output.Repositories[i].Tags = &tags
+		}
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+)
+
+// CopyData contains the result of a copy to be rendered.
+type CopyData struct {
+	Source      string
+	Destination string
+	Digest      string
+	MediaType   string
+	Platforms   []string
+	Blobs       int
+	Size        int64
+}
+
+type CopyView interface {
+	Render(data *CopyData) error
+}
+
+// Human view implementation
+type copyHumanView struct {
+	*HumanView
+}
+
+func newCopyHumanView(hv *HumanView) *copyHumanView {
+	return &copyHumanView{HumanView: hv}
+}
+
+func (v *copyHumanView) Render(data *CopyData) error {
+	v.Printf("Copied %s to %s\n", data.Source, data.Destination)
+	v.Printf("  Digest:     %s\n", data.Digest)
+	v.Printf("  Media Type: %s\n", data.MediaType)
+	if len(data.Platforms) > 0 {
+		v.Printf("  Platforms:  %s\n", strings.Join(data.Platforms, ", "))
+	}
+	v.Printf("  Blobs:      %d (%s)\n", data.Blobs, oci.FormatBytes(data.Size))
+	return nil
+}
+
+// JSON view implementation
+type copyJSONView struct {
+	*JSONView
+}
+
+func newCopyJSONView(jv *JSONView) *copyJSONView {
+	return &copyJSONView{JSONView: jv}
+}
+
+func (v *copyJSONView) Render(data *CopyData) error {
+	type jsonOutput struct {
+		Source      string   `json:"source"`
+		Destination string   `json:"destination"`
+		Digest      string   `json:"digest"`
+		MediaType   string   `json:"media_type"`
+		Platforms   []string `json:"platforms,omitempty"`
+		Blobs       int      `json:"blobs"`
+		Size        int64    `json:"size"`
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(jsonOutput(*data)); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
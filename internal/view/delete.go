@@ -0,0 +1,127 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Deletion represents a tag or manifest removed from a registry.
+type Deletion struct {
+	// Ref is the deleted reference, a tag or a digest.
+	Ref    string
+	Digest string
+	// Tags lists the tags removed along with a manifest deleted by digest.
+	Tags    []string
+	Created time.Time
+}
+
+// DeleteData contains the deletions to be rendered.
+type DeleteData struct {
+	Repository string
+	DryRun     bool
+	Deletions  []Deletion
+	// Kept is the number of tags a prune left in place.
+	Kept int
+}
+
+type DeleteView interface {
+	Render(data *DeleteData) error
+}
+
+// Human view implementation
+type deleteHumanView struct {
+	*HumanView
+}
+
+func newDeleteHumanView(hv *HumanView) *deleteHumanView {
+	return &deleteHumanView{HumanView: hv}
+}
+
+func (v *deleteHumanView) Render(data *DeleteData) error {
+	if len(data.Deletions) == 0 {
+		v.Printf("Nothing to delete in %s (%d tags kept)\n", data.Repository, data.Kept)
+		return nil
+	}
+
+	if data.DryRun {
+		v.Printf("Would delete from %s (dry run):\n\n", data.Repository)
+	} else {
+		v.Printf("Deleted from %s:\n\n", data.Repository)
+	}
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Reference\tDigest\tTags\tCreated\n")
+	for _, d := range data.Deletions {
+		tags := "-"
+		if len(d.Tags) > 0 {
+			tags = strings.Join(d.Tags, ", ")
+		}
+		created := "-"
+		if !d.Created.IsZero() {
+			created = d.Created.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Ref, shortDigest(d.Digest), tags, created)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	if data.Kept > 0 {
+		v.Printf("\n%d tags kept\n", data.Kept)
+	}
+
+	return nil
+}
+
+// JSON view implementation
+type deleteJSONView struct {
+	*JSONView
+}
+
+func newDeleteJSONView(jv *JSONView) *deleteJSONView {
+	return &deleteJSONView{JSONView: jv}
+}
+
+func (v *deleteJSONView) Render(data *DeleteData) error {
+	type jsonDeletion struct {
+		Ref     string   `json:"ref"`
+		Digest  string   `json:"digest"`
+		Tags    []string `json:"tags,omitempty"`
+		Created string   `json:"created,omitempty"`
+	}
+
+	type jsonOutput struct {
+		Repository string         `json:"repository"`
+		DryRun     bool           `json:"dry_run"`
+		Deleted    []jsonDeletion `json:"deleted"`
+		Kept       int            `json:"kept"`
+	}
+
+	output := jsonOutput{
+		Repository: data.Repository,
+		DryRun:     data.DryRun,
+		Deleted:    make([]jsonDeletion, len(data.Deletions)),
+		Kept:       data.Kept,
+	}
+	for i, d := range data.Deletions {
+		output.Deleted[i] = jsonDeletion{
+			Ref:    d.Ref,
+			Digest: d.Digest,
+			Tags:   d.Tags,
+		}
+		if !d.Created.IsZero() {
+			output.Deleted[i].Created = d.Created.Format(time.RFC3339)
+		}
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+)
+
+// DiffImage identifies one side of a diff.
+type DiffImage struct {
+	ImageRef string
+	Digest   string
+	Platform string
+	// OS is the detected distro and version, empty when detection was
+	// skipped.
+	OS string
+}
+
+// DiffChange represents a change to one config field or entry.
+type DiffChange struct {
+	Type  string
+	Field string
+	Key   string
+	Old   string
+	New   string
+}
+
+// DiffLayer compares the layers at one position.
+type DiffLayer struct {
+	Index  int
+	Status string
+	Old    string
+	New    string
+}
+
+// DiffData contains the comparison of two images to be rendered.
+type DiffData struct {
+	Old DiffImage
+	New DiffImage
+	// Summary is a one-line description of the changes for release notes.
+	Summary      string
+	Changes      []DiffChange
+	Layers       []DiffLayer
+	SharedLayers int
+}
+
+type DiffView interface {
+	Render(data *DiffData) error
+}
+
+// Human view implementation
+type diffHumanView struct {
+	*HumanView
+}
+
+func newDiffHumanView(hv *HumanView) *diffHumanView {
+	return &diffHumanView{HumanView: hv}
+}
+
+func (v *diffHumanView) Render(data *DiffData) error {
+	v.Printf("%s → %s: %s\n", data.Old.ImageRef, data.New.ImageRef, data.Summary)
+	v.Printf("\n")
+
+	if len(data.Changes) > 0 {
+		v.Printf("Config:\n")
+		w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+		for _, c := range data.Changes {
+			field := c.Field
+			if c.Key != "" {
+				field += " " + c.Key
+			}
+			var value string
+			switch c.Type {
+			case "added":
+				value = c.New
+			case "removed":
+				value = c.Old
+			default:
+				value = c.Old + " → " + c.New
+			}
+			_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\n", field, c.Type, value)
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("failed to flush output: %w", err)
+		}
+		v.Printf("\n")
+	}
+
+	v.Printf("Layers (%d shared):\n", data.SharedLayers)
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "#\tStatus\tOld\tNew\n")
+	for _, l := range data.Layers {
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", l.Index, l.Status, dash(shortDigest(l.Old)), dash(shortDigest(l.New)))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	return nil
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// JSON view implementation
+type diffJSONView struct {
+	*JSONView
+}
+
+func newDiffJSONView(jv *JSONView) *diffJSONView {
+	return &diffJSONView{JSONView: jv}
+}
+
+func (v *diffJSONView) Render(data *DiffData) error {
+	type jsonImage struct {
+		Image    string `json:"image"`
+		Digest   string `json:"digest"`
+		Platform string `json:"platform"`
+		OS       string `json:"os,omitempty"`
+	}
+
+	type jsonChange struct {
+		Type  string `json:"type"`
+		Field string `json:"field"`
+		Key   string `json:"key,omitempty"`
+		Old   string `json:"old,omitempty"`
+		New   string `json:"new,omitempty"`
+	}
+
+	type jsonLayer struct {
+		Index  int    `json:"index"`
+		Status string `json:"status"`
+		Old    string `json:"old,omitempty"`
+		New    string `json:"new,omitempty"`
+	}
+
+	type jsonOutput struct {
+		Old          jsonImage    `json:"old"`
+		New          jsonImage    `json:"new"`
+		Summary      string       `json:"summary"`
+		Changes      []jsonChange `json:"changes"`
+		Layers       []jsonLayer  `json:"layers"`
+		SharedLayers int          `json:"shared_layers"`
+	}
+
+	image := func(i DiffImage) jsonImage {
+		return jsonImage{Image: i.ImageRef, Digest: i.Digest, Platform: i.Platform, OS: i.OS}
+	}
+	output := jsonOutput{
+		Old:          image(data.Old),
+		New:          image(data.New),
+		Summary:      data.Summary,
+		Changes:      make([]jsonChange, len(data.Changes)),
+		Layers:       make([]jsonLayer, len(data.Layers)),
+		SharedLayers: data.SharedLayers,
+	}
+	for i, c := range data.Changes {
+		output.Changes[i] = jsonChange(c)
+	}
+	for i, l := range data.Layers {
+		output.Layers[i] = jsonLayer(l)
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
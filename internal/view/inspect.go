@@ -20,6 +20,22 @@ type InspectData struct {
 	Architecture string
 	TotalSize    int64
 	Layers       []LayerData
+	// OSInfo is nil when operating system detection was skipped.
+	OSInfo *OSData
+}
+
+// OSData describes the operating system detected in an image.
+type OSData struct {
+	Distro     string
+	Name       string
+	PrettyName string
+	Version    string
+	Codename   string
+	Distroless bool
+	Static     bool
+	// EOL is the zero time when the release is not in the lifecycle table.
+	EOL       time.Time
+	EOLStatus string
 }
 
 // LayerData contains information about a single layer.
@@ -50,6 +66,12 @@ func (v *inspectHumanView) Render(data *InspectData) error {
 	v.Printf("OS/Arch: %s/%s\n", data.OS, data.Architecture)
 	v.Printf("Size: %s\n", oci.FormatBytes(data.TotalSize))
 	v.Printf("\n")
+
+	if data.OSInfo != nil {
+		v.renderOS(data.OSInfo)
+		v.Printf("\n")
+	}
+
 	v.Printf("Layers:\n")
 
 	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
@@ -66,6 +88,35 @@ func (v *inspectHumanView) Render(data *InspectData) error {
 	return nil
 }
 
+func (v *inspectHumanView) renderOS(os *OSData) {
+	v.Printf("Operating System:\n")
+	if os.Distro == "scratch" {
+		v.Printf("  Distro: scratch (no OS release files found)\n")
+	} else {
+		if os.PrettyName != "" {
+			v.Printf("  Name: %s\n", os.PrettyName)
+		}
+		v.Printf("  Distro: %s\n", os.Distro)
+		if os.Version != "" {
+			v.Printf("  Version: %s\n", os.Version)
+		}
+		if os.Codename != "" {
+			v.Printf("  Codename: %s\n", os.Codename)
+		}
+		if os.Distroless {
+			v.Printf("  Distroless: yes\n")
+		}
+	}
+	if os.Static {
+		v.Printf("  Static: yes (no dynamic loader)\n")
+	}
+	if os.EOL.IsZero() {
+		v.Printf("  EOL: %s\n", os.EOLStatus)
+	} else {
+		v.Printf("  EOL: %s (%s)\n", os.EOL.Format(time.DateOnly), os.EOLStatus)
+	}
+}
+
 // JSON view implementation
 type inspectJSONView struct {
 	*JSONView
@@ -82,6 +133,18 @@ func (v *inspectJSONView) Render(data *InspectData) error {
 		Size   int64  `json:"size"`
 	}
 
+	type jsonOSInfo struct {
+		Distro     string `json:"distro"`
+		Name       string `json:"name,omitempty"`
+		PrettyName string `json:"pretty_name,omitempty"`
+		Version    string `json:"version,omitempty"`
+		Codename   string `json:"codename,omitempty"`
+		Distroless bool   `json:"distroless"`
+		Static     bool   `json:"static"`
+		EOL        string `json:"eol,omitempty"`
+		EOLStatus  string `json:"eol_status"`
+	}
+
 	type jsonOutput struct {
 		Image    string      `json:"image"`
 		Registry string      `json:"registry"`
@@ -91,6 +154,7 @@ func (v *inspectJSONView) Render(data *InspectData) error {
 		Arch     string      `json:"arch"`
 		Size     int64       `json:"size"`
 		Layers   []jsonLayer `json:"layers"`
+		OSInfo   *jsonOSInfo `json:"os_info,omitempty"`
 	}
 
 	layers := make([]jsonLayer, len(data.Layers))
@@ -113,6 +177,22 @@ func (v *inspectJSONView) Render(data *InspectData) error {
 		Layers:   layers,
 	}
 
+	if data.OSInfo != nil {
+		output.OSInfo = &jsonOSInfo{
+			Distro:     data.OSInfo.Distro,
+			Name:       data.OSInfo.Name,
+			PrettyName: data.OSInfo.PrettyName,
+			Version:    data.OSInfo.Version,
+			Codename:   data.OSInfo.Codename,
+			Distroless: data.OSInfo.Distroless,
+			Static:     data.OSInfo.Static,
+			EOLStatus:  data.OSInfo.EOLStatus,
+		}
+		if !data.OSInfo.EOL.IsZero() {
+			output.OSInfo.EOL = data.OSInfo.EOL.Format(time.DateOnly)
+		}
+	}
+
 	encoder := json.NewEncoder(v.Writer)
 	encoder.SetIndent("", "  ")
 	if err := encoder.Encode(output); err != nil {
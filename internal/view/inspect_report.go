@@ -0,0 +1,243 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+)
+
+// InspectReportImage is one image referenced from the scanned manifests.
+type InspectReportImage struct {
+	ImageRef string
+	// Sources lists where the image is referenced, e.g. "deploy/web.yaml:21".
+	Sources []string
+	// Inspect is nil when the image could not be inspected.
+	Inspect *InspectData
+	// Lint is nil when linting was not requested or failed.
+	Lint  []LintFinding
+	Error string
+}
+
+// InspectReportData contains the aggregated report of inspecting every
+// image referenced in a set of manifests.
+type InspectReportData struct {
+	Paths  []string
+	Linted bool
+	Images []InspectReportImage
+}
+
+// lintCounts returns the number of findings per severity.
+func lintCounts(findings []LintFinding) map[string]int {
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	return counts
+}
+
+type InspectReportView interface {
+	Render(data *InspectReportData) error
+}
+
+// Human view implementation
+type inspectReportHumanView struct {
+	*HumanView
+}
+
+func newInspectReportHumanView(hv *HumanView) *inspectReportHumanView {
+	return &inspectReportHumanView{HumanView: hv}
+}
+
+func (v *inspectReportHumanView) Render(data *InspectReportData) error {
+	if len(data.Images) == 0 {
+		v.Printf("No image references found in %s\n", strings.Join(data.Paths, ", "))
+		return nil
+	}
+
+	v.Printf("Found %d images in %s\n\n", len(data.Images), strings.Join(data.Paths, ", "))
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	header := "Image\tDigest\tOS/Arch\tSize\tCreated\tOS\tEOL"
+	if data.Linted {
+		header += "\tLint"
+	}
+	_, _ = fmt.Fprintln(w, header+"\tSources")
+
+	var totalSize int64
+	failed := 0
+	for _, img := range data.Images {
+		sources := img.Sources[0]
+		if len(img.Sources) > 1 {
+			sources += fmt.Sprintf(" (+%d)", len(img.Sources)-1)
+		}
+
+		if img.Inspect == nil {
+			failed++
+			row := img.ImageRef + "\t-\t-\t-\t-\t-\t-"
+			if data.Linted {
+				row += "\t-"
+			}
+			_, _ = fmt.Fprintln(w, row+"\t"+sources)
+			continue
+		}
+
+		d := img.Inspect
+		totalSize += d.TotalSize
+		osName, eol := "-", "-"
+		if d.OSInfo != nil {
+			osName = d.OSInfo.Distro
+			if d.OSInfo.Version != "" {
+				osName += " " + d.OSInfo.Version
+			}
+			eol = d.OSInfo.EOLStatus
+		}
+		row := fmt.Sprintf("%s\t%s\t%s/%s\t%s\t%s\t%s\t%s",
+			img.ImageRef, shortDigest(d.Digest.String()), d.OS, d.Architecture,
+			oci.FormatBytes(d.TotalSize), d.Created.Format(time.DateOnly), osName, eol)
+		if data.Linted {
+			row += "\t" + lintSummary(img.Lint)
+		}
+		_, _ = fmt.Fprintln(w, row+"\t"+sources)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	if failed > 0 {
+		v.Printf("\nErrors:\n")
+		for _, img := range data.Images {
+			if img.Error != "" {
+				v.Printf("  %s: %s\n", img.ImageRef, img.Error)
+			}
+		}
+	}
+
+	v.Printf("\n%d images, %d failed, %s in total\n", len(data.Images), failed, oci.FormatBytes(totalSize))
+	return nil
+}
+
+func lintSummary(findings []LintFinding) string {
+	if len(findings) == 0 {
+		return "ok"
+	}
+	counts := lintCounts(findings)
+	var parts []string
+	for _, severity := range []string{"error", "warning", "note"} {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// JSON view implementation
+type inspectReportJSONView struct {
+	*JSONView
+}
+
+func newInspectReportJSONView(jv *JSONView) *inspectReportJSONView {
+	return &inspectReportJSONView{JSONView: jv}
+}
+
+func (v *inspectReportJSONView) Render(data *InspectReportData) error {
+	type jsonFinding struct {
+		RuleID   string `json:"rule_id"`
+		Severity string `json:"severity"`
+		Message  string `json:"message"`
+		Path     string `json:"path,omitempty"`
+	}
+
+	type jsonOSInfo struct {
+		Distro    string `json:"distro"`
+		Version   string `json:"version,omitempty"`
+		EOL       string `json:"eol,omitempty"`
+		EOLStatus string `json:"eol_status"`
+	}
+
+	type jsonImage struct {
+		Image   string        `json:"image"`
+		Sources []string      `json:"sources"`
+		Digest  string        `json:"digest,omitempty"`
+		Created string        `json:"created,omitempty"`
+		OS      string        `json:"os,omitempty"`
+		Arch    string        `json:"arch,omitempty"`
+		Size    int64         `json:"size,omitempty"`
+		Layers  int           `json:"layers,omitempty"`
+		OSInfo  *jsonOSInfo   `json:"os_info,omitempty"`
+		Lint    []jsonFinding `json:"lint,omitempty"`
+		Error   string        `json:"error,omitempty"`
+	}
+
+	type jsonSummary struct {
+		Images   int            `json:"images"`
+		Failed   int            `json:"failed"`
+		Size     int64          `json:"size"`
+		Findings map[string]int `json:"findings,omitempty"`
+	}
+
+	type jsonOutput struct {
+		Paths   []string    `json:"paths"`
+		Images  []jsonImage `json:"images"`
+		Summary jsonSummary `json:"summary"`
+	}
+
+	output := jsonOutput{
+		Paths:   data.Paths,
+		Images:  make([]jsonImage, len(data.Images)),
+		Summary: jsonSummary{Images: len(data.Images)},
+	}
+	var findings []LintFinding
+	for i, img := range data.Images {
+		out := jsonImage{
+			Image:   img.ImageRef,
+			Sources: img.Sources,
+			Error:   img.Error,
+		}
+		if d := img.Inspect; d != nil {
+			out.Digest = d.Digest.String()
+			out.Created = d.Created.Format(time.RFC3339)
+			out.OS = d.OS
+			out.Arch = d.Architecture
+			out.Size = d.TotalSize
+			out.Layers = len(d.Layers)
+			if d.OSInfo != nil {
+				out.OSInfo = &jsonOSInfo{
+					Distro:    d.OSInfo.Distro,
+					Version:   d.OSInfo.Version,
+					EOLStatus: d.OSInfo.EOLStatus,
+				}
+				if !d.OSInfo.EOL.IsZero() {
+					out.OSInfo.EOL = d.OSInfo.EOL.Format(time.DateOnly)
+				}
+			}
+			output.Summary.Size += d.TotalSize
+		} else {
+			output.Summary.Failed++
+		}
+		for _, f := range img.Lint {
+			out.Lint = append(out.Lint, jsonFinding{
+				RuleID:   f.RuleID,
+				Severity: f.Severity,
+				Message:  f.Message,
+				Path:     f.Path,
+			})
+		}
+		findings = append(findings, img.Lint...)
+		output.Images[i] = out
+	}
+	if data.Linted {
+		output.Summary.Findings = lintCounts(findings)
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
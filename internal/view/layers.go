@@ -0,0 +1,171 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+)
+
+// LayerShareImage describes how much of an image is shared.
+type LayerShareImage struct {
+	Image  string
+	Layers int
+	Size   int64
+	Shared int64
+	Unique int64
+}
+
+// SharedLayer represents a layer used by several images.
+type SharedLayer struct {
+	Digest string
+	Size   int64
+	Images []string
+}
+
+// LayerShareBase represents a suggested common base.
+type LayerShareBase struct {
+	From   string
+	Layers int
+	Size   int64
+	Images []string
+	Others []string
+}
+
+// LayerShareData contains a layer sharing analysis to be rendered.
+type LayerShareData struct {
+	Images    []LayerShareImage
+	Shared    []SharedLayer
+	PullSize  int64
+	TotalSize int64
+	// Base is nil when no two images start with the same layer.
+	Base *LayerShareBase
+}
+
+type LayerShareView interface {
+	Render(data *LayerShareData) error
+}
+
+// Human view implementation
+type layerShareHumanView struct {
+	*HumanView
+}
+
+func newLayerShareHumanView(hv *HumanView) *layerShareHumanView {
+	return &layerShareHumanView{HumanView: hv}
+}
+
+func (v *layerShareHumanView) Render(data *LayerShareData) error {
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Image\tLayers\tSize\tShared\tUnique\n")
+	for _, img := range data.Images {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", img.Image, img.Layers,
+			oci.FormatBytes(img.Size), oci.FormatBytes(img.Shared), oci.FormatBytes(img.Unique))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	if len(data.Shared) > 0 {
+		v.Printf("\nShared layers:\n")
+		w = tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Digest\tSize\tImages\n")
+		for _, l := range data.Shared {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%d\n", shortDigest(l.Digest), oci.FormatBytes(l.Size), len(l.Images))
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("failed to flush output: %w", err)
+		}
+	}
+
+	v.Printf("\nPulling all %d images: %s", len(data.Images), oci.FormatBytes(data.PullSize))
+	if saved := data.TotalSize - data.PullSize; saved > 0 {
+		v.Printf(" (%s without layer sharing, %.0f%% saved)", oci.FormatBytes(data.TotalSize),
+			float64(saved)/float64(data.TotalSize)*100)
+	}
+	v.Printf("\n")
+
+	if b := data.Base; b != nil {
+		layers := "the first layer"
+		if b.Layers > 1 {
+			layers = fmt.Sprintf("the first %d layers", b.Layers)
+		}
+		v.Printf("\nSuggested base: %s of %s (%s), used by %d of %d images\n",
+			layers, b.From, oci.FormatBytes(b.Size), len(b.Images), len(data.Images))
+		for _, other := range b.Others {
+			v.Printf("  not on this base: %s\n", other)
+		}
+	}
+
+	return nil
+}
+
+// JSON view implementation
+type layerShareJSONView struct {
+	*JSONView
+}
+
+func newLayerShareJSONView(jv *JSONView) *layerShareJSONView {
+	return &layerShareJSONView{JSONView: jv}
+}
+
+func (v *layerShareJSONView) Render(data *LayerShareData) error {
+	type jsonImage struct {
+		Image  string `json:"image"`
+		Layers int    `json:"layers"`
+		Size   int64  `json:"size"`
+		Shared int64  `json:"shared"`
+		Unique int64  `json:"unique"`
+	}
+
+	type jsonLayer struct {
+		Digest string   `json:"digest"`
+		Size   int64    `json:"size"`
+		Images []string `json:"images"`
+	}
+
+	type jsonBase struct {
+		From   string   `json:"from"`
+		Layers int      `json:"layers"`
+		Size   int64    `json:"size"`
+		Images []string `json:"images"`
+		Others []string `json:"others"`
+	}
+
+	type jsonOutput struct {
+		Images    []jsonImage `json:"images"`
+		Shared    []jsonLayer `json:"shared_layers"`
+		PullSize  int64       `json:"pull_size"`
+		TotalSize int64       `json:"total_size"`
+		Base      *jsonBase   `json:"suggested_base"`
+	}
+
+	output := jsonOutput{
+		Images:    make([]jsonImage, len(data.Images)),
+		Shared:    make([]jsonLayer, len(data.Shared)),
+		PullSize:  data.PullSize,
+		TotalSize: data.TotalSize,
+	}
+	for i, img := range data.Images {
+		output.Images[i] = jsonImage(img)
+	}
+	for i, l := range data.Shared {
+		output.Shared[i] = jsonLayer(l)
+	}
+	if b := data.Base; b != nil {
+		others := b.Others
+		if others == nil {
+			others = []string{}
+		}
+		output.Base = &jsonBase{From: b.From, Layers: b.Layers, Size: b.Size, Images: b.Images, Others: others}
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/bschaatsbergen/cek/version"
+)
+
+// LintRule describes a rule that was evaluated.
+type LintRule struct {
+	ID          string
+	Description string
+	Severity    string
+}
+
+// LintFinding represents a single rule violation.
+type LintFinding struct {
+	RuleID   string
+	Severity string
+	Message  string
+	Path     string
+	Layer    int
+	// Line is the line of the Dockerfile instruction the finding comes
+	// from, or 0 when it is not known.
+	Line int
+	// Fingerprint identifies the finding across runs in SARIF output.
+	Fingerprint string
+}
+
+// LintData contains the lint results to be rendered.
+type LintData struct {
+	ImageRef string
+	// Artifact is the source file findings are attributed to in SARIF
+	// output, usually the Dockerfile the image was built from.
+	Artifact string
+	Rules    []LintRule
+	Findings []LintFinding
+}
+
+type LintView interface {
+	Render(data *LintData) error
+}
+
+// Human view implementation
+type lintHumanView struct {
+	*HumanView
+}
+
+func newLintHumanView(hv *HumanView) *lintHumanView {
+	return &lintHumanView{HumanView: hv}
+}
+
+func (v *lintHumanView) Render(data *LintData) error {
+	if len(data.Findings) == 0 {
+		v.Printf("No issues found in %s (%d rules checked)\n", data.ImageRef, len(data.Rules))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Severity\tRule\tLayer\tMessage\n")
+
+	counts := make(map[string]int)
+	for _, f := range data.Findings {
+		layer := "-"
+		if f.Layer > 0 {
+			layer = fmt.Sprintf("%d", f.Layer)
+		}
+		counts[f.Severity]++
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Severity, f.RuleID, layer, f.Message)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	v.Printf("\n%d issues (%d errors, %d warnings, %d notes)\n",
+		len(data.Findings), counts["error"], counts["warning"], counts["note"])
+
+	return nil
+}
+
+// JSON view implementation
+type lintJSONView struct {
+	*JSONView
+}
+
+func newLintJSONView(jv *JSONView) *lintJSONView {
+	return &lintJSONView{JSONView: jv}
+}
+
+func (v *lintJSONView) Render(data *LintData) error {
+	type jsonFinding struct {
+		RuleID   string `json:"rule_id"`
+		Severity string `json:"severity"`
+		Message  string `json:"message"`
+		Path     string `json:"path,omitempty"`
+		Layer    int    `json:"layer,omitempty"`
+	}
+
+	type jsonOutput struct {
+		Image    string        `json:"image"`
+		Rules    []string      `json:"rules"`
+		Findings []jsonFinding `json:"findings"`
+	}
+
+	rules := make([]string, len(data.Rules))
+	for i, r := range data.Rules {
+		rules[i] = r.ID
+	}
+
+	findings := make([]jsonFinding, len(data.Findings))
+	for i, f := range data.Findings {
+		findings[i] = jsonFinding{
+			RuleID:   f.RuleID,
+			Severity: f.Severity,
+			Message:  f.Message,
+			Path:     f.Path,
+			Layer:    f.Layer,
+		}
+	}
+
+	output := jsonOutput{
+		Image:    data.ImageRef,
+		Rules:    rules,
+		Findings: findings,
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
+
+// SARIF view implementation. SARIF is independent of the --json flag, so it
+// is not part of the Viewer interface.
+type LintSARIFView struct {
+	*Stream
+}
+
+func NewLintSARIFView(s *Stream) *LintSARIFView {
+	return &LintSARIFView{Stream: s}
+}
+
+// Render writes a SARIF 2.1.0 log with a single run.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+func (v *LintSARIFView) Render(data *LintData) error {
+	type sarifText struct {
+		Text string `json:"text"`
+	}
+
+	type sarifRule struct {
+		ID                   string    `json:"id"`
+		ShortDescription     sarifText `json:"shortDescription"`
+		DefaultConfiguration struct {
+			Level string `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+
+	type sarifLocation struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region struct {
+				StartLine int `json:"startLine"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	}
+
+	type sarifResult struct {
+		RuleID              string            `json:"ruleId"`
+		RuleIndex           int               `json:"ruleIndex"`
+		Level               string            `json:"level"`
+		Message             sarifText         `json:"message"`
+		Locations           []sarifLocation   `json:"locations"`
+		PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+		Properties          map[string]any    `json:"properties,omitempty"`
+	}
+
+	type sarifRun struct {
+		Tool struct {
+			Driver struct {
+				Name           string      `json:"name"`
+				Version        string      `json:"version"`
+				InformationURI string      `json:"informationUri"`
+				Rules          []sarifRule `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+
+	type sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	var run sarifRun
+	run.Tool.Driver.Name = "cek"
+	run.Tool.Driver.Version = version.Version
+	run.Tool.Driver.InformationURI = "https://github.com/bschaatsbergen/cek"
+	run.Tool.Driver.Rules = make([]sarifRule, len(data.Rules))
+
+	ruleIndex := make(map[string]int)
+	for i, r := range data.Rules {
+		rule := &run.Tool.Driver.Rules[i]
+		rule.ID = r.ID
+		rule.ShortDescription.Text = r.Description
+		rule.DefaultConfiguration.Level = r.Severity
+		ruleIndex[r.ID] = i
+	}
+
+	artifact := data.Artifact
+	if artifact == "" {
+		artifact = "Dockerfile"
+	}
+
+	run.Results = make([]sarifResult, len(data.Findings))
+	for i, f := range data.Findings {
+		// Code scanning requires a location, so findings are attributed to
+		// the Dockerfile, at the first line when the instruction they come
+		// from is not known, and the image details go into properties.
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = artifact
+		loc.PhysicalLocation.Region.StartLine = max(f.Line, 1)
+
+		properties := map[string]any{"image": data.ImageRef}
+		if f.Path != "" {
+			properties["path"] = f.Path
+		}
+		if f.Layer > 0 {
+			properties["layer"] = f.Layer
+		}
+
+		run.Results[i] = sarifResult{
+			RuleID:     f.RuleID,
+			RuleIndex:  ruleIndex[f.RuleID],
+			Level:      f.Severity,
+			Message:    sarifText{Text: f.Message},
+			Locations:  []sarifLocation{loc},
+			Properties: properties,
+		}
+		if f.Fingerprint != "" {
+			run.Results[i].PartialFingerprints = map[string]string{"cekFindingHash/v1": f.Fingerprint}
+		}
+	}
+
+	output := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode SARIF: %w", err)
+	}
+
+	return nil
+}
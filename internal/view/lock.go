@@ -0,0 +1,142 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// Lock modes.
+const (
+	LockModeLockfile = "lockfile"
+	LockModeWrite    = "write"
+	LockModeCheck    = "check"
+)
+
+// LockImage represents an image reference and its locked digest.
+type LockImage struct {
+	Image  string
+	Status string
+	Digest string
+	// Previous is the digest that was locked or pinned before, for updated
+	// and drifted images.
+	Previous  string
+	Platforms map[string]string
+	Sources   []string
+}
+
+// LockData contains the result of a lock run to be rendered.
+type LockData struct {
+	Mode     string
+	Lockfile string
+	Images   []LockImage
+	// Pinned counts the references rewritten in place.
+	Pinned int
+}
+
+type LockView interface {
+	Render(data *LockData) error
+}
+
+// Human view implementation
+type lockHumanView struct {
+	*HumanView
+}
+
+func newLockHumanView(hv *HumanView) *lockHumanView {
+	return &lockHumanView{HumanView: hv}
+}
+
+func (v *lockHumanView) Render(data *LockData) error {
+	if len(data.Images) == 0 {
+		v.Printf("No image references found\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Image\tStatus\tDigest\tSources\n")
+	for _, img := range data.Images {
+		digest := shortDigest(img.Digest)
+		if img.Previous != "" {
+			digest = shortDigest(img.Previous) + " -> " + digest
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", img.Image, img.Status, digest, strings.Join(img.Sources, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	counts := make(map[string]int)
+	for _, img := range data.Images {
+		counts[img.Status]++
+	}
+	v.Printf("\n")
+	switch data.Mode {
+	case LockModeCheck:
+		if counts["ok"] == len(data.Images) {
+			v.Printf("All %d images match their locked digests\n", len(data.Images))
+		} else {
+			v.Printf("%d drifted, %d unlocked, %d ok\n", counts["drifted"], counts["unlocked"], counts["ok"])
+		}
+	case LockModeWrite:
+		v.Printf("Pinned %d references\n", data.Pinned)
+	default:
+		v.Printf("Wrote %s: %d added, %d updated, %d unchanged, %d removed\n",
+			data.Lockfile, counts["added"], counts["updated"], counts["unchanged"], counts["removed"])
+	}
+
+	return nil
+}
+
+// JSON view implementation
+type lockJSONView struct {
+	*JSONView
+}
+
+func newLockJSONView(jv *JSONView) *lockJSONView {
+	return &lockJSONView{JSONView: jv}
+}
+
+func (v *lockJSONView) Render(data *LockData) error {
+	type jsonImage struct {
+		Image     string            `json:"image"`
+		Status    string            `json:"status"`
+		Digest    string            `json:"digest"`
+		Previous  string            `json:"previous_digest,omitempty"`
+		Platforms map[string]string `json:"platforms,omitempty"`
+		Sources   []string          `json:"sources"`
+	}
+
+	type jsonOutput struct {
+		Mode     string      `json:"mode"`
+		Lockfile string      `json:"lockfile,omitempty"`
+		Images   []jsonImage `json:"images"`
+		Pinned   int         `json:"pinned,omitempty"`
+	}
+
+	output := jsonOutput{
+		Mode:     data.Mode,
+		Lockfile: data.Lockfile,
+		Images:   make([]jsonImage, len(data.Images)),
+		Pinned:   data.Pinned,
+	}
+	for i, img := range data.Images {
+		output.Images[i] = jsonImage{
+			Image:     img.Image,
+			Status:    img.Status,
+			Digest:    img.Digest,
+			Previous:  img.Previous,
+			Platforms: img.Platforms,
+			Sources:   img.Sources,
+		}
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+)
+
+// MirrorStep represents the action taken for one tag.
+type MirrorStep struct {
+	Tag    string
+	Action string
+	Digest string
+}
+
+// MirrorData contains the result of a mirror run to be rendered.
+type MirrorData struct {
+	Source      string
+	Destination string
+	DryRun      bool
+	// Incomplete is set when the mirror stopped at a failed tag, so Steps
+	// only holds the tags before it.
+	Incomplete bool
+	Steps      []MirrorStep
+}
+
+// counts returns the number of steps per action.
+func (d *MirrorData) counts() map[string]int {
+	counts := make(map[string]int)
+	for _, s := range d.Steps {
+		counts[s.Action]++
+	}
+	return counts
+}
+
+type MirrorView interface {
+	Render(data *MirrorData) error
+}
+
+// Human view implementation
+type mirrorHumanView struct {
+	*HumanView
+}
+
+func newMirrorHumanView(hv *HumanView) *mirrorHumanView {
+	return &mirrorHumanView{HumanView: hv}
+}
+
+func (v *mirrorHumanView) Render(data *MirrorData) error {
+	if data.DryRun {
+		v.Printf("Dry run: %s -> %s (no changes made)\n\n", data.Source, data.Destination)
+	} else {
+		v.Printf("Mirror: %s -> %s\n\n", data.Source, data.Destination)
+	}
+
+	if len(data.Steps) == 0 {
+		v.Printf("No matching tags\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Tag\tAction\tDigest\n")
+	for _, s := range data.Steps {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", s.Tag, s.Action, shortDigest(s.Digest))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	counts := data.counts()
+	v.Printf("\n%d copied, %d retagged, %d unchanged, %d pruned\n",
+		counts["copy"], counts["retag"], counts["unchanged"], counts["prune"])
+	if data.Incomplete {
+		v.Printf("Stopped before all tags were mirrored\n")
+	}
+
+	return nil
+}
+
+// JSON view implementation
+type mirrorJSONView struct {
+	*JSONView
+}
+
+func newMirrorJSONView(jv *JSONView) *mirrorJSONView {
+	return &mirrorJSONView{JSONView: jv}
+}
+
+func (v *mirrorJSONView) Render(data *MirrorData) error {
+	type jsonStep struct {
+		Tag    string `json:"tag"`
+		Action string `json:"action"`
+		Digest string `json:"digest"`
+	}
+
+	type jsonSummary struct {
+		Copied    int `json:"copied"`
+		Retagged  int `json:"retagged"`
+		Unchanged int `json:"unchanged"`
+		Pruned    int `json:"pruned"`
+	}
+
+	type jsonOutput struct {
+		Source      string      `json:"source"`
+		Destination string      `json:"destination"`
+		DryRun      bool        `json:"dry_run"`
+		Incomplete  bool        `json:"incomplete,omitempty"`
+		Steps       []jsonStep  `json:"steps"`
+		Summary     jsonSummary `json:"summary"`
+	}
+
+	steps := make([]jsonStep, len(data.Steps))
+	for i, s := range data.Steps {
+		steps[i] = jsonStep(s)
+	}
+
+	counts := data.counts()
+	output := jsonOutput{
+		Source:      data.Source,
+		Destination: data.Destination,
+		DryRun:      data.DryRun,
+		Incomplete:  data.Incomplete,
+		Steps:       steps,
+		Summary: jsonSummary{
+			Copied:    counts["copy"],
+			Retagged:  counts["retag"],
+			Unchanged: counts["unchanged"],
+			Pruned:    counts["prune"],
+		},
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
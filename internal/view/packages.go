@@ -0,0 +1,99 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+)
+
+// PackageInfo represents a single package detected in an image.
+type PackageInfo struct {
+	Name      string
+	Version   string
+	Ecosystem string
+	Source    string
+	Path      string
+	Layer     int
+}
+
+// PackagesData contains the package inventory to be rendered.
+type PackagesData struct {
+	ImageRef string
+	Packages []PackageInfo
+}
+
+type PackagesView interface {
+	Render(data *PackagesData) error
+}
+
+// Human view implementation
+type packagesHumanView struct {
+	*HumanView
+}
+
+func newPackagesHumanView(hv *HumanView) *packagesHumanView {
+	return &packagesHumanView{HumanView: hv}
+}
+
+func (v *packagesHumanView) Render(data *PackagesData) error {
+	if len(data.Packages) == 0 {
+		v.Printf("No packages found in %s\n", data.ImageRef)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Ecosystem\tName\tVersion\tLayer\tPath\n")
+
+	for _, pkg := range data.Packages {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", pkg.Ecosystem, pkg.Name, pkg.Version, pkg.Layer, pkg.Path)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	return nil
+}
+
+// JSON view implementation
+type packagesJSONView struct {
+	*JSONView
+}
+
+func newPackagesJSONView(jv *JSONView) *packagesJSONView {
+	return &packagesJSONView{JSONView: jv}
+}
+
+func (v *packagesJSONView) Render(data *PackagesData) error {
+	type jsonPackage struct {
+		Name      string `json:"name"`
+		Version   string `json:"version"`
+		Ecosystem string `json:"ecosystem"`
+		Source    string `json:"source,omitempty"`
+		Path      string `json:"path"`
+		Layer     int    `json:"layer"`
+	}
+
+	type jsonOutput struct {
+		Image    string        `json:"image"`
+		Packages []jsonPackage `json:"packages"`
+	}
+
+	packages := make([]jsonPackage, len(data.Packages))
+	for i, pkg := range data.Packages {
+		packages[i] = jsonPackage(pkg)
+	}
+
+	output := jsonOutput{
+		Image:    data.ImageRef,
+		Packages: packages,
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
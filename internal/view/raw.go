@@ -0,0 +1,95 @@
+package view
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// RawData contains a manifest or config blob exactly as the registry
+// serves it.
+type RawData struct {
+	ImageRef  string
+	Digest    string
+	MediaType string
+	Size      int64
+	Content   []byte
+	// Pretty indents the content instead of writing the raw bytes.
+	Pretty bool
+	// DescriptorOnly renders only the digest, media type and size.
+	DescriptorOnly bool
+}
+
+type RawView interface {
+	Render(data *RawData) error
+}
+
+// Human view implementation
+type rawHumanView struct {
+	*HumanView
+}
+
+func newRawHumanView(hv *HumanView) *rawHumanView {
+	return &rawHumanView{HumanView: hv}
+}
+
+func (v *rawHumanView) Render(data *RawData) error {
+	if data.DescriptorOnly {
+		v.Printf("Digest:     %s\n", data.Digest)
+		v.Printf("Media Type: %s\n", data.MediaType)
+		v.Printf("Size:       %d\n", data.Size)
+		return nil
+	}
+
+	if data.Pretty {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data.Content, "", "  "); err != nil {
+			return fmt.Errorf("failed to pretty-print content: %w", err)
+		}
+		v.Printf("%s\n", buf.String())
+		return nil
+	}
+
+	// Write the bytes unmodified so the output hashes to the digest.
+	if _, err := v.Writer.Write(data.Content); err != nil {
+		return fmt.Errorf("failed to write content: %w", err)
+	}
+	return nil
+}
+
+// JSON view implementation
+type rawJSONView struct {
+	*JSONView
+}
+
+func newRawJSONView(jv *JSONView) *rawJSONView {
+	return &rawJSONView{JSONView: jv}
+}
+
+func (v *rawJSONView) Render(data *RawData) error {
+	type jsonOutput struct {
+		Image     string          `json:"image"`
+		Digest    string          `json:"digest"`
+		MediaType string          `json:"media_type"`
+		Size      int64           `json:"size"`
+		Content   json.RawMessage `json:"content,omitempty"`
+	}
+
+	output := jsonOutput{
+		Image:     data.ImageRef,
+		Digest:    data.Digest,
+		MediaType: data.MediaType,
+		Size:      data.Size,
+	}
+	if !data.DescriptorOnly {
+		output.Content = data.Content
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
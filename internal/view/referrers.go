@@ -0,0 +1,132 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+)
+
+// ReferrerArtifact represents an artifact attached to an image.
+type ReferrerArtifact struct {
+	Digest       string
+	MediaType    string
+	ArtifactType string
+	Size         int64
+	Source       string
+	Tag          string
+	Annotations  map[string]string
+}
+
+// ReferrersData contains the attached artifacts to be rendered.
+type ReferrersData struct {
+	ImageRef  string
+	Digest    string
+	Artifacts []ReferrerArtifact
+}
+
+type ReferrersView interface {
+	Render(data *ReferrersData) error
+}
+
+// Human view implementation
+type referrersHumanView struct {
+	*HumanView
+}
+
+func newReferrersHumanView(hv *HumanView) *referrersHumanView {
+	return &referrersHumanView{HumanView: hv}
+}
+
+func (v *referrersHumanView) Render(data *ReferrersData) error {
+	if len(data.Artifacts) == 0 {
+		v.Printf("No artifacts attached to %s (%s)\n", data.ImageRef, data.Digest)
+		return nil
+	}
+
+	v.Printf("Subject: %s\n\n", data.Digest)
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Artifact Type\tDigest\tSize\tSource\tAnnotations\n")
+
+	for _, a := range data.Artifacts {
+		artifactType := a.ArtifactType
+		if artifactType == "" {
+			artifactType = "-"
+		}
+		source := a.Source
+		if a.Tag != "" {
+			source = fmt.Sprintf("%s (%s)", a.Source, a.Tag)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			artifactType, shortDigest(a.Digest), oci.FormatBytes(a.Size), source, formatAnnotations(a.Annotations))
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	return nil
+}
+
+// formatAnnotations renders annotations as sorted key=value pairs.
+func formatAnnotations(annotations map[string]string) string {
+	if len(annotations) == 0 {
+		return "-"
+	}
+	pairs := make([]string, 0, len(annotations))
+	for k, v := range annotations {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// JSON view implementation
+type referrersJSONView struct {
+	*JSONView
+}
+
+func newReferrersJSONView(jv *JSONView) *referrersJSONView {
+	return &referrersJSONView{JSONView: jv}
+}
+
+func (v *referrersJSONView) Render(data *ReferrersData) error {
+	type jsonArtifact struct {
+		Digest       string            `json:"digest"`
+		MediaType    string            `json:"media_type"`
+		ArtifactType string            `json:"artifact_type,omitempty"`
+		Size         int64             `json:"size"`
+		Source       string            `json:"source"`
+		Tag          string            `json:"tag,omitempty"`
+		Annotations  map[string]string `json:"annotations,omitempty"`
+	}
+
+	type jsonOutput struct {
+		Image     string         `json:"image"`
+		Digest    string         `json:"digest"`
+		Artifacts []jsonArtifact `json:"artifacts"`
+	}
+
+	artifacts := make([]jsonArtifact, len(data.Artifacts))
+	for i, a := range data.Artifacts {
+		artifacts[i] = jsonArtifact(a)
+	}
+
+	output := jsonOutput{
+		Image:     data.ImageRef,
+		Digest:    data.Digest,
+		Artifacts: artifacts,
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
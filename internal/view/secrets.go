@@ -0,0 +1,120 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+)
+
+// SecretFinding represents a single secret found in an image.
+type SecretFinding struct {
+	RuleID      string
+	Description string
+	Source      string
+	Layer       int
+	Path        string
+	Line        int
+	Secret      string
+	Removed     bool
+}
+
+// SecretsData contains the secret scan results to be rendered.
+type SecretsData struct {
+	ImageRef string
+	Findings []SecretFinding
+}
+
+type SecretsView interface {
+	Render(data *SecretsData) error
+}
+
+// Human view implementation
+type secretsHumanView struct {
+	*HumanView
+}
+
+func newSecretsHumanView(hv *HumanView) *secretsHumanView {
+	return &secretsHumanView{HumanView: hv}
+}
+
+func (v *secretsHumanView) Render(data *SecretsData) error {
+	if len(data.Findings) == 0 {
+		v.Printf("No secrets found in %s\n", data.ImageRef)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Layer\tSource\tLocation\tRule\tSecret\n")
+
+	for _, f := range data.Findings {
+		layer := "-"
+		if f.Layer > 0 {
+			layer = fmt.Sprintf("%d", f.Layer)
+		}
+		location := f.Path
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", f.Path, f.Line)
+		}
+		if f.Removed {
+			location += " (removed)"
+		}
+		secret := f.Secret
+		if secret == "" {
+			secret = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", layer, f.Source, location, f.RuleID, secret)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	v.Printf("\n%d secrets found\n", len(data.Findings))
+
+	return nil
+}
+
+// JSON view implementation
+type secretsJSONView struct {
+	*JSONView
+}
+
+func newSecretsJSONView(jv *JSONView) *secretsJSONView {
+	return &secretsJSONView{JSONView: jv}
+}
+
+func (v *secretsJSONView) Render(data *SecretsData) error {
+	type jsonFinding struct {
+		RuleID      string `json:"rule_id"`
+		Description string `json:"description"`
+		Source      string `json:"source"`
+		Layer       int    `json:"layer"`
+		Path        string `json:"path"`
+		Line        int    `json:"line,omitempty"`
+		Secret      string `json:"secret,omitempty"`
+		Removed     bool   `json:"removed"`
+	}
+
+	type jsonOutput struct {
+		Image    string        `json:"image"`
+		Findings []jsonFinding `json:"findings"`
+	}
+
+	findings := make([]jsonFinding, len(data.Findings))
+	for i, f := range data.Findings {
+		findings[i] = jsonFinding(f)
+	}
+
+	output := jsonOutput{
+		Image:    data.ImageRef,
+		Findings: findings,
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
@@ -3,12 +3,34 @@ package view
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
 )
 
 // TagsData contains the list of tags to be rendered.
 type TagsData struct {
 	Repository string
 	Tags       []string
+	// Groups splits Tags by variant suffix, such as "alpine" or "slim".
+	Groups []TagGroup
+	// Images is set when tags were resolved, with one entry per digest.
+	Images []TagImage
+}
+
+// TagGroup is a run of tags sharing a variant suffix.
+type TagGroup struct {
+	Variant string
+	Tags    []string
+}
+
+// TagImage is a manifest and the tags pointing at it.
+type TagImage struct {
+	Digest    string
+	MediaType string
+	Platforms []string
+	Created   time.Time
+	Tags      []string
 }
 
 type TagsView interface {
@@ -30,8 +52,50 @@ func (v *tagsHumanView) Render(data *TagsData) error {
 		return nil
 	}
 
-	for _, tag := range data.Tags {
-		v.Printf("%s\n", tag)
+	if len(data.Images) > 0 {
+		return v.renderImages(data.Images)
+	}
+
+	if len(data.Groups) <= 1 {
+		for _, tag := range data.Tags {
+			v.Printf("%s\n", tag)
+		}
+		return nil
+	}
+
+	// Separate variants with a blank line, keeping one tag per line so
+	// the output can still be piped to grep.
+	for i, group := range data.Groups {
+		if i > 0 {
+			v.Printf("\n")
+		}
+		for _, tag := range group.Tags {
+			v.Printf("%s\n", tag)
+		}
+	}
+
+	return nil
+}
+
+func (v *tagsHumanView) renderImages(images []TagImage) error {
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Tags\tDigest\tMedia Type\tPlatforms\tCreated\n")
+
+	for _, img := range images {
+		platforms := "-"
+		if len(img.Platforms) > 0 {
+			platforms = strings.Join(img.Platforms, ", ")
+		}
+		created := "-"
+		if !img.Created.IsZero() {
+			created = img.Created.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			strings.Join(img.Tags, ", "), shortDigest(img.Digest), img.MediaType, platforms, created)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
 	}
 
 	return nil
@@ -47,10 +111,25 @@ func newTagsJSONView(jv *JSONView) *tagsJSONView {
 }
 
 func (v *tagsJSONView) Render(data *TagsData) error {
+	type jsonGroup struct {
+		Variant string   `json:"variant"`
+		Tags    []string `json:"tags"`
+	}
+
+	type jsonImage struct {
+		Digest    string   `json:"digest"`
+		MediaType string   `json:"media_type"`
+		Platforms []string `json:"platforms,omitempty"`
+		Created   string   `json:"created,omitempty"`
+		Tags      []string `json:"tags"`
+	}
+
 	type jsonOutput struct {
-		Repository string   `json:"repository"`
-		Tags       []string `json:"tags,omitempty"`
-		Message    string   `json:"message,omitempty"`
+		Repository string      `json:"repository"`
+		Tags       []string    `json:"tags,omitempty"`
+		Groups     []jsonGroup `json:"groups,omitempty"`
+		Images     []jsonImage `json:"images,omitempty"`
+		Message    string      `json:"message,omitempty"`
 	}
 
 	output := jsonOutput{
@@ -62,6 +141,23 @@ func (v *tagsJSONView) Render(data *TagsData) error {
 	} else {
 		output.Tags = data.Tags
 	}
+	if len(data.Groups) > 1 {
+		for _, group := range data.Groups {
+			output.Groups = append(output.Groups, jsonGroup(group))
+		}
+	}
+	for _, img := range data.Images {
+		image := jsonImage{
+			Digest:    img.Digest,
+			MediaType: img.MediaType,
+			Platforms: img.Platforms,
+			Tags:      img.Tags,
+		}
+		if !img.Created.IsZero() {
+			image.Created = img.Created.Format(time.RFC3339)
+		}
+		output.Images = append(output.Images, image)
+	}
 
 	encoder := json.NewEncoder(v.Writer)
 	encoder.SetIndent("", "  ")
@@ -0,0 +1,152 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+// VerifySignature represents a signature found for an image and the outcome
+// of verifying it.
+type VerifySignature struct {
+	// Subject is the digest the signature is attached to.
+	Subject         string
+	Source          string
+	DockerReference string
+	Verified        bool
+	Error           string
+	// LogIndex and IntegratedTime are set when a Rekor bundle was verified.
+	LogIndex       int64
+	IntegratedTime time.Time
+}
+
+// VerifyData contains the signature verification results to be rendered.
+type VerifyData struct {
+	ImageRef   string
+	Digest     string
+	Signatures []VerifySignature
+}
+
+type VerifyView interface {
+	Render(data *VerifyData) error
+}
+
+// Human view implementation
+type verifyHumanView struct {
+	*HumanView
+}
+
+func newVerifyHumanView(hv *HumanView) *verifyHumanView {
+	return &verifyHumanView{HumanView: hv}
+}
+
+func (v *verifyHumanView) Render(data *VerifyData) error {
+	v.Printf("Image:   %s\n", data.ImageRef)
+	v.Printf("Digest:  %s\n\n", data.Digest)
+
+	if len(data.Signatures) == 0 {
+		v.Printf("No signatures found\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Source\tSubject\tIdentity\tRekor\tStatus\n")
+
+	verified := 0
+	for _, s := range data.Signatures {
+		status := "verified"
+		if s.Verified {
+			verified++
+		} else {
+			status = "invalid: " + s.Error
+		}
+		identity := s.DockerReference
+		if identity == "" {
+			identity = "-"
+		}
+		rekor := "-"
+		if !s.IntegratedTime.IsZero() {
+			rekor = fmt.Sprintf("#%d (%s)", s.LogIndex, s.IntegratedTime.Format(time.RFC3339))
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Source, shortDigest(s.Subject), identity, rekor, status)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	v.Printf("\n%d of %d signatures verified\n", verified, len(data.Signatures))
+
+	return nil
+}
+
+// shortDigest abbreviates a digest to its algorithm and first 12 hex digits.
+func shortDigest(digest string) string {
+	const prefix = len("sha256:") + 12
+	if len(digest) <= prefix {
+		return digest
+	}
+	return digest[:prefix]
+}
+
+// JSON view implementation
+type verifyJSONView struct {
+	*JSONView
+}
+
+func newVerifyJSONView(jv *JSONView) *verifyJSONView {
+	return &verifyJSONView{JSONView: jv}
+}
+
+func (v *verifyJSONView) Render(data *VerifyData) error {
+	type jsonRekor struct {
+		LogIndex       int64     `json:"log_index"`
+		IntegratedTime time.Time `json:"integrated_time"`
+	}
+
+	type jsonSignature struct {
+		Subject         string     `json:"subject"`
+		Source          string     `json:"source"`
+		DockerReference string     `json:"docker_reference,omitempty"`
+		Verified        bool       `json:"verified"`
+		Error           string     `json:"error,omitempty"`
+		Rekor           *jsonRekor `json:"rekor,omitempty"`
+	}
+
+	type jsonOutput struct {
+		Image      string          `json:"image"`
+		Digest     string          `json:"digest"`
+		Verified   bool            `json:"verified"`
+		Signatures []jsonSignature `json:"signatures"`
+	}
+
+	output := jsonOutput{
+		Image:      data.ImageRef,
+		Digest:     data.Digest,
+		Signatures: make([]jsonSignature, len(data.Signatures)),
+	}
+	for i, s := range data.Signatures {
+		output.Signatures[i] = jsonSignature{
+			Subject:         s.Subject,
+			Source:          s.Source,
+			DockerReference: s.DockerReference,
+			Verified:        s.Verified,
+			Error:           s.Error,
+		}
+		if !s.IntegratedTime.IsZero() {
+			output.Signatures[i].Rekor = &jsonRekor{LogIndex: s.LogIndex, IntegratedTime: s.IntegratedTime}
+		}
+		if s.Verified {
+			output.Verified = true
+		}
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
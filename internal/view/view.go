@@ -8,10 +8,28 @@ var _ Viewer = (*JSONView)(nil)
 // InspectView, CatView, LsView).
 type Viewer interface {
 	Inspect() InspectView
+	InspectReport() InspectReportView
 	Cat() CatView
 	Ls() LsView
 	Export() ExportView
 	Tags() TagsView
+	Packages() PackagesView
+	Vuln() VulnView
+	Secrets() SecretsView
+	Lint() LintView
+	Verify() VerifyView
+	Referrers() ReferrersView
+	Attestations() AttestationsView
+	Raw() RawView
+	Copy() CopyView
+	Mirror() MirrorView
+	Catalog() CatalogView
+	Delete() DeleteView
+	Watch() WatchView
+	Lock() LockView
+	Diff() DiffView
+	LayerShare() LayerShareView
+	Base() BaseView
 	Logger() Logger
 }
 
@@ -48,6 +66,10 @@ func (h *HumanView) Inspect() InspectView {
 	return newInspectHumanView(h)
 }
 
+func (h *HumanView) InspectReport() InspectReportView {
+	return newInspectReportHumanView(h)
+}
+
 func (h *HumanView) Cat() CatView {
 	return newCatHumanView(h)
 }
@@ -64,6 +86,74 @@ func (h *HumanView) Tags() TagsView {
 	return newTagsHumanView(h)
 }
 
+func (h *HumanView) Packages() PackagesView {
+	return newPackagesHumanView(h)
+}
+
+func (h *HumanView) Vuln() VulnView {
+	return newVulnHumanView(h)
+}
+
+func (h *HumanView) Secrets() SecretsView {
+	return newSecretsHumanView(h)
+}
+
+func (h *HumanView) Lint() LintView {
+	return newLintHumanView(h)
+}
+
+func (h *HumanView) Verify() VerifyView {
+	return newVerifyHumanView(h)
+}
+
+func (h *HumanView) Referrers() ReferrersView {
+	return newReferrersHumanView(h)
+}
+
+func (h *HumanView) Attestations() AttestationsView {
+	return newAttestationsHumanView(h)
+}
+
+func (h *HumanView) Raw() RawView {
+	return newRawHumanView(h)
+}
+
+func (h *HumanView) Copy() CopyView {
+	return newCopyHumanView(h)
+}
+
+func (h *HumanView) Mirror() MirrorView {
+	return newMirrorHumanView(h)
+}
+
+func (h *HumanView) Catalog() CatalogView {
+	return newCatalogHumanView(h)
+}
+
+func (h *HumanView) Delete() DeleteView {
+	return newDeleteHumanView(h)
+}
+
+func (h *HumanView) Watch() WatchView {
+	return newWatchHumanView(h)
+}
+
+func (h *HumanView) Lock() LockView {
+	return newLockHumanView(h)
+}
+
+func (h *HumanView) Diff() DiffView {
+	return newDiffHumanView(h)
+}
+
+func (h *HumanView) LayerShare() LayerShareView {
+	return newLayerShareHumanView(h)
+}
+
+func (h *HumanView) Base() BaseView {
+	return newBaseHumanView(h)
+}
+
 func (h *HumanView) Logger() Logger {
 	return h.logger
 }
@@ -90,6 +180,10 @@ func (j *JSONView) Inspect() InspectView {
 	return newInspectJSONView(j)
 }
 
+func (j *JSONView) InspectReport() InspectReportView {
+	return newInspectReportJSONView(j)
+}
+
 func (j *JSONView) Cat() CatView {
 	return newCatJSONView(j)
 }
@@ -106,6 +200,74 @@ func (j *JSONView) Tags() TagsView {
 	return newTagsJSONView(j)
 }
 
+func (j *JSONView) Packages() PackagesView {
+	return newPackagesJSONView(j)
+}
+
+func (j *JSONView) Vuln() VulnView {
+	return newVulnJSONView(j)
+}
+
+func (j *JSONView) Secrets() SecretsView {
+	return newSecretsJSONView(j)
+}
+
+func (j *JSONView) Lint() LintView {
+	return newLintJSONView(j)
+}
+
+func (j *JSONView) Verify() VerifyView {
+	return newVerifyJSONView(j)
+}
+
+func (j *JSONView) Referrers() ReferrersView {
+	return newReferrersJSONView(j)
+}
+
+func (j *JSONView) Attestations() AttestationsView {
+	return newAttestationsJSONView(j)
+}
+
+func (j *JSONView) Raw() RawView {
+	return newRawJSONView(j)
+}
+
+func (j *JSONView) Copy() CopyView {
+	return newCopyJSONView(j)
+}
+
+func (j *JSONView) Mirror() MirrorView {
+	return newMirrorJSONView(j)
+}
+
+func (j *JSONView) Catalog() CatalogView {
+	return newCatalogJSONView(j)
+}
+
+func (j *JSONView) Delete() DeleteView {
+	return newDeleteJSONView(j)
+}
+
+func (j *JSONView) Watch() WatchView {
+	return newWatchJSONView(j)
+}
+
+func (j *JSONView) Lock() LockView {
+	return newLockJSONView(j)
+}
+
+func (j *JSONView) Diff() DiffView {
+	return newDiffJSONView(j)
+}
+
+func (j *JSONView) LayerShare() LayerShareView {
+	return newLayerShareJSONView(j)
+}
+
+func (j *JSONView) Base() BaseView {
+	return newBaseJSONView(j)
+}
+
 func (j *JSONView) Logger() Logger {
 	return j.logger
 }
@@ -0,0 +1,154 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// VulnFinding represents a single advisory affecting an installed package.
+type VulnFinding struct {
+	ID           string
+	Aliases      []string
+	Summary      string
+	Severity     string
+	Score        float64
+	Package      string
+	Ecosystem    string
+	Version      string
+	FixedVersion string
+	Path         string
+	Layer        int
+}
+
+// VulnData contains the vulnerability report to be rendered.
+type VulnData struct {
+	ImageRef   string
+	Packages   int
+	Advisories int
+	Findings   []VulnFinding
+	// UnsupportedDistro is the distribution of the image when its OS
+	// packages could not be checked, so only language packages were.
+	UnsupportedDistro string
+	// Unreadable lists package metadata files that could not be fully
+	// parsed, so packages listed in them may not have been checked.
+	Unreadable []string
+}
+
+type VulnView interface {
+	Render(data *VulnData) error
+}
+
+// maxSummaryWidth keeps the human table readable in a standard terminal.
+const maxSummaryWidth = 60
+
+// Human view implementation
+type vulnHumanView struct {
+	*HumanView
+}
+
+func newVulnHumanView(hv *HumanView) *vulnHumanView {
+	return &vulnHumanView{HumanView: hv}
+}
+
+func (v *vulnHumanView) Render(data *VulnData) error {
+	if data.UnsupportedDistro != "" {
+		v.Printf("OS packages of %s images are not checked: only Debian, Ubuntu, Alpine, RHEL, AlmaLinux, Rocky Linux, openSUSE and SLES are supported\n", data.UnsupportedDistro)
+	}
+	for _, p := range data.Unreadable {
+		v.Printf("Warning: %s could not be fully read, some of its packages are not checked\n", p)
+	}
+	if len(data.Findings) == 0 {
+		v.Printf("No vulnerabilities found in %s (%d packages checked)\n", data.ImageRef, data.Packages)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(v.Writer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "ID\tSeverity\tPackage\tInstalled\tFixed\tSummary\n")
+
+	counts := make(map[string]int)
+	for _, f := range data.Findings {
+		counts[f.Severity]++
+
+		fixed := f.FixedVersion
+		if fixed == "" {
+			fixed = "-"
+		}
+		summary := f.Summary
+		if len(summary) > maxSummaryWidth {
+			summary = summary[:maxSummaryWidth-3] + "..."
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", f.ID, f.Severity, f.Package, f.Version, fixed, summary)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	var parts []string
+	for _, severity := range []string{"critical", "high", "medium", "low", "unknown"} {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	v.Printf("\n%d vulnerabilities (%s) in %d packages checked\n", len(data.Findings), strings.Join(parts, ", "), data.Packages)
+
+	return nil
+}
+
+// JSON view implementation
+type vulnJSONView struct {
+	*JSONView
+}
+
+func newVulnJSONView(jv *JSONView) *vulnJSONView {
+	return &vulnJSONView{JSONView: jv}
+}
+
+func (v *vulnJSONView) Render(data *VulnData) error {
+	type jsonFinding struct {
+		ID           string   `json:"id"`
+		Aliases      []string `json:"aliases,omitempty"`
+		Summary      string   `json:"summary,omitempty"`
+		Severity     string   `json:"severity"`
+		Score        float64  `json:"score,omitempty"`
+		Package      string   `json:"package"`
+		Ecosystem    string   `json:"ecosystem"`
+		Version      string   `json:"version"`
+		FixedVersion string   `json:"fixed_version,omitempty"`
+		Path         string   `json:"path"`
+		Layer        int      `json:"layer"`
+	}
+
+	type jsonOutput struct {
+		Image             string        `json:"image"`
+		Packages          int           `json:"packages"`
+		Advisories        int           `json:"advisories"`
+		Findings          []jsonFinding `json:"findings"`
+		UnsupportedDistro string        `json:"unsupported_distro,omitempty"`
+		Unreadable        []string      `json:"unreadable,omitempty"`
+	}
+
+	findings := make([]jsonFinding, len(data.Findings))
+	for i, f := range data.Findings {
+		findings[i] = jsonFinding(f)
+	}
+
+	output := jsonOutput{
+		Image:             data.ImageRef,
+		Packages:          data.Packages,
+		Advisories:        data.Advisories,
+		Findings:          findings,
+		UnsupportedDistro: data.UnsupportedDistro,
+		Unreadable:        data.Unreadable,
+	}
+
+	encoder := json.NewEncoder(v.Writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
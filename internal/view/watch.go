@@ -0,0 +1,141 @@
+package view
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+)
+
+// WatchLayer represents a layer added or removed by a re-push.
+type WatchLayer struct {
+	Digest string
+	Size   int64
+}
+
+// WatchData contains a watch event to be rendered. Previous is empty for
+// the event reporting the digest a watch starts from.
+type WatchData struct {
+	Image    string
+	Time     time.Time
+	Interval time.Duration
+	Digest   string
+	Previous string
+	// Added, Removed and Unchanged summarize the layer changes between
+	// Previous and Digest.
+	Added     []WatchLayer
+	Removed   []WatchLayer
+	Unchanged int
+}
+
+// Changed reports whether the event is a digest change.
+func (d *WatchData) Changed() bool {
+	return d.Previous != ""
+}
+
+type WatchView interface {
+	Render(data *WatchData) error
+}
+
+// Human view implementation
+type watchHumanView struct {
+	*HumanView
+}
+
+func newWatchHumanView(hv *HumanView) *watchHumanView {
+	return &watchHumanView{HumanView: hv}
+}
+
+func (v *watchHumanView) Render(data *WatchData) error {
+	if !data.Changed() {
+		v.Printf("Watching %s every %s (%s)\n", data.Image, data.Interval, data.Digest)
+		return nil
+	}
+
+	v.Printf("%s %s changed\n", data.Time.Format(time.RFC3339), data.Image)
+	v.Printf("  Old: %s\n", data.Previous)
+	v.Printf("  New: %s\n", data.Digest)
+	v.Printf("  Layers: %d added (%s), %d removed (%s), %d unchanged\n",
+		len(data.Added), oci.FormatBytes(layerSize(data.Added)),
+		len(data.Removed), oci.FormatBytes(layerSize(data.Removed)),
+		data.Unchanged)
+	for _, l := range data.Added {
+		v.Printf("    + %s  %s\n", shortDigest(l.Digest), oci.FormatBytes(l.Size))
+	}
+	for _, l := range data.Removed {
+		v.Printf("    - %s  %s\n", shortDigest(l.Digest), oci.FormatBytes(l.Size))
+	}
+
+	return nil
+}
+
+func layerSize(layers []WatchLayer) int64 {
+	var total int64
+	for _, l := range layers {
+		total += l.Size
+	}
+	return total
+}
+
+// JSON view implementation
+type watchJSONView struct {
+	*JSONView
+}
+
+func newWatchJSONView(jv *JSONView) *watchJSONView {
+	return &watchJSONView{JSONView: jv}
+}
+
+// Render writes each event as a single line, so a watch produces NDJSON.
+func (v *watchJSONView) Render(data *WatchData) error {
+	type jsonLayer struct {
+		Digest string `json:"digest"`
+		Size   int64  `json:"size"`
+	}
+
+	type jsonLayers struct {
+		Added     []jsonLayer `json:"added"`
+		Removed   []jsonLayer `json:"removed"`
+		Unchanged int         `json:"unchanged"`
+	}
+
+	type jsonOutput struct {
+		Event    string      `json:"event"`
+		Time     string      `json:"time"`
+		Image    string      `json:"image"`
+		Digest   string      `json:"digest"`
+		Previous string      `json:"previous_digest,omitempty"`
+		Layers   *jsonLayers `json:"layers,omitempty"`
+	}
+
+	toJSON := func(layers []WatchLayer) []jsonLayer {
+		out := make([]jsonLayer, len(layers))
+		for i, l := range layers {
+			out[i] = jsonLayer{Digest: l.Digest, Size: l.Size}
+		}
+		return out
+	}
+
+	output := jsonOutput{
+		Event:  "watching",
+		Time:   data.Time.Format(time.RFC3339),
+		Image:  data.Image,
+		Digest: data.Digest,
+	}
+	if data.Changed() {
+		output.Event = "changed"
+		output.Previous = data.Previous
+		output.Layers = &jsonLayers{
+			Added:     toJSON(data.Added),
+			Removed:   toJSON(data.Removed),
+			Unchanged: data.Unchanged,
+		}
+	}
+
+	if err := json.NewEncoder(v.Writer).Encode(output); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
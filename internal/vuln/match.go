@@ -0,0 +1,217 @@
+// Package vuln matches detected packages against a local OSV advisory
+// database. Everything runs offline.
+package vuln
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bschaatsbergen/cek/internal/packages"
+)
+
+// Finding is a single advisory affecting an installed package.
+type Finding struct {
+	ID           string
+	Aliases      []string
+	Summary      string
+	Severity     Severity
+	Score        float64
+	Package      packages.Package
+	FixedVersion string
+}
+
+// MatchOptions tunes how packages are matched against advisories.
+type MatchOptions struct {
+	// Releases maps an ecosystem to the distribution release of the image,
+	// e.g. "Debian" to "12", "Ubuntu" to "22.04", "Alpine" to "v3.19" or
+	// "Red Hat" to "9".
+	// Advisories scoped to a different release are ignored. Without a
+	// release, advisories for any release of the distribution match.
+	Releases map[packages.Ecosystem]string
+}
+
+// PackageFilter returns a LoadDB filter that keeps only advisories for the
+// given packages.
+func PackageFilter(pkgs []packages.Package) func(ecosystem, name string) bool {
+	wanted := make(map[dbKey]bool)
+	for _, pkg := range pkgs {
+		wanted[packageKey(pkg)] = true
+	}
+	return func(ecosystem, name string) bool {
+		return wanted[dbKey{ecosystem: ecosystem, name: name}]
+	}
+}
+
+// packageKey returns the lookup key for pkg. Distro advisories are published
+// for source packages, so OS packages are looked up by their source name.
+func packageKey(pkg packages.Package) dbKey {
+	ecosystem := string(pkg.Ecosystem)
+	name := pkg.Name
+	if pkg.Source != "" {
+		name = pkg.Source
+	}
+	return dbKey{ecosystem: ecosystem, name: normalizeName(ecosystem, name)}
+}
+
+// Match returns all findings for pkgs, sorted by descending severity and
+// then by package name and advisory ID.
+func (db *DB) Match(pkgs []packages.Package, opts *MatchOptions) []Finding {
+	var findings []Finding
+
+	for _, pkg := range pkgs {
+		for _, adv := range db.advisories[packageKey(pkg)] {
+			if f, ok := matchAdvisory(adv, pkg, opts); ok {
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity > findings[j].Severity
+		}
+		if findings[i].Package.Name != findings[j].Package.Name {
+			return findings[i].Package.Name < findings[j].Package.Name
+		}
+		return findings[i].ID < findings[j].ID
+	})
+
+	return findings
+}
+
+func matchAdvisory(adv *Advisory, pkg packages.Package, opts *MatchOptions) (Finding, bool) {
+	key := packageKey(pkg)
+
+	for i := range adv.Affected {
+		affected := &adv.Affected[i]
+		ecosystem, release := splitEcosystem(affected.Package.Ecosystem)
+		if ecosystem != key.ecosystem || normalizeName(ecosystem, affected.Package.Name) != key.name {
+			continue
+		}
+		if opts != nil && release != "" {
+			if want := opts.Releases[pkg.Ecosystem]; want != "" && want != release {
+				continue
+			}
+		}
+
+		fixed, ok := isAffected(affected, pkg.Version, comparerFor(ecosystem))
+		if !ok {
+			continue
+		}
+
+		severity, score := advisorySeverity(adv, affected)
+		return Finding{
+			ID:           adv.ID,
+			Aliases:      adv.Aliases,
+			Summary:      adv.Summary,
+			Severity:     severity,
+			Score:        score,
+			Package:      pkg,
+			FixedVersion: fixed,
+		}, true
+	}
+
+	return Finding{}, false
+}
+
+// splitEcosystem splits an OSV ecosystem into the ecosystem and the release
+// it is scoped to, if any.
+func splitEcosystem(s string) (ecosystem, release string) {
+	ecosystem, release, _ = strings.Cut(s, ":")
+	switch ecosystem {
+	case "Ubuntu":
+		// Long-term support releases are marked, as in "Ubuntu:22.04:LTS".
+		release = strings.TrimSuffix(release, ":LTS")
+	case "Red Hat":
+		// Releases are CPE fragments such as "enterprise_linux:9::appstream",
+		// scoped here to the major version.
+		if _, cpe, ok := strings.Cut(release, ":"); ok {
+			version, _, _ := strings.Cut(cpe, ":")
+			release, _, _ = strings.Cut(version, ".")
+		}
+	}
+	return ecosystem, release
+}
+
+// isAffected evaluates the explicit version list and the ECOSYSTEM and
+// SEMVER ranges of an affected entry. It returns the version that fixes
+// the vulnerability, if any.
+func isAffected(affected *osvAffected, version string, compare compareFunc) (fixed string, ok bool) {
+	for _, v := range affected.Versions {
+		if v == version {
+			ok = true
+		}
+	}
+
+	for _, r := range affected.Ranges {
+		if r.Type != "ECOSYSTEM" && r.Type != "SEMVER" {
+			continue
+		}
+		if rangeFixed, inRange := inRange(r.Events, version, compare); inRange {
+			ok = true
+			if fixed == "" {
+				fixed = rangeFixed
+			}
+		}
+	}
+
+	return fixed, ok
+}
+
+// inRange implements the OSV range evaluation algorithm: events are
+// processed in version order, with "introduced" opening an affected
+// interval and "fixed" or "last_affected" closing it.
+func inRange(events []osvEvent, version string, compare compareFunc) (fixed string, affected bool) {
+	eventVersion := func(e osvEvent) string {
+		switch {
+		case e.Introduced != "":
+			return e.Introduced
+		case e.Fixed != "":
+			return e.Fixed
+		case e.LastAffected != "":
+			return e.LastAffected
+		default:
+			return e.Limit
+		}
+	}
+
+	sorted := make([]osvEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		// "0" marks the beginning of time and sorts before every version.
+		a, b := eventVersion(sorted[i]), eventVersion(sorted[j])
+		if a == "0" || b == "0" {
+			return a == "0" && b != "0"
+		}
+		return compare(a, b) < 0
+	})
+
+	for _, e := range sorted {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || compare(version, e.Introduced) >= 0 {
+				affected = true
+				fixed = ""
+			}
+		case e.Fixed != "":
+			if compare(version, e.Fixed) >= 0 {
+				affected = false
+			} else if affected && fixed == "" {
+				fixed = e.Fixed
+			}
+		case e.LastAffected != "":
+			if compare(version, e.LastAffected) > 0 {
+				affected = false
+			}
+		case e.Limit != "":
+			if compare(version, e.Limit) >= 0 {
+				affected = false
+			}
+		}
+	}
+
+	if !affected {
+		return "", false
+	}
+	return fixed, true
+}
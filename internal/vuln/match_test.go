@@ -0,0 +1,213 @@
+package vuln_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/packages"
+	"github.com/bschaatsbergen/cek/internal/vuln"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var advisories = map[string]string{
+	"DSA-0001.json": `{
+		"id": "DSA-0001",
+		"summary": "openssl: buffer overflow",
+		"affected": [
+			{"package": {"ecosystem": "Debian:12", "name": "openssl"},
+			 "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "3.0.13-1~deb12u1"}]}]},
+			{"package": {"ecosystem": "Debian:11", "name": "openssl"},
+			 "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1.1.1w-0+deb11u2"}]}]}
+		],
+		"severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}]
+	}`,
+	"GHSA-0002.json": `{
+		"id": "GHSA-0002",
+		"aliases": ["CVE-2021-23337"],
+		"summary": "Command injection in lodash",
+		"affected": [
+			{"package": {"ecosystem": "npm", "name": "lodash"},
+			 "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]}
+		],
+		"database_specific": {"severity": "MODERATE"}
+	}`,
+	"GHSA-0003.json": `{
+		"id": "GHSA-0003",
+		"affected": [
+			{"package": {"ecosystem": "npm", "name": "lodash"},
+			 "ranges": [{"type": "SEMVER", "events": [{"introduced": "4.0.0"}, {"last_affected": "4.17.15"}]}]}
+		]
+	}`,
+	"PYSEC-0004.json": `{
+		"id": "PYSEC-0004",
+		"affected": [
+			{"package": {"ecosystem": "PyPI", "name": "Requests"}, "versions": ["2.31.0"]}
+		]
+	}`,
+	"WITHDRAWN.json": `{
+		"id": "GHSA-WITHDRAWN",
+		"withdrawn": "2024-01-01T00:00:00Z",
+		"affected": [{"package": {"ecosystem": "npm", "name": "lodash"}, "versions": ["4.17.20"]}]
+	}`,
+}
+
+var installed = []packages.Package{
+	{Name: "libssl3", Version: "3.0.11-1~deb12u2", Ecosystem: packages.EcosystemDebian, Source: "openssl"},
+	{Name: "lodash", Version: "4.17.20", Ecosystem: packages.EcosystemNPM},
+	{Name: "requests", Version: "2.31.0", Ecosystem: packages.EcosystemPyPI},
+}
+
+func writeDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range advisories {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func writeZip(t *testing.T) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), "all.zip")
+	f, err := os.Create(p)
+	require.NoError(t, err)
+	zw := zip.NewWriter(f)
+	for name, content := range advisories {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+	return p
+}
+
+func findingIDs(findings []vuln.Finding) []string {
+	ids := make([]string, len(findings))
+	for i, f := range findings {
+		ids[i] = f.ID
+	}
+	return ids
+}
+
+func TestMatch(t *testing.T) {
+	for name, path := range map[string]string{"dir": writeDir(t), "zip": writeZip(t)} {
+		t.Run(name, func(t *testing.T) {
+			db, err := vuln.LoadDB(path, vuln.PackageFilter(installed))
+			require.NoError(t, err)
+			assert.Equal(t, 4, db.Len())
+
+			findings := db.Match(installed, &vuln.MatchOptions{
+				Releases: map[packages.Ecosystem]string{packages.EcosystemDebian: "12"},
+			})
+
+			// Sorted by severity: critical first, unknown last.
+			assert.Equal(t, []string{"DSA-0001", "GHSA-0002", "PYSEC-0004"}, findingIDs(findings))
+
+			assert.Equal(t, vuln.SeverityCritical, findings[0].Severity)
+			assert.Equal(t, 9.8, findings[0].Score)
+			assert.Equal(t, "3.0.13-1~deb12u1", findings[0].FixedVersion)
+			assert.Equal(t, "libssl3", findings[0].Package.Name)
+
+			assert.Equal(t, vuln.SeverityMedium, findings[1].Severity)
+			assert.Equal(t, "4.17.21", findings[1].FixedVersion)
+
+			assert.Equal(t, vuln.SeverityUnknown, findings[2].Severity)
+			assert.Empty(t, findings[2].FixedVersion)
+		})
+	}
+}
+
+func TestMatch_ReleaseScoping(t *testing.T) {
+	db, err := vuln.LoadDB(writeDir(t), nil)
+	require.NoError(t, err)
+
+	pkgs := []packages.Package{
+		{Name: "openssl", Version: "3.0.11-1~deb12u2", Ecosystem: packages.EcosystemDebian},
+	}
+
+	// The version is only vulnerable according to the Debian 12 entry.
+	for release, want := range map[string]int{"12": 1, "11": 0, "": 1} {
+		findings := db.Match(pkgs, &vuln.MatchOptions{
+			Releases: map[packages.Ecosystem]string{packages.EcosystemDebian: release},
+		})
+		assert.Len(t, findings, want, "release %q", release)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for input, want := range map[string]vuln.Severity{
+		"low":      vuln.SeverityLow,
+		"Moderate": vuln.SeverityMedium,
+		"HIGH":     vuln.SeverityHigh,
+		"critical": vuln.SeverityCritical,
+	} {
+		got, err := vuln.ParseSeverity(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	_, err := vuln.ParseSeverity("severe")
+	assert.Error(t, err)
+}
+
+func TestMatch_RedHat(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "RHSA.json"), []byte(`{
+		"id": "RHSA-0001",
+		"affected": [
+			{"package": {"ecosystem": "Red Hat:enterprise_linux:9::appstream", "name": "openssl-libs"},
+			 "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1:3.0.7-28.el9"}]}]},
+			{"package": {"ecosystem": "Red Hat:enterprise_linux:8::baseos", "name": "openssl-libs"},
+			 "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "1:1.1.1k-12.el8"}]}]}
+		]
+	}`), 0o644))
+
+	db, err := vuln.LoadDB(dir, nil)
+	require.NoError(t, err)
+
+	pkgs := []packages.Package{
+		{Name: "openssl-libs", Version: "1:3.0.7-27.el9", Ecosystem: packages.EcosystemRedHat},
+	}
+	for release, want := range map[string]int{"9": 1, "8": 0} {
+		findings := db.Match(pkgs, &vuln.MatchOptions{
+			Releases: map[packages.Ecosystem]string{packages.EcosystemRedHat: release},
+		})
+		require.Len(t, findings, want, "release %q", release)
+		if want > 0 {
+			assert.Equal(t, "1:3.0.7-28.el9", findings[0].FixedVersion)
+		}
+	}
+}
+
+func TestMatch_PreRelease(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "GHSA.json"), []byte(`{
+		"id": "GHSA-0005",
+		"affected": [
+			{"package": {"ecosystem": "PyPI", "name": "django"},
+			 "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "5.0.0"}]}]},
+			{"package": {"ecosystem": "Maven", "name": "org.example:lib"},
+			 "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "2.0.0"}]}]},
+			{"package": {"ecosystem": "RubyGems", "name": "rack"},
+			 "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "3.0.0"}]}]}
+		]
+	}`), 0o644))
+
+	db, err := vuln.LoadDB(dir, nil)
+	require.NoError(t, err)
+
+	// Pre-releases of the fixed version predate the fix.
+	findings := db.Match([]packages.Package{
+		{Name: "django", Version: "5.0.0rc1", Ecosystem: packages.EcosystemPyPI},
+		{Name: "org.example:lib", Version: "2.0.0-beta", Ecosystem: packages.EcosystemMaven},
+		{Name: "rack", Version: "3.0.0.beta1", Ecosystem: packages.EcosystemRubyGems},
+	}, nil)
+	assert.Len(t, findings, 3)
+}
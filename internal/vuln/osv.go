@@ -0,0 +1,186 @@
+package vuln
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Advisory is the subset of the OSV schema used for matching.
+// See https://ossf.github.io/osv-schema/.
+type Advisory struct {
+	ID               string           `json:"id"`
+	Aliases          []string         `json:"aliases"`
+	Summary          string           `json:"summary"`
+	Details          string           `json:"details"`
+	Withdrawn        string           `json:"withdrawn"`
+	Severity         []osvSeverity    `json:"severity"`
+	Affected         []osvAffected    `json:"affected"`
+	DatabaseSpecific osvDatabaseExtra `json:"database_specific"`
+}
+
+type osvSeverity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges           []osvRange       `json:"ranges"`
+	Versions         []string         `json:"versions"`
+	Severity         []osvSeverity    `json:"severity"`
+	EcosystemExtra   osvDatabaseExtra `json:"ecosystem_specific"`
+	DatabaseSpecific osvDatabaseExtra `json:"database_specific"`
+}
+
+type osvRange struct {
+	Type   string     `json:"type"`
+	Events []osvEvent `json:"events"`
+}
+
+type osvEvent struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// osvDatabaseExtra captures the free-form severity fields that GitHub,
+// Ubuntu and other databases attach to advisories and affected entries.
+type osvDatabaseExtra struct {
+	Severity any `json:"severity"`
+	Urgency  any `json:"urgency"`
+}
+
+// DB is an in-memory index of OSV advisories keyed by ecosystem and package.
+type DB struct {
+	advisories map[dbKey][]*Advisory
+	count      int
+}
+
+type dbKey struct {
+	ecosystem string
+	name      string
+}
+
+// Len returns the number of advisories loaded.
+func (db *DB) Len() int {
+	return db.count
+}
+
+// LoadDB reads an OSV dump from a directory of JSON files (searched
+// recursively) or a zip archive such as the all.zip files published per
+// ecosystem. When filter is non-nil, only advisories for which it returns
+// true for at least one affected package are kept, which keeps memory usage
+// proportional to the image instead of the database.
+func LoadDB(path string, filter func(ecosystem, name string) bool) (*DB, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open advisory database: %w", err)
+	}
+
+	db := &DB{advisories: make(map[dbKey][]*Advisory)}
+
+	if info.IsDir() {
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || filepath.Ext(p) != ".json" {
+				return nil
+			}
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = f.Close()
+			}()
+			return db.add(p, f, filter)
+		})
+	} else {
+		err = db.loadZip(path, filter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load advisory database: %w", err)
+	}
+
+	return db, nil
+}
+
+func (db *DB) loadZip(path string, filter func(ecosystem, name string) bool) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = db.add(f.Name, rc, filter)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) add(name string, r io.Reader, filter func(ecosystem, name string) bool) error {
+	var adv Advisory
+	if err := json.NewDecoder(r).Decode(&adv); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if adv.ID == "" || adv.Withdrawn != "" {
+		return nil
+	}
+
+	added := false
+	seen := make(map[dbKey]bool)
+	for _, affected := range adv.Affected {
+		ecosystem, _, _ := strings.Cut(affected.Package.Ecosystem, ":")
+		key := dbKey{ecosystem: ecosystem, name: normalizeName(ecosystem, affected.Package.Name)}
+		if seen[key] {
+			continue
+		}
+		if filter != nil && !filter(key.ecosystem, key.name) {
+			continue
+		}
+		seen[key] = true
+		db.advisories[key] = append(db.advisories[key], &adv)
+		added = true
+	}
+	if added {
+		db.count++
+	}
+	return nil
+}
+
+// normalizeName applies the ecosystem's package name canonicalization so
+// lookups are not sensitive to spelling differences.
+func normalizeName(ecosystem, name string) string {
+	switch ecosystem {
+	case "PyPI":
+		// PEP 503 normalization.
+		name = strings.ToLower(name)
+		return strings.NewReplacer("_", "-", ".", "-").Replace(name)
+	default:
+		return name
+	}
+}
@@ -0,0 +1,180 @@
+package vuln
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Severity is a qualitative severity rating, ordered from lowest to highest.
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSeverity parses a severity name as used by --fail-on. GitHub's
+// "moderate" and Debian's "unimportant" ratings are accepted as aliases.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low", "unimportant", "negligible":
+		return SeverityLow, nil
+	case "medium", "moderate":
+		return SeverityMedium, nil
+	case "high", "important":
+		return SeverityHigh, nil
+	case "critical":
+		return SeverityCritical, nil
+	case "unknown", "":
+		return SeverityUnknown, nil
+	}
+	return SeverityUnknown, fmt.Errorf("unknown severity %q (expected low, medium, high or critical)", s)
+}
+
+// severityFromScore maps a CVSS base score to its qualitative rating.
+func severityFromScore(score float64) Severity {
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	default:
+		return SeverityUnknown
+	}
+}
+
+// advisorySeverity picks the most specific severity available for an
+// affected package: its own CVSS vector, then the advisory's CVSS vector,
+// then free-form ratings from the database.
+func advisorySeverity(adv *Advisory, affected *osvAffected) (Severity, float64) {
+	for _, severities := range [][]osvSeverity{affected.Severity, adv.Severity} {
+		for _, s := range severities {
+			if s.Type != "CVSS_V3" {
+				continue
+			}
+			if score, err := cvss3BaseScore(s.Score); err == nil {
+				return severityFromScore(score), score
+			}
+		}
+	}
+
+	for _, extra := range []osvDatabaseExtra{affected.EcosystemExtra, affected.DatabaseSpecific, adv.DatabaseSpecific} {
+		for _, v := range []any{extra.Severity, extra.Urgency} {
+			if s, ok := v.(string); ok {
+				if sev, err := ParseSeverity(s); err == nil && sev != SeverityUnknown {
+					return sev, 0
+				}
+			}
+		}
+	}
+
+	return SeverityUnknown, 0
+}
+
+// cvss3BaseScore computes the base score of a CVSS v3.0 or v3.1 vector such
+// as "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H".
+func cvss3BaseScore(vector string) (float64, error) {
+	if !strings.HasPrefix(vector, "CVSS:3.") {
+		return 0, fmt.Errorf("not a CVSS v3 vector: %s", vector)
+	}
+
+	metrics := make(map[string]string)
+	for _, part := range strings.Split(vector, "/")[1:] {
+		k, v, ok := strings.Cut(part, ":")
+		if !ok {
+			return 0, fmt.Errorf("malformed CVSS metric %q", part)
+		}
+		metrics[k] = v
+	}
+
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+
+	values := make(map[string]float64)
+	for metric, table := range weights {
+		w, ok := table[metrics[metric]]
+		if !ok {
+			return 0, fmt.Errorf("missing or invalid CVSS metric %s", metric)
+		}
+		values[metric] = w
+	}
+
+	scopeChanged := metrics["S"] == "C"
+	if metrics["S"] != "C" && metrics["S"] != "U" {
+		return 0, fmt.Errorf("missing or invalid CVSS metric S")
+	}
+
+	var pr float64
+	switch metrics["PR"] {
+	case "N":
+		pr = 0.85
+	case "L":
+		pr = 0.62
+		if scopeChanged {
+			pr = 0.68
+		}
+	case "H":
+		pr = 0.27
+		if scopeChanged {
+			pr = 0.5
+		}
+	default:
+		return 0, fmt.Errorf("missing or invalid CVSS metric PR")
+	}
+
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	var impact float64
+	if scopeChanged {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	exploitability := 8.22 * values["AV"] * values["AC"] * pr * values["UI"]
+
+	if impact <= 0 {
+		return 0, nil
+	}
+	if scopeChanged {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), nil
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), nil
+}
+
+// roundUp implements the CVSS v3.1 Roundup function, which rounds to one
+// decimal place while avoiding floating point artifacts.
+func roundUp(x float64) float64 {
+	i := int(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return (math.Floor(float64(i)/10000) + 1) / 10
+}
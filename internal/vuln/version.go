@@ -0,0 +1,769 @@
+package vuln
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// compareFunc returns -1, 0 or 1 when a is lower than, equal to or higher
+// than b.
+type compareFunc func(a, b string) int
+
+// comparerFor returns the version comparison for an OSV ecosystem, ignoring
+// any release suffix such as the "12" in "Debian:12".
+func comparerFor(ecosystem string) compareFunc {
+	base, _, _ := strings.Cut(ecosystem, ":")
+	switch base {
+	case "Debian", "Ubuntu":
+		return CompareDebian
+	case "Alpine", "Wolfi", "Chainguard":
+		return CompareAlpine
+	case "Red Hat", "Rocky Linux", "AlmaLinux", "openSUSE", "SUSE", "Mageia", "Photon OS":
+		return CompareRPM
+	case "Go", "npm", "crates.io", "NuGet", "Hex", "Pub":
+		return CompareSemver
+	case "PyPI":
+		return ComparePyPI
+	case "Maven":
+		return CompareMaven
+	case "RubyGems":
+		return CompareRubyGems
+	default:
+		return CompareGeneric
+	}
+}
+
+// CompareDebian compares two Debian package versions following the dpkg
+// algorithm: [epoch:]upstream_version[-debian_revision].
+func CompareDebian(a, b string) int {
+	aEpoch, aUpstream, aRevision := splitDebian(a)
+	bEpoch, bUpstream, bRevision := splitDebian(b)
+
+	if c := compareInt(aEpoch, bEpoch); c != 0 {
+		return c
+	}
+	if c := compareDpkgPart(aUpstream, bUpstream); c != 0 {
+		return c
+	}
+	return compareDpkgPart(aRevision, bRevision)
+}
+
+func splitDebian(v string) (epoch int, upstream, revision string) {
+	if e, rest, ok := strings.Cut(v, ":"); ok {
+		epoch, _ = strconv.Atoi(e)
+		v = rest
+	}
+	upstream = v
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		upstream, revision = v[:i], v[i+1:]
+	}
+	return epoch, upstream, revision
+}
+
+// compareDpkgPart alternates between comparing non-digit prefixes, where '~'
+// sorts before everything including the end of the string, and numeric runs.
+func compareDpkgPart(a, b string) int {
+	for a != "" || b != "" {
+		var aStr, bStr string
+		aStr, a = splitWhile(a, func(r rune) bool { return !unicode.IsDigit(r) })
+		bStr, b = splitWhile(b, func(r rune) bool { return !unicode.IsDigit(r) })
+		if c := compareDpkgLexical(aStr, bStr); c != 0 {
+			return c
+		}
+
+		var aNum, bNum string
+		aNum, a = splitWhile(a, unicode.IsDigit)
+		bNum, b = splitWhile(b, unicode.IsDigit)
+		if c := compareNumeric(aNum, bNum); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareDpkgLexical(a, b string) int {
+	order := func(s string, i int) int {
+		if i >= len(s) {
+			return 0
+		}
+		c := s[i]
+		switch {
+		case c == '~':
+			return -1
+		case unicode.IsLetter(rune(c)):
+			return int(c)
+		default:
+			return int(c) + 256
+		}
+	}
+	for i := 0; i < len(a) || i < len(b); i++ {
+		if c := compareInt(order(a, i), order(b, i)); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// CompareRPM compares two RPM versions of the form [epoch:]version[-release]
+// using the rpmvercmp algorithm.
+func CompareRPM(a, b string) int {
+	aEpoch, aVersion, aRelease := splitRPM(a)
+	bEpoch, bVersion, bRelease := splitRPM(b)
+
+	if c := compareInt(aEpoch, bEpoch); c != 0 {
+		return c
+	}
+	if c := rpmvercmp(aVersion, bVersion); c != 0 {
+		return c
+	}
+	if aRelease == "" || bRelease == "" {
+		// A missing release matches any release.
+		return 0
+	}
+	return rpmvercmp(aRelease, bRelease)
+}
+
+func splitRPM(v string) (epoch int, version, release string) {
+	if e, rest, ok := strings.Cut(v, ":"); ok {
+		epoch, _ = strconv.Atoi(e)
+		v = rest
+	}
+	version = v
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		version, release = v[:i], v[i+1:]
+	}
+	return epoch, version, release
+}
+
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+	isSep := func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '~' && r != '^'
+	}
+
+	for {
+		_, a = splitWhile(a, isSep)
+		_, b = splitWhile(b, isSep)
+
+		// Tilde sorts before anything, caret after the end of the string but
+		// before any other segment.
+		switch {
+		case strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~"):
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		case strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^"):
+			if a == "" {
+				return -1
+			}
+			if b == "" {
+				return 1
+			}
+			if !strings.HasPrefix(a, "^") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "^") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if a == "" || b == "" {
+			break
+		}
+
+		var aSeg, bSeg string
+		numeric := unicode.IsDigit(rune(a[0]))
+		if numeric {
+			aSeg, a = splitWhile(a, unicode.IsDigit)
+			bSeg, b = splitWhile(b, unicode.IsDigit)
+		} else {
+			aSeg, a = splitWhile(a, unicode.IsLetter)
+			bSeg, b = splitWhile(b, unicode.IsLetter)
+		}
+
+		// Numeric segments are always newer than alpha segments.
+		if bSeg == "" {
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		var c int
+		if numeric {
+			c = compareNumeric(aSeg, bSeg)
+		} else {
+			c = strings.Compare(aSeg, bSeg)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	default:
+		return 1
+	}
+}
+
+// apkSuffixOrder ranks the pre- and post-release suffixes apk understands.
+// Pre-release suffixes sort before a bare version, post-release after.
+var apkSuffixOrder = map[string]int{
+	"alpha": -4,
+	"beta":  -3,
+	"pre":   -2,
+	"rc":    -1,
+	"cvs":   1,
+	"svn":   2,
+	"git":   3,
+	"hg":    4,
+	"p":     5,
+}
+
+// CompareAlpine compares two apk package versions, e.g. 1.2.4_git2023-r2.
+func CompareAlpine(a, b string) int {
+	aVersion, aRevision := splitAlpine(a)
+	bVersion, bRevision := splitAlpine(b)
+
+	aMain, aSuffixes := splitAlpineSuffixes(aVersion)
+	bMain, bSuffixes := splitAlpineSuffixes(bVersion)
+
+	if c := compareDotted(aMain, bMain); c != 0 {
+		return c
+	}
+
+	for i := 0; i < len(aSuffixes) || i < len(bSuffixes); i++ {
+		var aName, bName, aNum, bNum string
+		if i < len(aSuffixes) {
+			aName, aNum = aSuffixes[i][0], aSuffixes[i][1]
+		}
+		if i < len(bSuffixes) {
+			bName, bNum = bSuffixes[i][0], bSuffixes[i][1]
+		}
+		if c := compareInt(apkSuffixOrder[aName], apkSuffixOrder[bName]); c != 0 {
+			return c
+		}
+		if c := compareNumeric(aNum, bNum); c != 0 {
+			return c
+		}
+	}
+
+	return compareNumeric(aRevision, bRevision)
+}
+
+func splitAlpine(v string) (version, revision string) {
+	if i := strings.LastIndex(v, "-r"); i >= 0 {
+		return v[:i], v[i+2:]
+	}
+	return v, ""
+}
+
+func splitAlpineSuffixes(v string) (main string, suffixes [][2]string) {
+	parts := strings.Split(v, "_")
+	for _, part := range parts[1:] {
+		name, num := splitWhile(part, unicode.IsLetter)
+		suffixes = append(suffixes, [2]string{name, num})
+	}
+	return parts[0], suffixes
+}
+
+// CompareSemver compares semantic versions. A leading "v" is ignored and
+// missing minor or patch components are treated as zero. Pre-release
+// versions sort before the corresponding release.
+func CompareSemver(a, b string) int {
+	aCore, aPre := splitSemver(a)
+	bCore, bPre := splitSemver(b)
+
+	if c := compareDotted(aCore, bCore); c != 0 {
+		return c
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+
+	aIDs, bIDs := strings.Split(aPre, "."), strings.Split(bPre, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		aNum, aErr := strconv.Atoi(aIDs[i])
+		bNum, bErr := strconv.Atoi(bIDs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInt(aNum, bNum); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aIDs[i], bIDs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(len(aIDs), len(bIDs))
+}
+
+func splitSemver(v string) (core, pre string) {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "+")
+	core, pre, _ = strings.Cut(v, "-")
+	return core, pre
+}
+
+// pep440Re matches PEP 440 versions, including the alternative spellings
+// the specification normalizes, e.g. 1.0-alpha.1 for 1.0a1.
+var pep440Re = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|alpha|b|beta|c|rc|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+// pep440Phases ranks pre-release phases. A final release ranks above all of
+// them, a development release without a pre-release below.
+var pep440Phases = map[string]int{
+	"a": 1, "alpha": 1,
+	"b": 2, "beta": 2,
+	"c": 3, "rc": 3, "pre": 3, "preview": 3,
+}
+
+type pep440Version struct {
+	epoch   string
+	release []string
+	// phase and pre order pre-releases, post post-releases (-1 for none)
+	// and dev development releases (math.MaxInt for none).
+	phase, pre, post, dev int
+	local                 []string
+}
+
+func parsePEP440(v string) (*pep440Version, bool) {
+	m := pep440Re.FindStringSubmatch(strings.ToLower(strings.TrimSpace(v)))
+	if m == nil {
+		return nil, false
+	}
+	atoi := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	p := &pep440Version{
+		epoch:   m[1],
+		release: strings.Split(m[2], "."),
+		phase:   len(pep440Phases),
+		post:    -1,
+		dev:     math.MaxInt,
+	}
+	if m[3] != "" {
+		p.phase, p.pre = pep440Phases[m[3]], atoi(m[4])
+	}
+	switch {
+	case m[5] != "":
+		p.post = atoi(m[5])
+	case m[6] != "":
+		p.post = atoi(m[7])
+	}
+	if m[8] != "" {
+		p.dev = atoi(m[9])
+		// 1.0.dev1 sorts before 1.0a1, but 1.0.post1.dev1 after 1.0.
+		if m[3] == "" && p.post < 0 {
+			p.phase = 0
+		}
+	}
+	if m[10] != "" {
+		p.local = strings.FieldsFunc(m[10], func(r rune) bool { return r == '.' || r == '-' || r == '_' })
+	}
+	return p, true
+}
+
+// ComparePyPI compares Python package versions following PEP 440:
+// development releases sort before pre-releases (a, b, rc), which sort
+// before the release, which sorts before its post-releases. Versions that
+// are not valid PEP 440 are compared with CompareGeneric.
+func ComparePyPI(a, b string) int {
+	av, aOK := parsePEP440(a)
+	bv, bOK := parsePEP440(b)
+	if !aOK || !bOK {
+		return CompareGeneric(a, b)
+	}
+
+	if c := compareNumeric(av.epoch, bv.epoch); c != 0 {
+		return c
+	}
+	for i := 0; i < len(av.release) || i < len(bv.release); i++ {
+		var aPart, bPart string
+		if i < len(av.release) {
+			aPart = av.release[i]
+		}
+		if i < len(bv.release) {
+			bPart = bv.release[i]
+		}
+		if c := compareNumeric(aPart, bPart); c != 0 {
+			return c
+		}
+	}
+	for _, c := range []int{
+		compareInt(av.phase, bv.phase),
+		compareInt(av.pre, bv.pre),
+		compareInt(av.post, bv.post),
+		compareInt(av.dev, bv.dev),
+	} {
+		if c != 0 {
+			return c
+		}
+	}
+
+	// Numeric local segments sort after alphanumeric ones, and a local
+	// version after the same version without one.
+	for i := 0; i < len(av.local) && i < len(bv.local); i++ {
+		aNum := strings.Trim(av.local[i], "0123456789") == ""
+		bNum := strings.Trim(bv.local[i], "0123456789") == ""
+		var c int
+		switch {
+		case aNum && bNum:
+			c = compareNumeric(av.local[i], bv.local[i])
+		case aNum:
+			c = 1
+		case bNum:
+			c = -1
+		default:
+			c = strings.Compare(av.local[i], bv.local[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(av.local), len(bv.local))
+}
+
+// mavenQualifiers ranks the qualifiers Maven's ComparableVersion knows.
+// The empty qualifier is a release; unknown qualifiers rank above all of
+// these and compare alphabetically among themselves.
+var mavenQualifiers = map[string]int{
+	"alpha":     0,
+	"beta":      1,
+	"milestone": 2,
+	"rc":        3,
+	"cr":        3,
+	"snapshot":  4,
+	"":          5,
+	"ga":        5,
+	"final":     5,
+	"release":   5,
+	"sp":        6,
+}
+
+// mavenItem is a number, without leading zeros, or a qualifier.
+type mavenItem struct {
+	numeric bool
+	value   string
+}
+
+// null reports whether the item is equal to a missing one, such as the
+// trailing zero of 1.0 or the ga of 1-ga.
+func (i mavenItem) null() bool {
+	if i.numeric {
+		return i.value == ""
+	}
+	rank, ok := mavenQualifiers[i.value]
+	return ok && rank == mavenQualifiers[""]
+}
+
+// mavenItems splits a Maven version into numbers and qualifiers. Like
+// ComparableVersion, null items are dropped before each dash and at the
+// end, and a, b and m directly followed by a number stand for alpha, beta
+// and milestone. Unlike it, the items are not nested into sublists.
+func mavenItems(v string) []mavenItem {
+	var items []mavenItem
+	trim := func() {
+		for len(items) > 0 && items[len(items)-1].null() {
+			items = items[:len(items)-1]
+		}
+	}
+
+	v = strings.ToLower(v)
+	for v != "" {
+		switch {
+		case v[0] == '.':
+			v = v[1:]
+		case v[0] == '-':
+			trim()
+			v = v[1:]
+		case v[0] >= '0' && v[0] <= '9':
+			var num string
+			num, v = splitWhile(v, unicode.IsDigit)
+			items = append(items, mavenItem{numeric: true, value: strings.TrimLeft(num, "0")})
+		default:
+			var q string
+			q, v = splitWhile(v, func(r rune) bool { return r != '.' && r != '-' && !unicode.IsDigit(r) })
+			if v != "" && v[0] >= '0' && v[0] <= '9' {
+				switch q {
+				case "a":
+					q = "alpha"
+				case "b":
+					q = "beta"
+				case "m":
+					q = "milestone"
+				}
+			}
+			// A qualifier starts a new list, after a number as well.
+			trim()
+			items = append(items, mavenItem{value: q})
+		}
+	}
+	trim()
+	return items
+}
+
+// compareMavenItem compares two items, either of which may be missing.
+// Numbers sort after qualifiers, and a missing item is the release.
+func compareMavenItem(a, b *mavenItem) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -compareMavenItem(b, nil)
+	case b == nil:
+		if a.numeric {
+			return compareNumeric(a.value, "")
+		}
+		return compareMavenQualifier(a.value, "")
+	case a.numeric && b.numeric:
+		return compareNumeric(a.value, b.value)
+	case a.numeric:
+		return 1
+	case b.numeric:
+		return -1
+	default:
+		return compareMavenQualifier(a.value, b.value)
+	}
+}
+
+func compareMavenQualifier(a, b string) int {
+	aRank, aKnown := mavenQualifiers[a]
+	bRank, bKnown := mavenQualifiers[b]
+	if !aKnown {
+		aRank = len(mavenQualifiers)
+	}
+	if !bKnown {
+		bRank = len(mavenQualifiers)
+	}
+	if c := compareInt(aRank, bRank); c != 0 || aKnown || bKnown {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// CompareMaven compares Maven artifact versions following the ordering of
+// Maven's ComparableVersion: alpha < beta < milestone < rc < snapshot <
+// release < sp, so 2.0.0-beta sorts before 2.0.0.
+func CompareMaven(a, b string) int {
+	aItems, bItems := mavenItems(a), mavenItems(b)
+	for i := 0; i < len(aItems) || i < len(bItems); i++ {
+		var aItem, bItem *mavenItem
+		if i < len(aItems) {
+			aItem = &aItems[i]
+		}
+		if i < len(bItems) {
+			bItem = &bItems[i]
+		}
+		if c := compareMavenItem(aItem, bItem); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// gemSegments splits a gem version into numeric and alphabetic segments as
+// Gem::Version does, with trailing zeros dropped from the release and the
+// pre-release parts.
+func gemSegments(v string) []string {
+	v = strings.ReplaceAll(strings.TrimSpace(v), "-", ".pre.")
+
+	var segments []string
+	for v != "" {
+		var seg string
+		switch {
+		case v[0] >= '0' && v[0] <= '9':
+			seg, v = splitWhile(v, unicode.IsDigit)
+			seg = strings.TrimLeft(seg, "0")
+		case unicode.IsLetter(rune(v[0])):
+			seg, v = splitWhile(v, unicode.IsLetter)
+		default:
+			v = v[1:]
+			continue
+		}
+		segments = append(segments, seg)
+	}
+
+	pre := len(segments)
+	for i, seg := range segments {
+		if !isNumeric(seg) {
+			pre = i
+			break
+		}
+	}
+	release, prerelease := segments[:pre], segments[pre:]
+	for len(release) > 0 && release[len(release)-1] == "" {
+		release = release[:len(release)-1]
+	}
+	for len(prerelease) > 0 && prerelease[len(prerelease)-1] == "" {
+		prerelease = prerelease[:len(prerelease)-1]
+	}
+	return append(release[:len(release):len(release)], prerelease...)
+}
+
+// isNumeric reports whether a gem segment is a number. Numbers are stored
+// without leading zeros, so zero is the empty string.
+func isNumeric(seg string) bool {
+	return seg == "" || (seg[0] >= '0' && seg[0] <= '9')
+}
+
+// CompareRubyGems compares gem versions following Gem::Version: a version
+// with a letter in it is a pre-release and sorts before the release, so
+// 2.0.0.rc1 and 2.0.0-beta sort before 2.0.0.
+func CompareRubyGems(a, b string) int {
+	aSegs, bSegs := gemSegments(a), gemSegments(b)
+	for i := 0; i < len(aSegs) || i < len(bSegs); i++ {
+		var aSeg, bSeg string
+		if i < len(aSegs) {
+			aSeg = aSegs[i]
+		}
+		if i < len(bSegs) {
+			bSeg = bSegs[i]
+		}
+		aNum, bNum := isNumeric(aSeg), isNumeric(bSeg)
+		var c int
+		switch {
+		case aNum && bNum:
+			c = compareNumeric(aSeg, bSeg)
+		case aNum:
+			c = 1
+		case bNum:
+			c = -1
+		default:
+			c = strings.Compare(aSeg, bSeg)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// CompareGeneric compares versions for ecosystems without a dedicated
+// algorithm by comparing dot separated components, numerically where
+// possible. A component with a qualifier, such as the rc1 of 1.0.0rc1 or
+// the beta of 2.0.0-beta, sorts before the bare component, so pre-releases
+// sort before their release.
+func CompareGeneric(a, b string) int {
+	split := func(s string) []string {
+		return strings.FieldsFunc(strings.TrimPrefix(s, "v"), func(r rune) bool { return r == '.' || r == '-' || r == '_' })
+	}
+	aParts, bParts := split(a), split(b)
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart string
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+
+		aNum, aRest := splitWhile(aPart, unicode.IsDigit)
+		bNum, bRest := splitWhile(bPart, unicode.IsDigit)
+		if c := compareNumeric(aNum, bNum); c != 0 {
+			return c
+		}
+		switch {
+		case aRest == bRest:
+		case aRest == "":
+			return 1
+		case bRest == "":
+			return -1
+		default:
+			return strings.Compare(aRest, bRest)
+		}
+	}
+	return 0
+}
+
+// compareDotted compares dot separated components, numerically where
+// possible, treating missing components as zero.
+func compareDotted(a, b string) int {
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '-' || r == '_' })
+	}
+	aParts, bParts := split(a), split(b)
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aPart, bPart := "0", "0"
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+
+		aNum, aRest := splitWhile(aPart, unicode.IsDigit)
+		bNum, bRest := splitWhile(bPart, unicode.IsDigit)
+		if c := compareNumeric(aNum, bNum); c != 0 {
+			return c
+		}
+		if c := strings.Compare(aRest, bRest); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareNumeric compares two digit strings of arbitrary length. Empty
+// strings are treated as zero.
+func compareNumeric(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if c := compareInt(len(a), len(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func splitWhile(s string, f func(rune) bool) (match, rest string) {
+	i := strings.IndexFunc(s, func(r rune) bool { return !f(r) })
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
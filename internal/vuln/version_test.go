@@ -0,0 +1,198 @@
+package vuln
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareDebian(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0-1", "1.0-2", -1},
+		{"1:1.0", "2.0", 1},
+		{"1.0~rc1", "1.0", -1},
+		{"3.0.11-1~deb12u2", "3.0.11-1~deb12u1", 1},
+		{"3.0.11-1~deb12u2", "3.0.11-1", -1},
+		{"2.36-9+deb12u4", "2.36-9", 1},
+		{"1.2.10", "1.2.9", 1},
+		{"1.0a", "1.0+", -1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CompareDebian(tt.a, tt.b), "%s vs %s", tt.a, tt.b)
+		assert.Equal(t, -tt.want, CompareDebian(tt.b, tt.a), "%s vs %s", tt.b, tt.a)
+	}
+}
+
+func TestCompareAlpine(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.4-r2", "1.2.4-r2", 0},
+		{"1.2.4-r2", "1.2.4-r10", -1},
+		{"1.36.1-r15", "1.36.1-r2", 1},
+		{"3.1.4_rc1-r0", "3.1.4-r0", -1},
+		{"3.1.4_p1-r0", "3.1.4-r0", 1},
+		{"1.10.0-r0", "1.9.0-r0", 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CompareAlpine(tt.a, tt.b), "%s vs %s", tt.a, tt.b)
+		assert.Equal(t, -tt.want, CompareAlpine(tt.b, tt.a), "%s vs %s", tt.b, tt.a)
+	}
+}
+
+func TestCompareRPM(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0-1.el9", "1.0-1.el9", 0},
+		{"1.0-1.el9", "1.0-2.el9", -1},
+		{"1:1.0-1", "2.0-1", 1},
+		{"1.0~beta-1", "1.0-1", -1},
+		{"1.0^git1-1", "1.0-1", 1},
+		{"1.10-1", "1.9-1", 1},
+		{"1.0a-1", "1.0.1-1", -1},
+		{"3.0.7-27.el9", "3.0.7-25.el9_3", 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CompareRPM(tt.a, tt.b), "%s vs %s", tt.a, tt.b)
+		assert.Equal(t, -tt.want, CompareRPM(tt.b, tt.a), "%s vs %s", tt.b, tt.a)
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "v1.0.0", 0},
+		{"1.9.0", "1.10.0", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.2", "1.0.0-alpha.10", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.21", "1.21.0", 0},
+		{"0.0.0-20230101000000-abcdef", "0.1.0", -1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CompareSemver(tt.a, tt.b), "%s vs %s", tt.a, tt.b)
+		assert.Equal(t, -tt.want, CompareSemver(tt.b, tt.a), "%s vs %s", tt.b, tt.a)
+	}
+}
+
+func TestComparePyPI(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0rc1", "1.0.0", -1},
+		{"1.0a1", "1.0b1", -1},
+		{"1.0b2", "1.0rc1", -1},
+		{"1.0.dev1", "1.0a1", -1},
+		{"1.0rc1.dev1", "1.0rc1", -1},
+		{"1.0", "1.0.post1", -1},
+		{"1.0.post1.dev1", "1.0.post1", -1},
+		{"1.0.post1.dev1", "1.0", 1},
+		{"1.0-1", "1.0.post1", 0},
+		{"1.0alpha1", "1.0a1", 0},
+		{"1.0-RC1", "1.0rc1", 0},
+		{"1.0", "1.0.0", 0},
+		{"1.0+local.1", "1.0", 1},
+		{"1.0+abc", "1.0+1", -1},
+		{"1!0.1", "2.0", 1},
+		{"1.10", "1.9", 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ComparePyPI(tt.a, tt.b), "%s vs %s", tt.a, tt.b)
+		assert.Equal(t, -tt.want, ComparePyPI(tt.b, tt.a), "%s vs %s", tt.b, tt.a)
+	}
+}
+
+func TestCompareMaven(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.0.0-beta", "2.0.0", -1},
+		{"1.0-alpha-1", "1.0-beta-1", -1},
+		{"1.0-m1", "1.0-rc1", -1},
+		{"1.0-rc1", "1.0", -1},
+		{"1.0-SNAPSHOT", "1.0", -1},
+		{"1.0-rc1", "1.0-SNAPSHOT", -1},
+		{"1.0-cr1", "1.0-rc1", 0},
+		{"1.0.Final", "1.0", 0},
+		{"1-ga", "1.0.0", 0},
+		{"1.0-sp1", "1.0", 1},
+		{"1.0.1", "1.0-rc1", 1},
+		{"1.0-foo", "1.0-sp", 1},
+		{"2.10", "2.9", 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CompareMaven(tt.a, tt.b), "%s vs %s", tt.a, tt.b)
+		assert.Equal(t, -tt.want, CompareMaven(tt.b, tt.a), "%s vs %s", tt.b, tt.a)
+	}
+}
+
+func TestCompareRubyGems(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.0.0-beta", "2.0.0", -1},
+		{"2.0.0.rc1", "2.0.0", -1},
+		{"1.0.0.pre", "1.0.0", -1},
+		{"1.0.a", "1.0.b", -1},
+		{"1.0.0.alpha", "1.0.0.beta.1", -1},
+		{"1.0.a", "1.a", 0},
+		{"1.0", "1.0.0", 0},
+		{"1.10", "1.9", 1},
+		{"1.0.1", "1.0.1.rc2", 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CompareRubyGems(tt.a, tt.b), "%s vs %s", tt.a, tt.b)
+		assert.Equal(t, -tt.want, CompareRubyGems(tt.b, tt.a), "%s vs %s", tt.b, tt.a)
+	}
+}
+
+func TestCompareGeneric(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0rc1", "1.0.0", -1},
+		{"2.0.0-beta", "2.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0", "1.0.0", 0},
+		{"1.10", "1.9", 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CompareGeneric(tt.a, tt.b), "%s vs %s", tt.a, tt.b)
+		assert.Equal(t, -tt.want, CompareGeneric(tt.b, tt.a), "%s vs %s", tt.b, tt.a)
+	}
+}
+
+func TestCVSS3BaseScore(t *testing.T) {
+	tests := []struct {
+		vector string
+		want   float64
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1},
+		{"CVSS:3.0/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N", 5.5},
+		{"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:N/A:N", 0},
+	}
+	for _, tt := range tests {
+		got, err := cvss3BaseScore(tt.vector)
+		assert.NoError(t, err, tt.vector)
+		assert.Equal(t, tt.want, got, tt.vector)
+	}
+
+	_, err := cvss3BaseScore("AV:N/AC:L/Au:N/C:P/I:P/A:P")
+	assert.Error(t, err)
+}
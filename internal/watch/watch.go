@@ -0,0 +1,159 @@
+// Package watch polls a tag and reports when the digest behind it changes.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Layer is a compressed layer of an image manifest.
+type Layer struct {
+	Digest v1.Hash
+	Size   int64
+}
+
+// LayerDiff summarizes how the layers of two images differ.
+type LayerDiff struct {
+	Added   []Layer
+	Removed []Layer
+	// Unchanged counts layers present in both images.
+	Unchanged int
+}
+
+// Event reports a tag that moved to a new digest.
+type Event struct {
+	Time time.Time
+	Old  v1.Hash
+	New  v1.Hash
+	// Layers compares the images for the watched platform.
+	Layers LayerDiff
+}
+
+// Watcher tracks the digest behind a tag. The layers of the current image
+// are kept, so changes can be summarized even when the registry no longer
+// serves the previous manifest.
+type Watcher struct {
+	ref      name.Reference
+	platform *v1.Platform
+	opts     []remote.Option
+
+	digest v1.Hash
+	layers []Layer
+}
+
+// New resolves ref and returns a Watcher starting from its current digest.
+// For indexes, layers are compared for platform, or linux/amd64 when empty.
+func New(ctx context.Context, ref name.Reference, platform string, opts ...remote.Option) (*Watcher, error) {
+	w := &Watcher{ref: ref, opts: opts}
+	if platform != "" {
+		p, err := v1.ParsePlatform(platform)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse platform: %w", err)
+		}
+		w.platform = p
+	}
+
+	desc, err := remote.Head(ref, w.remoteOptions(ctx)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	layers, err := w.fetchLayers(ctx, desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	w.digest, w.layers = desc.Digest, layers
+	return w, nil
+}
+
+// Digest returns the digest last seen behind the tag.
+func (w *Watcher) Digest() v1.Hash {
+	return w.digest
+}
+
+// Poll checks the tag with a HEAD request, which registries such as Docker
+// Hub do not count against pull rate limits. It returns nil when the digest
+// is unchanged, and otherwise an Event after fetching the new manifest.
+func (w *Watcher) Poll(ctx context.Context) (*Event, error) {
+	desc, err := remote.Head(w.ref, w.remoteOptions(ctx)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", w.ref, err)
+	}
+	if desc.Digest == w.digest {
+		return nil, nil
+	}
+
+	layers, err := w.fetchLayers(ctx, desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	event := &Event{
+		Time:   time.Now().UTC(),
+		Old:    w.digest,
+		New:    desc.Digest,
+		Layers: Diff(w.layers, layers),
+	}
+	w.digest, w.layers = desc.Digest, layers
+	return event, nil
+}
+
+// fetchLayers reads the layer descriptors from the manifest, without
+// downloading any layer.
+func (w *Watcher) fetchLayers(ctx context.Context, digest v1.Hash) ([]Layer, error) {
+	opts := w.remoteOptions(ctx)
+	if w.platform != nil {
+		opts = append(opts, remote.WithPlatform(*w.platform))
+	}
+
+	ref := w.ref.Context().Digest(digest.String())
+	img, err := remote.Image(ref, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest of %s: %w", ref, err)
+	}
+
+	layers := make([]Layer, len(manifest.Layers))
+	for i, l := range manifest.Layers {
+		layers[i] = Layer{Digest: l.Digest, Size: l.Size}
+	}
+	return layers, nil
+}
+
+func (w *Watcher) remoteOptions(ctx context.Context) []remote.Option {
+	return append([]remote.Option{remote.WithContext(ctx)}, w.opts...)
+}
+
+// Diff compares the layers of two images by digest, keeping the order in
+// which layers appear in each image.
+func Diff(old, updated []Layer) LayerDiff {
+	inOld := make(map[v1.Hash]bool, len(old))
+	for _, l := range old {
+		inOld[l.Digest] = true
+	}
+	inUpdated := make(map[v1.Hash]bool, len(updated))
+	for _, l := range updated {
+		inUpdated[l.Digest] = true
+	}
+
+	var diff LayerDiff
+	for _, l := range updated {
+		if inOld[l.Digest] {
+			diff.Unchanged++
+		} else {
+			diff.Added = append(diff.Added, l)
+		}
+	}
+	for _, l := range old {
+		if !inUpdated[l.Digest] {
+			diff.Removed = append(diff.Removed, l)
+		}
+	}
+	return diff
+}
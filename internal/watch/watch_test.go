@@ -0,0 +1,84 @@
+package watch_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bschaatsbergen/cek/internal/watch"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_Poll(t *testing.T) {
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(srv.Close)
+	tag, err := name.NewTag(strings.TrimPrefix(srv.URL, "http://") + "/nginx:1.27")
+	require.NoError(t, err)
+
+	base, err := random.Image(128, 2)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(tag, base))
+	baseDigest, err := base.Digest()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	w, err := watch.New(ctx, tag, "")
+	require.NoError(t, err)
+	assert.Equal(t, baseDigest, w.Digest())
+
+	event, err := w.Poll(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, event, "unchanged tag")
+
+	// Re-push the tag with an extra layer on top.
+	extra, err := random.Layer(256, "application/vnd.oci.image.layer.v1.tar+gzip")
+	require.NoError(t, err)
+	updated, err := mutate.AppendLayers(base, extra)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(tag, updated))
+	updatedDigest, err := updated.Digest()
+	require.NoError(t, err)
+
+	event, err = w.Poll(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, baseDigest, event.Old)
+	assert.Equal(t, updatedDigest, event.New)
+	assert.Equal(t, 2, event.Layers.Unchanged)
+	require.Len(t, event.Layers.Added, 1)
+	extraDigest, err := extra.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, extraDigest, event.Layers.Added[0].Digest)
+	assert.Empty(t, event.Layers.Removed)
+	assert.Equal(t, updatedDigest, w.Digest())
+
+	event, err = w.Poll(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, event)
+}
+
+func TestDiff(t *testing.T) {
+	layer := func(c string) watch.Layer {
+		return watch.Layer{Digest: v1.Hash{Algorithm: "sha256", Hex: strings.Repeat(c, 64)}, Size: 10}
+	}
+	a, b, c, d := layer("a"), layer("b"), layer("c"), layer("d")
+
+	diff := watch.Diff([]watch.Layer{a, b, c}, []watch.Layer{a, d, c})
+	assert.Equal(t, []watch.Layer{d}, diff.Added)
+	assert.Equal(t, []watch.Layer{b}, diff.Removed)
+	assert.Equal(t, 2, diff.Unchanged)
+
+	diff = watch.Diff(nil, []watch.Layer{a})
+	assert.Equal(t, []watch.Layer{a}, diff.Added)
+	assert.Empty(t, diff.Removed)
+}
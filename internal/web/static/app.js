@@ -0,0 +1,328 @@
+// The cek web app. Every call goes to the JSON API of cek serve, whose
+// payloads are the --json output of the command of the same name.
+"use strict";
+
+const $ = (id) => document.getElementById(id);
+
+// maxRows caps how many files a directory or a filter shows at once.
+const maxRows = 1000;
+
+const state = {
+  image: "",
+  platform: "",
+  layer: null, // null is the merged filesystem
+  files: [],
+};
+
+async function api(endpoint, params) {
+  const query = new URLSearchParams();
+  for (const [key, value] of Object.entries(params)) {
+    if (value !== null && value !== undefined && value !== "") {
+      query.set(key, value);
+    }
+  }
+  const response = await fetch(`api/${endpoint}?${query}`);
+  const data = await response.json().catch(() => ({ error: response.statusText }));
+  if (!response.ok) {
+    throw new Error(data.error || response.statusText);
+  }
+  return data;
+}
+
+function element(tag, props = {}, ...children) {
+  const el = document.createElement(tag);
+  Object.assign(el, props);
+  el.append(...children.filter((c) => c !== null && c !== undefined));
+  return el;
+}
+
+function formatBytes(bytes) {
+  const units = ["B", "KB", "MB", "GB", "TB"];
+  let i = 0;
+  while (bytes >= 1024 && i < units.length - 1) {
+    bytes /= 1024;
+    i++;
+  }
+  return `${i === 0 ? bytes : bytes.toFixed(1)} ${units[i]}`;
+}
+
+function shortDigest(digest) {
+  return digest ? digest.slice(0, "sha256:".length + 12) : "";
+}
+
+function setStatus(id, message, error = false) {
+  $(id).textContent = message;
+  $(id).classList.toggle("error", error);
+}
+
+// Tabs
+
+for (const button of document.querySelectorAll("nav button")) {
+  button.addEventListener("click", () => {
+    for (const b of document.querySelectorAll("nav button")) {
+      b.classList.toggle("active", b === button);
+    }
+    for (const tab of document.querySelectorAll(".tab")) {
+      tab.classList.toggle("active", tab.id === button.dataset.tab);
+    }
+  });
+}
+
+// Explore
+
+$("image-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  loadImage($("image").value.trim(), $("platform").value.trim());
+});
+
+async function loadImage(image, platform) {
+  state.image = image;
+  state.platform = platform;
+  state.layer = null;
+  history.replaceState(null, "", `#${new URLSearchParams({ image, platform })}`);
+
+  setStatus("status", `Loading ${image}…`);
+  $("image-view").hidden = true;
+  try {
+    const [inspect, config] = await Promise.all([
+      api("inspect", { image, platform }),
+      // The config comes from the registry only; other sources have no history.
+      api("config", { image, platform }).catch(() => null),
+    ]);
+    renderSummary(inspect);
+    renderLayers(inspect.layers);
+    renderHistory(config && config.content ? config.content.history || [] : []);
+    $("image-view").hidden = false;
+    setStatus("status", "");
+    await loadFiles();
+  } catch (err) {
+    setStatus("status", err.message, true);
+  }
+}
+
+function renderSummary(inspect) {
+  const rows = [
+    ["Image", inspect.image],
+    ["Digest", inspect.digest],
+    ["Platform", `${inspect.os}/${inspect.arch}`],
+    ["Size", `${formatBytes(inspect.size)} in ${inspect.layers.length} layers`],
+    ["Created", inspect.created],
+  ];
+  if (inspect.os_info) {
+    const info = inspect.os_info;
+    let os = info.pretty_name || `${info.distro} ${info.version || ""}`;
+    if (info.eol) {
+      os += ` (end of life ${info.eol.slice(0, 10)})`;
+    }
+    rows.push(["OS", os]);
+  }
+  $("summary").replaceChildren(...rows.flatMap(([k, v]) => [element("dt", { textContent: k }), element("dd", { textContent: v })]));
+}
+
+function renderLayers(layers) {
+  const items = [{ label: "All layers (merged)", layer: null }].concat(
+    layers.map((l) => ({
+      label: `${l.index}. ${shortDigest(l.digest)}`,
+      detail: formatBytes(l.size),
+      layer: l.index,
+    })),
+  );
+  $("layers").replaceChildren(
+    ...items.map((item) => {
+      const li = element("li", { tabIndex: 0 }, element("span", { textContent: item.label }), item.detail ? element("small", { textContent: item.detail }) : null);
+      li.classList.toggle("selected", item.layer === state.layer);
+      const select = () => {
+        state.layer = item.layer;
+        for (const other of $("layers").children) {
+          other.classList.toggle("selected", other === li);
+        }
+        loadFiles();
+      };
+      li.addEventListener("click", select);
+      li.addEventListener("keydown", (event) => event.key === "Enter" && select());
+      return li;
+    }),
+  );
+}
+
+function renderHistory(entries) {
+  if (entries.length === 0) {
+    $("history").replaceChildren(element("li", { className: "muted", textContent: "No history" }));
+    return;
+  }
+  let layer = 0;
+  $("history").replaceChildren(
+    ...entries.map((h) => {
+      const label = h.empty_layer ? "no layer" : `layer ${++layer}`;
+      const command = (h.created_by || "").replace(/^\/bin\/sh -c (#\(nop\) )?/, "").trim();
+      return element(
+        "li",
+        { className: h.empty_layer ? "muted" : "" },
+        element("small", { textContent: `${label}${h.created ? " · " + h.created.slice(0, 10) : ""}` }),
+        element("code", { textContent: command || h.comment || "" }),
+      );
+    }),
+  );
+}
+
+async function loadFiles() {
+  const title = state.layer === null ? "Files" : `Files in layer ${state.layer}`;
+  $("files-title").textContent = `${title} (loading…)`;
+  try {
+    const data = await api("ls", { image: state.image, platform: state.platform, layer: state.layer });
+    state.files = data.files || [];
+    $("files-title").textContent = `${title} (${state.files.length})`;
+    $("filter").value = "";
+    renderTree();
+  } catch (err) {
+    $("files-title").textContent = title;
+    $("tree").replaceChildren(element("p", { className: "error", textContent: err.message }));
+  }
+}
+
+// buildTree nests the flat file list of ls into directories.
+function buildTree(files) {
+  const root = { name: "/", path: "/", dir: true, children: new Map() };
+  const ensure = (path) => {
+    if (path === "/" || path === "") {
+      return root;
+    }
+    const slash = path.lastIndexOf("/");
+    const parent = ensure(path.slice(0, slash) || "/");
+    const name = path.slice(slash + 1);
+    if (!parent.children.has(name)) {
+      parent.children.set(name, { name, path, dir: true, children: new Map() });
+    }
+    return parent.children.get(name);
+  };
+  for (const file of files) {
+    const node = ensure(file.path.replace(/\/+$/, ""));
+    node.mode = file.mode;
+    node.size = file.size;
+    node.dir = file.mode.startsWith("d");
+  }
+  return root;
+}
+
+function sortedChildren(node) {
+  return [...node.children.values()].sort((a, b) => (a.dir === b.dir ? a.name.localeCompare(b.name) : a.dir ? -1 : 1));
+}
+
+function renderTree() {
+  const root = buildTree(state.files);
+  $("tree").replaceChildren(renderChildren(root));
+}
+
+// renderChildren renders a directory listing; subdirectories render their
+// own contents when first opened, so large images stay responsive.
+function renderChildren(node) {
+  const list = element("ul");
+  const children = sortedChildren(node);
+  for (const child of children.slice(0, maxRows)) {
+    if (child.dir && child.children.size > 0) {
+      const details = element("details", {}, element("summary", { textContent: `${child.name}/` }));
+      details.addEventListener("toggle", () => {
+        if (details.open && details.children.length === 1) {
+          details.append(renderChildren(child));
+        }
+      });
+      list.append(element("li", {}, details));
+    } else {
+      list.append(fileItem(child, child.dir ? `${child.name}/` : child.name));
+    }
+  }
+  if (children.length > maxRows) {
+    list.append(element("li", { className: "muted", textContent: `${children.length - maxRows} more, use the filter` }));
+  }
+  return list;
+}
+
+function fileItem(node, label) {
+  const button = element("button", { type: "button", className: "file", textContent: label });
+  if (node.dir) {
+    button.disabled = true;
+  } else {
+    button.addEventListener("click", () => viewFile(node.path));
+  }
+  return element("li", {}, button, node.size ? element("small", { textContent: formatBytes(node.size) }) : null);
+}
+
+$("filter").addEventListener("input", () => {
+  const query = $("filter").value.trim().toLowerCase();
+  if (query === "") {
+    renderTree();
+    return;
+  }
+  const matches = state.files.filter((f) => !f.mode.startsWith("d") && f.path.toLowerCase().includes(query));
+  const list = element("ul");
+  for (const file of matches.slice(0, maxRows)) {
+    list.append(fileItem({ path: file.path, size: file.size, dir: false }, file.path));
+  }
+  if (matches.length === 0) {
+    list.append(element("li", { className: "muted", textContent: "No matching files" }));
+  } else if (matches.length > maxRows) {
+    list.append(element("li", { className: "muted", textContent: `${matches.length - maxRows} more` }));
+  }
+  $("tree").replaceChildren(list);
+});
+
+async function viewFile(path) {
+  $("viewer-title").textContent = `${path} (loading…)`;
+  const code = $("viewer").firstElementChild;
+  try {
+    const data = await api("cat", { image: state.image, platform: state.platform, layer: state.layer, path });
+    const content = data.content || "";
+    if (content.includes("\u0000")) {
+      $("viewer-title").textContent = path;
+      code.textContent = "Binary file";
+      return;
+    }
+    const { html, language } = highlighter.highlight(content, path);
+    $("viewer-title").textContent = language ? `${path} · ${language}` : path;
+    code.innerHTML = html;
+  } catch (err) {
+    $("viewer-title").textContent = path;
+    code.textContent = err.message;
+  }
+}
+
+// Compare
+
+$("compare-form").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const params = { old: $("old").value.trim(), new: $("new").value.trim(), platform: $("compare-platform").value.trim() };
+  setStatus("compare-status", `Comparing ${params.old} and ${params.new}…`);
+  $("compare-view").hidden = true;
+  try {
+    renderDiff(await api("diff", params));
+    setStatus("compare-status", "");
+    $("compare-view").hidden = false;
+  } catch (err) {
+    setStatus("compare-status", err.message, true);
+  }
+});
+
+function renderDiff(data) {
+  $("compare-summary").textContent = `${data.old.image} → ${data.new.image}: ${data.summary}`;
+
+  const cell = (text, className = "") => element("td", { textContent: text || "", className });
+  const changes = data.changes || [];
+  $("changes").tBodies[0].replaceChildren(
+    ...(changes.length === 0
+      ? [element("tr", {}, element("td", { colSpan: 5, className: "muted", textContent: "No config changes" }))]
+      : changes.map((c) => element("tr", { className: `change-${c.type}` }, cell(c.type), cell(c.field), cell(c.key), cell(c.old), cell(c.new)))),
+  );
+  $("compare-layers").tBodies[0].replaceChildren(
+    ...(data.layers || []).map((l) =>
+      element("tr", { className: `layer-${l.status}` }, cell(String(l.index)), cell(l.status), cell(shortDigest(l.old), "digest"), cell(shortDigest(l.new), "digest")),
+    ),
+  );
+}
+
+// An image in the address, from a shared link, is loaded right away.
+const initial = new URLSearchParams(location.hash.slice(1));
+if (initial.get("image")) {
+  $("image").value = initial.get("image");
+  $("platform").value = initial.get("platform") || "";
+  loadImage($("image").value, $("platform").value);
+}
@@ -0,0 +1,154 @@
+// A small syntax highlighter for the files found in images: shell scripts,
+// configuration formats and common programming languages. Each language is
+// a list of token rules tried in order; text matching none is left as is.
+"use strict";
+
+const highlighter = (() => {
+  const words = (list) => new RegExp(`\\b(?:${list.split(" ").join("|")})\\b`);
+
+  const strings = /"(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)*'/;
+  const numbers = /\b\d+(?:\.\d+)?\b/;
+  const hashComment = /#.*/;
+  const cComment = /\/\/.*|\/\*[\s\S]*?\*\//;
+
+  const languages = {
+    shell: [
+      ["comment", /(?:^|[ \t])#.*/],
+      ["string", strings],
+      ["variable", /\$\{[^}]*\}|\$\w+/],
+      ["keyword", words("if then else elif fi for while until do done case esac in function return export local readonly set unset exit shift source")],
+    ],
+    dockerfile: [
+      ["comment", hashComment],
+      ["keyword", /^\s*(?:FROM|RUN|CMD|LABEL|EXPOSE|ENV|ADD|COPY|ENTRYPOINT|VOLUME|USER|WORKDIR|ARG|ONBUILD|STOPSIGNAL|HEALTHCHECK|SHELL)\b/m],
+      ["string", strings],
+      ["variable", /\$\{[^}]*\}|\$\w+/],
+    ],
+    yaml: [
+      ["comment", /(?:^|[ \t])#.*/],
+      ["key", /^[ \t-]*[\w.\/-]+(?=:(?:\s|$))/m],
+      ["string", strings],
+      ["keyword", words("true false null yes no on off")],
+      ["number", numbers],
+    ],
+    json: [
+      ["key", /"(?:[^"\\\n]|\\.)*"(?=\s*:)/],
+      ["string", /"(?:[^"\\\n]|\\.)*"/],
+      ["keyword", words("true false null")],
+      ["number", /-?\b\d+(?:\.\d+)?(?:[eE][+-]?\d+)?\b/],
+    ],
+    conf: [
+      ["comment", /^\s*[#;].*/m],
+      ["section", /^\s*\[[^\]\n]+\]/m],
+      ["key", /^\s*[\w.\/-]+(?=\s*[=:])/m],
+      ["string", strings],
+      ["number", numbers],
+    ],
+    python: [
+      ["comment", hashComment],
+      ["string", /"""[\s\S]*?"""|'''[\s\S]*?'''/],
+      ["string", strings],
+      ["keyword", words("and as assert async await break class continue def del elif else except False finally for from global if import in is lambda None nonlocal not or pass raise return True try while with yield")],
+      ["number", numbers],
+    ],
+    javascript: [
+      ["comment", cComment],
+      ["string", /`(?:[^`\\]|\\.)*`/],
+      ["string", strings],
+      ["keyword", words("async await break case catch class const continue default delete do else export extends false finally for function if import in instanceof let new null return super switch this throw true try typeof undefined var void while yield")],
+      ["number", numbers],
+    ],
+    go: [
+      ["comment", cComment],
+      ["string", /`[^`]*`/],
+      ["string", strings],
+      ["keyword", words("break case chan const continue default defer else fallthrough false for func go goto if import interface map nil package range return select struct switch true type var")],
+      ["number", numbers],
+    ],
+    c: [
+      ["comment", cComment],
+      ["keyword", /^\s*#\s*\w+/m],
+      ["string", strings],
+      ["keyword", words("auto break case char const continue default do double else enum extern float for goto if int long register return short signed sizeof static struct switch typedef union unsigned void volatile while")],
+      ["number", numbers],
+    ],
+    markup: [
+      ["comment", /<!--[\s\S]*?-->/],
+      ["keyword", /<\/?[\w:.-]+|\/?>/],
+      ["key", /\b[\w:-]+(?==)/],
+      ["string", strings],
+    ],
+  };
+
+  const extensions = {
+    sh: "shell", bash: "shell", zsh: "shell", ksh: "shell", profile: "shell", bashrc: "shell",
+    dockerfile: "dockerfile", containerfile: "dockerfile",
+    yaml: "yaml", yml: "yaml",
+    json: "json",
+    conf: "conf", cnf: "conf", cfg: "conf", ini: "conf", toml: "conf", properties: "conf",
+    service: "conf", socket: "conf", timer: "conf", env: "conf", list: "conf",
+    py: "python",
+    js: "javascript", mjs: "javascript", cjs: "javascript", ts: "javascript",
+    go: "go",
+    c: "c", h: "c", cc: "c", cpp: "c", hpp: "c", java: "c", rs: "c",
+    html: "markup", htm: "markup", xml: "markup", svg: "markup", plist: "markup",
+  };
+
+  // languageFor picks a language from the file name, falling back to the
+  // first line for scripts and to conf for extensionless files in /etc.
+  function languageFor(path, text) {
+    const name = path.split("/").pop().toLowerCase();
+    if (name === "dockerfile" || name === "containerfile") {
+      return "dockerfile";
+    }
+    const dot = name.lastIndexOf(".");
+    if (dot >= 0 && extensions[name.slice(dot + 1)]) {
+      return extensions[name.slice(dot + 1)];
+    }
+    const first = text.slice(0, 100).split("\n")[0];
+    if (/^#!.*\b(?:sh|bash|ash|dash|zsh)\b/.test(first)) {
+      return "shell";
+    }
+    if (/^#!.*\bpython/.test(first)) {
+      return "python";
+    }
+    if (/^#!.*\bnode\b/.test(first)) {
+      return "javascript";
+    }
+    if (dot < 0 && path.startsWith("/etc/")) {
+      return "conf";
+    }
+    return null;
+  }
+
+  function escape(text) {
+    return text.replace(/[&<>"]/g, (c) => ({ "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;" })[c]);
+  }
+
+  // highlight returns text as HTML with tokens wrapped in spans whose class
+  // names the token type, and the name of the language used.
+  function highlight(text, path) {
+    const name = languageFor(path, text);
+    const rules = languages[name];
+    if (!rules) {
+      return { html: escape(text), language: null };
+    }
+
+    const pattern = new RegExp(rules.map(([, re]) => `(${re.source})`).join("|"), "gm");
+    let html = "";
+    let last = 0;
+    for (const match of text.matchAll(pattern)) {
+      if (match[0] === "") {
+        continue;
+      }
+      const rule = rules[match.slice(1).findIndex((group) => group !== undefined)];
+      html += escape(text.slice(last, match.index));
+      html += `<span class="tok-${rule[0]}">${escape(match[0])}</span>`;
+      last = match.index + match[0].length;
+    }
+    html += escape(text.slice(last));
+    return { html, language: name };
+  }
+
+  return { highlight, escape };
+})();
@@ -0,0 +1,21 @@
+// Package web holds the embedded web app served by cek serve.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the web app. The app calls the JSON API under /api/,
+// which is served separately.
+func Handler() http.Handler {
+	root, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServerFS(root)
+}
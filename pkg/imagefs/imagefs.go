@@ -0,0 +1,613 @@
+// Package imagefs exposes the filesystem of a container image, or of some of
+// its layers, as an io/fs filesystem.
+//
+// The filesystem is the overlay a running container would see: layers are
+// stacked bottom-up, upper layers replace entries of lower ones, and whiteout
+// markers delete them. Building an FS reads the tar headers of every layer
+// once. File contents are read from their layer when a file is opened: the
+// layer is decompressed no further than the file, and what was decompressed
+// is kept in a temporary file, so opening every file of an image decompresses
+// each layer once. Close removes the temporary files. To build filesystems of
+// several stacks of the same layers, such as an image as of each of its
+// layers, read the layers once with ReadLayer and Stack them.
+//
+// Names follow the io/fs conventions: unrooted, slash-separated paths such as
+// "etc/os-release", with "." for the root. Open, Stat and ReadDir follow
+// symbolic links, which resolve within the image and never outside its root,
+// so fs.WalkDir, fs.Glob, fs.Sub and testing/fstest work as they do on a
+// directory. Lstat and ReadLink inspect links themselves.
+package imagefs
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bschaatsbergen/cek/internal/oci"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// maxLinks is how many symbolic links are followed resolving a name.
+const maxLinks = 40
+
+var (
+	errNotDir       = errors.New("not a directory")
+	errIsDir        = errors.New("is a directory")
+	errNotLink      = errors.New("not a symbolic link")
+	errTooManyLinks = errors.New("too many levels of symbolic links")
+)
+
+// FS is the merged filesystem of a stack of image layers. It implements
+// fs.FS, fs.StatFS, fs.ReadDirFS and fs.ReadFileFS, and is safe for
+// concurrent use.
+type FS struct {
+	layers []*contents
+	root   *node
+}
+
+var (
+	_ fs.StatFS     = (*FS)(nil)
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+)
+
+// node is an entry of the filesystem and, for directories, its children.
+type node struct {
+	// path is the clean absolute path of the entry in its layer.
+	path   string
+	header *tar.Header
+	// layer is the index of the layer the entry comes from, -1 for
+	// directories only implied by their contents.
+	layer int
+	// size is the size of the file, which for hard links is the size of
+	// the file they link to.
+	size     int64
+	children map[string]*node
+}
+
+// New returns the filesystem of img, which is what a container started from
+// it would see.
+func New(img v1.Image) (*FS, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layers: %w", err)
+	}
+	return NewFromLayers(layers...)
+}
+
+// NewFromLayers returns the filesystem of layers stacked in order, the first
+// being the bottom layer. A single layer gives the contents of that layer,
+// without its whiteouts.
+func NewFromLayers(layers ...v1.Layer) (*FS, error) {
+	read := make([]*Layer, len(layers))
+	for i, layer := range layers {
+		l, err := ReadLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %w", i+1, err)
+		}
+		read[i] = l
+	}
+	return Stack(read...), nil
+}
+
+// Layer is the entries of an image layer and the paths its whiteouts
+// delete, as read by ReadLayer. A Layer can be part of any number of
+// filesystems.
+type Layer struct {
+	layer     v1.Layer
+	entries   []*node
+	whiteouts []whiteout
+}
+
+type whiteout struct {
+	path   string
+	opaque bool
+}
+
+// ReadLayer reads the tar headers of layer.
+func ReadLayer(layer v1.Layer) (*Layer, error) {
+	l := &Layer{layer: layer}
+	files := make(map[string]*node)
+	err := oci.WalkChanges(layer, 0, func(f *oci.FileEntry) error {
+		n := &node{path: f.Path, header: f.Header, size: f.Header.Size}
+		// Hard links name an earlier entry of the same layer.
+		if f.Header.Typeflag == tar.TypeLink {
+			if target, ok := files[oci.CleanPath(f.Header.Linkname)]; ok {
+				n.size = target.size
+			}
+		}
+		files[f.Path] = n
+		l.entries = append(l.entries, n)
+		return nil
+	}, func(p string, opaque bool) error {
+		l.whiteouts = append(l.whiteouts, whiteout{path: p, opaque: opaque})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Len returns the number of entries in the layer, whiteouts excluded.
+func (l *Layer) Len() int {
+	return len(l.entries)
+}
+
+// Changes calls entry for every entry of the layer, in the order of the
+// layer, with its clean absolute path and tar header, and whiteout for
+// every path its whiteouts delete.
+func (l *Layer) Changes(entry func(p string, hdr *tar.Header), whiteout func(p string, opaque bool)) {
+	for _, e := range l.entries {
+		entry(e.path, e.header)
+	}
+	for _, w := range l.whiteouts {
+		whiteout(w.path, w.opaque)
+	}
+}
+
+// Stack returns the filesystem of layers stacked in order, the first being
+// the bottom layer.
+func Stack(layers ...*Layer) *FS {
+	fsys := &FS{
+		layers: make([]*contents, len(layers)),
+		root:   impliedDir("/"),
+	}
+	// The layers are added top-down, so an entry is only added when no upper
+	// layer replaces or deletes it. A layer's whiteouts only apply to the
+	// layers below it.
+	overlay := oci.NewOverlay()
+	for i := len(layers) - 1; i >= 0; i-- {
+		l := layers[i]
+		fsys.layers[i] = &contents{layer: l.layer, index: i}
+		for _, w := range l.whiteouts {
+			overlay.Whiteout(w.path, w.opaque)
+		}
+		for _, e := range l.entries {
+			if overlay.Visible(e.path, e.header) {
+				fsys.add(i, e)
+			}
+		}
+		overlay.NextLayer()
+	}
+	return fsys
+}
+
+func impliedDir(p string) *node {
+	return &node{
+		path:   p,
+		header: &tar.Header{Name: p, Typeflag: tar.TypeDir, Mode: 0o755},
+		layer:  -1,
+	}
+}
+
+func (n *node) isDir() bool {
+	return n.header.Typeflag == tar.TypeDir
+}
+
+func (n *node) isSymlink() bool {
+	return n.header.Typeflag == tar.TypeSymlink
+}
+
+// add adds the entry e of the layer at index. Entries are copied, as the
+// layer may be part of other filesystems.
+func (fsys *FS) add(index int, e *node) {
+	if e.path == "/" {
+		fsys.root.header, fsys.root.layer = e.header, index
+		return
+	}
+	n := *e
+	n.layer = index
+	parent := fsys.ensure(path.Dir(n.path))
+	name := path.Base(n.path)
+	// A directory keeps the entries of upper layers added below it.
+	if old, ok := parent.children[name]; ok && old.isDir() && n.isDir() {
+		n.children = old.children
+	}
+	parent.children[name] = &n
+}
+
+// ensure returns the directory node for p, creating it and its parents as
+// implied directories when missing.
+func (fsys *FS) ensure(p string) *node {
+	if p == "/" {
+		if fsys.root.children == nil {
+			fsys.root.children = make(map[string]*node)
+		}
+		return fsys.root
+	}
+	parent := fsys.ensure(path.Dir(p))
+	name := path.Base(p)
+	n, ok := parent.children[name]
+	if !ok || !n.isDir() {
+		n = impliedDir(p)
+		parent.children[name] = n
+	}
+	if n.children == nil {
+		n.children = make(map[string]*node)
+	}
+	return n
+}
+
+func split(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// resolve returns the node for name. Symbolic links are followed, except in
+// the last element unless follow is set.
+func (fsys *FS) resolve(op, name string, follow bool) (*node, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	p := "/"
+	if name != "." {
+		p += name
+	}
+	for links := 0; ; links++ {
+		if links > maxLinks {
+			return nil, &fs.PathError{Op: op, Path: name, Err: errTooManyLinks}
+		}
+
+		n, dir := fsys.root, "/"
+		parts := split(p)
+		restart := false
+		for i, elem := range parts {
+			if !n.isDir() {
+				return nil, &fs.PathError{Op: op, Path: name, Err: errNotDir}
+			}
+			child := n.children[elem]
+			if child == nil {
+				return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+			}
+			last := i == len(parts)-1
+			if child.isSymlink() && (!last || follow) {
+				target := child.header.Linkname
+				if !path.IsAbs(target) {
+					target = path.Join(dir, target)
+				}
+				p = oci.CleanPath(path.Join(append([]string{target}, parts[i+1:]...)...))
+				restart = true
+				break
+			}
+			n, dir = child, path.Join(dir, elem)
+		}
+		if !restart {
+			return n, nil
+		}
+	}
+}
+
+// Open opens the named file, following symbolic links. Directories
+// implement fs.ReadDirFile. The contents of a file are read from its layer
+// when it is opened.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	n, err := fsys.resolve("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	info := &fileInfo{name: path.Base(name), node: n}
+	if n.isDir() {
+		return &dir{info: info, entries: n.list()}, nil
+	}
+
+	var data []byte
+	if n.layer >= 0 && (n.header.Typeflag == tar.TypeReg || n.header.Typeflag == tar.TypeLink) {
+		data, err = fsys.read(n)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+	}
+	return &file{info: info, Reader: bytes.NewReader(data)}, nil
+}
+
+// read returns the contents of the regular file or hard link n.
+func (fsys *FS) read(n *node) ([]byte, error) {
+	target := n.path
+	if n.header.Typeflag == tar.TypeLink {
+		target = oci.CleanPath(n.header.Linkname)
+	}
+	return fsys.layers[n.layer].read(target)
+}
+
+// Close removes the temporary files holding the layers decompressed so far.
+// The filesystem remains usable and decompresses layers again as needed.
+func (fsys *FS) Close() error {
+	var errs []error
+	for _, c := range fsys.layers {
+		errs = append(errs, c.close())
+	}
+	return errors.Join(errs...)
+}
+
+// contents reads the files of a layer. The tar stream is decompressed once
+// and copied to a temporary file as it is read: a file already passed is read
+// back from there, and a file further in the layer by reading on to it.
+type contents struct {
+	layer v1.Layer
+	index int
+
+	mu    sync.Mutex
+	rc    io.ReadCloser
+	tr    *tar.Reader
+	spool *os.File
+	// written is how much of the tar stream has been copied to spool.
+	written int64
+	files   map[string]span
+	done    bool
+}
+
+// span is where the data of a regular file is in the spooled tar stream.
+// Sparse files are not stored as is, so they are read from the layer.
+type span struct {
+	offset, size int64
+	sparse       bool
+}
+
+func (c *contents) Write(p []byte) (int, error) {
+	n, err := c.spool.Write(p)
+	c.written += int64(n)
+	return n, err
+}
+
+// read returns the contents of the first regular file at the clean absolute
+// path p in the layer.
+func (c *contents) read(p string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		if s, ok := c.files[p]; ok {
+			if s.sparse {
+				return c.readSparse(p)
+			}
+			data := make([]byte, s.size)
+			if _, err := c.spool.ReadAt(data, s.offset); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", p, err)
+			}
+			return data, nil
+		}
+		if c.done {
+			return nil, fmt.Errorf("%s not found in layer %d", p, c.index+1)
+		}
+		if err := c.next(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// next copies the next entry of the layer to the spool and records where
+// its data is.
+func (c *contents) next() error {
+	if c.tr == nil {
+		rc, err := c.layer.Uncompressed()
+		if err != nil {
+			return fmt.Errorf("failed to get uncompressed layer: %w", err)
+		}
+		spool, err := os.CreateTemp("", "cek-layer-")
+		if err != nil {
+			_ = rc.Close()
+			return fmt.Errorf("failed to create layer spool: %w", err)
+		}
+		c.rc, c.spool, c.written = rc, spool, 0
+		c.tr = tar.NewReader(io.TeeReader(rc, c))
+		c.files = make(map[string]span)
+	}
+
+	header, err := c.tr.Next()
+	if err == io.EOF {
+		// The spool now holds the whole layer.
+		rc := c.rc
+		c.rc, c.tr, c.done = nil, nil, true
+		return rc.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tar header: %w", err)
+	}
+	offset := c.written
+	size, err := io.Copy(io.Discard, c.tr)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", header.Name, err)
+	}
+	p := oci.CleanPath(header.Name)
+	if _, ok := c.files[p]; !ok && header.Typeflag == tar.TypeReg {
+		c.files[p] = span{offset: offset, size: size, sparse: c.written-offset != size}
+	}
+	return nil
+}
+
+// readSparse reads the sparse file at p from the layer itself.
+func (c *contents) readSparse(p string) ([]byte, error) {
+	var data []byte
+	err := oci.WalkLayer(c.layer, c.index, func(f *oci.FileEntry) error {
+		if f.Path != p || f.Header.Typeflag != tar.TypeReg {
+			return nil
+		}
+		b, err := io.ReadAll(f.Reader)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Path, err)
+		}
+		data = b
+		return fs.SkipAll
+	})
+	return data, err
+}
+
+func (c *contents) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.spool == nil {
+		return nil
+	}
+	var errs []error
+	if c.rc != nil {
+		errs = append(errs, c.rc.Close())
+	}
+	errs = append(errs, c.spool.Close(), os.Remove(c.spool.Name()))
+	c.rc, c.tr, c.spool, c.files, c.done = nil, nil, nil, nil, false
+	return errors.Join(errs...)
+}
+
+// ReadFile returns the contents of the named file, following symbolic
+// links.
+func (fsys *FS) ReadFile(name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, ok := f.(*dir); ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+	return io.ReadAll(f)
+}
+
+// Stat returns information about the named file, following symbolic links.
+// Its Sys method returns the *tar.Header of the entry.
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	n, err := fsys.resolve("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{name: path.Base(name), node: n}, nil
+}
+
+// Lstat returns information about the named file without following a final
+// symbolic link.
+func (fsys *FS) Lstat(name string) (fs.FileInfo, error) {
+	n, err := fsys.resolve("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{name: path.Base(name), node: n}, nil
+}
+
+// ReadLink returns the target of the named symbolic link as stored in the
+// image.
+func (fsys *FS) ReadLink(name string) (string, error) {
+	n, err := fsys.resolve("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if !n.isSymlink() {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: errNotLink}
+	}
+	return n.header.Linkname, nil
+}
+
+// Realpath returns name with every symbolic link in it resolved.
+func (fsys *FS) Realpath(name string) (string, error) {
+	n, err := fsys.resolve("realpath", name, true)
+	if err != nil {
+		return "", err
+	}
+	if n.path == "/" {
+		return ".", nil
+	}
+	return strings.TrimPrefix(n.path, "/"), nil
+}
+
+// ReadDir returns the entries of the named directory sorted by name,
+// following symbolic links to it. Links in the directory are reported as
+// links.
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	n, err := fsys.resolve("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !n.isDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+	return n.list(), nil
+}
+
+func (n *node) list() []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(n.children))
+	for name, child := range n.children {
+		entries = append(entries, fs.FileInfoToDirEntry(&fileInfo{name: name, node: child}))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries
+}
+
+// fileInfo describes a node under the name it was reached by.
+type fileInfo struct {
+	name string
+	node *node
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.node.size }
+func (fi *fileInfo) Mode() fs.FileMode  { return fi.node.header.FileInfo().Mode() }
+func (fi *fileInfo) ModTime() time.Time { return fi.node.header.ModTime }
+func (fi *fileInfo) IsDir() bool        { return fi.node.isDir() }
+func (fi *fileInfo) Sys() any           { return fi.node.header }
+
+// LayerIndex returns the index, in the stack of its filesystem, of the layer
+// holding the entry info describes. It returns -1 for directories only
+// implied by the entries below them, and for info not returned by an FS.
+func LayerIndex(info fs.FileInfo) int {
+	fi, ok := info.(*fileInfo)
+	if !ok {
+		return -1
+	}
+	return fi.node.layer
+}
+
+// file is an open regular file, or an empty one for other non-directories
+// such as devices.
+type file struct {
+	info *fileInfo
+	*bytes.Reader
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+// dir is an open directory.
+type dir struct {
+	info    *fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir) Close() error               { return nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errIsDir}
+}
+
+func (d *dir) ReadDir(count int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if count <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(rest) {
+		count = len(rest)
+	}
+	d.offset += count
+	return rest[:count], nil
+}
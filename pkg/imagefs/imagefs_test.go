@@ -0,0 +1,296 @@
+package imagefs_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bschaatsbergen/cek/pkg/imagefs"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tarEntry struct {
+	name     string
+	content  string
+	typeflag byte
+	linkname string
+}
+
+func file(name, content string) tarEntry {
+	return tarEntry{name: name, content: content, typeflag: tar.TypeReg}
+}
+
+func dir(name string) tarEntry {
+	return tarEntry{name: name, typeflag: tar.TypeDir}
+}
+
+func symlink(name, target string) tarEntry {
+	return tarEntry{name: name, typeflag: tar.TypeSymlink, linkname: target}
+}
+
+func newLayer(t *testing.T, entries ...tarEntry) v1.Layer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.content)), Typeflag: e.typeflag, Linkname: e.linkname}
+		if e.typeflag == tar.TypeDir {
+			hdr.Mode = 0o755
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(e.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	data := buf.Bytes()
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+	require.NoError(t, err)
+	return layer
+}
+
+func newFS(t *testing.T, layers ...v1.Layer) *imagefs.FS {
+	t.Helper()
+
+	fsys, err := imagefs.NewFromLayers(layers...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, fsys.Close())
+	})
+	return fsys
+}
+
+// countingLayer counts how often the layer is decompressed.
+type countingLayer struct {
+	v1.Layer
+	opened int
+}
+
+func (l *countingLayer) Uncompressed() (io.ReadCloser, error) {
+	l.opened++
+	return l.Layer.Uncompressed()
+}
+
+func TestFS_Conformance(t *testing.T) {
+	fsys := newFS(t,
+		newLayer(t,
+			dir("etc/"),
+			file("etc/os-release", "ID=alpine\n"),
+			file("usr/bin/sh", "#!binary"),
+			symlink("bin", "usr/bin"),
+		),
+		newLayer(t,
+			file("app/config.yaml", "port: 8080\n"),
+			symlink("app/current", "/app/config.yaml"),
+			tarEntry{name: "app/hardlink", typeflag: tar.TypeLink, linkname: "app/config.yaml"},
+		),
+	)
+
+	require.NoError(t, fstest.TestFS(fsys,
+		"etc/os-release", "usr/bin/sh", "bin", "app/config.yaml", "app/current", "app/hardlink",
+	))
+
+	data, err := fs.ReadFile(fsys, "app/hardlink")
+	require.NoError(t, err)
+	assert.Equal(t, "port: 8080\n", string(data))
+}
+
+func TestFS_UpperLayerWins(t *testing.T) {
+	fsys := newFS(t,
+		newLayer(t, file("etc/motd", "old\n"), file("etc/hosts", "localhost\n")),
+		newLayer(t, file("etc/motd", "new\n")),
+	)
+
+	data, err := fs.ReadFile(fsys, "etc/motd")
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(data))
+
+	data, err = fs.ReadFile(fsys, "etc/hosts")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost\n", string(data))
+}
+
+func TestFS_Whiteouts(t *testing.T) {
+	fsys := newFS(t,
+		newLayer(t,
+			file("etc/motd", "hello\n"),
+			file("var/cache/a", "a"),
+			file("var/cache/b", "b"),
+		),
+		newLayer(t,
+			file("etc/.wh.motd", ""),
+			file("var/cache/.wh..wh..opq", ""),
+			file("var/cache/c", "c"),
+		),
+	)
+
+	_, err := fsys.Stat("etc/motd")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	entries, err := fs.ReadDir(fsys, "var/cache")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "c", entries[0].Name())
+}
+
+func TestFS_FileReplacedByDirectory(t *testing.T) {
+	fsys := newFS(t,
+		newLayer(t, file("opt/app", "binary"), dir("srv/"), file("srv/index.html", "<html>")),
+		newLayer(t, file("opt/app/run", "script"), file("srv", "file")),
+	)
+
+	info, err := fsys.Stat("opt/app")
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+
+	info, err = fsys.Stat("srv")
+	require.NoError(t, err)
+	assert.False(t, info.IsDir())
+	_, err = fsys.Stat("srv/index.html")
+	assert.Error(t, err)
+}
+
+func TestFS_Symlinks(t *testing.T) {
+	fsys := newFS(t, newLayer(t,
+		file("usr/lib/os-release", "ID=debian\n"),
+		symlink("etc/os-release", "../usr/lib/os-release"),
+		symlink("lib", "/usr/lib"),
+		symlink("escape", "../../../usr/lib/os-release"),
+		symlink("loop", "loop"),
+	))
+
+	for _, name := range []string{"etc/os-release", "lib/os-release", "escape"} {
+		data, err := fs.ReadFile(fsys, name)
+		require.NoError(t, err, name)
+		assert.Equal(t, "ID=debian\n", string(data), name)
+	}
+
+	info, err := fsys.Lstat("etc/os-release")
+	require.NoError(t, err)
+	assert.Equal(t, fs.ModeSymlink, info.Mode().Type())
+
+	target, err := fsys.ReadLink("lib")
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/lib", target)
+
+	_, err = fsys.ReadLink("usr/lib/os-release")
+	assert.Error(t, err)
+
+	_, err = fsys.Open("loop")
+	assert.Error(t, err)
+}
+
+func TestFS_SingleLayer(t *testing.T) {
+	lower := newLayer(t, file("etc/motd", "hello\n"))
+	upper := newLayer(t, file("etc/.wh.motd", ""), file("etc/issue", "Welcome\n"))
+
+	fsys := newFS(t, upper)
+
+	matches, err := fs.Glob(fsys, "etc/*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"etc/issue"}, matches)
+
+	fsys = newFS(t, lower, upper)
+	matches, err = fs.Glob(fsys, "etc/*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"etc/issue"}, matches)
+}
+
+func TestFS_InvalidNames(t *testing.T) {
+	fsys := newFS(t, newLayer(t, file("etc/motd", "hello\n")))
+
+	for _, name := range []string{"/etc/motd", "etc/../etc/motd", "etc/"} {
+		_, err := fsys.Open(name)
+		var pathErr *fs.PathError
+		require.True(t, errors.As(err, &pathErr), name)
+		assert.ErrorIs(t, err, fs.ErrInvalid, name)
+	}
+}
+
+func TestFS_Stack(t *testing.T) {
+	lower, err := imagefs.ReadLayer(newLayer(t, file("etc/motd", "old\n"), file("usr/bin/sh", "#!binary")))
+	require.NoError(t, err)
+	upper, err := imagefs.ReadLayer(newLayer(t, file("etc/motd", "new\n")))
+	require.NoError(t, err)
+	assert.Equal(t, 2, lower.Len())
+
+	// Stacking a layer again leaves the filesystems built before intact.
+	merged := imagefs.Stack(lower, upper)
+	base := imagefs.Stack(lower)
+
+	data, err := fs.ReadFile(merged, "etc/motd")
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(data))
+	data, err = fs.ReadFile(base, "etc/motd")
+	require.NoError(t, err)
+	assert.Equal(t, "old\n", string(data))
+
+	info, err := merged.Stat("etc/motd")
+	require.NoError(t, err)
+	assert.Equal(t, 1, imagefs.LayerIndex(info))
+	info, err = merged.Stat("usr/bin/sh")
+	require.NoError(t, err)
+	assert.Equal(t, 0, imagefs.LayerIndex(info))
+	info, err = merged.Stat("usr/bin")
+	require.NoError(t, err)
+	assert.Equal(t, -1, imagefs.LayerIndex(info), "implied by its contents")
+}
+
+func TestFS_Realpath(t *testing.T) {
+	fsys := newFS(t, newLayer(t,
+		file("usr/lib/os-release", "ID=debian\n"),
+		symlink("etc/os-release", "../usr/lib/os-release"),
+		symlink("lib", "/usr/lib"),
+	))
+
+	for name, want := range map[string]string{
+		".":              ".",
+		"lib":            "usr/lib",
+		"lib/os-release": "usr/lib/os-release",
+		"etc/os-release": "usr/lib/os-release",
+		"etc":            "etc",
+	} {
+		got, err := fsys.Realpath(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, err := fsys.Realpath("lib/missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestFS_DecompressesLayersOnce(t *testing.T) {
+	layer := &countingLayer{Layer: newLayer(t,
+		file("a", "1"),
+		file("b/c", "2"),
+		tarEntry{name: "d", typeflag: tar.TypeLink, linkname: "a"},
+		file("e", "3"),
+	)}
+	fsys := newFS(t, layer)
+	// Reading the headers decompresses the layer once.
+	layer.opened = 0
+
+	// Read out of order, so some files are read back from the spool.
+	for name, want := range map[string]string{"b/c": "2", "e": "3", "a": "1", "d": "1"} {
+		data, err := fsys.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data), name)
+	}
+	assert.Equal(t, 1, layer.opened)
+
+	require.NoError(t, fsys.Close())
+	data, err := fsys.ReadFile("e")
+	require.NoError(t, err)
+	assert.Equal(t, "3", string(data))
+	assert.Equal(t, 2, layer.opened)
+}